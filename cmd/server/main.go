@@ -1,17 +1,28 @@
 package main
 
 import (
+	"context"
 	"log"
+	"strings"
 	"time"
+	_ "time/tzdata" // user timezones must resolve even without system zoneinfo
 
 	"daily-notes-api/internal/config"
 	"daily-notes-api/internal/database"
 	"daily-notes-api/internal/handlers"
 	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
 	"daily-notes-api/internal/repository"
+	"daily-notes-api/internal/scheduler"
 	"daily-notes-api/pkg/auth"
 	"daily-notes-api/pkg/cache"
+	"daily-notes-api/pkg/collab"
 	"daily-notes-api/pkg/email"
+	"daily-notes-api/pkg/events"
+	"daily-notes-api/pkg/inbound"
+	"daily-notes-api/pkg/oidc"
+	"daily-notes-api/pkg/passwordpolicy"
+	"daily-notes-api/pkg/webhook"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,6 +30,9 @@ import (
 func main() {
 	// Load configuration
 	cfg := config.LoadConfig()
+	if cfg.UsesDefaultSecret() && !cfg.IsDevelopment() {
+		log.Fatal("JWT_SECRET is not set; refusing to sign with the default secret outside APP_ENV=development")
+	}
 
 	// Connect to database
 	db, err := database.NewMySQLConnection(cfg)
@@ -28,13 +42,30 @@ func main() {
 	defer db.Close()
 
 	// Initialize JWT manager
-	jwtManager := auth.NewJWTManager(cfg)
+	jwtManager, err := auth.NewJWTManager(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize JWT signing:", err)
+	}
+
+	passwordHasher, err := auth.NewPasswordHasher(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize password hashing:", err)
+	}
+
+	var breachedPasswords *passwordpolicy.BreachedList
+	if cfg.BreachedPasswordsDir != "" {
+		breachedPasswords = passwordpolicy.NewBreachedList(cfg.BreachedPasswordsDir)
+	}
+	passwordPolicy := passwordpolicy.New(cfg.PasswordMinLength, cfg.PasswordMinEntropyBits, breachedPasswords)
 
 	// Initialize Email service
 	var emailService *email.EmailService
-	if cfg.SMTPUsername != "" && cfg.SMTPPassword != "" {
-		emailService = email.NewEmailService(cfg)
-		log.Printf("Email service initialized with SMTP host: %s", cfg.SMTPHost)
+	if cfg.EmailTransport != email.TransportSMTP || (cfg.SMTPUsername != "" && cfg.SMTPPassword != "") {
+		emailService, err = email.NewEmailService(cfg)
+		if err != nil {
+			log.Fatal("Failed to initialize email service:", err)
+		}
+		log.Printf("Email service initialized with %s transport", emailService.TransportName())
 	} else {
 		log.Printf("Warning: SMTP credentials not provided, email functionality disabled")
 	}
@@ -69,10 +100,143 @@ func main() {
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	noteRepo := repository.NewNoteRepository(db)
+	templateRepo := repository.NewNoteTemplateRepository(db)
+	reminderRepo := repository.NewReminderRepository(db)
+	digestRepo := repository.NewDigestRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	inboundRepo := repository.NewInboundRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	revisionRepo := repository.NewNoteRevisionRepository(db)
+	noteCollaboratorRepo := repository.NewNoteCollaboratorRepository(db)
+	taskRepo := repository.NewTaskRepository(db)
+	statsRepo := repository.NewStatsRepository(db)
+	settingsRepo := repository.NewUserSettingsRepository(db)
+	accountRepo := repository.NewAccountRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	tokenRepo := repository.NewAccessTokenRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
+	oauthRepo := repository.NewOAuthRepository(db)
+	loginSecurityRepo := repository.NewLoginSecurityRepository(db)
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+
+	// Administrators are granted explicitly; the seeded account never is
+	if cfg.BootstrapAdmin != "" {
+		admin, err := userRepo.GetByUsername(cfg.BootstrapAdmin)
+		if err != nil {
+			log.Fatal("Failed to load BOOTSTRAP_ADMIN user:", err)
+		}
+		if admin == nil {
+			log.Fatalf("BOOTSTRAP_ADMIN user %q does not exist", cfg.BootstrapAdmin)
+		}
+		if match, _ := passwordHasher.Verify("admin123", admin.PasswordHash); match {
+			log.Fatalf("BOOTSTRAP_ADMIN user %q still has the seeded password; change it first", cfg.BootstrapAdmin)
+		}
+		if admin.Role != models.RoleAdmin {
+			if err := userRepo.SetRole(admin.ID, models.RoleAdmin); err != nil {
+				log.Fatal("Failed to grant admin role:", err)
+			}
+			log.Printf("Granted admin role to %s", admin.Username)
+		}
+	}
+
+	// Live note change events, fanned out through Redis when available
+	eventHub := events.NewHub(cacheService)
 
 	// Initialize handlers (pass cache service to note handler)
-	authHandler := handlers.NewAuthHandler(userRepo, jwtManager, emailService, cfg.AppName, cfg.AppURL)
-	noteHandler := handlers.NewNoteHandler(noteRepo, cacheService)
+	authHandler, err := handlers.NewAuthHandler(userRepo, sessionRepo, loginSecurityRepo, jwtManager, passwordHasher, passwordPolicy, emailService,
+		cfg.AppName, cfg.AppURL, cfg.LoginMaxFailures, time.Duration(cfg.LoginLockoutMinutes)*time.Minute)
+	if err != nil {
+		log.Fatal("Failed to initialize authentication:", err)
+	}
+	noteHandler := handlers.NewNoteHandler(noteRepo, templateRepo, userRepo, webhookRepo, eventHub, cacheService)
+	templateHandler := handlers.NewNoteTemplateHandler(templateRepo)
+	reminderHandler := handlers.NewReminderHandler(reminderRepo, noteRepo)
+	digestHandler := handlers.NewDigestHandler(digestRepo, settingsRepo, cfg.AppName)
+	adminHandler := handlers.NewAdminHandler(outboxRepo, loginSecurityRepo, userRepo, noteRepo)
+	webhookHandler := handlers.NewWebhookHandler(webhookRepo, cfg.WebhookAllowPrivateTargets)
+	eventsHandler := handlers.NewEventsHandler(eventHub)
+	taskHandler := handlers.NewTaskHandler(taskRepo, noteRepo, noteHandler)
+	statsHandler := handlers.NewStatsHandler(statsRepo, cacheService)
+	settingsHandler := handlers.NewSettingsHandler(settingsRepo)
+	sessionHandler := handlers.NewSessionHandler(sessionRepo)
+	tokenHandler := handlers.NewAccessTokenHandler(tokenRepo)
+	jwksHandler := handlers.NewJWKSHandler(jwtManager)
+	oauthHandler := handlers.NewOAuthHandler(oauthRepo, userRepo, authHandler, jwtManager, cfg.AppName, cfg.APIURL,
+		time.Duration(cfg.OAuthAccessTokenMinutes)*time.Minute, time.Duration(cfg.OAuthRefreshTokenDays)*24*time.Hour)
+
+	// Single sign-on providers, discovered on first use
+	var oidcProviders []*oidc.Provider
+	for _, p := range cfg.OIDCProviders {
+		oidcProviders = append(oidcProviders, oidc.NewProvider(oidc.Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  strings.TrimRight(cfg.APIURL, "/") + "/api/v1/auth/oidc/" + p.Name + "/callback",
+			Scopes:       p.Scopes,
+		}, nil))
+		log.Printf("OIDC login enabled for provider %s", p.Name)
+	}
+	oidcHandler := handlers.NewOIDCHandler(oidcProviders, identityRepo, userRepo, authHandler)
+	passwordResetHandler := handlers.NewPasswordResetHandler(passwordResetRepo, userRepo, outboxRepo, authHandler, cfg.PasswordResetTTL)
+	accountHandler := handlers.NewAccountHandler(accountRepo, userRepo, passwordHasher, emailService, cfg.ExportSigningSecret,
+		time.Duration(cfg.AccountDeletionGraceDays)*24*time.Hour, cfg.AppName, cfg.AppURL, cfg.APIURL)
+	// Collaborative editing sessions, shared between instances through Redis
+	var collabStore collab.Store = collab.NewMemoryStore()
+	if client := cacheService.Client(); client != nil {
+		collabStore = collab.NewRedisStore(client)
+	}
+	collabHub := collab.NewHub(collabStore, handlers.NewCollabPersister(noteRepo, revisionRepo, noteHandler),
+		time.Duration(cfg.CollabPersistSeconds)*time.Second)
+	collabHandler := handlers.NewCollabHandler(noteRepo, revisionRepo, userRepo, noteCollaboratorRepo, collabHub, cfg.CollabAllowedOrigins)
+	inboundHandler := handlers.NewInboundHandler(inboundRepo, noteRepo, noteHandler, cacheService, cfg.InboundEmailDomain, cfg.InboundWebhookSecret)
+
+	// Start background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	go eventHub.Run(jobsCtx)
+	go collabHub.Run(jobsCtx)
+
+	webhookWorker := scheduler.NewWebhookWorker(webhookRepo, webhook.NewSender(10*time.Second, cfg.AppName+" Webhooks", cfg.WebhookAllowPrivateTargets),
+		time.Duration(cfg.WebhookPollSeconds)*time.Second, cfg.WebhookMaxAttempts, cfg.WebhookDisableAfter)
+	go webhookWorker.Start(jobsCtx)
+
+	accountWorker := scheduler.NewAccountWorker(accountRepo, userRepo, settingsRepo, templateRepo, reminderRepo, emailService,
+		time.Duration(cfg.AccountPollSeconds)*time.Second, cfg.ExportDir, time.Duration(cfg.ExportTTLHours)*time.Hour,
+		cfg.ExportSigningSecret, cfg.AppName, cfg.AppURL, cfg.APIURL)
+	go accountWorker.Start(jobsCtx)
+
+	if emailService != nil {
+		outboxWorker := scheduler.NewOutboxWorker(outboxRepo, emailService,
+			time.Duration(cfg.OutboxPollSeconds)*time.Second, cfg.OutboxMaxAttempts)
+		go outboxWorker.Start(jobsCtx)
+
+		reminderScheduler := scheduler.NewReminderScheduler(reminderRepo, emailService,
+			time.Duration(cfg.ReminderPollSeconds)*time.Second, cfg.AppName, cfg.AppURL)
+		go reminderScheduler.Start(jobsCtx)
+
+		digestScheduler := scheduler.NewDigestScheduler(digestRepo, userRepo, emailService,
+			time.Duration(cfg.DigestPollMinutes)*time.Minute, cfg.AppName, cfg.AppURL, cfg.APIURL)
+		go digestScheduler.Start(jobsCtx)
+	} else {
+		log.Printf("Warning: email service disabled, note reminders and digests will not be delivered")
+	}
+
+	if cfg.InboundSMTPAddr != "" {
+		smtpServer := &inbound.SMTPServer{
+			Addr:      cfg.InboundSMTPAddr,
+			Hostname:  cfg.InboundEmailDomain,
+			MaxBytes:  handlers.InboundMaxBytes,
+			Recipient: inboundHandler.CheckRecipient,
+			Deliver:   inboundHandler.Deliver,
+		}
+		go func() {
+			if err := smtpServer.ListenAndServe(jobsCtx); err != nil {
+				log.Printf("Inbound SMTP listener failed: %v", err)
+			}
+		}()
+	}
 
 	// Setup Gin router
 	r := gin.New()
@@ -104,42 +268,184 @@ func main() {
 		c.JSON(200, healthData)
 	})
 
+	// Public keys for verifying our tokens
+	r.GET("/.well-known/jwks.json", jwksHandler.GetKeySet)
+
+	// OAuth2 authorization server for third-party clients
+	r.GET("/.well-known/oauth-authorization-server", oauthHandler.Metadata)
+	oauth := r.Group("/oauth")
+	{
+		oauth.GET("/authorize", oauthHandler.Authorize)
+		oauth.POST("/authorize", oauthHandler.Approve)
+		oauth.POST("/token", oauthHandler.Token)
+		oauth.POST("/introspect", oauthHandler.Introspect)
+		oauth.POST("/revoke", oauthHandler.Revoke)
+	}
+
 	// Public API routes (no authentication required)
 	api := r.Group("/api/v1")
 	{
 		// Authentication routes
 		auth := api.Group("/auth")
 		{
-			auth.POST("/register", authHandler.Register)
-			auth.POST("/login", authHandler.Login)
+			// Endpoints hashing a submitted password share one limit per IP
+			authRateLimit := middleware.RateLimit(cfg.AuthRateLimit, cfg.AuthRateWindow, cfg.AuthRateCleanup)
+
+			auth.POST("/register", authRateLimit, authHandler.Register)
+			auth.POST("/login", authRateLimit, authHandler.Login)
 			auth.POST("/test-email", authHandler.TestEmail)
+
+			// Forgotten password, reset through an emailed link
+			auth.POST("/password/forgot", authRateLimit, passwordResetHandler.ForgotPassword)
+			auth.POST("/password/reset", authRateLimit, passwordResetHandler.ResetPassword)
+
+			// Single sign-on (authorization code with PKCE)
+			auth.GET("/oidc/providers", oidcHandler.GetProviders)
+			auth.GET("/oidc/:provider/login", oidcHandler.StartLogin)
+			auth.GET("/oidc/:provider/callback", oidcHandler.Callback)
+			auth.POST("/oidc/link", authRateLimit, oidcHandler.ConfirmLink)
 		}
+
+		// Digest unsubscribe link from emails (token based, no login); GET
+		// only asks for confirmation
+		api.GET("/digest/unsubscribe", digestHandler.ConfirmUnsubscribe)
+		// RFC 8058 one-click unsubscribe posted by mail clients
+		api.POST("/digest/unsubscribe", digestHandler.Unsubscribe)
+
+		// Inbound email webhook (raw RFC 822 body, authorized by the address token)
+		api.POST("/inbound/email", inboundHandler.ReceiveEmail)
+
+		// Data export download link from emails (signed, no login)
+		api.GET("/exports/:id/download", accountHandler.DownloadExport)
+
+		// Collaborative editing WebSocket (token in header or access_token query)
+		api.GET("/notes/:id/collab", middleware.WebSocketAuth(jwtManager, sessionRepo, tokenRepo, oauthRepo),
+			middleware.RequireScope(models.ScopeNotesWrite), collabHandler.Connect)
 	}
 
-	// Protected API routes (authentication required)
+	// Protected API routes (authentication required). Each group either
+	// names the scope a personal access token needs or is session only.
 	protected := api.Group("")
-	protected.Use(middleware.AuthMiddleware(jwtManager))
+	protected.Use(middleware.AuthMiddleware(jwtManager, sessionRepo, tokenRepo, oauthRepo), middleware.UserSettings(settingsRepo))
+	noteScopes := middleware.RequireReadWriteScope(models.ScopeNotesRead, models.ScopeNotesWrite)
 	{
 		// User profile routes
 		user := protected.Group("/user")
+		user.Use(middleware.SessionOnly())
 		{
 			user.GET("/profile", authHandler.GetProfile)
 			user.PUT("/profile", authHandler.UpdateProfile)
+			user.GET("/settings", settingsHandler.GetSettings)
+			user.PUT("/settings", settingsHandler.UpdateSettings)
+			user.POST("/export", accountHandler.RequestExport)
+			user.GET("/exports", accountHandler.GetExports)
+			user.DELETE("", accountHandler.DeleteAccount)
+			user.POST("/deletion/cancel", accountHandler.CancelDeletion)
 			user.POST("/change-password", authHandler.ChangePassword)
+			user.GET("/sessions", sessionHandler.GetSessions)
+			user.DELETE("/sessions/:id", sessionHandler.RevokeSession)
+			user.POST("/sessions/revoke-others", sessionHandler.RevokeOtherSessions)
+			user.POST("/tokens", tokenHandler.CreateToken)
+			user.GET("/tokens", tokenHandler.GetTokens)
+			user.GET("/tokens/:id", tokenHandler.GetToken)
+			user.PUT("/tokens/:id", tokenHandler.UpdateToken)
+			user.DELETE("/tokens/:id", tokenHandler.DeleteToken)
+			user.POST("/oauth/clients", oauthHandler.CreateClient)
+			user.GET("/oauth/clients", oauthHandler.GetClients)
+			user.DELETE("/oauth/clients/:id", oauthHandler.DeleteClient)
+			user.GET("/oauth/grants", oauthHandler.GetGrants)
+			user.DELETE("/oauth/grants/:id", oauthHandler.RevokeGrant)
+			user.GET("/digest", digestHandler.GetDigest)
+			user.PUT("/digest", digestHandler.UpdateDigest)
+			user.GET("/inbound-address", inboundHandler.GetAddress)
+			user.POST("/inbound-address/rotate", inboundHandler.RotateAddress)
 		}
 
 		// Notes routes
 		notes := protected.Group("/notes")
+		notes.Use(noteScopes)
 		{
 			notes.POST("", noteHandler.CreateNote)
 			notes.GET("", noteHandler.GetNotes)
+			notes.GET("/graph", noteHandler.GetGraph)
+			notes.GET("/shared", collabHandler.GetSharedNotes)
 			notes.GET("/:id", noteHandler.GetNote)
 			notes.PUT("/:id", noteHandler.UpdateNote)
 			notes.DELETE("/:id", noteHandler.DeleteNote)
+			notes.POST("/:id/reminders", reminderHandler.CreateReminder)
+			notes.GET("/:id/reminders", reminderHandler.GetNoteReminders)
+			notes.GET("/:id/revisions", collabHandler.GetRevisions)
+			notes.POST("/:id/collaborators", collabHandler.AddCollaborator)
+			notes.GET("/:id/collaborators", collabHandler.GetCollaborators)
+			notes.DELETE("/:id/collaborators/:userId", collabHandler.RemoveCollaborator)
+			notes.GET("/:id/links", noteHandler.GetLinks)
+			notes.GET("/:id/backlinks", noteHandler.GetBacklinks)
+		}
+
+		// Reminder routes
+		reminders := protected.Group("/reminders")
+		reminders.Use(noteScopes)
+		{
+			reminders.GET("", reminderHandler.GetReminders)
+			reminders.POST("/:id/snooze", reminderHandler.SnoozeReminder)
+			reminders.DELETE("/:id", reminderHandler.CancelReminder)
+		}
+
+		// Task routes (checklist items parsed from note content)
+		tasks := protected.Group("/tasks")
+		tasks.Use(noteScopes)
+		{
+			tasks.GET("", taskHandler.GetTasks)
+			tasks.PATCH("/:id", taskHandler.UpdateTask)
+			tasks.POST("/carry-over", taskHandler.CarryOver)
+		}
+
+		// Webhook routes
+		webhooks := protected.Group("/webhooks")
+		webhooks.Use(middleware.SessionOnly())
+		{
+			webhooks.POST("", webhookHandler.CreateWebhook)
+			webhooks.GET("", webhookHandler.GetWebhooks)
+			webhooks.GET("/:id", webhookHandler.GetWebhook)
+			webhooks.PUT("/:id", webhookHandler.UpdateWebhook)
+			webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+			webhooks.GET("/:id/deliveries", webhookHandler.GetDeliveries)
+			webhooks.POST("/:id/deliveries/:deliveryId/redeliver", webhookHandler.Redeliver)
+		}
+
+		// Note template routes
+		templates := protected.Group("/templates")
+		templates.Use(noteScopes)
+		{
+			templates.POST("", templateHandler.CreateTemplate)
+			templates.GET("", templateHandler.GetTemplates)
+			templates.GET("/:id", templateHandler.GetTemplate)
+			templates.PUT("/:id", templateHandler.UpdateTemplate)
+			templates.DELETE("/:id", templateHandler.DeleteTemplate)
 		}
 
 		// Categories route
-		protected.GET("/categories", noteHandler.GetCategories)
+		protected.GET("/categories", noteScopes, noteHandler.GetCategories)
+
+		// Writing statistics and streaks
+		protected.GET("/stats", noteScopes, statsHandler.GetStats)
+
+		// Delta sync for offline clients
+		protected.GET("/sync", noteScopes, noteHandler.GetSyncChanges)
+		protected.POST("/sync", noteScopes, noteHandler.PushSyncChanges)
+
+		// Live note changes (Server-Sent Events)
+		protected.GET("/events", noteScopes, eventsHandler.Stream)
+
+		// Admin routes
+		admin := protected.Group("/admin")
+		admin.Use(middleware.SessionOnly(), middleware.AdminOnly(userRepo))
+		{
+			admin.GET("/email-outbox", adminHandler.GetOutbox)
+			admin.POST("/email-outbox/:id/requeue", adminHandler.RequeueOutboxMessage)
+			admin.POST("/users/:id/unlock", adminHandler.UnlockUser)
+			admin.POST("/note-links/reindex", adminHandler.ReindexNoteLinks)
+		}
 	}
 
 	// Start server
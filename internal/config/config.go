@@ -3,12 +3,18 @@ package config
 import (
 	"log"
 	"os"
+	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// DefaultJWTSecret is used when JWT_SECRET is not set. It is only allowed in
+// development.
+const DefaultJWTSecret = "default-secret-change-this"
+
 type Config struct {
 	DBHost         string
 	DBPort         string
@@ -19,6 +25,42 @@ type Config struct {
 	JWTSecret      string
 	JWTExpiryHours int
 
+	// Environment is "development" or "production"
+	Environment string
+
+	// BootstrapAdmin is the username given the admin role at startup
+	BootstrapAdmin string
+
+	// Token signing. HS256 signs with JWTSecret, RS256 and EdDSA with the PEM
+	// private key in JWTSigningKeyFile.
+	JWTAlgorithm            string
+	JWTSigningKeyFile       string
+	JWTVerificationKeyFiles []string // public keys of retired signing keys, accepted until their tokens expire
+
+	// Password hashing. Hashes using another algorithm or other parameters
+	// are upgraded on the next login.
+	PasswordHashAlgorithm string // argon2id or bcrypt
+	Argon2MemoryKiB       uint32
+	Argon2Iterations      uint32
+	Argon2Parallelism     uint8
+	BcryptCost            int
+
+	// At most this many passwords are hashed or verified at once, since each
+	// Argon2id hash holds Argon2MemoryKiB while it runs
+	PasswordHashConcurrency int
+
+	// Password policy
+	PasswordMinLength      int
+	PasswordMinEntropyBits float64
+	BreachedPasswordsDir   string // Pwned Passwords range files, empty disables the breach check
+
+	// How long an emailed password reset link stays valid
+	PasswordResetTTL time.Duration
+
+	// Account lockout after repeated failed logins
+	LoginMaxFailures    int
+	LoginLockoutMinutes int
+
 	// Rate limiting configuration
 	AuthRateLimit     int           // requests per window
 	AuthRateWindow    time.Duration // time window
@@ -26,14 +68,20 @@ type Config struct {
 	GeneralRateLimit  int           // general API rate limit
 	GeneralRateWindow time.Duration // general API time window
 
-	// SMTP Email configuration
-	SMTPHost     string
-	SMTPPort     int
-	SMTPUsername string
-	SMTPPassword string
-	SMTPFrom     string
-	AppName      string
-	AppURL       string
+	// Email configuration
+	EmailTransport   string // smtp, log, file or http
+	EmailFileDir     string // directory for the file transport
+	EmailHTTPURL     string // send endpoint for the http transport
+	EmailTemplateDir string // optional directory overriding the embedded email templates
+	SMTPSecurity     string // starttls, tls or none
+	SMTPHost         string
+	SMTPPort         int
+	SMTPUsername     string
+	SMTPPassword     string
+	SMTPFrom         string
+	AppName          string
+	AppURL           string
+	APIURL           string
 
 	// Redis Cache configuration
 	RedisHost       string
@@ -42,6 +90,55 @@ type Config struct {
 	RedisDB         int
 	CacheEnabled    bool
 	CacheTTLMinutes int
+
+	// Inbound email-to-note gateway
+	InboundEmailDomain   string // domain of the u-<token>@domain addresses
+	InboundWebhookSecret string // required in X-Inbound-Secret when set
+	InboundSMTPAddr      string // listen address for the SMTP listener, empty disables it
+
+	// Background jobs
+	ReminderPollSeconds int
+	DigestPollMinutes   int
+	OutboxPollSeconds   int
+	OutboxMaxAttempts   int
+	WebhookPollSeconds  int
+	WebhookMaxAttempts  int
+	WebhookDisableAfter int // consecutive failed attempts before a webhook is disabled
+	AccountPollSeconds  int // how often data exports and due account deletions are processed
+
+	// WebhookAllowPrivateTargets lets webhooks reach loopback and private
+	// addresses, for local development only
+	WebhookAllowPrivateTargets bool
+
+	// Collaborative editing
+	CollabPersistSeconds int // how often live sessions are saved back to the note
+
+	// CollabAllowedOrigins lists the browser origins that may open editing
+	// sessions; requests without an Origin header are not from browsers
+	CollabAllowedOrigins []string
+
+	// Data export and account deletion
+	ExportDir                string // where export archives are written
+	ExportTTLHours           int    // how long the download link and archive are kept
+	ExportSigningSecret      string // signs export download links
+	AccountDeletionGraceDays int    // days a deletion can still be cancelled
+
+	// Single sign-on through OpenID Connect providers
+	OIDCProviders []OIDCProviderConfig
+
+	// OAuth2 authorization server for third-party clients
+	OAuthAccessTokenMinutes int
+	OAuthRefreshTokenDays   int
+}
+
+// OIDCProviderConfig is one OpenID Connect identity provider. Providers are
+// listed in OIDC_PROVIDERS and configured with OIDC_<NAME>_* variables.
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
 }
 
 func LoadConfig() *Config {
@@ -67,6 +164,43 @@ func LoadConfig() *Config {
 	cacheEnabled, _ := strconv.ParseBool(getEnv("CACHE_ENABLED", "true"))
 	cacheTTLMinutes, _ := strconv.Atoi(getEnv("CACHE_TTL_MINUTES", "30"))
 
+	// Background job configuration
+	reminderPollSeconds, _ := strconv.Atoi(getEnv("REMINDER_POLL_SECONDS", "30"))
+	digestPollMinutes, _ := strconv.Atoi(getEnv("DIGEST_POLL_MINUTES", "5"))
+	outboxPollSeconds, _ := strconv.Atoi(getEnv("OUTBOX_POLL_SECONDS", "10"))
+	outboxMaxAttempts, _ := strconv.Atoi(getEnv("OUTBOX_MAX_ATTEMPTS", "8"))
+	webhookPollSeconds, _ := strconv.Atoi(getEnv("WEBHOOK_POLL_SECONDS", "5"))
+	webhookMaxAttempts, _ := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "8"))
+	webhookDisableAfter, _ := strconv.Atoi(getEnv("WEBHOOK_DISABLE_AFTER", "20"))
+	webhookAllowPrivateTargets, _ := strconv.ParseBool(getEnv("WEBHOOK_ALLOW_PRIVATE_TARGETS", "false"))
+	collabPersistSeconds, _ := strconv.Atoi(getEnv("COLLAB_PERSIST_SECONDS", "5"))
+	accountPollSeconds, _ := strconv.Atoi(getEnv("ACCOUNT_POLL_SECONDS", "30"))
+
+	// Data export and account deletion
+	jwtSecret := getEnv("JWT_SECRET", DefaultJWTSecret)
+	exportTTLHours, _ := strconv.Atoi(getEnv("EXPORT_TTL_HOURS", "72"))
+	accountDeletionGraceDays, _ := strconv.Atoi(getEnv("ACCOUNT_DELETION_GRACE_DAYS", "14"))
+
+	// Password hashing
+	argon2MemoryKiB, _ := strconv.ParseUint(getEnv("ARGON2_MEMORY_KIB", "65536"), 10, 32)
+	argon2Iterations, _ := strconv.ParseUint(getEnv("ARGON2_ITERATIONS", "3"), 10, 32)
+	argon2Parallelism, _ := strconv.ParseUint(getEnv("ARGON2_PARALLELISM", "4"), 10, 8)
+	bcryptCost, _ := strconv.Atoi(getEnv("BCRYPT_COST", "10"))
+	passwordHashConcurrency, _ := strconv.Atoi(getEnv("PASSWORD_HASH_CONCURRENCY", strconv.Itoa(runtime.NumCPU())))
+
+	// Password policy
+	passwordMinLength, _ := strconv.Atoi(getEnv("PASSWORD_MIN_LENGTH", "10"))
+	passwordMinEntropyBits, _ := strconv.ParseFloat(getEnv("PASSWORD_MIN_ENTROPY_BITS", "40"), 64)
+	passwordResetTTLMinutes, _ := strconv.Atoi(getEnv("PASSWORD_RESET_TTL_MINUTES", "60"))
+
+	// Account lockout
+	loginMaxFailures, _ := strconv.Atoi(getEnv("LOGIN_MAX_FAILURES", "10"))
+	loginLockoutMinutes, _ := strconv.Atoi(getEnv("LOGIN_LOCKOUT_MINUTES", "15"))
+
+	// OAuth2 authorization server
+	oauthAccessTokenMinutes, _ := strconv.Atoi(getEnv("OAUTH_ACCESS_TOKEN_MINUTES", "60"))
+	oauthRefreshTokenDays, _ := strconv.Atoi(getEnv("OAUTH_REFRESH_TOKEN_DAYS", "30"))
+
 	return &Config{
 		DBHost:         getEnv("DB_HOST", "localhost"),
 		DBPort:         getEnv("DB_PORT", "3306"),
@@ -74,9 +208,38 @@ func LoadConfig() *Config {
 		DBPassword:     getEnv("DB_PASSWORD", ""),
 		DBName:         getEnv("DB_NAME", "daily_notes"),
 		ServerPort:     getEnv("SERVER_PORT", "8080"),
-		JWTSecret:      getEnv("JWT_SECRET", "default-secret-change-this"),
+		JWTSecret:      jwtSecret,
 		JWTExpiryHours: jwtExpiryHours,
 
+		Environment: strings.ToLower(getEnv("APP_ENV", "production")),
+
+		BootstrapAdmin: getEnv("BOOTSTRAP_ADMIN", ""),
+
+		// Token signing
+		JWTAlgorithm:            getEnv("JWT_ALGORITHM", "HS256"),
+		JWTSigningKeyFile:       getEnv("JWT_SIGNING_KEY_FILE", ""),
+		JWTVerificationKeyFiles: splitList(getEnv("JWT_VERIFICATION_KEY_FILES", "")),
+
+		// Password hashing
+		PasswordHashAlgorithm: getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+		Argon2MemoryKiB:       uint32(argon2MemoryKiB),
+		Argon2Iterations:      uint32(argon2Iterations),
+		Argon2Parallelism:     uint8(argon2Parallelism),
+		BcryptCost:            bcryptCost,
+
+		PasswordHashConcurrency: passwordHashConcurrency,
+
+		// Password policy
+		PasswordMinLength:      passwordMinLength,
+		PasswordMinEntropyBits: passwordMinEntropyBits,
+		BreachedPasswordsDir:   getEnv("BREACHED_PASSWORDS_DIR", ""),
+
+		PasswordResetTTL: time.Duration(passwordResetTTLMinutes) * time.Minute,
+
+		// Account lockout
+		LoginMaxFailures:    loginMaxFailures,
+		LoginLockoutMinutes: loginLockoutMinutes,
+
 		// Rate limiting
 		AuthRateLimit:     authRateLimit,
 		AuthRateWindow:    time.Duration(authRateWindowMinutes) * time.Minute,
@@ -84,14 +247,20 @@ func LoadConfig() *Config {
 		GeneralRateLimit:  generalRateLimit,
 		GeneralRateWindow: time.Duration(generalRateWindowMinutes) * time.Minute,
 
-		// SMTP Email settings
-		SMTPHost:     getEnv("SMTP_HOST", "smtp.gmail.com"),
-		SMTPPort:     smtpPort,
-		SMTPUsername: getEnv("SMTP_USERNAME", ""),
-		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
-		SMTPFrom:     getEnv("SMTP_FROM", ""),
-		AppName:      getEnv("APP_NAME", "Daily Notes"),
-		AppURL:       getEnv("APP_URL", "http://localhost:3000"),
+		// Email settings
+		EmailTransport:   getEnv("EMAIL_TRANSPORT", "smtp"),
+		EmailFileDir:     getEnv("EMAIL_FILE_DIR", "tmp/mail"),
+		EmailHTTPURL:     getEnv("EMAIL_HTTP_URL", "http://localhost:8025/api/v1/send"),
+		EmailTemplateDir: getEnv("EMAIL_TEMPLATE_DIR", ""),
+		SMTPSecurity:     getEnv("SMTP_SECURITY", "starttls"),
+		SMTPHost:         getEnv("SMTP_HOST", "smtp.gmail.com"),
+		SMTPPort:         smtpPort,
+		SMTPUsername:     getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:     getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:         getEnv("SMTP_FROM", ""),
+		AppName:          getEnv("APP_NAME", "Daily Notes"),
+		AppURL:           getEnv("APP_URL", "http://localhost:3000"),
+		APIURL:           getEnv("API_URL", "http://localhost:8080"),
 
 		// Redis Cache settings
 		RedisHost:       getEnv("REDIS_HOST", "localhost"),
@@ -100,7 +269,94 @@ func LoadConfig() *Config {
 		RedisDB:         redisDB,
 		CacheEnabled:    cacheEnabled,
 		CacheTTLMinutes: cacheTTLMinutes,
+
+		// Inbound email
+		InboundEmailDomain:   getEnv("INBOUND_EMAIL_DOMAIN", "notes.example"),
+		InboundWebhookSecret: getEnv("INBOUND_WEBHOOK_SECRET", ""),
+		InboundSMTPAddr:      getEnv("INBOUND_SMTP_ADDR", ""),
+
+		// Background jobs
+		ReminderPollSeconds: reminderPollSeconds,
+		DigestPollMinutes:   digestPollMinutes,
+		OutboxPollSeconds:   outboxPollSeconds,
+		OutboxMaxAttempts:   outboxMaxAttempts,
+		WebhookPollSeconds:  webhookPollSeconds,
+		WebhookMaxAttempts:  webhookMaxAttempts,
+		WebhookDisableAfter: webhookDisableAfter,
+		AccountPollSeconds:  accountPollSeconds,
+
+		WebhookAllowPrivateTargets: webhookAllowPrivateTargets,
+
+		// Collaborative editing
+		CollabPersistSeconds: collabPersistSeconds,
+		CollabAllowedOrigins: splitList(getEnv("COLLAB_ALLOWED_ORIGINS", getEnv("APP_URL", "http://localhost:3000"))),
+
+		// Data export and account deletion
+		ExportDir:                getEnv("EXPORT_DIR", "tmp/exports"),
+		ExportTTLHours:           exportTTLHours,
+		ExportSigningSecret:      getEnv("EXPORT_SIGNING_SECRET", jwtSecret),
+		AccountDeletionGraceDays: accountDeletionGraceDays,
+
+		// Single sign-on
+		OIDCProviders: loadOIDCProviders(),
+
+		// OAuth2 authorization server
+		OAuthAccessTokenMinutes: oauthAccessTokenMinutes,
+		OAuthRefreshTokenDays:   oauthRefreshTokenDays,
+	}
+}
+
+// loadOIDCProviders reads OIDC_PROVIDERS=google,corp and, for each name,
+// OIDC_GOOGLE_ISSUER, OIDC_GOOGLE_CLIENT_ID, OIDC_GOOGLE_CLIENT_SECRET and
+// the optional space separated OIDC_GOOGLE_SCOPES
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProviderConfig{
+			Name:         name,
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			log.Printf("Warning: OIDC provider %s is missing %sISSUER or %sCLIENT_ID, skipping it", name, prefix, prefix)
+			continue
+		}
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
+// IsDevelopment reports whether the server runs in development mode
+func (c *Config) IsDevelopment() bool {
+	return c.Environment == "development"
+}
+
+// UsesDefaultSecret reports whether tokens or export links would be signed
+// with DefaultJWTSecret
+func (c *Config) UsesDefaultSecret() bool {
+	if c.ExportSigningSecret == DefaultJWTSecret {
+		return true
+	}
+	return c.JWTAlgorithm == "HS256" && c.JWTSecret == DefaultJWTSecret
+}
+
+// splitList splits a comma separated value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
 
 func getEnv(key, defaultValue string) string {
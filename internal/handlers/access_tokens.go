@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"strings"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccessTokenHandler struct {
+	tokenRepo repository.AccessTokenRepository
+}
+
+func NewAccessTokenHandler(tokenRepo repository.AccessTokenRepository) *AccessTokenHandler {
+	return &AccessTokenHandler{
+		tokenRepo: tokenRepo,
+	}
+}
+
+// CreateToken issues a personal access token. The token itself is only
+// returned here.
+func (h *AccessTokenHandler) CreateToken(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req models.CreateAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "name",
+			Message: "Name is required and cannot be empty",
+		})
+		return
+	}
+
+	token, err := h.tokenRepo.Create(userID, &req)
+	if err != nil {
+		response.InternalServerError(c, "Failed to create access token")
+		return
+	}
+
+	token.Localize(middleware.GetUserLocation(c))
+	response.Created(c, token)
+}
+
+func (h *AccessTokenHandler) GetTokens(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	tokens, err := h.tokenRepo.GetAll(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get access tokens")
+		return
+	}
+
+	loc := middleware.GetUserLocation(c)
+	for _, token := range tokens {
+		token.Localize(loc)
+	}
+
+	response.Success(c, tokens)
+}
+
+func (h *AccessTokenHandler) GetToken(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Token ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	token, err := h.tokenRepo.GetByID(id, userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get access token")
+		return
+	}
+
+	if token == nil {
+		response.NotFound(c, "Access token not found")
+		return
+	}
+
+	token.Localize(middleware.GetUserLocation(c))
+	response.Success(c, token)
+}
+
+// UpdateToken renames a token or changes its scopes; the token value and
+// expiry stay the same
+func (h *AccessTokenHandler) UpdateToken(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Token ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "name",
+			Message: "Name is required and cannot be empty",
+		})
+		return
+	}
+
+	token, err := h.tokenRepo.Update(id, userID, &req)
+	if err != nil {
+		response.InternalServerError(c, "Failed to update access token")
+		return
+	}
+
+	if token == nil {
+		response.NotFound(c, "Access token not found")
+		return
+	}
+
+	token.Localize(middleware.GetUserLocation(c))
+	response.Success(c, token)
+}
+
+// DeleteToken revokes a token immediately
+func (h *AccessTokenHandler) DeleteToken(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Token ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	if err := h.tokenRepo.Delete(id, userID); err != nil {
+		if err.Error() == "access token not found or not owned by user" {
+			response.NotFound(c, "Access token not found or you don't have permission to delete it")
+			return
+		}
+		response.InternalServerError(c, "Failed to delete access token")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Access token deleted successfully"})
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/auth"
+	"daily-notes-api/pkg/email"
+	"daily-notes-api/pkg/response"
+	"daily-notes-api/pkg/signedurl"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountHandler serves GDPR data exports and account deletion
+type AccountHandler struct {
+	accountRepo    repository.AccountRepository
+	userRepo       repository.UserRepository
+	passwordHasher *auth.PasswordHasher
+	emailService   *email.EmailService
+	signingSecret  string
+	gracePeriod    time.Duration
+	appName        string
+	appURL         string
+	apiURL         string
+}
+
+func NewAccountHandler(accountRepo repository.AccountRepository, userRepo repository.UserRepository, passwordHasher *auth.PasswordHasher, emailService *email.EmailService, signingSecret string, gracePeriod time.Duration, appName, appURL, apiURL string) *AccountHandler {
+	return &AccountHandler{
+		accountRepo:    accountRepo,
+		userRepo:       userRepo,
+		passwordHasher: passwordHasher,
+		emailService:   emailService,
+		signingSecret:  signingSecret,
+		gracePeriod:    gracePeriod,
+		appName:        appName,
+		appURL:         appURL,
+		apiURL:         apiURL,
+	}
+}
+
+// RequestExport queues a data export; the archive is emailed when ready
+func (h *AccountHandler) RequestExport(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	pending, err := h.accountRepo.HasPendingExport(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to request data export")
+		return
+	}
+	if pending {
+		response.Conflict(c, "A data export is already being prepared")
+		return
+	}
+
+	export, err := h.accountRepo.CreateExport(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to request data export")
+		return
+	}
+
+	export.Localize(middleware.GetUserLocation(c))
+	response.Accepted(c, export)
+}
+
+func (h *AccountHandler) GetExports(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	exports, err := h.accountRepo.GetExports(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get data exports")
+		return
+	}
+
+	loc := middleware.GetUserLocation(c)
+	for _, export := range exports {
+		export.DownloadURL = export.SignedDownloadURL(h.signingSecret, h.apiURL)
+		export.Localize(loc)
+	}
+
+	response.Success(c, exports)
+}
+
+// DownloadExport serves an export archive to the holder of a signed link
+func (h *AccountHandler) DownloadExport(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Export ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	path := fmt.Sprintf(models.ExportDownloadPath, id)
+	if !signedurl.Verify(h.signingSecret, path, c.Query(signedurl.ParamExpires), c.Query(signedurl.ParamSignature)) {
+		response.Forbidden(c, "Download link is invalid or has expired")
+		return
+	}
+
+	export, err := h.accountRepo.GetExport(id)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get data export")
+		return
+	}
+	if export == nil || export.Status != models.ExportStatusReady {
+		response.NotFound(c, "Data export not found")
+		return
+	}
+
+	if _, err := os.Stat(export.FilePath); err != nil {
+		log.Printf("Data export %d archive is missing: %v", export.ID, err)
+		response.NotFound(c, "Data export not found")
+		return
+	}
+
+	c.FileAttachment(export.FilePath, fmt.Sprintf("notes-export-%s.zip", export.CreatedAt.Format("2006-01-02")))
+}
+
+// DeleteAccount schedules the account for deletion after the grace period.
+// The password is checked again since the token alone may be stolen.
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get user")
+		return
+	}
+	if user == nil {
+		response.NotFound(c, "User not found")
+		return
+	}
+
+	if match, _ := h.passwordHasher.Verify(req.Password, user.PasswordHash); !match {
+		response.Unauthorized(c, "Password is incorrect")
+		return
+	}
+
+	loc := middleware.GetUserLocation(c)
+	if user.DeletionScheduledAt != nil {
+		response.Success(c, models.AccountDeletionResponse{DeletionScheduledAt: user.DeletionScheduledAt.In(loc)})
+		return
+	}
+
+	// DATETIME has second precision
+	deleteAt := time.Now().Add(h.gracePeriod).Truncate(time.Second)
+
+	var messages []*models.OutboxMessage
+	if h.emailService != nil {
+		msg, err := h.emailService.BuildDeletionScheduledEmail(h.emailData(user, deleteAt.In(loc)))
+		if err != nil {
+			log.Printf("Failed to build deletion scheduled email for user %d: %v", userID, err)
+		} else {
+			messages = append(messages, models.NewOutboxMessage("deletion_scheduled", msg))
+		}
+	}
+
+	if err := h.accountRepo.ScheduleDeletion(userID, deleteAt, messages); err != nil {
+		response.InternalServerError(c, "Failed to schedule account deletion")
+		return
+	}
+
+	response.Accepted(c, models.AccountDeletionResponse{DeletionScheduledAt: deleteAt.In(loc)})
+}
+
+// CancelDeletion keeps an account that is still within its grace period
+func (h *AccountHandler) CancelDeletion(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get user")
+		return
+	}
+	if user == nil {
+		response.NotFound(c, "User not found")
+		return
+	}
+
+	var messages []*models.OutboxMessage
+	if h.emailService != nil && user.DeletionScheduledAt != nil {
+		msg, err := h.emailService.BuildDeletionCancelledEmail(h.emailData(user, time.Time{}))
+		if err != nil {
+			log.Printf("Failed to build deletion cancelled email for user %d: %v", userID, err)
+		} else {
+			messages = append(messages, models.NewOutboxMessage("deletion_cancelled", msg))
+		}
+	}
+
+	cancelled, err := h.accountRepo.CancelDeletion(userID, messages)
+	if err != nil {
+		response.InternalServerError(c, "Failed to cancel account deletion")
+		return
+	}
+	if !cancelled {
+		response.NotFound(c, "Account is not scheduled for deletion")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Account deletion cancelled"})
+}
+
+func (h *AccountHandler) emailData(user *models.User, deleteAt time.Time) email.AccountEmailData {
+	data := email.AccountEmailData{
+		Locale:   user.Locale,
+		UserName: user.FullName,
+		Email:    user.Email,
+		AppName:  h.appName,
+		AppURL:   h.appURL,
+	}
+	if !deleteAt.IsZero() {
+		data.DeletionDate = deleteAt.Format("Mon, 02 Jan 2006 15:04 MST")
+	}
+	return data
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"strconv"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	outboxRepo        repository.OutboxRepository
+	loginSecurityRepo repository.LoginSecurityRepository
+	userRepo          repository.UserRepository
+	noteRepo          repository.NoteRepository
+}
+
+func NewAdminHandler(outboxRepo repository.OutboxRepository, loginSecurityRepo repository.LoginSecurityRepository, userRepo repository.UserRepository, noteRepo repository.NoteRepository) *AdminHandler {
+	return &AdminHandler{
+		outboxRepo:        outboxRepo,
+		loginSecurityRepo: loginSecurityRepo,
+		userRepo:          userRepo,
+		noteRepo:          noteRepo,
+	}
+}
+
+// GetOutbox lists queued, sent and dead emails, newest first
+func (h *AdminHandler) GetOutbox(c *gin.Context) {
+	var filter models.OutboxFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	switch filter.Status {
+	case "", models.OutboxStatusPending, models.OutboxStatusSent, models.OutboxStatusDead:
+	default:
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "status",
+			Message: "Status must be one of pending, sent or dead",
+			Value:   filter.Status,
+		})
+		return
+	}
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 100 {
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "limit",
+			Message: "Limit cannot exceed 100 items per page",
+			Value:   strconv.Itoa(filter.Limit),
+		})
+		return
+	}
+
+	messages, total, err := h.outboxRepo.GetAll(&filter)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get outbox messages")
+		return
+	}
+
+	meta := response.CalculatePagination(filter.Page, filter.Limit, total)
+	response.SuccessWithMeta(c, messages, meta)
+}
+
+// RequeueOutboxMessage gives a dead email a fresh set of delivery attempts
+func (h *AdminHandler) RequeueOutboxMessage(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Message ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	msg, err := h.outboxRepo.Requeue(id)
+	if err != nil {
+		response.InternalServerError(c, "Failed to requeue message")
+		return
+	}
+
+	if msg == nil {
+		response.NotFound(c, "Dead message not found")
+		return
+	}
+
+	response.Success(c, msg)
+}
+
+// UnlockUser lifts a lockout and clears the user's failed logins
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "User ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	user, err := h.userRepo.GetByID(id)
+	if err != nil {
+		response.InternalServerError(c, "Failed to unlock user")
+		return
+	}
+	if user == nil {
+		response.NotFound(c, "User not found")
+		return
+	}
+
+	cleared, err := h.loginSecurityRepo.ClearFailures(user.Username)
+	if err != nil {
+		response.InternalServerError(c, "Failed to unlock user")
+		return
+	}
+
+	if !cleared {
+		response.NotFound(c, "User has no failed logins to clear")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "User unlocked successfully"})
+}
+
+// ReindexNoteLinks rebuilds the [[link]] index from note content, e.g. for
+// notes written before links were indexed
+func (h *AdminHandler) ReindexNoteLinks(c *gin.Context) {
+	indexed, err := h.noteRepo.ReindexLinks()
+	if err != nil {
+		response.InternalServerError(c, "Failed to reindex note links")
+		return
+	}
+
+	response.Success(c, gin.H{"notes_indexed": indexed})
+}
@@ -1,34 +1,67 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"strconv"
 	"strings"
+	"time"
 
+	"daily-notes-api/internal/middleware"
 	"daily-notes-api/internal/models"
 	"daily-notes-api/internal/repository"
 	"daily-notes-api/pkg/auth"
 	"daily-notes-api/pkg/email"
+	"daily-notes-api/pkg/passwordpolicy"
 	"daily-notes-api/pkg/response"
+	"daily-notes-api/pkg/useragent"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxUserAgentLength matches the user_sessions.user_agent column
+const maxUserAgentLength = 500
+
 type AuthHandler struct {
-	userRepo     repository.UserRepository
-	jwtManager   *auth.JWTManager
-	emailService *email.EmailService
-	appName      string
-	appURL       string
+	userRepo          repository.UserRepository
+	sessionRepo       repository.SessionRepository
+	loginSecurityRepo repository.LoginSecurityRepository
+	jwtManager        *auth.JWTManager
+	passwordHasher    *auth.PasswordHasher
+	passwordPolicy    *passwordpolicy.Policy
+	emailService      *email.EmailService
+	appName           string
+	appURL            string
+	maxLoginFailures  int
+	lockoutDuration   time.Duration
+
+	// Checked against for unknown usernames, so they take as long as a
+	// wrong password
+	dummyHash string
 }
 
-func NewAuthHandler(userRepo repository.UserRepository, jwtManager *auth.JWTManager, emailService *email.EmailService, appName, appURL string) *AuthHandler {
-	return &AuthHandler{
-		userRepo:     userRepo,
-		jwtManager:   jwtManager,
-		emailService: emailService,
-		appName:      appName,
-		appURL:       appURL,
+func NewAuthHandler(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, loginSecurityRepo repository.LoginSecurityRepository, jwtManager *auth.JWTManager, passwordHasher *auth.PasswordHasher, passwordPolicy *passwordpolicy.Policy, emailService *email.EmailService, appName, appURL string, maxLoginFailures int, lockoutDuration time.Duration) (*AuthHandler, error) {
+	// Without it unknown usernames would answer faster than wrong passwords
+	dummyHash, err := passwordHasher.Hash("dummy password for unknown usernames")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dummy password hash: %w", err)
 	}
+
+	return &AuthHandler{
+		userRepo:          userRepo,
+		sessionRepo:       sessionRepo,
+		loginSecurityRepo: loginSecurityRepo,
+		jwtManager:        jwtManager,
+		passwordHasher:    passwordHasher,
+		passwordPolicy:    passwordPolicy,
+		emailService:      emailService,
+		appName:           appName,
+		appURL:            appURL,
+		maxLoginFailures:  maxLoginFailures,
+		lockoutDuration:   lockoutDuration,
+		dummyHash:         dummyHash,
+	}, nil
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -54,12 +87,12 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		})
 	}
 
-	if len(req.Password) < 6 {
-		validationErrors = append(validationErrors, response.ValidationError{
-			Field:   "password",
-			Message: "Password must be at least 6 characters long",
-		})
+	passwordErrors, err := h.passwordErrors("password", req.Password, req.Username, req.Email)
+	if err != nil {
+		response.InternalServerError(c, "Failed to check password")
+		return
 	}
+	validationErrors = append(validationErrors, passwordErrors...)
 
 	if len(validationErrors) > 0 {
 		response.ValidationErrorsResponse(c, validationErrors)
@@ -88,39 +121,26 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	passwordHash, err := h.passwordHasher.Hash(req.Password)
+	if err != nil {
+		response.InternalServerError(c, "Failed to hash password")
+		return
+	}
+
 	// Create user
-	user, err := h.userRepo.Create(&req)
+	user, err := h.userRepo.CreateWithOutbox(&req, passwordHash, h.welcomeOutbox(&req))
 	if err != nil {
 		response.InternalServerError(c, "Failed to create user")
 		return
 	}
 
 	// Generate JWT token
-	token, err := h.jwtManager.GenerateToken(user.ID, user.Username)
+	token, err := h.startSession(c, user, "")
 	if err != nil {
 		response.InternalServerError(c, "Failed to generate token")
 		return
 	}
 
-	// Send welcome email asynchronously
-	go func() {
-		if h.emailService != nil {
-			welcomeData := email.WelcomeEmailData{
-				UserName: user.FullName,
-				Email:    user.Email,
-				AppName:  h.appName,
-				AppURL:   h.appURL,
-			}
-
-			if err := h.emailService.SendWelcomeEmail(welcomeData); err != nil {
-				log.Printf("Failed to send welcome email to %s: %v", user.Email, err)
-				// Don't fail the registration if email fails
-			} else {
-				log.Printf("Welcome email sent successfully to %s", user.Email)
-			}
-		}
-	}()
-
 	loginResponse := &models.LoginResponse{
 		User:  user.ToResponse(),
 		Token: token,
@@ -129,6 +149,34 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	response.Created(c, loginResponse)
 }
 
+// welcomeOutbox builds the welcome email, queued in the same transaction as
+// the user so a crash or SMTP outage cannot lose it
+func (h *AuthHandler) welcomeOutbox(req *models.RegisterRequest) []*models.OutboxMessage {
+	if h.emailService == nil {
+		return nil
+	}
+
+	welcome, err := h.emailService.BuildWelcomeEmail(email.WelcomeEmailData{
+		Locale:   req.Locale,
+		UserName: req.FullName,
+		Email:    req.Email,
+		AppName:  h.appName,
+		AppURL:   h.appURL,
+	})
+	if err != nil {
+		log.Printf("Failed to build welcome email for %s: %v", req.Email, err)
+		return nil
+	}
+
+	return []*models.OutboxMessage{{
+		Kind:      "welcome",
+		Recipient: welcome.To,
+		Subject:   welcome.Subject,
+		HTMLBody:  welcome.Body,
+		TextBody:  welcome.TextBody,
+	}}
+}
+
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -153,9 +201,14 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Get user by username
-	user, err := h.userRepo.GetByUsername(req.Username)
+	user, err := h.checkCredentials(c, req.Username, req.Password)
 	if err != nil {
+		var throttledErr *loginThrottledError
+		if errors.As(err, &throttledErr) {
+			c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(throttledErr.retryAfter)))
+			response.TooManyRequests(c, throttledErr.Error())
+			return
+		}
 		response.InternalServerError(c, "Failed to authenticate user")
 		return
 	}
@@ -165,14 +218,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Verify password
-	if !auth.CheckPasswordHash(req.Password, user.PasswordHash) {
-		response.Unauthorized(c, "Invalid username or password")
-		return
-	}
-
 	// Generate JWT token
-	token, err := h.jwtManager.GenerateToken(user.ID, user.Username)
+	token, err := h.startSession(c, user, strings.TrimSpace(req.DeviceName))
 	if err != nil {
 		response.InternalServerError(c, "Failed to generate token")
 		return
@@ -186,6 +233,101 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	response.Success(c, loginResponse)
 }
 
+// checkCredentials returns the user when the password matches, or nil when
+// the username or password is wrong. Attempts are counted per username
+// before the password is checked, and usernames with recent failed logins
+// get a loginThrottledError instead, whether or not an account has them.
+// Hashes using an outdated algorithm or parameters are upgraded on the way.
+func (h *AuthHandler) checkCredentials(c *gin.Context, username, password string) (*models.User, error) {
+	now := time.Now()
+	failures, wait, err := h.loginSecurityRepo.TryAttempt(username, now)
+	if err != nil {
+		return nil, err
+	}
+	if wait > 0 {
+		return nil, &loginThrottledError{retryAfter: wait, locked: failures.Locked(now)}
+	}
+
+	// Get user by username
+	user, err := h.userRepo.GetByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		// Take as long as a wrong password, so response times don't tell
+		// which usernames exist
+		h.passwordHasher.Verify(password, h.dummyHash)
+		h.recordFailedLogin(c, username, nil, failures)
+		return nil, nil
+	}
+
+	// Verify password
+	match, rehash := h.passwordHasher.Verify(password, user.PasswordHash)
+	if !match {
+		h.recordFailedLogin(c, username, user, failures)
+		return nil, nil
+	}
+
+	if _, err := h.loginSecurityRepo.ClearFailures(username); err != nil {
+		log.Printf("Failed to clear failed logins for user %d: %v", user.ID, err)
+	}
+
+	if rehash {
+		if newHash, err := h.passwordHasher.Hash(password); err != nil {
+			log.Printf("Failed to rehash password for user %d: %v", user.ID, err)
+		} else if err := h.userRepo.RehashPassword(user.ID, user.PasswordHash, newHash); err != nil {
+			log.Printf("Failed to rehash password for user %d: %v", user.ID, err)
+		} else {
+			user.PasswordHash = newHash
+		}
+	}
+
+	return user, nil
+}
+
+// passwordErrors explains why the password policy rejects password, as
+// validation errors on field
+func (h *AuthHandler) passwordErrors(field, password, username, email string) ([]response.ValidationError, error) {
+	reasons, err := h.passwordPolicy.Check(password, username, email)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []response.ValidationError
+	for _, reason := range reasons {
+		errs = append(errs, response.ValidationError{Field: field, Message: reason})
+	}
+	return errs, nil
+}
+
+// startSession records a session for the request's device and returns a
+// token bound to it
+func (h *AuthHandler) startSession(c *gin.Context, user *models.User, device string) (string, error) {
+	userAgent := c.Request.UserAgent()
+	if device == "" {
+		device = useragent.Describe(userAgent)
+	}
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	session, err := h.sessionRepo.Create(&models.Session{
+		UserID:    user.ID,
+		Device:    device,
+		UserAgent: userAgent,
+		IPAddress: c.ClientIP(),
+		ExpiresAt: time.Now().Add(h.jwtManager.Expiry()),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	h.noticeLogin(c, user)
+
+	return h.jwtManager.GenerateToken(user.ID, user.Username, session.ID)
+}
+
 func (h *AuthHandler) GetProfile(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -204,7 +346,9 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, user.ToResponse())
+	profile := user.ToResponse()
+	profile.Localize(middleware.GetUserLocation(c))
+	response.Success(c, profile)
 }
 
 func (h *AuthHandler) UpdateProfile(c *gin.Context) {
@@ -217,6 +361,7 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 	var req struct {
 		FullName string `json:"full_name" binding:"required,min=2,max=100"`
 		Email    string `json:"email" binding:"required,email,max=100"`
+		Locale   string `json:"locale" binding:"omitempty,oneof=en id"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -235,7 +380,21 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userRepo.UpdateProfile(userID.(int), req.FullName, req.Email)
+	// Keep the current locale when the client does not send one
+	if req.Locale == "" {
+		current, err := h.userRepo.GetByID(userID.(int))
+		if err != nil {
+			response.InternalServerError(c, "Failed to get user profile")
+			return
+		}
+		if current == nil {
+			response.NotFound(c, "User not found")
+			return
+		}
+		req.Locale = current.Locale
+	}
+
+	user, err := h.userRepo.UpdateProfile(userID.(int), req.FullName, req.Email, req.Locale)
 	if err != nil {
 		response.InternalServerError(c, "Failed to update profile")
 		return
@@ -246,7 +405,9 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, user.ToResponse())
+	profile := user.ToResponse()
+	profile.Localize(middleware.GetUserLocation(c))
+	response.Success(c, profile)
 }
 
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
@@ -258,7 +419,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 
 	var req struct {
 		CurrentPassword string `json:"current_password" binding:"required"`
-		NewPassword     string `json:"new_password" binding:"required,min=6,max=100"`
+		NewPassword     string `json:"new_password" binding:"required,max=100"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -279,13 +440,23 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	}
 
 	// Verify current password
-	if !auth.CheckPasswordHash(req.CurrentPassword, user.PasswordHash) {
+	if match, _ := h.passwordHasher.Verify(req.CurrentPassword, user.PasswordHash); !match {
 		response.Unauthorized(c, "Current password is incorrect")
 		return
 	}
 
+	passwordErrors, err := h.passwordErrors("new_password", req.NewPassword, user.Username, user.Email)
+	if err != nil {
+		response.InternalServerError(c, "Failed to check password")
+		return
+	}
+	if len(passwordErrors) > 0 {
+		response.ValidationErrorsResponse(c, passwordErrors)
+		return
+	}
+
 	// Hash new password
-	newPasswordHash, err := auth.HashPassword(req.NewPassword)
+	newPasswordHash, err := h.passwordHasher.Hash(req.NewPassword)
 	if err != nil {
 		response.InternalServerError(c, "Failed to hash new password")
 		return
@@ -298,6 +469,12 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
+	// Log out every other device that knew the old password
+	sessionID, _ := middleware.GetCurrentSessionID(c)
+	if _, err := h.sessionRepo.RevokeOthers(userID.(int), sessionID); err != nil {
+		log.Printf("Failed to revoke sessions for user %d: %v", userID.(int), err)
+	}
+
 	response.Success(c, gin.H{"message": "Password changed successfully"})
 }
 
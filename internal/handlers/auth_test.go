@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"daily-notes-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type loginTest struct {
+	users  *fakeUserRepo
+	logins *fakeLoginSecurityRepo
+	router *gin.Engine
+}
+
+func newLoginTest(t *testing.T) *loginTest {
+	users := newFakeUserRepo()
+	logins := newFakeLoginSecurityRepo()
+	h := newTestAuthHandler(t, users, logins)
+	users.add(&models.User{Username: "ann", Email: "ann@example.com", PasswordHash: hashPassword(t, h, "correct horse battery")})
+
+	router := gin.New()
+	router.POST("/auth/login", h.Login)
+	return &loginTest{users: users, logins: logins, router: router}
+}
+
+func (lt *loginTest) login(username, password string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(models.LoginRequest{Username: username, Password: password})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	lt.router.ServeHTTP(w, req)
+	return w
+}
+
+type loginResult struct {
+	Status     int
+	RetryAfter string
+	Body       string
+}
+
+func TestLoginThrottlesUnknownUsernamesLikeExistingOnes(t *testing.T) {
+	run := func(username string) []loginResult {
+		lt := newLoginTest(t)
+		var results []loginResult
+		record := func() {
+			w := lt.login(username, "wrong password")
+			results = append(results, loginResult{w.Code, w.Header().Get("Retry-After"), w.Body.String()})
+		}
+
+		// Three free attempts, then a delay
+		for i := 0; i < models.LoginDelayAfter+1; i++ {
+			record()
+		}
+
+		// Once the delay has passed, the fifth failure locks the username
+		lt.logins.failures[username].Attempts = 4
+		lt.logins.failures[username].LastAttemptAt = time.Now().Add(-2 * models.MaxLoginDelay)
+		record()
+		record()
+
+		if len(lt.logins.locks) != 1 || lt.logins.locks[0] != username {
+			t.Errorf("locked usernames = %v, want [%s]", lt.logins.locks, username)
+		}
+		return results
+	}
+
+	existing, unknown := run("ann"), run("ghost")
+
+	want := []int{401, 401, 401, 429, 401, 429}
+	for i, status := range want {
+		if existing[i].Status != status {
+			t.Errorf("attempt %d status = %d, want %d", i+1, existing[i].Status, status)
+		}
+		if existing[i].Status != unknown[i].Status || existing[i].Body != unknown[i].Body {
+			t.Errorf("attempt %d differs for an unknown username:\n%+v\n%+v", i+1, existing[i], unknown[i])
+		}
+		// Retry-After counts down, so only compare whether it is set
+		if (existing[i].RetryAfter == "") != (unknown[i].RetryAfter == "") {
+			t.Errorf("attempt %d Retry-After = %q and %q", i+1, existing[i].RetryAfter, unknown[i].RetryAfter)
+		}
+	}
+}
+
+func TestLoginParallelAttemptsCannotSkipTheDelay(t *testing.T) {
+	lt := newLoginTest(t)
+
+	const attempts = 20
+	statuses := make(chan int, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses <- lt.login("ann", "wrong password").Code
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	counts := make(map[int]int)
+	for status := range statuses {
+		counts[status]++
+	}
+	if counts[http.StatusUnauthorized] != models.LoginDelayAfter || counts[http.StatusTooManyRequests] != attempts-models.LoginDelayAfter {
+		t.Errorf("statuses = %v, want %d password checks and the rest throttled", counts, models.LoginDelayAfter)
+	}
+}
+
+func TestLoginClearsFailures(t *testing.T) {
+	lt := newLoginTest(t)
+
+	lt.login("ann", "wrong password")
+	if w := lt.login("ann", "correct horse battery"); w.Code != http.StatusOK {
+		t.Fatalf("Login status = %d, body %s", w.Code, w.Body)
+	}
+	if _, ok := lt.logins.failures["ann"]; ok {
+		t.Errorf("failures were not cleared after a successful login")
+	}
+}
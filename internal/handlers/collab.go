@@ -0,0 +1,455 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/collab"
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	collabMaxMessageBytes = 1 << 20
+	collabWriteTimeout    = 10 * time.Second
+	collabReceiveTimeout  = 10 * time.Second
+
+	revisionsDefaultLimit = 50
+	revisionsMaxLimit     = 200
+)
+
+type CollabHandler struct {
+	noteRepo         repository.NoteRepository
+	revisionRepo     repository.NoteRevisionRepository
+	userRepo         repository.UserRepository
+	collaboratorRepo repository.NoteCollaboratorRepository
+	hub              *collab.Hub
+	allowedOrigins   map[string]bool
+}
+
+func NewCollabHandler(noteRepo repository.NoteRepository, revisionRepo repository.NoteRevisionRepository, userRepo repository.UserRepository,
+	collaboratorRepo repository.NoteCollaboratorRepository, hub *collab.Hub, allowedOrigins []string) *CollabHandler {
+	origins := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		origins[normalizeOrigin(origin)] = true
+	}
+
+	return &CollabHandler{
+		noteRepo:         noteRepo,
+		revisionRepo:     revisionRepo,
+		userRepo:         userRepo,
+		collaboratorRepo: collaboratorRepo,
+		hub:              hub,
+		allowedOrigins:   origins,
+	}
+}
+
+// normalizeOrigin reduces a URL to its lowercase scheme and host
+func normalizeOrigin(origin string) string {
+	u, err := url.Parse(strings.TrimSpace(origin))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// checkOrigin rejects WebSocket handshakes from browser pages outside the
+// allowed origins. The access token may come from a query parameter that a
+// hostile page could have obtained, so the origin is checked as well.
+// Clients other than browsers send no Origin.
+func (h *CollabHandler) checkOrigin(config *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	if normalized := normalizeOrigin(origin); normalized == "" || !h.allowedOrigins[normalized] {
+		return fmt.Errorf("origin %q is not allowed", origin)
+	}
+	return nil
+}
+
+// sessionOwner returns the owner of the note when userID owns it or was
+// invited to edit it, or 0
+func (h *CollabHandler) sessionOwner(c *gin.Context, userID, noteID int) (int, bool) {
+	ownerID, err := h.collaboratorRepo.GetOwnerID(noteID, userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to retrieve note")
+		return 0, false
+	}
+	if ownerID == 0 {
+		response.NotFound(c, "Note not found")
+		return 0, false
+	}
+	return ownerID, true
+}
+
+// Connect upgrades to a WebSocket joining the note's editing session. The
+// session is shared by the owner's devices and tabs and by the users the
+// owner invited as collaborators.
+func (h *CollabHandler) Connect(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	noteID, ok := parseIDParam(c, "id", "Note ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	ownerID, ok := h.sessionOwner(c, userID, noteID)
+	if !ok {
+		return
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil || user == nil {
+		response.InternalServerError(c, "Failed to retrieve user")
+		return
+	}
+
+	check, _ := middleware.GetCredentialCheck(c)
+	server := websocket.Server{
+		Handshake: h.checkOrigin,
+		Handler: func(ws *websocket.Conn) {
+			h.serve(ws, check, noteID, ownerID, userID, user.FullName)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// serve relays messages between the socket and the hub until either side
+// leaves or the credential the socket was opened with is revoked
+func (h *CollabHandler) serve(ws *websocket.Conn, check middleware.CredentialCheck, noteID, ownerID, userID int, name string) {
+	defer ws.Close()
+	ws.MaxPayloadBytes = collabMaxMessageBytes
+
+	ctx, cancel := context.WithTimeout(context.Background(), collabReceiveTimeout)
+	client, err := h.hub.Join(ctx, noteID, ownerID, userID, name)
+	cancel()
+	if err != nil {
+		log.Printf("Failed to join collab session for note %d: %v", noteID, err)
+		websocket.JSON.Send(ws, collab.ServerMessage{Type: collab.MessageError, Error: "Failed to open the editing session"})
+		return
+	}
+	defer h.hub.Leave(client)
+
+	go func() {
+		defer h.hub.Leave(client)
+		for {
+			var raw []byte
+			if err := websocket.Message.Receive(ws, &raw); err != nil {
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), collabReceiveTimeout)
+			h.hub.Receive(ctx, client, raw)
+			cancel()
+		}
+	}()
+
+	recheck := time.NewTicker(credentialRecheckInterval)
+	defer recheck.Stop()
+
+	for {
+		select {
+		case <-recheck.C:
+			if credentialRevoked(check, userID) {
+				ws.SetWriteDeadline(time.Now().Add(collabWriteTimeout))
+				websocket.JSON.Send(ws, collab.ServerMessage{Type: collab.MessageError, Error: "Session has ended, please log in again"})
+				return
+			}
+		case msg := <-client.Messages():
+			if !h.write(ws, msg) {
+				return
+			}
+		case <-client.Done():
+			// Flush what was queued before the hub dropped the client,
+			// typically the reason why
+			for {
+				select {
+				case msg := <-client.Messages():
+					if !h.write(ws, msg) {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (h *CollabHandler) write(ws *websocket.Conn, msg []byte) bool {
+	ws.SetWriteDeadline(time.Now().Add(collabWriteTimeout))
+	return websocket.Message.Send(ws, string(msg)) == nil
+}
+
+// GetRevisions lists snapshots saved by editing sessions, newest first
+func (h *CollabHandler) GetRevisions(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	noteID, ok := parseIDParam(c, "id", "Note ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	ownerID, ok := h.sessionOwner(c, userID, noteID)
+	if !ok {
+		return
+	}
+
+	limit := revisionsDefaultLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > revisionsMaxLimit {
+			response.ValidationErrorResponse(c, response.ValidationError{
+				Field:   "limit",
+				Message: "Limit must be a number between 1 and 200",
+				Value:   limitStr,
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	revisions, err := h.revisionRepo.GetByNoteID(noteID, ownerID, limit)
+	if err != nil {
+		response.InternalServerError(c, "Failed to retrieve note revisions")
+		return
+	}
+	if revisions == nil {
+		revisions = []*models.NoteRevision{}
+	}
+
+	loc := middleware.GetUserLocation(c)
+	for _, revision := range revisions {
+		revision.Localize(loc)
+	}
+
+	response.Success(c, revisions)
+}
+
+// AddCollaborator invites another user to edit the note in its editing
+// session
+func (h *CollabHandler) AddCollaborator(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	noteID, ok := parseIDParam(c, "id", "Note ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	var req models.AddCollaboratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	collaborator, err := h.userRepo.GetByUsername(req.Username)
+	if err != nil {
+		response.InternalServerError(c, "Failed to retrieve user")
+		return
+	}
+	if collaborator == nil {
+		response.NotFound(c, "User not found")
+		return
+	}
+	if collaborator.ID == userID {
+		response.BadRequest(c, "You already own this note")
+		return
+	}
+
+	added, err := h.collaboratorRepo.Add(noteID, userID, collaborator.ID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to add collaborator")
+		return
+	}
+	if !added {
+		response.NotFound(c, "Note not found")
+		return
+	}
+
+	h.respondWithCollaborators(c, noteID, userID, true)
+}
+
+// GetCollaborators lists the users invited to edit the note
+func (h *CollabHandler) GetCollaborators(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	noteID, ok := parseIDParam(c, "id", "Note ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	note, err := h.noteRepo.GetByID(noteID, userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to retrieve note")
+		return
+	}
+	if note == nil {
+		response.NotFound(c, "Note not found")
+		return
+	}
+
+	h.respondWithCollaborators(c, noteID, userID, false)
+}
+
+func (h *CollabHandler) respondWithCollaborators(c *gin.Context, noteID, ownerID int, created bool) {
+	collaborators, err := h.collaboratorRepo.GetByNote(noteID, ownerID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to retrieve collaborators")
+		return
+	}
+
+	loc := middleware.GetUserLocation(c)
+	for _, collaborator := range collaborators {
+		collaborator.Localize(loc)
+	}
+
+	if created {
+		response.Created(c, collaborators)
+		return
+	}
+	response.Success(c, collaborators)
+}
+
+// RemoveCollaborator revokes a user's access to the note and disconnects
+// the editing sessions they have open
+func (h *CollabHandler) RemoveCollaborator(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	noteID, ok := parseIDParam(c, "id", "Note ID must be a valid number")
+	if !ok {
+		return
+	}
+	collaboratorID, ok := parseIDParam(c, "userId", "User ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	removed, err := h.collaboratorRepo.Remove(noteID, userID, collaboratorID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to remove collaborator")
+		return
+	}
+	if !removed {
+		response.NotFound(c, "Collaborator not found")
+		return
+	}
+
+	h.hub.Revoke(c.Request.Context(), noteID, collaboratorID)
+
+	response.Success(c, gin.H{"message": "Collaborator removed successfully"})
+}
+
+// GetSharedNotes lists notes other users invited the current user to edit
+func (h *CollabHandler) GetSharedNotes(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	notes, err := h.collaboratorRepo.GetSharedWith(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to retrieve shared notes")
+		return
+	}
+
+	loc := middleware.GetUserLocation(c)
+	for _, note := range notes {
+		note.Localize(loc)
+	}
+
+	response.Success(c, notes)
+}
+
+// collabPersister writes editing session snapshots back to notes
+type collabPersister struct {
+	noteRepo     repository.NoteRepository
+	revisionRepo repository.NoteRevisionRepository
+	notes        *NoteHandler
+}
+
+// NewCollabPersister saves sessions through the note repository, recording a
+// revision and notifying caches, event streams and webhooks like a REST update
+func NewCollabPersister(noteRepo repository.NoteRepository, revisionRepo repository.NoteRevisionRepository, notes *NoteHandler) collab.Persister {
+	return &collabPersister{
+		noteRepo:     noteRepo,
+		revisionRepo: revisionRepo,
+		notes:        notes,
+	}
+}
+
+func (p *collabPersister) Load(ctx context.Context, noteID, ownerID int) (*collab.Snapshot, error) {
+	note, err := p.noteRepo.GetByID(noteID, ownerID)
+	if err != nil || note == nil {
+		return nil, err
+	}
+	return &collab.Snapshot{Content: note.Content, Version: note.Version}, nil
+}
+
+func (p *collabPersister) Save(ctx context.Context, noteID, ownerID int, content string, baseVersion, rev int) (int, error) {
+	current, err := p.noteRepo.GetByID(noteID, ownerID)
+	if err != nil {
+		return 0, err
+	}
+	if current == nil || current.Version != baseVersion {
+		return 0, collab.ErrStale
+	}
+
+	req := &models.UpdateNoteRequest{Title: current.Title, Content: content, Category: current.Category}
+	note, rewritten, err := p.noteRepo.UpdateIfVersion(noteID, ownerID, baseVersion, req)
+	if err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return 0, collab.ErrStale
+		}
+		return 0, err
+	}
+	if note == nil {
+		return 0, collab.ErrStale
+	}
+
+	revision := &models.NoteRevision{NoteID: noteID, UserID: ownerID, Version: note.Version, Revision: rev, Content: content}
+	if err := p.revisionRepo.Create(revision); err != nil {
+		// The note itself is saved; a missing history entry is not worth
+		// failing the session over
+		log.Printf("Failed to record revision of note %d: %v", noteID, err)
+	}
+
+	p.notes.invalidateCache(nil, ownerID, noteID)
+	p.notes.publishEvent(ownerID, models.WebhookEventNoteUpdated, note)
+	p.notes.publishRewrites(ownerID, rewritten)
+
+	return note.Version, nil
+}
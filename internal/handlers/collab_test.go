@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/pkg/collab"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestCollabCheckOrigin(t *testing.T) {
+	h := NewCollabHandler(nil, nil, nil, nil, nil, []string{"https://notes.example/app", "http://localhost:3000"})
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"", true},
+		{"https://notes.example", true},
+		{"HTTPS://Notes.Example", true},
+		{"http://localhost:3000", true},
+		{"http://notes.example", false},
+		{"https://notes.example.evil.test", false},
+		{"http://localhost:8000", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/v1/notes/1/collab", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		if err := h.checkOrigin(nil, req); (err == nil) != tt.allowed {
+			t.Errorf("checkOrigin(%q) error = %v, want allowed %v", tt.origin, err, tt.allowed)
+		}
+	}
+}
+
+type fakeCollabPersister struct{}
+
+func (fakeCollabPersister) Load(ctx context.Context, noteID, ownerID int) (*collab.Snapshot, error) {
+	return &collab.Snapshot{Content: "hello", Version: 1}, nil
+}
+
+func (fakeCollabPersister) Save(ctx context.Context, noteID, ownerID int, content string, baseVersion, rev int) (int, error) {
+	return baseVersion + 1, nil
+}
+
+func TestCollabServeClosesWhenCredentialRevoked(t *testing.T) {
+	defer func(interval time.Duration) { credentialRecheckInterval = interval }(credentialRecheckInterval)
+	credentialRecheckInterval = 10 * time.Millisecond
+
+	var revoked atomic.Bool
+	check := middleware.CredentialCheck(func() (bool, error) { return !revoked.Load(), nil })
+
+	hub := collab.NewHub(collab.NewMemoryStore(), fakeCollabPersister{}, time.Hour)
+	h := NewCollabHandler(nil, nil, nil, nil, hub, nil)
+	server := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		h.serve(ws, check, 1, 1, 1, "Ann")
+	}))
+	defer server.Close()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	// The socket stays open while the session is active
+	time.Sleep(5 * credentialRecheckInterval)
+	revoked.Store(true)
+
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var last collab.ServerMessage
+	for {
+		var msg collab.ServerMessage
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			break
+		}
+		last = msg
+	}
+	if last.Type != collab.MessageError || !strings.Contains(last.Error, "Session has ended") {
+		t.Errorf("last message = %+v, want the session ended error", last)
+	}
+}
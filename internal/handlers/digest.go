@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DigestHandler struct {
+	digestRepo   repository.DigestRepository
+	settingsRepo repository.UserSettingsRepository
+	appName      string
+}
+
+func NewDigestHandler(digestRepo repository.DigestRepository, settingsRepo repository.UserSettingsRepository, appName string) *DigestHandler {
+	return &DigestHandler{
+		digestRepo:   digestRepo,
+		settingsRepo: settingsRepo,
+		appName:      appName,
+	}
+}
+
+func (h *DigestHandler) GetDigest(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	sub, err := h.digestRepo.GetByUserID(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get digest settings")
+		return
+	}
+
+	if sub == nil {
+		// Not subscribed yet: report the defaults in the account timezone
+		settings, err := h.settingsRepo.Get(userID)
+		if err != nil || settings == nil {
+			response.InternalServerError(c, "Failed to get digest settings")
+			return
+		}
+
+		sub = &models.DigestSubscription{
+			UserID:    userID,
+			Frequency: models.DigestFrequencyNone,
+			Timezone:  settings.Timezone,
+			SendHour:  7,
+		}
+	}
+
+	response.Success(c, sub)
+}
+
+func (h *DigestHandler) UpdateDigest(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req models.UpdateDigestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	sub, err := h.digestRepo.Upsert(userID, &req)
+	if err != nil {
+		response.InternalServerError(c, "Failed to update digest settings")
+		return
+	}
+
+	response.Success(c, sub)
+}
+
+// ConfirmUnsubscribe shows the page behind the link in the email. It only
+// asks for confirmation, so link scanners and prefetchers that follow the
+// link do not unsubscribe anyone.
+func (h *DigestHandler) ConfirmUnsubscribe(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.Data(http.StatusBadRequest, "text/html; charset=utf-8", []byte(unsubscribePage(h.appName, "This unsubscribe link is invalid.")))
+		return
+	}
+
+	action := "?token=" + url.QueryEscape(token)
+	form := `<p>Stop receiving summary emails?</p><form method="post" action="` + template.HTMLEscapeString(action) + `">` +
+		`<button type="submit" style="background-color: #4f46e5; color: white; border: none; padding: 12px 24px; border-radius: 6px; font-size: 16px; cursor: pointer;">Unsubscribe</button></form>`
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(pageHTML(h.appName, form)))
+}
+
+// Unsubscribe turns digests off, without login. It is posted by the
+// confirmation page and by mail clients supporting RFC 8058 one-click
+// unsubscribe.
+func (h *DigestHandler) Unsubscribe(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.Data(http.StatusBadRequest, "text/html; charset=utf-8", []byte(unsubscribePage(h.appName, "This unsubscribe link is invalid.")))
+		return
+	}
+
+	found, err := h.digestRepo.Unsubscribe(token)
+	if err != nil {
+		c.Data(http.StatusInternalServerError, "text/html; charset=utf-8", []byte(unsubscribePage(h.appName, "Something went wrong, please try again later.")))
+		return
+	}
+
+	if !found {
+		c.Data(http.StatusNotFound, "text/html; charset=utf-8", []byte(unsubscribePage(h.appName, "This unsubscribe link is invalid or has expired.")))
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(unsubscribePage(h.appName, "You have been unsubscribed from summary emails.")))
+}
+
+func unsubscribePage(appName, message string) string {
+	return pageHTML(appName, `<p>`+template.HTMLEscapeString(message)+`</p>`)
+}
+
+// pageHTML wraps body, which must already be escaped, in a minimal page
+func pageHTML(appName, body string) string {
+	return `<!DOCTYPE html><html><head><meta charset="UTF-8"><title>` + template.HTMLEscapeString(appName) +
+		`</title></head><body style="font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; text-align: center; padding: 40px;">` +
+		`<h1 style="color: #4f46e5;">` + template.HTMLEscapeString(appName) + `</h1>` + body + `</body></html>`
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"daily-notes-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeDigestRepo struct {
+	repository.DigestRepository
+
+	tokens       map[string]bool
+	unsubscribed []string
+}
+
+func (r *fakeDigestRepo) Unsubscribe(token string) (bool, error) {
+	if !r.tokens[token] {
+		return false, nil
+	}
+	r.unsubscribed = append(r.unsubscribed, token)
+	return true, nil
+}
+
+func TestDigestUnsubscribe(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		target           string
+		wantStatus       int
+		wantBody         string
+		wantUnsubscribed bool
+	}{
+		{"GET asks for confirmation", http.MethodGet, "/unsubscribe?token=abc", http.StatusOK, `<form method="post" action="?token=abc">`, false},
+		{"GET without token", http.MethodGet, "/unsubscribe", http.StatusBadRequest, "invalid", false},
+		{"POST unsubscribes", http.MethodPost, "/unsubscribe?token=abc", http.StatusOK, "You have been unsubscribed", true},
+		{"POST with unknown token", http.MethodPost, "/unsubscribe?token=nope", http.StatusNotFound, "invalid or has expired", false},
+		{"POST without token", http.MethodPost, "/unsubscribe", http.StatusBadRequest, "invalid", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeDigestRepo{tokens: map[string]bool{"abc": true}}
+			h := NewDigestHandler(repo, nil, "Daily Notes")
+
+			router := gin.New()
+			router.GET("/unsubscribe", h.ConfirmUnsubscribe)
+			router.POST("/unsubscribe", h.Unsubscribe)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader("List-Unsubscribe=One-Click"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", w.Body, tt.wantBody)
+			}
+			if got := len(repo.unsubscribed) > 0; got != tt.wantUnsubscribed {
+				t.Errorf("unsubscribed = %v, want %v", got, tt.wantUnsubscribed)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/pkg/events"
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const eventsHeartbeat = 25 * time.Second
+
+// credentialRecheckInterval is how often long-lived connections check that
+// the session or token they were opened with has not been revoked
+var credentialRecheckInterval = time.Minute
+
+type EventsHandler struct {
+	hub *events.Hub
+}
+
+func NewEventsHandler(hub *events.Hub) *EventsHandler {
+	return &EventsHandler{
+		hub: hub,
+	}
+}
+
+// Stream sends the current user's note changes as Server-Sent Events. A
+// reconnecting client passes the last ID it saw in Last-Event-ID (or the
+// last_event_id query parameter) and first receives what it missed. When
+// that ID is no longer known it receives a resync event instead and should
+// reload its notes. The stream ends with a revoked event once the session
+// or token it was opened with is revoked.
+func (h *EventsHandler) Stream(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	if lastID != "" && !events.ValidID(lastID) {
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "Last-Event-ID",
+			Message: "Last event ID is not valid",
+			Value:   lastID,
+		})
+		return
+	}
+
+	// Subscribe before reading the backlog so nothing published in between
+	// is lost; duplicates are filtered by ID below
+	live, unsubscribe := h.hub.Subscribe(userID)
+	defer unsubscribe()
+
+	var backlog []events.Event
+	resync := false
+	if lastID != "" {
+		var err error
+		backlog, err = h.hub.Since(c, userID, lastID)
+		if errors.Is(err, events.ErrResyncRequired) {
+			resync = true
+		} else if err != nil {
+			log.Printf("Failed to replay events for user %d: %v", userID, err)
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	send := func(event events.Event) {
+		c.Render(-1, sse.Event{
+			Id:    event.ID,
+			Event: event.Type,
+			Data:  string(event.Data),
+		})
+		c.Writer.Flush()
+		lastID = event.ID
+	}
+
+	// Tell the client how long to wait before reconnecting
+	c.Writer.WriteString("retry: 3000\n\n")
+	c.Writer.Flush()
+
+	if resync {
+		// The client's ID may be newer than anything issued since a restart;
+		// filtering live events against it would drop them
+		lastID = ""
+		c.Render(-1, sse.Event{Event: "resync", Data: "{}"})
+		c.Writer.Flush()
+	}
+	for _, event := range backlog {
+		send(event)
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	check, _ := middleware.GetCredentialCheck(c)
+	recheck := time.NewTicker(credentialRecheckInterval)
+	defer recheck.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-live:
+			if !ok {
+				// Dropped for falling behind; the client resumes from lastID
+				return
+			}
+			if events.After(event.ID, lastID) {
+				send(event)
+			}
+		case <-heartbeat.C:
+			c.Writer.WriteString(": ping\n\n")
+			c.Writer.Flush()
+		case <-recheck.C:
+			if credentialRevoked(check, userID) {
+				c.Render(-1, sse.Event{Event: "revoked", Data: "{}"})
+				c.Writer.Flush()
+				return
+			}
+		}
+	}
+}
+
+// credentialRevoked runs check and reports whether the credential is no
+// longer valid. A failed check keeps the connection open until the next one.
+func credentialRevoked(check middleware.CredentialCheck, userID int) bool {
+	if check == nil {
+		return false
+	}
+
+	valid, err := check()
+	if err != nil {
+		log.Printf("Failed to recheck credentials of user %d: %v", userID, err)
+		return false
+	}
+	return !valid
+}
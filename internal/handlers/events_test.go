@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/pkg/events"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEventsStreamEndsWhenCredentialRevoked(t *testing.T) {
+	defer func(interval time.Duration) { credentialRecheckInterval = interval }(credentialRecheckInterval)
+	credentialRecheckInterval = 10 * time.Millisecond
+
+	var revoked atomic.Bool
+	h := NewEventsHandler(events.NewHub(nil))
+	router := gin.New()
+	router.GET("/events", func(c *gin.Context) {
+		c.Set(middleware.UserIDKey, 1)
+		c.Set(middleware.CredentialCheckKey, middleware.CredentialCheck(func() (bool, error) {
+			return !revoked.Load(), nil
+		}))
+	}, h.Stream)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// The stream stays open while the session is active
+	time.Sleep(5 * credentialRecheckInterval)
+	revoked.Store(true)
+
+	done := make(chan string)
+	go func() {
+		body, _ := io.ReadAll(resp.Body)
+		done <- string(body)
+	}()
+
+	select {
+	case body := <-done:
+		if !strings.Contains(body, "event:revoked") {
+			t.Errorf("stream ended without a revoked event:\n%s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream still open after the session was revoked")
+	}
+}
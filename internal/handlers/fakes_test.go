@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"daily-notes-api/internal/config"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// The fakes embed the repository interface, so calling a method a test
+// does not expect panics
+
+type fakeUserRepo struct {
+	repository.UserRepository
+
+	mu    sync.Mutex
+	users map[int]*models.User
+}
+
+func newFakeUserRepo() *fakeUserRepo {
+	return &fakeUserRepo{users: make(map[int]*models.User)}
+}
+
+func (r *fakeUserRepo) add(user *models.User) *models.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user.ID = len(r.users) + 1
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	r.users[user.ID] = user
+	return user
+}
+
+func (r *fakeUserRepo) find(match func(*models.User) bool) *models.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if match(user) {
+			copied := *user
+			return &copied
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) CreateWithOutbox(req *models.RegisterRequest, passwordHash string, messages []*models.OutboxMessage) (*models.User, error) {
+	user := r.add(&models.User{Username: req.Username, Email: req.Email, PasswordHash: passwordHash, FullName: req.FullName})
+	return r.GetByID(user.ID)
+}
+
+func (r *fakeUserRepo) GetByID(id int) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.ID == id }), nil
+}
+
+func (r *fakeUserRepo) GetByUsername(username string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.Username == username }), nil
+}
+
+func (r *fakeUserRepo) GetByEmail(email string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.Email == email }), nil
+}
+
+func (r *fakeUserRepo) UsernameExists(username string) (bool, error) {
+	user, _ := r.GetByUsername(username)
+	return user != nil, nil
+}
+
+func (r *fakeUserRepo) RehashPassword(userID int, oldHash, newHash string) error {
+	return nil
+}
+
+func (r *fakeUserRepo) MarkEmailVerified(userID int, email string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user := r.users[userID]; user != nil && user.Email == email {
+		user.EmailVerifiedAt = &at
+	}
+	return nil
+}
+
+type fakeSessionRepo struct {
+	repository.SessionRepository
+
+	mu       sync.Mutex
+	sessions []*models.Session
+	revoked  []int
+}
+
+func (r *fakeSessionRepo) Create(session *models.Session) (*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session.ID = len(r.sessions) + 1
+	r.sessions = append(r.sessions, session)
+	return session, nil
+}
+
+func (r *fakeSessionRepo) RevokeOthers(userID, keepID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revoked = append(r.revoked, userID)
+	return 0, nil
+}
+
+type fakeLoginSecurityRepo struct {
+	repository.LoginSecurityRepository
+
+	mu       sync.Mutex
+	failures map[string]*models.LoginFailures
+	locks    []string
+}
+
+func newFakeLoginSecurityRepo() *fakeLoginSecurityRepo {
+	return &fakeLoginSecurityRepo{failures: make(map[string]*models.LoginFailures)}
+}
+
+func (r *fakeLoginSecurityRepo) TryAttempt(username string, now time.Time) (*models.LoginFailures, time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.failures[username]
+	if !ok {
+		f = &models.LoginFailures{Username: username}
+		r.failures[username] = f
+	}
+	wait := f.Attempt(now)
+	copied := *f
+	return &copied, wait, nil
+}
+
+func (r *fakeLoginSecurityRepo) Lock(username string, until time.Time, messages []*models.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures[username].LockedUntil = &until
+	r.locks = append(r.locks, username)
+	return nil
+}
+
+func (r *fakeLoginSecurityRepo) ClearFailures(username string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.failures[username]
+	delete(r.failures, username)
+	return ok, nil
+}
+
+func (r *fakeLoginSecurityRepo) GetLoginHistory(userID int, device, ipRange string) (*models.LoginHistory, error) {
+	return &models.LoginHistory{}, nil
+}
+
+func (r *fakeLoginSecurityRepo) RecordLogin(userID int, device, ipRange string, messages []*models.OutboxMessage) error {
+	return nil
+}
+
+// testConfig uses cheap password hashing so tests stay fast
+func testConfig() *config.Config {
+	return &config.Config{
+		JWTSecret:               "test-secret",
+		JWTExpiryHours:          1,
+		JWTAlgorithm:            "HS256",
+		PasswordHashAlgorithm:   "bcrypt",
+		BcryptCost:              4,
+		Argon2MemoryKiB:         64,
+		Argon2Iterations:        1,
+		Argon2Parallelism:       1,
+		PasswordHashConcurrency: 4,
+	}
+}
+
+func newTestAuthHandler(t *testing.T, userRepo repository.UserRepository, loginSecurityRepo repository.LoginSecurityRepository) *AuthHandler {
+	t.Helper()
+
+	cfg := testConfig()
+	jwtManager, err := auth.NewJWTManager(cfg)
+	if err != nil {
+		t.Fatalf("NewJWTManager() error = %v", err)
+	}
+	hasher, err := auth.NewPasswordHasher(cfg)
+	if err != nil {
+		t.Fatalf("NewPasswordHasher() error = %v", err)
+	}
+
+	h, err := NewAuthHandler(userRepo, &fakeSessionRepo{}, loginSecurityRepo, jwtManager, hasher, nil, nil,
+		"Daily Notes", "https://notes.example", 5, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("NewAuthHandler() error = %v", err)
+	}
+	return h
+}
+
+func hashPassword(t *testing.T, h *AuthHandler, password string) string {
+	t.Helper()
+	hash, err := h.passwordHasher.Hash(password)
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	return hash
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/cache"
+	"daily-notes-api/pkg/inbound"
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InboundMaxBytes caps the size of an inbound email
+const InboundMaxBytes = 10 << 20
+
+// InboundHandler turns emails sent to a user's secret address into notes,
+// either from the webhook or from the embedded SMTP listener
+type InboundHandler struct {
+	inboundRepo   repository.InboundRepository
+	noteRepo      repository.NoteRepository
+	notes         *NoteHandler
+	cacheService  *cache.CacheService
+	domain        string
+	webhookSecret string
+}
+
+func NewInboundHandler(inboundRepo repository.InboundRepository, noteRepo repository.NoteRepository, notes *NoteHandler, cacheService *cache.CacheService, domain, webhookSecret string) *InboundHandler {
+	return &InboundHandler{
+		inboundRepo:   inboundRepo,
+		noteRepo:      noteRepo,
+		notes:         notes,
+		cacheService:  cacheService,
+		domain:        domain,
+		webhookSecret: webhookSecret,
+	}
+}
+
+func (h *InboundHandler) GetAddress(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	addr, err := h.inboundRepo.GetOrCreate(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get inbound address")
+		return
+	}
+
+	addr.Address = inbound.FormatAddress(addr.Token, h.domain)
+	response.Success(c, addr)
+}
+
+// RotateAddress issues a new address; mail to the old one is rejected
+func (h *InboundHandler) RotateAddress(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	addr, err := h.inboundRepo.Rotate(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to rotate inbound address")
+		return
+	}
+
+	addr.Address = inbound.FormatAddress(addr.Token, h.domain)
+	response.Success(c, addr)
+}
+
+// ReceiveEmail accepts a raw RFC 822 message from a mail provider webhook
+func (h *InboundHandler) ReceiveEmail(c *gin.Context) {
+	if h.webhookSecret != "" {
+		secret := c.GetHeader("X-Inbound-Secret")
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(h.webhookSecret)) != 1 {
+			response.Unauthorized(c, "Invalid inbound secret")
+			return
+		}
+	}
+
+	raw, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, InboundMaxBytes))
+	if err != nil {
+		response.PayloadTooLarge(c, "Email is too large")
+		return
+	}
+
+	email, err := inbound.Parse(raw)
+	if err != nil {
+		response.BadRequest(c, "Request body must be a raw RFC 822 email")
+		return
+	}
+
+	// Providers usually pass the envelope recipient separately; fall back
+	// to the message headers
+	recipients := email.Recipients
+	if rcpt := c.Query("recipient"); rcpt != "" {
+		recipients = []string{rcpt}
+	}
+
+	notes, err := h.createNotes(recipients, email)
+	if err != nil {
+		if errors.Is(err, inbound.ErrUnknownRecipient) {
+			response.NotFound(c, "No inbound address matches the recipients")
+			return
+		}
+		response.InternalServerError(c, "Failed to create note from email")
+		return
+	}
+
+	response.Created(c, notes)
+}
+
+// CheckRecipient approves RCPT TO addresses for the SMTP listener
+func (h *InboundHandler) CheckRecipient(address string) error {
+	token, _, ok := inbound.ParseAddress(address, h.domain)
+	if !ok {
+		return inbound.ErrUnknownRecipient
+	}
+
+	userID, err := h.inboundRepo.GetUserIDByToken(token)
+	if err != nil {
+		return err
+	}
+	if userID == 0 {
+		return inbound.ErrUnknownRecipient
+	}
+	return nil
+}
+
+// Deliver creates notes for a message accepted by the SMTP listener
+func (h *InboundHandler) Deliver(recipients []string, raw []byte) error {
+	email, err := inbound.Parse(raw)
+	if err != nil {
+		// Retrying will not make the message parse; drop it
+		log.Printf("Dropping unparseable inbound email: %v", err)
+		return nil
+	}
+
+	_, err = h.createNotes(recipients, email)
+	return err
+}
+
+// createNotes creates one note per distinct user among recipients
+func (h *InboundHandler) createNotes(recipients []string, email *inbound.Email) ([]*models.Note, error) {
+	seen := make(map[int]bool)
+	notes := make([]*models.Note, 0)
+
+	for _, rcpt := range recipients {
+		token, category, ok := inbound.ParseAddress(rcpt, h.domain)
+		if !ok {
+			continue
+		}
+
+		userID, err := h.inboundRepo.GetUserIDByToken(token)
+		if err != nil {
+			return nil, err
+		}
+		if userID == 0 || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		note, err := h.noteRepo.Create(userID, noteFromEmail(email, category))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create note for user %d: %w", userID, err)
+		}
+		h.invalidateCache(userID)
+		h.notes.publishEvent(userID, models.WebhookEventNoteCreated, note)
+
+		log.Printf("Created note %d for user %d from inbound email", note.ID, userID)
+		notes = append(notes, note)
+	}
+
+	if len(notes) == 0 {
+		return nil, inbound.ErrUnknownRecipient
+	}
+	return notes, nil
+}
+
+func noteFromEmail(email *inbound.Email, category string) *models.CreateNoteRequest {
+	title := email.Subject
+	if title == "" {
+		title = "(no subject)"
+	}
+
+	content := email.Body()
+	if content == "" {
+		content = title
+	}
+
+	return &models.CreateNoteRequest{
+		Title:    truncateRunes(title, 255),
+		Content:  content,
+		Category: truncateRunes(strings.TrimSpace(category), 100),
+	}
+}
+
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
+
+func (h *InboundHandler) invalidateCache(userID int) {
+	if h.cacheService == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := h.cacheService.InvalidateUserNotesCache(ctx, userID); err != nil {
+		log.Printf("Failed to invalidate user notes cache: %v", err)
+	}
+}
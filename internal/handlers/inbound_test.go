@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"testing"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/inbound"
+)
+
+type fakeInboundRepo struct {
+	repository.InboundRepository
+
+	users map[string]int
+}
+
+func (r *fakeInboundRepo) GetUserIDByToken(token string) (int, error) {
+	return r.users[token], nil
+}
+
+func TestDeliverPublishesNoteCreated(t *testing.T) {
+	notes := &fakeNoteRepo{clientIDs: make(map[string]int)}
+	webhooks := &fakeWebhookRepo{}
+	h := NewInboundHandler(
+		&fakeInboundRepo{users: map[string]int{"abc123": 1}},
+		notes,
+		NewNoteHandler(notes, nil, nil, webhooks, nil, nil),
+		nil, "in.example.com", "",
+	)
+
+	raw := []byte("From: someone@example.com\r\nSubject: Groceries\r\n\r\nMilk\r\n")
+	recipients := []string{inbound.AddressPrefix + "abc123@in.example.com", "other@example.com"}
+	if err := h.Deliver(recipients, raw); err != nil {
+		t.Fatalf("Deliver error = %v", err)
+	}
+
+	if len(notes.notes) != 1 || notes.notes[0].Title != "Groceries" {
+		t.Fatalf("notes = %+v, want one note titled Groceries", notes.notes)
+	}
+	if len(webhooks.events) != 1 || webhooks.events[0] != models.WebhookEventNoteCreated {
+		t.Errorf("webhook events = %v, want [%s]", webhooks.events, models.WebhookEventNoteCreated)
+	}
+}
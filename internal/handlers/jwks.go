@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"daily-notes-api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	jwtManager *auth.JWTManager
+}
+
+func NewJWKSHandler(jwtManager *auth.JWTManager) *JWKSHandler {
+	return &JWKSHandler{jwtManager: jwtManager}
+}
+
+// GetKeySet publishes the public keys our tokens are signed with, so other
+// services can verify them. Retired keys stay listed while they are
+// configured as verification keys.
+func (h *JWKSHandler) GetKeySet(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.jwtManager.KeySet())
+}
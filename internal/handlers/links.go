@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLinks lists the [[links]] in a note, including ones no note matches yet
+func (h *NoteHandler) GetLinks(c *gin.Context) {
+	h.listLinks(c, false)
+}
+
+// GetBacklinks lists the notes that link to a note
+func (h *NoteHandler) GetBacklinks(c *gin.Context) {
+	h.listLinks(c, true)
+}
+
+func (h *NoteHandler) listLinks(c *gin.Context, backlinks bool) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	noteID, ok := parseIDParam(c, "id", "Note ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	note, err := h.noteRepo.GetByID(noteID, userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to retrieve note")
+		return
+	}
+	if note == nil {
+		response.NotFound(c, "Note not found")
+		return
+	}
+
+	fetch := h.noteRepo.GetLinks
+	if backlinks {
+		fetch = h.noteRepo.GetBacklinks
+	}
+
+	links, err := fetch(noteID, userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to retrieve note links")
+		return
+	}
+
+	response.Success(c, links)
+}
+
+// GetGraph returns the user's notes as nodes and their links as edges
+func (h *NoteHandler) GetGraph(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	graph, err := h.noteRepo.GetGraph(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to retrieve note graph")
+		return
+	}
+
+	response.Success(c, graph)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/netip"
+	"time"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/pkg/email"
+	"daily-notes-api/pkg/useragent"
+
+	"github.com/gin-gonic/gin"
+)
+
+const securityEmailTimeFormat = "Mon, 02 Jan 2006 15:04 MST"
+
+// loginThrottledError is returned by checkCredentials while a username waits
+// out a delay after failed logins or a lockout. It reads the same whether
+// or not an account has the username.
+type loginThrottledError struct {
+	retryAfter time.Duration
+	locked     bool
+}
+
+func (e *loginThrottledError) Error() string {
+	if e.locked {
+		return "Too many failed login attempts, the account is temporarily locked"
+	}
+	return fmt.Sprintf("Too many failed login attempts, try again in %d seconds", retryAfterSeconds(e.retryAfter))
+}
+
+func retryAfterSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// recordFailedLogin locks the username once it has too many failed
+// attempts, emailing the user when an account has it
+func (h *AuthHandler) recordFailedLogin(c *gin.Context, username string, user *models.User, failures *models.LoginFailures) {
+	now := time.Now()
+	if failures.Attempts < h.maxLoginFailures {
+		return
+	}
+
+	until := now.Add(h.lockoutDuration)
+	var messages []*models.OutboxMessage
+	if user != nil && h.emailService != nil {
+		data := h.securityEmailData(c, user, now)
+		data.LockedUntil = until.UTC().Format(securityEmailTimeFormat)
+		msg, err := h.emailService.BuildAccountLockedEmail(data)
+		if err != nil {
+			log.Printf("Failed to build account locked email for user %d: %v", user.ID, err)
+		} else {
+			messages = append(messages, models.NewOutboxMessage("account_locked", msg))
+		}
+	}
+
+	if err := h.loginSecurityRepo.Lock(username, until, messages); err != nil {
+		log.Printf("Failed to lock username %q: %v", username, err)
+	}
+}
+
+// noticeLogin remembers the device and IP range of a login, and emails the
+// user when either has not been seen on their account before
+func (h *AuthHandler) noticeLogin(c *gin.Context, user *models.User) {
+	device := useragent.Describe(c.Request.UserAgent())
+	ipRange := ipRangeOf(c.ClientIP())
+
+	history, err := h.loginSecurityRepo.GetLoginHistory(user.ID, device, ipRange)
+	if err != nil {
+		log.Printf("Failed to get login history for user %d: %v", user.ID, err)
+		return
+	}
+
+	var messages []*models.OutboxMessage
+	if history.HasLogins && (!history.KnownDevice || !history.KnownIPRange) && h.emailService != nil {
+		msg, err := h.emailService.BuildNewLoginEmail(h.securityEmailData(c, user, time.Now()))
+		if err != nil {
+			log.Printf("Failed to build new login email for user %d: %v", user.ID, err)
+		} else {
+			messages = append(messages, models.NewOutboxMessage("new_login", msg))
+		}
+	}
+
+	if err := h.loginSecurityRepo.RecordLogin(user.ID, device, ipRange, messages); err != nil {
+		log.Printf("Failed to record login for user %d: %v", user.ID, err)
+	}
+}
+
+func (h *AuthHandler) securityEmailData(c *gin.Context, user *models.User, at time.Time) email.SecurityEmailData {
+	return email.SecurityEmailData{
+		Locale:    user.Locale,
+		UserName:  user.FullName,
+		Email:     user.Email,
+		AppName:   h.appName,
+		AppURL:    h.appURL,
+		Device:    useragent.Describe(c.Request.UserAgent()),
+		IPAddress: c.ClientIP(),
+		Time:      at.UTC().Format(securityEmailTimeFormat),
+	}
+}
+
+// ipRangeOf groups addresses of the same network: a /24 for IPv4 and a /48
+// for IPv6
+func ipRangeOf(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap()
+
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ip
+	}
+	return prefix.String()
+}
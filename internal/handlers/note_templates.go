@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/notetemplate"
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NoteTemplateHandler struct {
+	templateRepo repository.NoteTemplateRepository
+}
+
+func NewNoteTemplateHandler(templateRepo repository.NoteTemplateRepository) *NoteTemplateHandler {
+	return &NoteTemplateHandler{
+		templateRepo: templateRepo,
+	}
+}
+
+func (h *NoteTemplateHandler) CreateTemplate(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req models.CreateNoteTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	if validationErrors := validateTemplateBodies(req.TitleTemplate, req.BodyTemplate); len(validationErrors) > 0 {
+		response.ValidationErrorsResponse(c, validationErrors)
+		return
+	}
+
+	tmpl, err := h.templateRepo.Create(userID, &req)
+	if err != nil {
+		response.InternalServerError(c, "Failed to create template")
+		return
+	}
+
+	tmpl.Localize(middleware.GetUserLocation(c))
+	response.Created(c, tmpl)
+}
+
+// GetTemplates lists the built-in templates followed by the user's own
+func (h *NoteTemplateHandler) GetTemplates(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	templates, err := h.templateRepo.GetAll(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get templates")
+		return
+	}
+
+	if templates == nil {
+		templates = make([]*models.NoteTemplate, 0)
+	}
+	loc := middleware.GetUserLocation(c)
+	for _, tmpl := range templates {
+		tmpl.Localize(loc)
+	}
+
+	response.Success(c, gin.H{
+		"builtin": notetemplate.Builtins(),
+		"custom":  templates,
+	})
+}
+
+func (h *NoteTemplateHandler) GetTemplate(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Template ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	tmpl, err := h.templateRepo.GetByID(id, userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get template")
+		return
+	}
+
+	if tmpl == nil {
+		response.NotFound(c, "Template not found")
+		return
+	}
+
+	tmpl.Localize(middleware.GetUserLocation(c))
+	response.Success(c, tmpl)
+}
+
+func (h *NoteTemplateHandler) UpdateTemplate(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Template ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateNoteTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	if validationErrors := validateTemplateBodies(req.TitleTemplate, req.BodyTemplate); len(validationErrors) > 0 {
+		response.ValidationErrorsResponse(c, validationErrors)
+		return
+	}
+
+	tmpl, err := h.templateRepo.Update(id, userID, &req)
+	if err != nil {
+		response.InternalServerError(c, "Failed to update template")
+		return
+	}
+
+	if tmpl == nil {
+		response.NotFound(c, "Template not found or you don't have permission to update it")
+		return
+	}
+
+	tmpl.Localize(middleware.GetUserLocation(c))
+	response.Success(c, tmpl)
+}
+
+func (h *NoteTemplateHandler) DeleteTemplate(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Template ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	if err := h.templateRepo.Delete(id, userID); err != nil {
+		if err.Error() == "note template not found or not owned by user" {
+			response.NotFound(c, "Template not found or you don't have permission to delete it")
+			return
+		}
+		response.InternalServerError(c, "Failed to delete template")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Template deleted successfully"})
+}
+
+// validateTemplateBodies makes sure both templates parse before they are stored
+func validateTemplateBodies(titleTmpl, bodyTmpl string) []response.ValidationError {
+	var validationErrors []response.ValidationError
+
+	if err := notetemplate.Validate("title", titleTmpl); err != nil {
+		validationErrors = append(validationErrors, response.ValidationError{
+			Field:   "title_template",
+			Message: "Invalid template syntax: " + err.Error(),
+		})
+	}
+
+	if err := notetemplate.Validate("body", bodyTmpl); err != nil {
+		validationErrors = append(validationErrors, response.ValidationError{
+			Field:   "body_template",
+			Message: "Invalid template syntax: " + err.Error(),
+		})
+	}
+
+	return validationErrors
+}
@@ -10,19 +10,30 @@ import (
 	"daily-notes-api/internal/models"
 	"daily-notes-api/internal/repository"
 	"daily-notes-api/pkg/cache"
+	"daily-notes-api/pkg/events"
+	"daily-notes-api/pkg/notetemplate"
 	"daily-notes-api/pkg/response"
+	"daily-notes-api/pkg/webhook"
 
 	"github.com/gin-gonic/gin"
 )
 
 type NoteHandler struct {
 	noteRepo     repository.NoteRepository
+	templateRepo repository.NoteTemplateRepository
+	userRepo     repository.UserRepository
+	webhookRepo  repository.WebhookRepository
+	eventHub     *events.Hub
 	cacheService *cache.CacheService
 }
 
-func NewNoteHandler(noteRepo repository.NoteRepository, cacheService *cache.CacheService) *NoteHandler {
+func NewNoteHandler(noteRepo repository.NoteRepository, templateRepo repository.NoteTemplateRepository, userRepo repository.UserRepository, webhookRepo repository.WebhookRepository, eventHub *events.Hub, cacheService *cache.CacheService) *NoteHandler {
 	return &NoteHandler{
 		noteRepo:     noteRepo,
+		templateRepo: templateRepo,
+		userRepo:     userRepo,
+		webhookRepo:  webhookRepo,
+		eventHub:     eventHub,
 		cacheService: cacheService,
 	}
 }
@@ -40,6 +51,15 @@ func (h *NoteHandler) CreateNote(c *gin.Context) {
 		return
 	}
 
+	if req.TemplateID != 0 || req.TemplateKey != "" {
+		if !h.applyTemplate(c, userID, &req) {
+			return
+		}
+	}
+	if req.Category == "" {
+		req.Category = middleware.GetUserSettings(c).DefaultCategory
+	}
+
 	note, err := h.noteRepo.Create(userID, &req)
 	if err != nil {
 		response.InternalServerError(c, "Failed to create note")
@@ -48,7 +68,9 @@ func (h *NoteHandler) CreateNote(c *gin.Context) {
 
 	// Invalidate cache after creating a note
 	h.invalidateCache(c, userID, 0)
+	h.publishEvent(userID, models.WebhookEventNoteCreated, note)
 
+	localizeNotes(c, note)
 	response.Created(c, note)
 }
 
@@ -82,6 +104,7 @@ func (h *NoteHandler) GetNote(c *gin.Context) {
 		err := h.cacheService.Get(ctx, cacheKey, &note)
 		if err == nil && note != nil {
 			log.Printf("Cache hit for note detail: %s", cacheKey)
+			localizeNotes(c, note)
 			response.Success(c, note)
 			return
 		}
@@ -113,6 +136,7 @@ func (h *NoteHandler) GetNote(c *gin.Context) {
 		}
 	}
 
+	localizeNotes(c, note)
 	response.Success(c, note)
 }
 
@@ -136,7 +160,7 @@ func (h *NoteHandler) GetNotes(c *gin.Context) {
 		filter.Page = 1
 	}
 	if filter.Limit <= 0 {
-		filter.Limit = 10
+		filter.Limit = middleware.GetUserSettings(c).DefaultPageSize
 	}
 	if filter.Limit > 100 {
 		validationErrors = append(validationErrors, response.ValidationError{
@@ -172,9 +196,11 @@ func (h *NoteHandler) GetNotes(c *gin.Context) {
 		if err == nil {
 			log.Printf("Cache hit for notes list: %s", cacheKey)
 			meta := response.CalculatePagination(filter.Page, filter.Limit, cachedData.Total)
+			notes = cachedData.Notes
 			if notes == nil {
 				notes = make([]*models.Note, 0)
 			}
+			localizeNotes(c, notes...)
 			response.SuccessWithMeta(c, notes, meta)
 			return
 		}
@@ -211,6 +237,7 @@ func (h *NoteHandler) GetNotes(c *gin.Context) {
 		}
 	}
 
+	localizeNotes(c, notes...)
 	meta := response.CalculatePagination(filter.Page, filter.Limit, total)
 	response.SuccessWithMeta(c, notes, meta)
 }
@@ -239,7 +266,7 @@ func (h *NoteHandler) UpdateNote(c *gin.Context) {
 		return
 	}
 
-	note, err := h.noteRepo.Update(id, userID, &req)
+	note, rewritten, err := h.noteRepo.Update(id, userID, &req)
 	if err != nil {
 		response.InternalServerError(c, "Failed to update note")
 		return
@@ -252,7 +279,10 @@ func (h *NoteHandler) UpdateNote(c *gin.Context) {
 
 	// Invalidate cache after updating a note
 	h.invalidateCache(c, userID, id)
+	h.publishEvent(userID, models.WebhookEventNoteUpdated, note)
+	h.publishRewrites(userID, rewritten)
 
+	localizeNotes(c, note)
 	response.Success(c, note)
 }
 
@@ -285,6 +315,7 @@ func (h *NoteHandler) DeleteNote(c *gin.Context) {
 
 	// Invalidate cache after deleting a note
 	h.invalidateCache(c, userID, id)
+	h.publishEvent(userID, models.WebhookEventNoteDeleted, gin.H{"id": id, "user_id": userID})
 
 	response.Success(c, gin.H{"message": "Note deleted successfully"})
 }
@@ -337,6 +368,88 @@ func (h *NoteHandler) GetCategories(c *gin.Context) {
 	response.Success(c, categories)
 }
 
+// applyTemplate renders the requested template into req, keeping any title,
+// content or category the client sent explicitly. It writes the error
+// response itself and returns false when the note should not be created.
+func (h *NoteHandler) applyTemplate(c *gin.Context, userID int, req *models.CreateNoteRequest) bool {
+	var titleTmpl, bodyTmpl, category string
+	var prompts []string
+
+	if req.TemplateID != 0 {
+		tmpl, err := h.templateRepo.GetByID(req.TemplateID, userID)
+		if err != nil {
+			response.InternalServerError(c, "Failed to get template")
+			return false
+		}
+		if tmpl == nil {
+			response.ValidationErrorResponse(c, response.ValidationError{
+				Field:   "template_id",
+				Message: "Template not found",
+				Value:   strconv.Itoa(req.TemplateID),
+			})
+			return false
+		}
+		titleTmpl, bodyTmpl, category, prompts = tmpl.TitleTemplate, tmpl.BodyTemplate, tmpl.Category, tmpl.Prompts
+	} else {
+		builtin, ok := notetemplate.GetBuiltin(req.TemplateKey)
+		if !ok {
+			response.ValidationErrorResponse(c, response.ValidationError{
+				Field:   "template_key",
+				Message: "Unknown built-in template",
+				Value:   req.TemplateKey,
+			})
+			return false
+		}
+		titleTmpl, bodyTmpl, category, prompts = builtin.TitleTemplate, builtin.BodyTemplate, builtin.Category, builtin.Prompts
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil || user == nil {
+		response.InternalServerError(c, "Failed to load user for template")
+		return false
+	}
+
+	data := notetemplate.NewData(time.Now().In(middleware.GetUserLocation(c)), notetemplate.User{
+		ID:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		FullName: user.FullName,
+	}, prompts, req.Variables)
+
+	title, body, err := notetemplate.Render(titleTmpl, bodyTmpl, data)
+	if err != nil {
+		log.Printf("Failed to render template for user %d: %v", userID, err)
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "template",
+			Message: "Failed to render template: " + err.Error(),
+		})
+		return false
+	}
+
+	if req.Title == "" {
+		req.Title = title
+	}
+	if req.Content == "" {
+		req.Content = body
+	}
+	if req.Category == "" {
+		req.Category = category
+	}
+
+	if runes := []rune(req.Title); len(runes) > 255 {
+		req.Title = string(runes[:255])
+	}
+	if req.Title == "" || req.Content == "" {
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "template",
+			Message: "Template rendered an empty title or content",
+		})
+		return false
+	}
+
+	return true
+}
+
 // invalidateCache removes cache entries after note operations
 func (h *NoteHandler) invalidateCache(c *gin.Context, userID, noteID int) {
 	if h.cacheService == nil {
@@ -360,3 +473,48 @@ func (h *NoteHandler) invalidateCache(c *gin.Context, userID, noteID int) {
 
 	log.Printf("Cache invalidated for user %d, note %d", userID, noteID)
 }
+
+// publishRewrites reports the notes whose links were rewritten by a rename
+// as updated
+func (h *NoteHandler) publishRewrites(userID int, notes []*models.Note) {
+	for _, note := range notes {
+		h.invalidateCache(nil, userID, note.ID)
+		h.publishEvent(userID, models.WebhookEventNoteUpdated, note)
+	}
+}
+
+// localizeNotes renders note timestamps in the user's timezone
+func localizeNotes(c *gin.Context, notes ...*models.Note) {
+	loc := middleware.GetUserLocation(c)
+	for _, note := range notes {
+		if note != nil {
+			note.Localize(loc)
+		}
+	}
+}
+
+// publishEvent notifies live event streams and queues webhook deliveries.
+// Failures are logged only; the note change itself already succeeded.
+func (h *NoteHandler) publishEvent(userID int, event string, data interface{}) {
+	if h.eventHub != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := h.eventHub.Publish(ctx, userID, event, data); err != nil {
+			log.Printf("Failed to publish %s event for user %d: %v", event, userID, err)
+		}
+		cancel()
+	}
+
+	if h.webhookRepo == nil {
+		return
+	}
+
+	payload, err := webhook.NewPayload(event, data)
+	if err != nil {
+		log.Printf("Failed to encode %s webhook payload: %v", event, err)
+		return
+	}
+
+	if _, err := h.webhookRepo.Enqueue(userID, event, string(payload)); err != nil {
+		log.Printf("Failed to enqueue %s webhooks for user %d: %v", event, userID, err)
+	}
+}
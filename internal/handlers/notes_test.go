@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// renamingNoteRepo updates a note and reports the notes linking to it as
+// rewritten
+type renamingNoteRepo struct {
+	repository.NoteRepository
+
+	linking []*models.Note
+}
+
+func (r *renamingNoteRepo) Update(id, userID int, req *models.UpdateNoteRequest) (*models.Note, []*models.Note, error) {
+	note := &models.Note{ID: id, UserID: userID, Title: req.Title, Content: req.Content, Version: 2}
+	return note, r.linking, nil
+}
+
+func TestUpdateNotePublishesRewrittenLinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		linking []*models.Note
+		want    int
+	}{
+		{"no linking notes", nil, 1},
+		{"linking notes rewritten", []*models.Note{{ID: 2, UserID: 1}, {ID: 3, UserID: 1}}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhooks := &fakeWebhookRepo{}
+			h := NewNoteHandler(&renamingNoteRepo{linking: tt.linking}, nil, nil, webhooks, nil, nil)
+
+			router := gin.New()
+			router.PUT("/notes/:id", func(c *gin.Context) { c.Set(middleware.UserIDKey, 1) }, h.UpdateNote)
+
+			body, _ := json.Marshal(models.UpdateNoteRequest{Title: "Renamed", Content: "text"})
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/notes/1", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("UpdateNote status = %d, body %s", w.Code, w.Body)
+			}
+			if len(webhooks.events) != tt.want {
+				t.Fatalf("webhook events = %v, want %d", webhooks.events, tt.want)
+			}
+			for _, event := range webhooks.events {
+				if event != models.WebhookEventNoteUpdated {
+					t.Errorf("event = %s, want %s", event, models.WebhookEventNoteUpdated)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,509 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/auth"
+	"daily-notes-api/pkg/pkce"
+
+	"github.com/gin-gonic/gin"
+)
+
+// refreshTokenPrefix tells refresh tokens apart from access tokens on the
+// introspection and revocation endpoints
+const refreshTokenPrefix = "rt_"
+
+// OAuthHandler is an OAuth2 authorization server for third-party clients.
+// Only the authorization code grant with PKCE and the refresh token grant
+// are supported. Its endpoints answer in the RFC 6749 format rather than
+// the API's response envelope.
+type OAuthHandler struct {
+	oauthRepo   repository.OAuthRepository
+	userRepo    repository.UserRepository
+	authHandler *AuthHandler
+	jwtManager  *auth.JWTManager
+	appName     string
+	apiURL      string
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+}
+
+func NewOAuthHandler(oauthRepo repository.OAuthRepository, userRepo repository.UserRepository, authHandler *AuthHandler, jwtManager *auth.JWTManager, appName, apiURL string, accessTTL, refreshTTL time.Duration) *OAuthHandler {
+	return &OAuthHandler{
+		oauthRepo:   oauthRepo,
+		userRepo:    userRepo,
+		authHandler: authHandler,
+		jwtManager:  jwtManager,
+		appName:     appName,
+		apiURL:      strings.TrimRight(apiURL, "/"),
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
+	}
+}
+
+// authorizeRequest is a validated /oauth/authorize request
+type authorizeRequest struct {
+	Client        *models.OAuthClient
+	RedirectURI   string
+	Scopes        []string
+	State         string
+	CodeChallenge string
+}
+
+// Metadata publishes the server endpoints (RFC 8414)
+func (h *OAuthHandler) Metadata(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"issuer":                                h.apiURL,
+		"authorization_endpoint":                h.apiURL + "/oauth/authorize",
+		"token_endpoint":                        h.apiURL + "/oauth/token",
+		"introspection_endpoint":                h.apiURL + "/oauth/introspect",
+		"revocation_endpoint":                   h.apiURL + "/oauth/revoke",
+		"jwks_uri":                              h.apiURL + "/.well-known/jwks.json",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      models.AccessTokenScopes,
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+	})
+}
+
+// Authorize shows the consent page
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+	req, ok := h.parseAuthorizeRequest(c, c.Query)
+	if !ok {
+		return
+	}
+
+	h.renderConsent(c, http.StatusOK, req, "")
+}
+
+// Approve handles the consent form. The user signs in on the form itself,
+// since the API has no browser session to rely on.
+func (h *OAuthHandler) Approve(c *gin.Context) {
+	req, ok := h.parseAuthorizeRequest(c, c.PostForm)
+	if !ok {
+		return
+	}
+
+	if c.PostForm("decision") != "approve" {
+		h.redirectError(c, req, "access_denied", "The user denied access")
+		return
+	}
+
+	user, err := h.authHandler.checkCredentials(c, strings.TrimSpace(c.PostForm("username")), c.PostForm("password"))
+	var throttledErr *loginThrottledError
+	if errors.As(err, &throttledErr) {
+		h.renderConsent(c, http.StatusTooManyRequests, req, "Too many failed attempts, please try again later.")
+		return
+	}
+	if err != nil {
+		h.renderConsent(c, http.StatusInternalServerError, req, "Something went wrong, please try again.")
+		return
+	}
+	if user == nil {
+		h.renderConsent(c, http.StatusUnauthorized, req, "Invalid username or password.")
+		return
+	}
+
+	code, err := h.oauthRepo.CreateAuthorizationCode(&models.OAuthAuthorizationCode{
+		ClientID:      req.Client.ID,
+		UserID:        user.ID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        req.Scopes,
+		CodeChallenge: req.CodeChallenge,
+	})
+	if err != nil {
+		h.renderConsent(c, http.StatusInternalServerError, req, "Something went wrong, please try again.")
+		return
+	}
+
+	h.redirect(c, req, url.Values{"code": {code}})
+}
+
+// parseAuthorizeRequest validates an authorization request. Problems with
+// the client or redirect URI are shown to the user, since redirecting to an
+// unverified URI would be an open redirect; everything else is reported
+// back to the client.
+func (h *OAuthHandler) parseAuthorizeRequest(c *gin.Context, param func(string) string) (*authorizeRequest, bool) {
+	client, err := h.oauthRepo.GetClientByClientID(param("client_id"))
+	if err != nil {
+		h.renderError(c, http.StatusInternalServerError, "Something went wrong, please try again later.")
+		return nil, false
+	}
+	if client == nil {
+		h.renderError(c, http.StatusBadRequest, "This application is not registered.")
+		return nil, false
+	}
+
+	redirectURI := param("redirect_uri")
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !client.AllowsRedirect(redirectURI) {
+		h.renderError(c, http.StatusBadRequest, "The redirect URI is not registered for this application.")
+		return nil, false
+	}
+
+	req := &authorizeRequest{
+		Client:        client,
+		RedirectURI:   redirectURI,
+		State:         param("state"),
+		CodeChallenge: param("code_challenge"),
+	}
+
+	if param("response_type") != "code" {
+		h.redirectError(c, req, "unsupported_response_type", "Only the code response type is supported")
+		return nil, false
+	}
+
+	// PKCE is required from every client, confidential or not
+	if req.CodeChallenge == "" || param("code_challenge_method") != "S256" {
+		h.redirectError(c, req, "invalid_request", "A S256 code_challenge is required")
+		return nil, false
+	}
+
+	scopes := strings.Fields(param("scope"))
+	if len(scopes) == 0 {
+		scopes = []string{models.ScopeNotesRead}
+	}
+	for _, scope := range scopes {
+		if !models.IsValidScope(scope) {
+			h.redirectError(c, req, "invalid_scope", "Unknown scope "+scope)
+			return nil, false
+		}
+	}
+	req.Scopes = uniqueStrings(scopes)
+
+	return req, true
+}
+
+// Token issues tokens for the authorization_code and refresh_token grants
+func (h *OAuthHandler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	client, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	switch c.PostForm("grant_type") {
+	case "authorization_code":
+		h.exchangeCode(c, client)
+	case "refresh_token":
+		h.refresh(c, client)
+	default:
+		oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "Only authorization_code and refresh_token are supported")
+	}
+}
+
+func (h *OAuthHandler) exchangeCode(c *gin.Context, client *models.OAuthClient) {
+	code, err := h.oauthRepo.TakeAuthorizationCode(c.PostForm("code"))
+	if err != nil {
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to check authorization code")
+		return
+	}
+	// The redirect URI may be left out when the client has only one
+	redirectURI := c.PostForm("redirect_uri")
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if code == nil || code.ClientID != client.ID || code.RedirectURI != redirectURI {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "Authorization code is invalid or expired")
+		return
+	}
+	if !pkce.Verify(c.PostForm("code_verifier"), code.CodeChallenge) {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "Code verifier does not match")
+		return
+	}
+
+	grant, refreshToken, err := h.oauthRepo.CreateGrant(client.ID, code.UserID, code.Scopes, time.Now().Add(h.refreshTTL))
+	if err != nil {
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to create grant")
+		return
+	}
+
+	h.issueTokens(c, client, grant, refreshToken)
+}
+
+func (h *OAuthHandler) refresh(c *gin.Context, client *models.OAuthClient) {
+	grant, refreshToken, err := h.oauthRepo.RotateRefreshToken(c.PostForm("refresh_token"), client.ID, time.Now().Add(h.refreshTTL))
+	if err != nil {
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to refresh token")
+		return
+	}
+	if grant == nil {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "Refresh token is invalid, expired or revoked")
+		return
+	}
+
+	h.issueTokens(c, client, grant, refreshToken)
+}
+
+func (h *OAuthHandler) issueTokens(c *gin.Context, client *models.OAuthClient, grant *models.OAuthGrant, refreshToken string) {
+	user, err := h.userRepo.GetByID(grant.UserID)
+	if err != nil || user == nil {
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to load user")
+		return
+	}
+
+	accessToken, err := h.jwtManager.GenerateOAuthToken(user.ID, user.Username, client.ClientID, grant.ID, grant.Scopes, h.accessTTL)
+	if err != nil {
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to generate token")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.OAuthTokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(h.accessTTL.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        strings.Join(grant.Scopes, " "),
+	})
+}
+
+// Introspect reports whether a token issued to the calling client is active
+// (RFC 7662). Tokens of other clients are reported as inactive.
+func (h *OAuthHandler) Introspect(c *gin.Context) {
+	client, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	token := c.PostForm("token")
+	if strings.HasPrefix(token, refreshTokenPrefix) {
+		grant, err := h.oauthRepo.GetGrantByRefreshToken(token)
+		if err != nil {
+			oauthError(c, http.StatusInternalServerError, "server_error", "Failed to check token")
+			return
+		}
+		if grant == nil || grant.ClientID != client.ID {
+			c.JSON(http.StatusOK, gin.H{"active": false})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"active":     true,
+			"token_type": "refresh_token",
+			"client_id":  client.ClientID,
+			"scope":      strings.Join(grant.Scopes, " "),
+			"sub":        grant.UserID,
+		})
+		return
+	}
+
+	claims, err := h.jwtManager.ValidateToken(token)
+	if err != nil || claims.GrantID == 0 || claims.ClientID != client.ClientID {
+		c.JSON(http.StatusOK, gin.H{"active": false})
+		return
+	}
+
+	grant, err := h.oauthRepo.GetActiveGrant(claims.GrantID, claims.UserID)
+	if err != nil {
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to check token")
+		return
+	}
+	if grant == nil {
+		c.JSON(http.StatusOK, gin.H{"active": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"active":     true,
+		"token_type": "access_token",
+		"client_id":  claims.ClientID,
+		"scope":      claims.Scope,
+		"username":   claims.Username,
+		"sub":        claims.UserID,
+		"exp":        claims.ExpiresAt.Unix(),
+		"iat":        claims.IssuedAt.Unix(),
+	})
+}
+
+// Revoke ends the grant behind an access or refresh token (RFC 7009), so
+// every token issued with it stops working. Unknown tokens are ignored.
+func (h *OAuthHandler) Revoke(c *gin.Context) {
+	client, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	grantID := 0
+	token := c.PostForm("token")
+	if strings.HasPrefix(token, refreshTokenPrefix) {
+		grant, err := h.oauthRepo.GetGrantByRefreshToken(token)
+		if err != nil {
+			oauthError(c, http.StatusInternalServerError, "server_error", "Failed to revoke token")
+			return
+		}
+		if grant != nil {
+			grantID = grant.ID
+		}
+	} else if claims, err := h.jwtManager.ValidateToken(token); err == nil && claims.ClientID == client.ClientID {
+		grantID = claims.GrantID
+	}
+
+	if grantID != 0 {
+		if err := h.oauthRepo.RevokeClientGrant(grantID, client.ID); err != nil {
+			oauthError(c, http.StatusInternalServerError, "server_error", "Failed to revoke token")
+			return
+		}
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// authenticateClient checks client credentials sent with HTTP Basic or in
+// the form. Public clients only send their client_id.
+func (h *OAuthHandler) authenticateClient(c *gin.Context) (*models.OAuthClient, bool) {
+	clientID, secret, basic := c.Request.BasicAuth()
+	if basic {
+		// RFC 6749 2.3.1 form-encodes the credentials before Basic encoding
+		if id, err := url.QueryUnescape(clientID); err == nil {
+			clientID = id
+		}
+		if s, err := url.QueryUnescape(secret); err == nil {
+			secret = s
+		}
+	} else {
+		clientID, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+
+	client, err := h.oauthRepo.GetClientByClientID(clientID)
+	if err != nil {
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to check client")
+		return nil, false
+	}
+
+	valid := client != nil
+	if valid && client.Confidential {
+		valid = subtle.ConstantTimeCompare([]byte(auth.HashToken(secret)), []byte(client.SecretHash)) == 1
+	}
+	if !valid {
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		oauthError(c, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+		return nil, false
+	}
+
+	return client, true
+}
+
+func (h *OAuthHandler) redirectError(c *gin.Context, req *authorizeRequest, code, description string) {
+	h.redirect(c, req, url.Values{"error": {code}, "error_description": {description}})
+}
+
+// redirect sends the user back to the client with params and the state
+func (h *OAuthHandler) redirect(c *gin.Context, req *authorizeRequest, params url.Values) {
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+
+	target, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		h.renderError(c, http.StatusBadRequest, "The redirect URI is invalid.")
+		return
+	}
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	target.RawQuery = query.Encode()
+
+	c.Redirect(http.StatusFound, target.String())
+}
+
+func oauthError(c *gin.Context, status int, code, description string) {
+	c.JSON(status, gin.H{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+var scopeDescriptions = map[string]string{
+	models.ScopeNotesRead:  "Read your notes, reminders, tasks and templates",
+	models.ScopeNotesWrite: "Create, change and delete your notes, reminders, tasks and templates",
+}
+
+var consentTemplate = template.Must(template.New("consent").Parse(`<!DOCTYPE html>
+<html><head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if .Client}}Authorize {{.Client}} - {{end}}{{.AppName}}</title></head>
+<body style="font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; max-width: 420px; margin: 40px auto; padding: 0 16px;">
+<h1 style="color: #4f46e5;">{{.AppName}}</h1>
+{{if .Message}}<p style="color: #b91c1c;">{{.Message}}</p>{{end}}
+{{if .Request}}
+<p><strong>{{.Client}}</strong> would like to:</p>
+<ul>{{range .Scopes}}<li>{{.}}</li>{{end}}</ul>
+<form method="POST" action="authorize">
+<input type="hidden" name="response_type" value="code">
+<input type="hidden" name="client_id" value="{{.Request.Client.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Scope}}">
+<input type="hidden" name="state" value="{{.Request.State}}">
+<input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="S256">
+<p><label>Username<br><input name="username" autocomplete="username" style="width: 100%;"></label></p>
+<p><label>Password<br><input name="password" type="password" autocomplete="current-password" style="width: 100%;"></label></p>
+<p><button name="decision" value="approve" type="submit">Allow</button>
+<button name="decision" value="deny" type="submit">Deny</button></p>
+</form>
+<p style="color: #6b7280; font-size: 14px;">You will be sent back to {{.Request.RedirectURI}}</p>
+{{end}}
+</body></html>`))
+
+func (h *OAuthHandler) renderConsent(c *gin.Context, status int, req *authorizeRequest, message string) {
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		scopes = append(scopes, scopeDescriptions[scope])
+	}
+
+	h.renderPage(c, status, gin.H{
+		"AppName": h.appName,
+		"Client":  req.Client.Name,
+		"Request": req,
+		"Scope":   strings.Join(req.Scopes, " "),
+		"Scopes":  scopes,
+		"Message": message,
+	})
+}
+
+func (h *OAuthHandler) renderError(c *gin.Context, status int, message string) {
+	h.renderPage(c, status, gin.H{
+		"AppName": h.appName,
+		"Message": message,
+	})
+}
+
+func (h *OAuthHandler) renderPage(c *gin.Context, status int, data gin.H) {
+	// The page takes a password, so it must not be framed by other sites
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "frame-ancestors 'none'")
+	c.Header("Cache-Control", "no-store")
+
+	var body strings.Builder
+	if err := consentTemplate.Execute(&body, data); err != nil {
+		log.Printf("Failed to render consent page: %v", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", []byte(body.String()))
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
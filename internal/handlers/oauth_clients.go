@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateClient registers a third-party application. The client secret of a
+// confidential client is only returned here.
+func (h *OAuthHandler) CreateClient(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req models.CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "name",
+			Message: "Name is required and cannot be empty",
+		})
+		return
+	}
+
+	// Redirect URIs are matched exactly
+	for _, uri := range req.RedirectURIs {
+		if !validRedirectURI(uri) {
+			response.ValidationErrorResponse(c, response.ValidationError{
+				Field:   "redirect_uris",
+				Message: "Redirect URIs must use https, http to a loopback address, or a private-use scheme like com.example.app:/callback, and must not have a fragment",
+				Value:   uri,
+			})
+			return
+		}
+	}
+
+	client, err := h.oauthRepo.CreateClient(userID, &req)
+	if err != nil {
+		response.InternalServerError(c, "Failed to create OAuth client")
+		return
+	}
+
+	client.Localize(middleware.GetUserLocation(c))
+	response.Created(c, client)
+}
+
+func (h *OAuthHandler) GetClients(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	clients, err := h.oauthRepo.GetClients(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get OAuth clients")
+		return
+	}
+
+	loc := middleware.GetUserLocation(c)
+	for _, client := range clients {
+		client.Localize(loc)
+	}
+
+	response.Success(c, clients)
+}
+
+// DeleteClient removes an application and every grant given to it
+func (h *OAuthHandler) DeleteClient(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Client ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	if err := h.oauthRepo.DeleteClient(id, userID); err != nil {
+		if err.Error() == "oauth client not found or not owned by user" {
+			response.NotFound(c, "OAuth client not found or you don't have permission to delete it")
+			return
+		}
+		response.InternalServerError(c, "Failed to delete OAuth client")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "OAuth client deleted successfully"})
+}
+
+// GetGrants lists the applications the user has given access to
+func (h *OAuthHandler) GetGrants(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	grants, err := h.oauthRepo.GetActiveGrants(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get authorized applications")
+		return
+	}
+
+	loc := middleware.GetUserLocation(c)
+	for _, grant := range grants {
+		grant.Localize(loc)
+	}
+
+	response.Success(c, grants)
+}
+
+// RevokeGrant takes access away from an application
+func (h *OAuthHandler) RevokeGrant(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Grant ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	revoked, err := h.oauthRepo.RevokeGrant(id, userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to revoke access")
+		return
+	}
+
+	if !revoked {
+		response.NotFound(c, "Authorized application not found")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Access revoked successfully"})
+}
+
+// validRedirectURI follows RFC 8252: web apps redirect over https, native
+// apps to http on a loopback address or to a private-use scheme named after
+// a domain they own. Any other scheme (javascript:, data:, plain http to a
+// remote host) could leak the authorization code.
+func validRedirectURI(uri string) bool {
+	parsed, err := url.Parse(uri)
+	if err != nil || strings.Contains(uri, "#") {
+		return false
+	}
+
+	switch parsed.Scheme {
+	case "https":
+		return parsed.Host != ""
+	case "http":
+		host := parsed.Hostname()
+		if host == "localhost" {
+			return true
+		}
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	default:
+		return strings.Contains(parsed.Scheme, ".") && parsed.Opaque == ""
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import "testing"
+
+func TestValidRedirectURI(t *testing.T) {
+	tests := []struct {
+		uri   string
+		valid bool
+	}{
+		{"https://app.example/callback", true},
+		{"https://app.example:8443/oauth?x=1", true},
+		{"http://127.0.0.1:51004/callback", true},
+		{"http://[::1]/callback", true},
+		{"http://localhost:3000/callback", true},
+		{"com.example.app:/oauth2redirect", true},
+		{"com.example.app://callback", true},
+		{"http://app.example/callback", false},
+		{"http://localhost.evil.test/callback", false},
+		{"https:///callback", false},
+		{"https://app.example/callback#frag", false},
+		{"https://app.example/callback#", false},
+		{"javascript:alert(1)", false},
+		{"data:text/html,hi", false},
+		{"myapp:/callback", false},
+		{"com.example.app:callback", false},
+		{"/callback", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := validRedirectURI(tt.uri); got != tt.valid {
+			t.Errorf("validRedirectURI(%q) = %v, want %v", tt.uri, got, tt.valid)
+		}
+	}
+}
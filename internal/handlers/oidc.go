@@ -0,0 +1,442 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/oidc"
+	"daily-notes-api/pkg/pkce"
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcLoginTTL is how long the user has to finish logging in at the provider
+const oidcLoginTTL = 10 * time.Minute
+
+// OIDCHandler logs users in through external OpenID Connect providers
+type OIDCHandler struct {
+	providers    map[string]*oidc.Provider
+	identityRepo repository.IdentityRepository
+	userRepo     repository.UserRepository
+	authHandler  *AuthHandler
+}
+
+func NewOIDCHandler(providers []*oidc.Provider, identityRepo repository.IdentityRepository, userRepo repository.UserRepository, authHandler *AuthHandler) *OIDCHandler {
+	byName := make(map[string]*oidc.Provider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+	return &OIDCHandler{
+		providers:    byName,
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		authHandler:  authHandler,
+	}
+}
+
+// GetProviders lists the configured providers
+func (h *OIDCHandler) GetProviders(c *gin.Context) {
+	names := make([]string, 0, len(h.providers))
+	for name := range h.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	response.Success(c, gin.H{"providers": names})
+}
+
+// StartLogin redirects to the provider with a fresh state, nonce and PKCE
+// verifier. An optional device_name query labels the resulting session.
+func (h *OIDCHandler) StartLogin(c *gin.Context) {
+	provider, ok := h.provider(c)
+	if !ok {
+		return
+	}
+
+	device := strings.TrimSpace(c.Query("device_name"))
+	if len(device) > 100 {
+		device = device[:100]
+	}
+
+	state := &models.OIDCLoginState{
+		Provider:  provider.Name(),
+		Device:    device,
+		ExpiresAt: time.Now().Add(oidcLoginTTL),
+	}
+	for _, field := range []*string{&state.State, &state.Nonce} {
+		value, err := oidc.RandomString()
+		if err != nil {
+			response.InternalServerError(c, "Failed to start login")
+			return
+		}
+		*field = value
+	}
+	verifier, err := pkce.NewVerifier()
+	if err != nil {
+		response.InternalServerError(c, "Failed to start login")
+		return
+	}
+	state.CodeVerifier = verifier
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	authURL, err := provider.AuthCodeURL(ctx, state.State, state.Nonce, state.CodeVerifier)
+	if err != nil {
+		log.Printf("OIDC provider %s unavailable: %v", provider.Name(), err)
+		response.InternalServerError(c, "Identity provider is unavailable")
+		return
+	}
+
+	if err := h.identityRepo.SaveLoginState(state); err != nil {
+		response.InternalServerError(c, "Failed to start login")
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback finishes the login: it exchanges the code, verifies the ID token,
+// finds or creates the user and returns a session token like Login does
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	provider, ok := h.provider(c)
+	if !ok {
+		return
+	}
+
+	if errCode := c.Query("error"); errCode != "" {
+		response.Unauthorized(c, "Login was not completed: "+errCode)
+		return
+	}
+
+	code, stateParam := c.Query("code"), c.Query("state")
+	if code == "" || stateParam == "" {
+		response.BadRequest(c, "Missing code or state")
+		return
+	}
+
+	state, err := h.identityRepo.TakeLoginState(stateParam)
+	if err != nil {
+		response.InternalServerError(c, "Failed to check login state")
+		return
+	}
+	if state == nil || state.Provider != provider.Name() {
+		response.Unauthorized(c, "Login expired or was already used, please try again")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	claims, err := provider.Exchange(ctx, code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		log.Printf("OIDC login with %s failed: %v", provider.Name(), err)
+		response.Unauthorized(c, "Identity provider login failed")
+		return
+	}
+
+	user, err := h.resolveUser(provider.Name(), claims)
+	if err != nil {
+		var linkErr *linkConfirmationError
+		switch {
+		case errors.As(err, &linkErr):
+			h.requestLinkConfirmation(c, linkErr.user, provider.Name(), claims, state.Device)
+		case errors.Is(err, errIdentityNoEmail):
+			response.Forbidden(c, "Your identity provider did not share an email address")
+		case errors.Is(err, errIdentityEmailUnverified):
+			response.Forbidden(c, "An account with this email already exists, and your identity provider has not verified the address")
+		default:
+			log.Printf("Failed to resolve OIDC user %s/%s: %v", provider.Name(), claims.Subject, err)
+			response.InternalServerError(c, "Failed to log in")
+		}
+		return
+	}
+
+	h.respondWithSession(c, user, state.Device)
+}
+
+// ConfirmLink links a pending provider login to the existing account with
+// the same email, once the user has proven they own it with its password
+func (h *OIDCHandler) ConfirmLink(c *gin.Context) {
+	var req models.ConfirmOIDCLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	link, err := h.identityRepo.TakePendingLink(req.LinkToken)
+	if err != nil {
+		response.InternalServerError(c, "Failed to check link request")
+		return
+	}
+	if link == nil {
+		response.Unauthorized(c, "Link request expired or was already used, please log in again")
+		return
+	}
+
+	owner, err := h.userRepo.GetByID(link.UserID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to check link request")
+		return
+	}
+	if owner == nil {
+		response.Unauthorized(c, "Link request expired or was already used, please log in again")
+		return
+	}
+
+	user, err := h.authHandler.checkCredentials(c, owner.Username, req.Password)
+	if err != nil {
+		var throttledErr *loginThrottledError
+		if errors.As(err, &throttledErr) {
+			c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(throttledErr.retryAfter)))
+			response.TooManyRequests(c, throttledErr.Error())
+			return
+		}
+		response.InternalServerError(c, "Failed to authenticate user")
+		return
+	}
+	if user == nil {
+		response.Unauthorized(c, "Invalid password, please log in with your identity provider again")
+		return
+	}
+
+	if err := h.identityRepo.Link(user.ID, link.Provider, link.Subject, link.Email); err != nil {
+		log.Printf("Failed to link OIDC identity %s/%s: %v", link.Provider, link.Subject, err)
+		response.InternalServerError(c, "Failed to link account")
+		return
+	}
+	if link.EmailVerified {
+		h.markEmailVerified(user, link.Email)
+	}
+
+	h.respondWithSession(c, user, link.Device)
+}
+
+func (h *OIDCHandler) respondWithSession(c *gin.Context, user *models.User, device string) {
+	token, err := h.authHandler.startSession(c, user, device)
+	if err != nil {
+		response.InternalServerError(c, "Failed to generate token")
+		return
+	}
+
+	response.Success(c, &models.LoginResponse{
+		User:  user.ToResponse(),
+		Token: token,
+	})
+}
+
+// requestLinkConfirmation answers with a token the client exchanges, along
+// with the account password, at ConfirmLink
+func (h *OIDCHandler) requestLinkConfirmation(c *gin.Context, user *models.User, provider string, claims *oidc.Claims, device string) {
+	token, err := h.identityRepo.CreatePendingLink(&models.OIDCPendingLink{
+		UserID:        user.ID,
+		Provider:      provider,
+		Subject:       claims.Subject,
+		Email:         strings.TrimSpace(claims.Email),
+		EmailVerified: claims.EmailVerified,
+		Device:        device,
+		ExpiresAt:     time.Now().Add(oidcLoginTTL),
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to log in")
+		return
+	}
+
+	response.ConflictWithData(c, "An account with this email already exists. Confirm its password to link it.", gin.H{
+		"link_token": token,
+		"expires_in": int(oidcLoginTTL.Seconds()),
+	})
+}
+
+var (
+	errIdentityNoEmail         = errors.New("identity has no email")
+	errIdentityEmailUnverified = errors.New("identity email is not verified")
+)
+
+// linkConfirmationError means the identity's email belongs to an existing
+// account that may only be linked after the user confirms its password
+type linkConfirmationError struct {
+	user *models.User
+}
+
+func (e *linkConfirmationError) Error() string {
+	return "linking requires the account password"
+}
+
+// resolveUser returns the user linked to the identity. An unlinked identity
+// gets a new account when its email is unused. It is only linked to the
+// account registered with its email without asking when both the provider
+// and this app have verified that email; anyone can register an account
+// with an address they don't own, and claim it here otherwise.
+func (h *OIDCHandler) resolveUser(provider string, claims *oidc.Claims) (*models.User, error) {
+	userID, err := h.identityRepo.GetUserID(provider, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if userID != 0 {
+		if err := h.identityRepo.TouchLogin(provider, claims.Subject, time.Now()); err != nil {
+			log.Printf("Failed to update identity %s/%s: %v", provider, claims.Subject, err)
+		}
+		user, err := h.userRepo.GetByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, fmt.Errorf("linked user %d not found", userID)
+		}
+		return user, nil
+	}
+
+	email := strings.TrimSpace(claims.Email)
+	if email == "" {
+		return nil, errIdentityNoEmail
+	}
+
+	user, err := h.userRepo.GetByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case user == nil:
+		user, err = h.provisionUser(email, claims)
+		if err != nil {
+			return nil, err
+		}
+		if claims.EmailVerified {
+			h.markEmailVerified(user, email)
+		}
+	case !claims.EmailVerified:
+		return nil, errIdentityEmailUnverified
+	case user.EmailVerifiedAt == nil:
+		return nil, &linkConfirmationError{user: user}
+	}
+
+	if err := h.identityRepo.Link(user.ID, provider, claims.Subject, email); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (h *OIDCHandler) markEmailVerified(user *models.User, email string) {
+	now := time.Now()
+	if err := h.userRepo.MarkEmailVerified(user.ID, email, now); err != nil {
+		log.Printf("Failed to mark email of user %d verified: %v", user.ID, err)
+		return
+	}
+	if user.Email == email {
+		user.EmailVerifiedAt = &now
+	}
+}
+
+// provisionUser creates an account for a first-time SSO user. It gets a
+// random password, so it can only log in through the provider.
+func (h *OIDCHandler) provisionUser(email string, claims *oidc.Claims) (*models.User, error) {
+	username, err := h.uniqueUsername(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	password := make([]byte, 32)
+	if _, err := rand.Read(password); err != nil {
+		return nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+
+	fullName := strings.TrimSpace(claims.Name)
+	if fullName == "" {
+		fullName = strings.TrimSpace(claims.GivenName)
+	}
+	if fullName == "" {
+		fullName = username
+	}
+
+	req := &models.RegisterRequest{
+		Username: username,
+		Email:    email,
+		Password: hex.EncodeToString(password),
+		FullName: fullName,
+	}
+	passwordHash, err := h.authHandler.passwordHasher.Hash(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	return h.userRepo.CreateWithOutbox(req, passwordHash, h.authHandler.welcomeOutbox(req))
+}
+
+// uniqueUsername derives a free username from the preferred username, the
+// email or the name claim, adding a number when it is taken
+func (h *OIDCHandler) uniqueUsername(claims *oidc.Claims) (string, error) {
+	base := ""
+	localPart, _, _ := strings.Cut(claims.Email, "@")
+	for _, candidate := range []string{claims.PreferredUsername, localPart, claims.Name} {
+		if base = sanitizeUsername(candidate); len(base) >= 3 {
+			break
+		}
+	}
+	if len(base) < 3 {
+		base = "user"
+	}
+
+	for i := 1; i <= 100; i++ {
+		username := base
+		if i > 1 {
+			username = fmt.Sprintf("%s%d", base, i)
+		}
+		exists, err := h.userRepo.UsernameExists(username)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return username, nil
+		}
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate username: %w", err)
+	}
+	return base + hex.EncodeToString(suffix), nil
+}
+
+// sanitizeUsername keeps lowercase letters, digits, dots, dashes and
+// underscores, leaving room for a numeric suffix
+func sanitizeUsername(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+		case r == '.' || r == '-' || r == '_':
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune('.')
+		}
+	}
+	username := strings.Trim(b.String(), ".-_")
+	if len(username) > 40 {
+		username = username[:40]
+	}
+	return username
+}
+
+func (h *OIDCHandler) provider(c *gin.Context) (*oidc.Provider, bool) {
+	provider, ok := h.providers[c.Param("provider")]
+	if !ok {
+		response.NotFound(c, "Identity provider not found")
+		return nil, false
+	}
+	return provider, true
+}
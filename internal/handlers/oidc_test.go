@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/oidc"
+	"daily-notes-api/pkg/oidc/oidctest"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeIdentityRepo struct {
+	repository.IdentityRepository
+
+	mu         sync.Mutex
+	identities map[string]int
+	states     map[string]*models.OIDCLoginState
+	links      map[string]*models.OIDCPendingLink
+}
+
+func newFakeIdentityRepo() *fakeIdentityRepo {
+	return &fakeIdentityRepo{
+		identities: make(map[string]int),
+		states:     make(map[string]*models.OIDCLoginState),
+		links:      make(map[string]*models.OIDCPendingLink),
+	}
+}
+
+func (r *fakeIdentityRepo) GetUserID(provider, subject string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.identities[provider+"/"+subject], nil
+}
+
+func (r *fakeIdentityRepo) Link(userID int, provider, subject, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.identities[provider+"/"+subject] = userID
+	return nil
+}
+
+func (r *fakeIdentityRepo) TouchLogin(provider, subject string, loginAt time.Time) error {
+	return nil
+}
+
+func (r *fakeIdentityRepo) SaveLoginState(state *models.OIDCLoginState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[state.State] = state
+	return nil
+}
+
+func (r *fakeIdentityRepo) TakeLoginState(state string) (*models.OIDCLoginState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.states[state]
+	delete(r.states, state)
+	return s, nil
+}
+
+func (r *fakeIdentityRepo) CreatePendingLink(link *models.OIDCPendingLink) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token := "link-" + link.Subject
+	r.links[token] = link
+	return token, nil
+}
+
+func (r *fakeIdentityRepo) TakePendingLink(token string) (*models.OIDCPendingLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	link := r.links[token]
+	delete(r.links, token)
+	return link, nil
+}
+
+type oidcTest struct {
+	provider   *oidctest.Server
+	users      *fakeUserRepo
+	identities *fakeIdentityRepo
+	auth       *AuthHandler
+	router     *gin.Engine
+}
+
+func newOIDCTest(t *testing.T) *oidcTest {
+	provider := oidctest.NewServer(t, "notes-app")
+	users := newFakeUserRepo()
+	identities := newFakeIdentityRepo()
+	authHandler := newTestAuthHandler(t, users, newFakeLoginSecurityRepo())
+
+	handler := NewOIDCHandler([]*oidc.Provider{oidc.NewProvider(oidc.Config{
+		Name:        "mock",
+		Issuer:      provider.URL,
+		ClientID:    "notes-app",
+		RedirectURL: "https://api.notes.example/api/v1/auth/oidc/mock/callback",
+	}, provider.Client())}, identities, users, authHandler)
+
+	router := gin.New()
+	router.GET("/auth/oidc/:provider/login", handler.StartLogin)
+	router.GET("/auth/oidc/:provider/callback", handler.Callback)
+	router.POST("/auth/oidc/link", handler.ConfirmLink)
+
+	return &oidcTest{provider: provider, users: users, identities: identities, auth: authHandler, router: router}
+}
+
+// login goes through the provider as identity and returns the callback
+// response
+func (tt *oidcTest) login(t *testing.T, identity oidctest.Identity) *httptest.ResponseRecorder {
+	t.Helper()
+
+	start := httptest.NewRecorder()
+	tt.router.ServeHTTP(start, httptest.NewRequest(http.MethodGet, "/auth/oidc/mock/login", nil))
+	if start.Code != http.StatusFound {
+		t.Fatalf("StartLogin status = %d, body %s", start.Code, start.Body)
+	}
+
+	code, state := tt.provider.Authorize(t, start.Header().Get("Location"), identity)
+	callback := httptest.NewRecorder()
+	query := url.Values{"code": {code}, "state": {state}}
+	tt.router.ServeHTTP(callback, httptest.NewRequest(http.MethodGet, "/auth/oidc/mock/callback?"+query.Encode(), nil))
+	return callback
+}
+
+func (tt *oidcTest) confirmLink(token, password string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(models.ConfirmOIDCLinkRequest{LinkToken: token, Password: password})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/auth/oidc/link", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	tt.router.ServeHTTP(w, req)
+	return w
+}
+
+func decodeData(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	var body struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid response %s: %v", w.Body, err)
+	}
+	if err := json.Unmarshal(body.Data, v); err != nil {
+		t.Fatalf("invalid response data %s: %v", body.Data, err)
+	}
+}
+
+func TestOIDCCallback(t *testing.T) {
+	verifiedAt := time.Now()
+
+	tests := []struct {
+		name     string
+		existing *models.User
+		linked   bool
+		identity oidctest.Identity
+		want     int
+		// wantUser is the username that ends up logged in and linked
+		wantUser     string
+		wantVerified bool
+	}{
+		{
+			name:         "new user with verified email",
+			identity:     oidctest.Identity{Subject: "s1", Email: "new@example.com", EmailVerified: true, PreferredUsername: "newbie"},
+			want:         http.StatusOK,
+			wantUser:     "newbie",
+			wantVerified: true,
+		},
+		{
+			name:     "new user whose provider omits email_verified",
+			identity: oidctest.Identity{Subject: "s1", Email: "new@example.com", PreferredUsername: "newbie"},
+			want:     http.StatusOK,
+			wantUser: "newbie",
+		},
+		{
+			name:     "provider without email",
+			identity: oidctest.Identity{Subject: "s1"},
+			want:     http.StatusForbidden,
+		},
+		{
+			name:     "existing account, unverified provider email",
+			existing: &models.User{Username: "victim", Email: "victim@example.com", EmailVerifiedAt: &verifiedAt},
+			identity: oidctest.Identity{Subject: "s1", Email: "victim@example.com", EmailVerified: false},
+			want:     http.StatusForbidden,
+		},
+		{
+			name:     "existing account whose email the app has not verified",
+			existing: &models.User{Username: "victim", Email: "victim@example.com"},
+			identity: oidctest.Identity{Subject: "s1", Email: "victim@example.com", EmailVerified: true},
+			want:     http.StatusConflict,
+		},
+		{
+			name:         "existing account with verified email",
+			existing:     &models.User{Username: "ann", Email: "ann@example.com", EmailVerifiedAt: &verifiedAt},
+			identity:     oidctest.Identity{Subject: "s1", Email: "ann@example.com", EmailVerified: true},
+			want:         http.StatusOK,
+			wantUser:     "ann",
+			wantVerified: true,
+		},
+		{
+			name:     "already linked identity",
+			existing: &models.User{Username: "ann", Email: "ann@example.com"},
+			linked:   true,
+			identity: oidctest.Identity{Subject: "s1", Email: "changed@example.com"},
+			want:     http.StatusOK,
+			wantUser: "ann",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newOIDCTest(t)
+			if tc.existing != nil {
+				user := tt.users.add(tc.existing)
+				if tc.linked {
+					tt.identities.Link(user.ID, "mock", tc.identity.Subject, user.Email)
+				}
+			}
+
+			w := tt.login(t, tc.identity)
+			if w.Code != tc.want {
+				t.Fatalf("Callback status = %d, want %d, body %s", w.Code, tc.want, w.Body)
+			}
+
+			userID, _ := tt.identities.GetUserID("mock", tc.identity.Subject)
+			if tc.wantUser == "" {
+				if userID != 0 {
+					t.Errorf("identity was linked to user %d", userID)
+				}
+				return
+			}
+
+			var login models.LoginResponse
+			decodeData(t, w, &login)
+			if login.Token == "" || login.User.Username != tc.wantUser {
+				t.Errorf("Callback logged in %+v, want %s", login.User, tc.wantUser)
+			}
+			if userID != login.User.ID {
+				t.Errorf("identity linked to user %d, want %d", userID, login.User.ID)
+			}
+
+			user, _ := tt.users.GetByID(userID)
+			if (user.EmailVerifiedAt != nil) != tc.wantVerified {
+				t.Errorf("EmailVerifiedAt = %v, want verified %v", user.EmailVerifiedAt, tc.wantVerified)
+			}
+		})
+	}
+}
+
+func TestOIDCConfirmLink(t *testing.T) {
+	tt := newOIDCTest(t)
+	owner := tt.users.add(&models.User{
+		Username:     "victim",
+		Email:        "victim@example.com",
+		PasswordHash: hashPassword(t, tt.auth, "correct horse battery"),
+	})
+	identity := oidctest.Identity{Subject: "s1", Email: "victim@example.com", EmailVerified: true}
+
+	pendingLink := func() string {
+		w := tt.login(t, identity)
+		if w.Code != http.StatusConflict {
+			t.Fatalf("Callback status = %d, want 409, body %s", w.Code, w.Body)
+		}
+		var data struct {
+			LinkToken string `json:"link_token"`
+		}
+		decodeData(t, w, &data)
+		return data.LinkToken
+	}
+
+	token := pendingLink()
+	if w := tt.confirmLink(token, "wrong password"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("ConfirmLink with wrong password status = %d, body %s", w.Code, w.Body)
+	}
+	if w := tt.confirmLink(token, "correct horse battery"); w.Code != http.StatusUnauthorized {
+		t.Errorf("reusing a link token status = %d, want 401", w.Code)
+	}
+	if userID, _ := tt.identities.GetUserID("mock", "s1"); userID != 0 {
+		t.Fatalf("identity linked without the password")
+	}
+
+	w := tt.confirmLink(pendingLink(), "correct horse battery")
+	if w.Code != http.StatusOK {
+		t.Fatalf("ConfirmLink status = %d, body %s", w.Code, w.Body)
+	}
+	if userID, _ := tt.identities.GetUserID("mock", "s1"); userID != owner.ID {
+		t.Errorf("identity linked to user %d, want %d", userID, owner.ID)
+	}
+	if user, _ := tt.users.GetByID(owner.ID); user.EmailVerifiedAt == nil {
+		t.Errorf("email not marked verified after confirming the link")
+	}
+
+	// The next login goes straight through
+	if w := tt.login(t, identity); w.Code != http.StatusOK {
+		t.Errorf("login after linking status = %d, body %s", w.Code, w.Body)
+	}
+}
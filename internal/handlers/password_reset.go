@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/email"
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasswordResetHandler lets users who forgot their password choose a new
+// one through a link emailed to their address
+type PasswordResetHandler struct {
+	resetRepo   repository.PasswordResetRepository
+	userRepo    repository.UserRepository
+	outboxRepo  repository.OutboxRepository
+	authHandler *AuthHandler
+	ttl         time.Duration
+}
+
+func NewPasswordResetHandler(resetRepo repository.PasswordResetRepository, userRepo repository.UserRepository, outboxRepo repository.OutboxRepository, authHandler *AuthHandler, ttl time.Duration) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		resetRepo:   resetRepo,
+		userRepo:    userRepo,
+		outboxRepo:  outboxRepo,
+		authHandler: authHandler,
+		ttl:         ttl,
+	}
+}
+
+// ForgotPassword emails a reset link. The answer is the same whether or not
+// the address belongs to an account, so it cannot be used to find accounts.
+func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	user, err := h.userRepo.GetByEmail(strings.TrimSpace(req.Email))
+	if err != nil {
+		response.InternalServerError(c, "Failed to request password reset")
+		return
+	}
+
+	if user != nil {
+		if err := h.sendResetEmail(user); err != nil {
+			log.Printf("Failed to send password reset email to user %d: %v", user.ID, err)
+		}
+	}
+
+	response.Accepted(c, gin.H{"message": "If an account uses this email, a password reset link has been sent to it"})
+}
+
+func (h *PasswordResetHandler) sendResetEmail(user *models.User) error {
+	emailService := h.authHandler.emailService
+	if emailService == nil {
+		return fmt.Errorf("email service not configured")
+	}
+
+	token, err := h.resetRepo.Create(user.ID, time.Now().Add(h.ttl))
+	if err != nil {
+		return err
+	}
+
+	appURL := strings.TrimRight(h.authHandler.appURL, "/")
+	msg, err := emailService.BuildPasswordResetEmail(email.PasswordResetEmailData{
+		Locale:         user.Locale,
+		UserName:       user.FullName,
+		Email:          user.Email,
+		AppName:        h.authHandler.appName,
+		AppURL:         appURL,
+		ResetURL:       fmt.Sprintf("%s/reset-password?token=%s", appURL, url.QueryEscape(token)),
+		ExpiresMinutes: int(h.ttl.Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.outboxRepo.Enqueue(models.NewOutboxMessage("password_reset", msg))
+}
+
+// ResetPassword sets a new password with the token from the reset email,
+// under the same policy as registration, and logs out every session
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	userID, err := h.resetRepo.GetUserID(req.Token)
+	if err != nil {
+		response.InternalServerError(c, "Failed to reset password")
+		return
+	}
+
+	var user *models.User
+	if userID != 0 {
+		if user, err = h.userRepo.GetByID(userID); err != nil {
+			response.InternalServerError(c, "Failed to reset password")
+			return
+		}
+	}
+	if user == nil {
+		invalidResetToken(c)
+		return
+	}
+
+	passwordErrors, err := h.authHandler.passwordErrors("new_password", req.NewPassword, user.Username, user.Email)
+	if err != nil {
+		response.InternalServerError(c, "Failed to check password")
+		return
+	}
+	if len(passwordErrors) > 0 {
+		response.ValidationErrorsResponse(c, passwordErrors)
+		return
+	}
+
+	passwordHash, err := h.authHandler.passwordHasher.Hash(req.NewPassword)
+	if err != nil {
+		response.InternalServerError(c, "Failed to hash new password")
+		return
+	}
+
+	// The token may have been used while the password was checked
+	if userID, err = h.resetRepo.Reset(req.Token, passwordHash); err != nil {
+		response.InternalServerError(c, "Failed to reset password")
+		return
+	}
+	if userID == 0 {
+		invalidResetToken(c)
+		return
+	}
+
+	// Log out every device that knew the old password, and lift a lockout
+	// caused by guessing it
+	if _, err := h.authHandler.sessionRepo.RevokeOthers(userID, 0); err != nil {
+		log.Printf("Failed to revoke sessions for user %d: %v", userID, err)
+	}
+	if _, err := h.authHandler.loginSecurityRepo.ClearFailures(user.Username); err != nil {
+		log.Printf("Failed to clear login failures for user %d: %v", userID, err)
+	}
+
+	response.Success(c, gin.H{"message": "Password reset successfully"})
+}
+
+func invalidResetToken(c *gin.Context) {
+	response.ValidationErrorResponse(c, response.ValidationError{
+		Field:   "token",
+		Message: "Password reset link is invalid or has expired",
+	})
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/email"
+	"daily-notes-api/pkg/passwordpolicy"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakePasswordResetRepo struct {
+	repository.PasswordResetRepository
+
+	mu     sync.Mutex
+	users  *fakeUserRepo
+	tokens map[string]fakeReset
+}
+
+type fakeReset struct {
+	userID    int
+	expiresAt time.Time
+}
+
+func (r *fakePasswordResetRepo) Create(userID int, expiresAt time.Time) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token := fmt.Sprintf("token-%d", len(r.tokens)+1)
+	r.tokens[token] = fakeReset{userID: userID, expiresAt: expiresAt}
+	return token, nil
+}
+
+func (r *fakePasswordResetRepo) GetUserID(token string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if reset, ok := r.tokens[token]; ok && time.Now().Before(reset.expiresAt) {
+		return reset.userID, nil
+	}
+	return 0, nil
+}
+
+func (r *fakePasswordResetRepo) Reset(token, newPasswordHash string) (int, error) {
+	userID, _ := r.GetUserID(token)
+	if userID == 0 {
+		return 0, nil
+	}
+
+	r.users.mu.Lock()
+	r.users.users[userID].PasswordHash = newPasswordHash
+	r.users.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for t, reset := range r.tokens {
+		if reset.userID == userID {
+			delete(r.tokens, t)
+		}
+	}
+	return userID, nil
+}
+
+type fakeOutboxRepo struct {
+	repository.OutboxRepository
+
+	mu       sync.Mutex
+	messages []*models.OutboxMessage
+}
+
+func (r *fakeOutboxRepo) Enqueue(msg *models.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+type passwordResetTest struct {
+	users    *fakeUserRepo
+	resets   *fakePasswordResetRepo
+	outbox   *fakeOutboxRepo
+	sessions *fakeSessionRepo
+	logins   *fakeLoginSecurityRepo
+	auth     *AuthHandler
+	user     *models.User
+	router   *gin.Engine
+}
+
+func newPasswordResetTest(t *testing.T) *passwordResetTest {
+	users := newFakeUserRepo()
+	logins := newFakeLoginSecurityRepo()
+	h := newTestAuthHandler(t, users, logins)
+	h.passwordPolicy = passwordpolicy.New(10, 40, nil)
+	h.emailService = email.NewEmailServiceWithTransport(email.NewLogTransport(io.Discard), email.NewTemplateRenderer(""), "Daily Notes <noreply@notes.example>")
+	sessions := &fakeSessionRepo{}
+	h.sessionRepo = sessions
+
+	user := users.add(&models.User{Username: "annabelle", Email: "ann@example.com", FullName: "Ann", PasswordHash: hashPassword(t, h, "old password")})
+	resets := &fakePasswordResetRepo{users: users, tokens: make(map[string]fakeReset)}
+	outbox := &fakeOutboxRepo{}
+	reset := NewPasswordResetHandler(resets, users, outbox, h, time.Hour)
+
+	router := gin.New()
+	router.POST("/auth/password/forgot", reset.ForgotPassword)
+	router.POST("/auth/password/reset", reset.ResetPassword)
+	return &passwordResetTest{users: users, resets: resets, outbox: outbox, sessions: sessions, logins: logins, auth: h, user: user, router: router}
+}
+
+func (pt *passwordResetTest) post(path string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	pt.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestForgotPassword(t *testing.T) {
+	tests := []struct {
+		name       string
+		email      string
+		wantCode   int
+		wantEmails int
+	}{
+		{"known address", "ann@example.com", http.StatusAccepted, 1},
+		{"unknown address gets the same answer", "bob@example.com", http.StatusAccepted, 0},
+		{"invalid address", "not-an-email", http.StatusUnprocessableEntity, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pt := newPasswordResetTest(t)
+
+			w := pt.post("/auth/password/forgot", models.ForgotPasswordRequest{Email: tt.email})
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if got := len(pt.outbox.messages); got != tt.wantEmails {
+				t.Fatalf("enqueued %d emails, want %d", got, tt.wantEmails)
+			}
+			if tt.wantEmails == 0 {
+				return
+			}
+
+			msg := pt.outbox.messages[0]
+			if msg.Kind != "password_reset" || msg.Recipient != tt.email {
+				t.Errorf("message kind %q to %q, want password_reset to %q", msg.Kind, msg.Recipient, tt.email)
+			}
+			if link := "https://notes.example/reset-password?token=token-1"; !strings.Contains(msg.TextBody, link) {
+				t.Errorf("email does not contain %s:\n%s", link, msg.TextBody)
+			}
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	const strong = "violet lantern orbit canyon"
+
+	tests := []struct {
+		name      string
+		token     string
+		expired   bool
+		password  string
+		wantCode  int
+		wantField string
+	}{
+		{"strong password", "token-1", false, strong, http.StatusOK, ""},
+		{"too short", "token-1", false, "short", http.StatusUnprocessableEntity, "new_password"},
+		{"contains username", "token-1", false, "annabelle violet lantern", http.StatusUnprocessableEntity, "new_password"},
+		{"unknown token", "token-9", false, strong, http.StatusUnprocessableEntity, "token"},
+		{"expired token", "token-1", true, strong, http.StatusUnprocessableEntity, "token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pt := newPasswordResetTest(t)
+			expiresAt := time.Now().Add(time.Hour)
+			if tt.expired {
+				expiresAt = time.Now().Add(-time.Minute)
+			}
+			if _, err := pt.resets.Create(pt.user.ID, expiresAt); err != nil {
+				t.Fatal(err)
+			}
+			pt.logins.TryAttempt(pt.user.Username, time.Now())
+
+			w := pt.post("/auth/password/reset", models.ResetPasswordRequest{Token: tt.token, NewPassword: tt.password})
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+
+			user, _ := pt.users.GetByID(pt.user.ID)
+			changed, _ := pt.auth.passwordHasher.Verify(tt.password, user.PasswordHash)
+
+			if tt.wantField != "" {
+				var body struct {
+					Errors []struct {
+						Field string `json:"field"`
+					} `json:"errors"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatal(err)
+				}
+				if len(body.Errors) == 0 || body.Errors[0].Field != tt.wantField {
+					t.Errorf("errors = %s, want field %s", w.Body, tt.wantField)
+				}
+				if changed || len(pt.sessions.revoked) != 0 {
+					t.Error("password changed or sessions revoked after a rejected reset")
+				}
+				return
+			}
+
+			if !changed {
+				t.Error("new password does not verify")
+			}
+			if len(pt.sessions.revoked) != 1 || pt.sessions.revoked[0] != pt.user.ID {
+				t.Errorf("revoked sessions of %v, want [%d]", pt.sessions.revoked, pt.user.ID)
+			}
+			if _, ok := pt.logins.failures[pt.user.Username]; ok {
+				t.Error("login failures were not cleared")
+			}
+
+			// The link works only once
+			if w := pt.post("/auth/password/reset", models.ResetPasswordRequest{Token: tt.token, NewPassword: tt.password}); w.Code != http.StatusUnprocessableEntity {
+				t.Errorf("second reset status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+			}
+		})
+	}
+}
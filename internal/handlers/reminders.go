@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/response"
+	"daily-notes-api/pkg/rrule"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReminderHandler struct {
+	reminderRepo repository.ReminderRepository
+	noteRepo     repository.NoteRepository
+}
+
+func NewReminderHandler(reminderRepo repository.ReminderRepository, noteRepo repository.NoteRepository) *ReminderHandler {
+	return &ReminderHandler{
+		reminderRepo: reminderRepo,
+		noteRepo:     noteRepo,
+	}
+}
+
+func (h *ReminderHandler) CreateReminder(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	noteID, ok := parseIDParam(c, "id", "Note ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	var req models.CreateReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	note, err := h.noteRepo.GetByID(noteID, userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get note")
+		return
+	}
+	if note == nil {
+		response.NotFound(c, "Note not found")
+		return
+	}
+
+	reminder := &models.Reminder{
+		NoteID:       noteID,
+		UserID:       userID,
+		StartsAt:     req.RemindAt,
+		OccurrenceAt: req.RemindAt,
+		RemindAt:     req.RemindAt,
+	}
+
+	if req.RRule != "" {
+		rule, err := rrule.Parse(req.RRule)
+		if err != nil {
+			response.ValidationErrorResponse(c, response.ValidationError{
+				Field:   "rrule",
+				Message: "Invalid recurrence rule: " + err.Error(),
+				Value:   req.RRule,
+			})
+			return
+		}
+
+		// The first occurrence may be later than remind_at when BYDAY excludes
+		// it. A series that started in the past begins with its next
+		// occurrence instead of sending the missed ones. Days are counted in
+		// the user's timezone, as the scheduler does.
+		start := req.RemindAt.In(middleware.GetUserLocation(c))
+		after := start.Add(-time.Second)
+		if now := time.Now(); now.After(after) {
+			after = now
+		}
+		first, ok := rule.Next(start, after)
+		if !ok {
+			response.ValidationErrorResponse(c, response.ValidationError{
+				Field:   "rrule",
+				Message: "Recurrence rule has no future occurrences",
+				Value:   req.RRule,
+			})
+			return
+		}
+		reminder.RRule = req.RRule
+		reminder.OccurrenceAt = first
+		reminder.RemindAt = first
+	} else if req.RemindAt.Before(time.Now()) {
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "remind_at",
+			Message: "Reminder time must be in the future",
+			Value:   req.RemindAt.Format(time.RFC3339),
+		})
+		return
+	}
+
+	created, err := h.reminderRepo.Create(reminder)
+	if err != nil {
+		response.InternalServerError(c, "Failed to create reminder")
+		return
+	}
+
+	created.Localize(middleware.GetUserLocation(c))
+	response.Created(c, created)
+}
+
+func (h *ReminderHandler) GetNoteReminders(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	noteID, ok := parseIDParam(c, "id", "Note ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	reminders, err := h.reminderRepo.GetByNote(noteID, userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get reminders")
+		return
+	}
+
+	localizeReminders(c, reminders)
+	response.Success(c, reminders)
+}
+
+func (h *ReminderHandler) GetReminders(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var filter models.RemindersFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	switch filter.Status {
+	case "", models.ReminderStatusPending, models.ReminderStatusSent, models.ReminderStatusCancelled:
+	default:
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "status",
+			Message: "Status must be one of pending, sent or cancelled",
+			Value:   filter.Status,
+		})
+		return
+	}
+
+	reminders, err := h.reminderRepo.GetAll(userID, &filter)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get reminders")
+		return
+	}
+
+	localizeReminders(c, reminders)
+	response.Success(c, reminders)
+}
+
+func (h *ReminderHandler) SnoozeReminder(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Reminder ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	var req models.SnoozeReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	var until time.Time
+	switch {
+	case req.Until != nil:
+		until = *req.Until
+	case req.Minutes > 0:
+		until = time.Now().Add(time.Duration(req.Minutes) * time.Minute)
+	default:
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "minutes",
+			Message: "Either minutes or until is required",
+		})
+		return
+	}
+
+	if !until.After(time.Now()) {
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "until",
+			Message: "Snooze time must be in the future",
+			Value:   until.Format(time.RFC3339),
+		})
+		return
+	}
+
+	reminder, err := h.reminderRepo.Snooze(id, userID, until)
+	if err != nil {
+		response.InternalServerError(c, "Failed to snooze reminder")
+		return
+	}
+
+	if reminder == nil {
+		response.NotFound(c, "Pending reminder not found")
+		return
+	}
+
+	reminder.Localize(middleware.GetUserLocation(c))
+	response.Success(c, reminder)
+}
+
+func (h *ReminderHandler) CancelReminder(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Reminder ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	if err := h.reminderRepo.Cancel(id, userID); err != nil {
+		if err.Error() == "pending reminder not found or not owned by user" {
+			response.NotFound(c, "Pending reminder not found or you don't have permission to cancel it")
+			return
+		}
+		response.InternalServerError(c, "Failed to cancel reminder")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Reminder cancelled successfully"})
+}
+
+// parseIDParam reads a numeric route parameter, writing a validation error
+// response and returning false when it is not a number
+func parseIDParam(c *gin.Context, name, message string) (int, bool) {
+	idStr := c.Param(name)
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   name,
+			Message: message,
+			Value:   idStr,
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// localizeReminders renders reminder times in the user's timezone
+func localizeReminders(c *gin.Context, reminders []*models.Reminder) {
+	loc := middleware.GetUserLocation(c)
+	for _, reminder := range reminders {
+		reminder.Localize(loc)
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	sessionRepo repository.SessionRepository
+}
+
+func NewSessionHandler(sessionRepo repository.SessionRepository) *SessionHandler {
+	return &SessionHandler{
+		sessionRepo: sessionRepo,
+	}
+}
+
+func (h *SessionHandler) GetSessions(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	sessions, err := h.sessionRepo.GetAllActive(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get sessions")
+		return
+	}
+
+	currentID, _ := middleware.GetCurrentSessionID(c)
+	loc := middleware.GetUserLocation(c)
+	for _, session := range sessions {
+		session.Current = session.ID == currentID
+		session.Localize(loc)
+	}
+
+	response.Success(c, sessions)
+}
+
+// RevokeSession logs one device out; revoking the current session works
+// like a logout
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Session ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	revoked, err := h.sessionRepo.Revoke(id, userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to revoke session")
+		return
+	}
+
+	if !revoked {
+		response.NotFound(c, "Session not found")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Session revoked successfully"})
+}
+
+// RevokeOtherSessions logs out every device except the one making the request
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	sessionID, exists := middleware.GetCurrentSessionID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	revoked, err := h.sessionRepo.RevokeOthers(userID, sessionID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to revoke sessions")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"message": "Other sessions revoked successfully",
+		"revoked": revoked,
+	})
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"time"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SettingsHandler struct {
+	settingsRepo repository.UserSettingsRepository
+}
+
+func NewSettingsHandler(settingsRepo repository.UserSettingsRepository) *SettingsHandler {
+	return &SettingsHandler{
+		settingsRepo: settingsRepo,
+	}
+}
+
+func (h *SettingsHandler) GetSettings(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	settings, err := h.settingsRepo.Get(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get settings")
+		return
+	}
+
+	if settings == nil {
+		response.NotFound(c, "User not found")
+		return
+	}
+
+	response.Success(c, settings)
+}
+
+func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req models.UpdateUserSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+			response.ValidationErrorResponse(c, response.ValidationError{
+				Field:   "timezone",
+				Message: "Timezone must be a valid IANA name such as Asia/Jakarta",
+				Value:   *req.Timezone,
+			})
+			return
+		}
+	}
+
+	settings, err := h.settingsRepo.Get(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get settings")
+		return
+	}
+
+	if settings == nil {
+		response.NotFound(c, "User not found")
+		return
+	}
+
+	if req.Timezone != nil {
+		settings.Timezone = *req.Timezone
+	}
+	if req.Locale != nil {
+		settings.Locale = *req.Locale
+	}
+	if req.WeekStart != nil {
+		settings.WeekStart = *req.WeekStart
+	}
+	if req.DefaultCategory != nil {
+		settings.DefaultCategory = *req.DefaultCategory
+	}
+	if req.DefaultPageSize != nil {
+		settings.DefaultPageSize = *req.DefaultPageSize
+	}
+	if req.Digest != nil {
+		if req.Digest.Frequency != nil {
+			settings.Digest.Frequency = *req.Digest.Frequency
+		}
+		if req.Digest.SendHour != nil {
+			settings.Digest.SendHour = *req.Digest.SendHour
+		}
+	}
+
+	if err := h.settingsRepo.Save(settings); err != nil {
+		response.InternalServerError(c, "Failed to update settings")
+		return
+	}
+
+	response.Success(c, settings)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/cache"
+	"daily-notes-api/pkg/response"
+	"daily-notes-api/pkg/streak"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	statsDays          = 30
+	statsWeeks         = 12
+	statsMonths        = 12
+	statsTopCategories = 5
+)
+
+type StatsHandler struct {
+	statsRepo    repository.StatsRepository
+	cacheService *cache.CacheService
+}
+
+func NewStatsHandler(statsRepo repository.StatsRepository, cacheService *cache.CacheService) *StatsHandler {
+	return &StatsHandler{
+		statsRepo:    statsRepo,
+		cacheService: cacheService,
+	}
+}
+
+// GetStats returns writing statistics. Days, hours and streaks follow the
+// timezone query parameter, else the user's timezone setting.
+func (h *StatsHandler) GetStats(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	settings := middleware.GetUserSettings(c)
+	timezone := c.Query("timezone")
+	if timezone == "" {
+		timezone = settings.Timezone
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "timezone",
+			Message: "Timezone must be a valid IANA name such as Asia/Jakarta",
+			Value:   timezone,
+		})
+		return
+	}
+	now := time.Now().In(loc)
+
+	var stats models.Stats
+	cacheKey := ""
+	if h.cacheService != nil {
+		cacheKey = h.cacheService.GenerateStatsKey(userID, loc.String(), settings.WeekStart, now.Format("2006-01-02"))
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		if err := h.cacheService.Get(ctx, cacheKey, &stats); err == nil {
+			log.Printf("Cache hit for stats: %s", cacheKey)
+			response.Success(c, stats)
+			return
+		}
+	}
+
+	stats.Timezone = loc.String()
+	stats.TotalNotes, stats.TotalWords, stats.TotalCharacters, err = h.statsRepo.GetTotals(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get statistics")
+		return
+	}
+
+	stats.TopCategories, err = h.statsRepo.GetTopCategories(userID, statsTopCategories)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get statistics")
+		return
+	}
+
+	_, offset := now.Zone()
+	days, err := h.statsRepo.GetNotesPerDay(userID, offset)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get statistics")
+		return
+	}
+
+	stats.HourHeatmap, err = h.statsRepo.GetHourHeatmap(userID, offset)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get statistics")
+		return
+	}
+
+	weekStart := time.Monday
+	if settings.WeekStart == models.WeekStartSunday {
+		weekStart = time.Sunday
+	}
+	foldDays(&stats, days, now, weekStart)
+
+	if h.cacheService != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := h.cacheService.Set(ctx, cacheKey, stats, 0); err != nil {
+			log.Printf("Failed to cache stats: %v", err)
+		}
+	}
+
+	response.Success(c, stats)
+}
+
+// foldDays sums local per-day counts, sorted by day, into days, weeks
+// (starting on weekStart), months and streaks
+func foldDays(stats *models.Stats, days []models.PeriodCount, now time.Time, weekStart time.Weekday) {
+	loc := now.Location()
+	perDay := make(map[string]int, len(days))
+	perMonth := make(map[string]int)
+	activeDays := make([]string, 0, len(days))
+
+	for _, day := range days {
+		perDay[day.Period] = day.Count
+		perMonth[day.Period[:7]] += day.Count
+		activeDays = append(activeDays, day.Period)
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	for i := statsDays - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i).Format("2006-01-02")
+		stats.NotesPerDay = append(stats.NotesPerDay, models.PeriodCount{Period: day, Count: perDay[day]})
+	}
+
+	thisWeek := today.AddDate(0, 0, -((int(today.Weekday()) - int(weekStart) + 7) % 7))
+	for i := statsWeeks - 1; i >= 0; i-- {
+		start := thisWeek.AddDate(0, 0, -7*i)
+		count := 0
+		for d := 0; d < 7; d++ {
+			count += perDay[start.AddDate(0, 0, d).Format("2006-01-02")]
+		}
+		stats.NotesPerWeek = append(stats.NotesPerWeek, models.PeriodCount{Period: start.Format("2006-01-02"), Count: count})
+	}
+
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	for i := statsMonths - 1; i >= 0; i-- {
+		month := monthStart.AddDate(0, -i, 0).Format("2006-01")
+		stats.NotesPerMonth = append(stats.NotesPerMonth, models.PeriodCount{Period: month, Count: perMonth[month]})
+	}
+
+	stats.CurrentStreak, stats.LongestStreak = streak.Compute(activeDays, now)
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	syncDefaultLimit = 100
+	syncMaxLimit     = 500
+)
+
+// GetSyncChanges returns notes changed and deleted since the given token.
+// Tokens are opaque to clients; an empty token returns every note.
+func (h *NoteHandler) GetSyncChanges(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	since, ok := parseSyncToken(c, c.Query("since"))
+	if !ok {
+		return
+	}
+
+	limit := syncDefaultLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > syncMaxLimit {
+			response.ValidationErrorResponse(c, response.ValidationError{
+				Field:   "limit",
+				Message: "Limit must be a number between 1 and 500",
+				Value:   limitStr,
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	// Fetch one extra change to know whether another page follows
+	changes, err := h.noteRepo.GetChangesSince(userID, since, limit+1)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get changes")
+		return
+	}
+
+	hasMore := len(changes) > limit
+	if hasMore {
+		changes = changes[:limit]
+	}
+
+	nextToken := since
+	var upserted []int
+	for _, change := range changes {
+		if change.Op == models.NoteChangeUpsert {
+			upserted = append(upserted, change.NoteID)
+		}
+		nextToken = change.Seq
+	}
+
+	notes, err := h.noteRepo.GetByIDs(userID, upserted)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get changed notes")
+		return
+	}
+
+	byID := make(map[int]*models.Note, len(notes))
+	for _, note := range notes {
+		byID[note.ID] = note
+	}
+
+	result := models.SyncChangesResponse{
+		Changed:   make([]*models.Note, 0, len(notes)),
+		Deleted:   make([]int, 0),
+		NextToken: strconv.FormatInt(nextToken, 10),
+		HasMore:   hasMore,
+	}
+	for _, change := range changes {
+		// A note upserted and then deleted by a concurrent request may be
+		// gone already; report it as deleted
+		if note, ok := byID[change.NoteID]; ok && change.Op == models.NoteChangeUpsert {
+			result.Changed = append(result.Changed, note)
+		} else {
+			result.Deleted = append(result.Deleted, change.NoteID)
+		}
+	}
+
+	localizeNotes(c, result.Changed...)
+	response.Success(c, result)
+}
+
+// PushSyncChanges applies a batch of offline mutations. Each mutation is
+// applied on its own; stale base versions are reported as conflicts together
+// with the server copy instead of overwriting it.
+func (h *NoteHandler) PushSyncChanges(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req models.SyncPushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	results := make([]models.SyncMutationResult, 0, len(req.Mutations))
+	changed := false
+	for _, mutation := range req.Mutations {
+		result, err := h.applyMutation(userID, &mutation)
+		if err != nil {
+			response.InternalServerError(c, "Failed to apply changes")
+			return
+		}
+		if result.Status == models.SyncStatusApplied {
+			changed = true
+		}
+		localizeNotes(c, result.Note, result.ServerNote)
+		results = append(results, result)
+	}
+
+	if changed {
+		h.invalidateCache(c, userID, 0)
+	}
+
+	latest, err := h.noteRepo.GetLatestChangeSeq(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get sync token")
+		return
+	}
+
+	response.Success(c, models.SyncPushResponse{
+		Results: results,
+		Token:   strconv.FormatInt(latest, 10),
+	})
+}
+
+func (h *NoteHandler) applyMutation(userID int, m *models.SyncMutation) (models.SyncMutationResult, error) {
+	result := models.SyncMutationResult{ClientID: m.ClientID, Op: m.Op, ID: m.ID}
+	invalid := func(message string) (models.SyncMutationResult, error) {
+		result.Status = models.SyncStatusInvalid
+		result.Message = message
+		return result, nil
+	}
+
+	if m.Op != models.SyncOpCreate && (m.ID <= 0 || m.BaseVersion <= 0) {
+		return invalid("id and base_version are required")
+	}
+	if m.Op != models.SyncOpDelete && (strings.TrimSpace(m.Title) == "" || m.Content == "") {
+		return invalid("title and content are required")
+	}
+
+	switch m.Op {
+	case models.SyncOpCreate:
+		req := &models.CreateNoteRequest{
+			Title:    m.Title,
+			Content:  m.Content,
+			Category: m.Category,
+		}
+		var note *models.Note
+		created := true
+		var err error
+		if m.ClientID != "" {
+			note, created, err = h.noteRepo.CreateForClient(userID, m.ClientID, req)
+		} else {
+			note, err = h.noteRepo.Create(userID, req)
+		}
+		if err != nil {
+			return result, err
+		}
+		result.ID = note.ID
+		result.Note = note
+		result.Status = models.SyncStatusApplied
+		// A retried push gets the note created the first time
+		if created {
+			h.publishEvent(userID, models.WebhookEventNoteCreated, note)
+		}
+
+	case models.SyncOpUpdate:
+		note, rewritten, err := h.noteRepo.UpdateIfVersion(m.ID, userID, m.BaseVersion, &models.UpdateNoteRequest{
+			Title:    m.Title,
+			Content:  m.Content,
+			Category: m.Category,
+		})
+		if err == repository.ErrVersionConflict {
+			return h.conflict(userID, result)
+		}
+		if err != nil {
+			return result, err
+		}
+		if note == nil {
+			result.Status = models.SyncStatusNotFound
+			return result, nil
+		}
+		result.Note = note
+		result.Status = models.SyncStatusApplied
+		h.publishEvent(userID, models.WebhookEventNoteUpdated, note)
+		h.publishRewrites(userID, rewritten)
+
+	case models.SyncOpDelete:
+		err := h.noteRepo.DeleteIfVersion(m.ID, userID, m.BaseVersion)
+		if err == repository.ErrVersionConflict {
+			return h.conflict(userID, result)
+		}
+		if err != nil {
+			if err.Error() == "note not found or not owned by user" {
+				result.Status = models.SyncStatusNotFound
+				return result, nil
+			}
+			return result, err
+		}
+		result.Status = models.SyncStatusApplied
+		h.publishEvent(userID, models.WebhookEventNoteDeleted, gin.H{"id": m.ID, "user_id": userID})
+	}
+
+	return result, nil
+}
+
+func (h *NoteHandler) conflict(userID int, result models.SyncMutationResult) (models.SyncMutationResult, error) {
+	server, err := h.noteRepo.GetByID(result.ID, userID)
+	if err != nil {
+		return result, err
+	}
+
+	result.Status = models.SyncStatusConflict
+	result.ServerNote = server
+	return result, nil
+}
+
+func parseSyncToken(c *gin.Context, token string) (int64, bool) {
+	if token == "" {
+		return 0, true
+	}
+
+	since, err := strconv.ParseInt(token, 10, 64)
+	if err != nil || since < 0 {
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "since",
+			Message: "Sync token is not valid",
+			Value:   token,
+		})
+		return 0, false
+	}
+	return since, true
+}
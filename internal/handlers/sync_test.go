@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeNoteRepo struct {
+	repository.NoteRepository
+
+	mu        sync.Mutex
+	notes     []*models.Note
+	clientIDs map[string]int
+}
+
+func (r *fakeNoteRepo) Create(userID int, req *models.CreateNoteRequest) (*models.Note, error) {
+	note, _, err := r.CreateForClient(userID, "", req)
+	return note, err
+}
+
+func (r *fakeNoteRepo) CreateForClient(userID int, clientID string, req *models.CreateNoteRequest) (*models.Note, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id, ok := r.clientIDs[clientID]; ok && clientID != "" {
+		return r.notes[id-1], false, nil
+	}
+
+	note := &models.Note{ID: len(r.notes) + 1, UserID: userID, Title: req.Title, Content: req.Content, Version: 1}
+	r.notes = append(r.notes, note)
+	r.clientIDs[clientID] = note.ID
+	return note, true, nil
+}
+
+func (r *fakeNoteRepo) GetLatestChangeSeq(userID int) (int64, error) {
+	return int64(len(r.notes)), nil
+}
+
+type fakeWebhookRepo struct {
+	repository.WebhookRepository
+
+	events []string
+}
+
+func (r *fakeWebhookRepo) Enqueue(userID int, event, payload string) (int, error) {
+	r.events = append(r.events, event)
+	return 1, nil
+}
+
+func TestPushSyncChangesAppliesCreatesOnce(t *testing.T) {
+	notes := &fakeNoteRepo{clientIDs: make(map[string]int)}
+	webhooks := &fakeWebhookRepo{}
+	h := NewNoteHandler(notes, nil, nil, webhooks, nil, nil)
+
+	router := gin.New()
+	router.POST("/sync", func(c *gin.Context) { c.Set(middleware.UserIDKey, 1) }, h.PushSyncChanges)
+
+	push := func(mutations ...models.SyncMutation) models.SyncPushResponse {
+		t.Helper()
+		body, _ := json.Marshal(models.SyncPushRequest{Mutations: mutations})
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/sync", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("PushSyncChanges status = %d, body %s", w.Code, w.Body)
+		}
+		var resp models.SyncPushResponse
+		decodeData(t, w, &resp)
+		return resp
+	}
+
+	create := models.SyncMutation{ClientID: "draft-1", Op: models.SyncOpCreate, Title: "Offline", Content: "written on a plane"}
+	first := push(create)
+	// The response to the first push was lost, so the client retries
+	retried := push(create, models.SyncMutation{Op: models.SyncOpCreate, Title: "Untagged", Content: "no client id"})
+
+	if len(notes.notes) != 2 {
+		t.Fatalf("created %d notes, want 2", len(notes.notes))
+	}
+	if got, want := retried.Results[0].ID, first.Results[0].ID; got != want || retried.Results[0].Status != models.SyncStatusApplied {
+		t.Errorf("retried create = %+v, want applied note %d", retried.Results[0], want)
+	}
+	if len(webhooks.events) != 2 {
+		t.Errorf("webhook events = %v, want one per created note", webhooks.events)
+	}
+}
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/response"
+	"daily-notes-api/pkg/tasklist"
+
+	"github.com/gin-gonic/gin"
+)
+
+// taskWriteAttempts bounds retries when a note changes while a task edit is
+// being written back to it
+const taskWriteAttempts = 3
+
+const carriedOverHeading = "## Carried over"
+
+var errTaskGone = errors.New("task no longer exists in its note")
+
+type TaskHandler struct {
+	taskRepo repository.TaskRepository
+	noteRepo repository.NoteRepository
+	notes    *NoteHandler
+}
+
+func NewTaskHandler(taskRepo repository.TaskRepository, noteRepo repository.NoteRepository, notes *NoteHandler) *TaskHandler {
+	return &TaskHandler{
+		taskRepo: taskRepo,
+		noteRepo: noteRepo,
+		notes:    notes,
+	}
+}
+
+func (h *TaskHandler) GetTasks(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var filter models.TasksFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	switch filter.Status {
+	case "", models.TaskStatusOpen, models.TaskStatusDone, models.TaskStatusCarried:
+	default:
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "status",
+			Message: "Status must be one of open, done or carried",
+			Value:   filter.Status,
+		})
+		return
+	}
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+	if filter.Limit > 100 {
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "limit",
+			Message: "Limit cannot exceed 100 items per page",
+			Value:   strconv.Itoa(filter.Limit),
+		})
+		return
+	}
+
+	tasks, total, err := h.taskRepo.GetAll(userID, &filter)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get tasks")
+		return
+	}
+
+	loc := middleware.GetUserLocation(c)
+	for _, task := range tasks {
+		task.Localize(loc)
+	}
+
+	meta := response.CalculatePagination(filter.Page, filter.Limit, total)
+	response.SuccessWithMeta(c, tasks, meta)
+}
+
+// UpdateTask completes or reopens a task by rewriting its checkbox in the
+// source note
+func (h *TaskHandler) UpdateTask(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Task ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	task, err := h.taskRepo.GetByID(id, userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get task")
+		return
+	}
+	if task == nil {
+		response.NotFound(c, "Task not found")
+		return
+	}
+
+	completed := task.Status != models.TaskStatusDone
+	if req.Completed != nil {
+		completed = *req.Completed
+	}
+	status := tasklist.Open
+	if completed {
+		status = tasklist.Done
+	}
+
+	err = h.rewriteNote(userID, task.NoteID, func(content string) (string, error) {
+		updated, found := tasklist.SetStatus(content, task.Line, task.Text, status)
+		if !found {
+			return "", errTaskGone
+		}
+		return updated, nil
+	})
+	if err != nil {
+		if errors.Is(err, errTaskGone) {
+			response.Conflict(c, "The task was removed from its note")
+			return
+		}
+		response.InternalServerError(c, "Failed to update task")
+		return
+	}
+
+	task, err = h.taskRepo.GetByID(id, userID)
+	if err != nil || task == nil {
+		response.InternalServerError(c, "Failed to get task")
+		return
+	}
+
+	task.Localize(middleware.GetUserLocation(c))
+	response.Success(c, task)
+}
+
+// CarryOver copies open tasks from earlier daily notes into today's daily
+// note, creating it if needed, and marks the originals as carried
+func (h *TaskHandler) CarryOver(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req models.CarryOverTasksRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	loc := middleware.GetUserLocation(c)
+	if req.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(req.Timezone); err != nil {
+			response.ValidationErrorResponse(c, response.ValidationError{
+				Field:   "timezone",
+				Message: "Timezone must be a valid IANA name such as Asia/Jakarta",
+				Value:   req.Timezone,
+			})
+			return
+		}
+	}
+	today := time.Now().In(loc).Format("2006-01-02")
+
+	tasks, err := h.taskRepo.GetUnfinishedBefore(userID, today)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get unfinished tasks")
+		return
+	}
+
+	daily, err := h.noteRepo.GetByTitle(userID, models.DailyNoteCategory, today)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get today's note")
+		return
+	}
+
+	// Skip tasks today's note already has, and repeats across days
+	seen := make(map[string]bool)
+	if daily != nil {
+		for _, item := range tasklist.Parse(daily.Content) {
+			seen[item.Text] = true
+		}
+	}
+	var texts []string
+	for _, task := range tasks {
+		if !seen[task.Text] {
+			seen[task.Text] = true
+			texts = append(texts, task.Text)
+		}
+	}
+
+	if len(texts) > 0 {
+		if daily == nil {
+			daily, err = h.noteRepo.Create(userID, &models.CreateNoteRequest{
+				Title:    today,
+				Content:  tasklist.Append("", carriedOverHeading, texts),
+				Category: models.DailyNoteCategory,
+			})
+			if err != nil {
+				response.InternalServerError(c, "Failed to create today's note")
+				return
+			}
+			h.notes.invalidateCache(c, userID, 0)
+			h.notes.publishEvent(userID, models.WebhookEventNoteCreated, daily)
+		} else {
+			err = h.rewriteNote(userID, daily.ID, func(content string) (string, error) {
+				return tasklist.Append(content, carriedOverHeading, texts), nil
+			})
+			if err != nil {
+				response.InternalServerError(c, "Failed to update today's note")
+				return
+			}
+		}
+	}
+
+	// The tasks are safe in today's note; mark where they came from
+	byNote := make(map[int][]*models.Task)
+	for _, task := range tasks {
+		byNote[task.NoteID] = append(byNote[task.NoteID], task)
+	}
+	for noteID, noteTasks := range byNote {
+		err := h.rewriteNote(userID, noteID, func(content string) (string, error) {
+			for _, task := range noteTasks {
+				content, _ = tasklist.SetStatus(content, task.Line, task.Text, tasklist.Carried)
+			}
+			return content, nil
+		})
+		if err != nil {
+			log.Printf("Failed to mark tasks carried in note %d: %v", noteID, err)
+		}
+	}
+
+	if daily != nil {
+		if daily, err = h.noteRepo.GetByID(daily.ID, userID); err != nil {
+			response.InternalServerError(c, "Failed to get today's note")
+			return
+		}
+	}
+
+	localizeNotes(c, daily)
+	response.Success(c, models.CarryOverTasksResponse{Note: daily, Carried: len(texts)})
+}
+
+// rewriteNote applies edit to a note's content and saves it, retrying when
+// the note changes concurrently
+func (h *TaskHandler) rewriteNote(userID, noteID int, edit func(string) (string, error)) error {
+	for attempt := 0; attempt < taskWriteAttempts; attempt++ {
+		note, err := h.noteRepo.GetByID(noteID, userID)
+		if err != nil {
+			return err
+		}
+		if note == nil {
+			return errTaskGone
+		}
+
+		content, err := edit(note.Content)
+		if err != nil {
+			return err
+		}
+		if content == note.Content {
+			return nil
+		}
+
+		req := &models.UpdateNoteRequest{Title: note.Title, Content: content, Category: note.Category}
+		updated, rewritten, err := h.noteRepo.UpdateIfVersion(noteID, userID, note.Version, req)
+		if errors.Is(err, repository.ErrVersionConflict) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if updated == nil {
+			return errTaskGone
+		}
+
+		h.notes.invalidateCache(nil, userID, noteID)
+		h.notes.publishEvent(userID, models.WebhookEventNoteUpdated, updated)
+		h.notes.publishRewrites(userID, rewritten)
+		return nil
+	}
+	return repository.ErrVersionConflict
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"daily-notes-api/internal/middleware"
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/response"
+	"daily-notes-api/pkg/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookRepo  repository.WebhookRepository
+	allowPrivate bool
+}
+
+func NewWebhookHandler(webhookRepo repository.WebhookRepository, allowPrivateTargets bool) *WebhookHandler {
+	return &WebhookHandler{
+		webhookRepo:  webhookRepo,
+		allowPrivate: allowPrivateTargets,
+	}
+}
+
+// validURL rejects webhook URLs the sender would refuse to deliver to
+func (h *WebhookHandler) validURL(c *gin.Context, rawURL string) bool {
+	if err := webhook.ValidateURL(rawURL, h.allowPrivate); err != nil {
+		message := "URL must be an http or https URL"
+		if errors.Is(err, webhook.ErrPrivateTarget) {
+			message = "URL must point to a public address"
+		}
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "url",
+			Message: message,
+			Value:   rawURL,
+		})
+		return false
+	}
+	return true
+}
+
+// CreateWebhook registers a webhook. The signing secret is only returned here.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	if !h.validURL(c, req.URL) {
+		return
+	}
+
+	webhook, err := h.webhookRepo.Create(userID, &req)
+	if err != nil {
+		response.InternalServerError(c, "Failed to create webhook")
+		return
+	}
+
+	response.Created(c, webhook)
+}
+
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	webhooks, err := h.webhookRepo.GetAll(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get webhooks")
+		return
+	}
+
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+
+	response.Success(c, webhooks)
+}
+
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	webhook, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	webhook.Secret = ""
+	response.Success(c, webhook)
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Webhook ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	if !h.validURL(c, req.URL) {
+		return
+	}
+
+	webhook, err := h.webhookRepo.Update(id, userID, &req)
+	if err != nil {
+		response.InternalServerError(c, "Failed to update webhook")
+		return
+	}
+
+	if webhook == nil {
+		response.NotFound(c, "Webhook not found")
+		return
+	}
+
+	webhook.Secret = ""
+	response.Success(c, webhook)
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Webhook ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	if err := h.webhookRepo.Delete(id, userID); err != nil {
+		if err.Error() == "webhook not found or not owned by user" {
+			response.NotFound(c, "Webhook not found or you don't have permission to delete it")
+			return
+		}
+		response.InternalServerError(c, "Failed to delete webhook")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetDeliveries lists the delivery log of a webhook, newest first
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	webhook, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	var filter models.WebhookDeliveriesFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.ValidationErrors(c, err)
+		return
+	}
+
+	switch filter.Status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed:
+	default:
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "status",
+			Message: "Status must be one of pending, succeeded or failed",
+			Value:   filter.Status,
+		})
+		return
+	}
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 100 {
+		response.ValidationErrorResponse(c, response.ValidationError{
+			Field:   "limit",
+			Message: "Limit cannot exceed 100 items per page",
+			Value:   strconv.Itoa(filter.Limit),
+		})
+		return
+	}
+
+	deliveries, total, err := h.webhookRepo.GetDeliveries(webhook.ID, &filter)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get webhook deliveries")
+		return
+	}
+
+	meta := response.CalculatePagination(filter.Page, filter.Limit, total)
+	response.SuccessWithMeta(c, deliveries, meta)
+}
+
+// Redeliver queues a past delivery again with its original payload
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	webhook, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, ok := parseIDParam(c, "deliveryId", "Delivery ID must be a valid number")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookRepo.Redeliver(deliveryID, webhook.ID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to redeliver webhook")
+		return
+	}
+
+	if delivery == nil {
+		response.NotFound(c, "Delivery not found")
+		return
+	}
+
+	response.Created(c, delivery)
+}
+
+// loadWebhook resolves the :id param to a webhook owned by the current user
+func (h *WebhookHandler) loadWebhook(c *gin.Context) (*models.Webhook, bool) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return nil, false
+	}
+
+	id, ok := parseIDParam(c, "id", "Webhook ID must be a valid number")
+	if !ok {
+		return nil, false
+	}
+
+	webhook, err := h.webhookRepo.GetByID(id, userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get webhook")
+		return nil, false
+	}
+
+	if webhook == nil {
+		response.NotFound(c, "Webhook not found")
+		return nil, false
+	}
+
+	return webhook, true
+}
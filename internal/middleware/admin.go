@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminOnly allows the request through only for users with the admin role.
+// It must run after AuthMiddleware.
+func AdminOnly(userRepo repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := GetCurrentUserID(c)
+		if !exists {
+			response.Unauthorized(c, "User not authenticated")
+			c.Abort()
+			return
+		}
+
+		user, err := userRepo.GetByID(userID)
+		if err != nil {
+			response.InternalServerError(c, "Failed to check permissions")
+			c.Abort()
+			return
+		}
+
+		if user == nil || user.Role != models.RoleAdmin {
+			response.Forbidden(c, "Admin access required")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
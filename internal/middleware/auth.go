@@ -1,8 +1,12 @@
 package middleware
 
 import (
+	"log"
 	"strings"
+	"time"
 
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
 	"daily-notes-api/pkg/auth"
 	"daily-notes-api/pkg/response"
 
@@ -10,11 +14,28 @@ import (
 )
 
 const (
-	AuthUserKey = "auth_user"
-	UserIDKey   = "user_id"
+	AuthUserKey    = "auth_user"
+	UserIDKey      = "user_id"
+	SessionIDKey   = "session_id"
+	AccessTokenKey = "access_token"
+	TokenScopesKey = "token_scopes"
+
+	CredentialCheckKey = "credential_check"
 )
 
-func AuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
+// CredentialCheck reports whether the session, OAuth grant or personal access
+// token a request authenticated with is still valid. Long-lived connections
+// call it periodically to notice revocation.
+type CredentialCheck func() (bool, error)
+
+// touchInterval limits how often last-seen is written per session or token
+const touchInterval = time.Minute
+
+// AuthMiddleware accepts a JWT whose session is still active, an OAuth
+// access token whose grant is still active, or a personal access token.
+// Routes must either declare the scope a token needs with RequireScope or be
+// limited to sessions with SessionOnly.
+func AuthMiddleware(jwtManager *auth.JWTManager, sessionRepo repository.SessionRepository, tokenRepo repository.AccessTokenRepository, oauthRepo repository.OAuthRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -32,6 +53,11 @@ func AuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
 		}
 
 		token := parts[1]
+		if strings.HasPrefix(token, models.AccessTokenPrefix) {
+			authenticateAccessToken(c, tokenRepo, token)
+			return
+		}
+
 		claims, err := jwtManager.ValidateToken(token)
 		if err != nil {
 			response.Unauthorized(c, "Invalid or expired token")
@@ -39,13 +65,170 @@ func AuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
 			return
 		}
 
-		// Store user info in context
-		c.Set(AuthUserKey, claims)
-		c.Set(UserIDKey, claims.UserID)
+		if claims.GrantID != 0 {
+			authenticateGrant(c, oauthRepo, claims)
+			return
+		}
+		authenticateSession(c, sessionRepo, claims)
+	}
+}
+
+func authenticateSession(c *gin.Context, sessionRepo repository.SessionRepository, claims *auth.JWTClaims) {
+	// Tokens issued before sessions existed cannot be revoked, so they
+	// are no longer accepted
+	if claims.SessionID == 0 {
+		response.Unauthorized(c, "Session has ended, please log in again")
+		c.Abort()
+		return
+	}
+
+	session, err := sessionRepo.GetActive(claims.SessionID, claims.UserID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to check session")
+		c.Abort()
+		return
+	}
+	if session == nil {
+		response.Unauthorized(c, "Session has ended, please log in again")
+		c.Abort()
+		return
+	}
+
+	if now := time.Now(); now.Sub(session.LastSeenAt) > touchInterval {
+		if err := sessionRepo.Touch(session.ID, c.ClientIP(), now); err != nil {
+			log.Printf("Failed to update session %d: %v", session.ID, err)
+		}
+	}
+
+	// Store user info in context
+	c.Set(AuthUserKey, claims)
+	c.Set(UserIDKey, claims.UserID)
+	c.Set(SessionIDKey, claims.SessionID)
+	c.Set(CredentialCheckKey, CredentialCheck(func() (bool, error) {
+		session, err := sessionRepo.GetActive(claims.SessionID, claims.UserID)
+		return session != nil, err
+	}))
+	c.Next()
+}
+
+func authenticateAccessToken(c *gin.Context, tokenRepo repository.AccessTokenRepository, token string) {
+	accessToken, err := tokenRepo.GetByToken(token)
+	if err != nil {
+		response.InternalServerError(c, "Failed to check access token")
+		c.Abort()
+		return
+	}
+
+	now := time.Now()
+	if accessToken == nil || accessToken.Expired(now) {
+		response.Unauthorized(c, "Invalid or expired token")
+		c.Abort()
+		return
+	}
+
+	if accessToken.LastUsedAt == nil || now.Sub(*accessToken.LastUsedAt) > touchInterval {
+		if err := tokenRepo.Touch(accessToken.ID, now); err != nil {
+			log.Printf("Failed to update access token %d: %v", accessToken.ID, err)
+		}
+	}
+
+	c.Set(UserIDKey, accessToken.UserID)
+	c.Set(AccessTokenKey, accessToken)
+	c.Set(TokenScopesKey, accessToken.Scopes)
+	c.Set(CredentialCheckKey, CredentialCheck(func() (bool, error) {
+		accessToken, err := tokenRepo.GetByToken(token)
+		return accessToken != nil && !accessToken.Expired(time.Now()), err
+	}))
+	c.Next()
+}
+
+func authenticateGrant(c *gin.Context, oauthRepo repository.OAuthRepository, claims *auth.JWTClaims) {
+	grant, err := oauthRepo.GetActiveGrant(claims.GrantID, claims.UserID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to check access token")
+		c.Abort()
+		return
+	}
+	if grant == nil {
+		response.Unauthorized(c, "Access has been revoked")
+		c.Abort()
+		return
+	}
+
+	if now := time.Now(); grant.LastUsedAt == nil || now.Sub(*grant.LastUsedAt) > touchInterval {
+		if err := oauthRepo.TouchGrant(grant.ID, now); err != nil {
+			log.Printf("Failed to update grant %d: %v", grant.ID, err)
+		}
+	}
+
+	c.Set(AuthUserKey, claims)
+	c.Set(UserIDKey, claims.UserID)
+	c.Set(TokenScopesKey, claims.Scopes())
+	c.Set(CredentialCheckKey, CredentialCheck(func() (bool, error) {
+		grant, err := oauthRepo.GetActiveGrant(claims.GrantID, claims.UserID)
+		return grant != nil, err
+	}))
+	c.Next()
+}
+
+// RequireScope lets personal access tokens and OAuth access tokens through
+// only when they grant scope. Sessions have every scope. It must run after
+// AuthMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if scopes, ok := GetTokenScopes(c); ok && !models.ScopesInclude(scopes, scope) {
+			response.Forbidden(c, "Access token lacks the "+scope+" scope")
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
 
+// RequireReadWriteScope requires read for safe methods and write otherwise
+func RequireReadWriteScope(read, write string) gin.HandlerFunc {
+	readScope, writeScope := RequireScope(read), RequireScope(write)
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case "GET", "HEAD":
+			readScope(c)
+		default:
+			writeScope(c)
+		}
+	}
+}
+
+// SessionOnly rejects personal access tokens and OAuth access tokens, for
+// routes such as account and token management that have no scope. It must
+// run after AuthMiddleware.
+func SessionOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetTokenScopes(c); ok {
+			response.Forbidden(c, "This endpoint cannot be used with an access token")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// WebSocketAuth authenticates like AuthMiddleware but also accepts the token
+// in the access_token query parameter, since browsers cannot set headers on
+// a WebSocket handshake
+func WebSocketAuth(jwtManager *auth.JWTManager, sessionRepo repository.SessionRepository, tokenRepo repository.AccessTokenRepository, oauthRepo repository.OAuthRepository) gin.HandlerFunc {
+	authenticate := AuthMiddleware(jwtManager, sessionRepo, tokenRepo, oauthRepo)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		authenticate(c)
+	}
+}
+
 // GetCurrentUser helper function to get current user from context
 func GetCurrentUser(c *gin.Context) (*auth.JWTClaims, bool) {
 	user, exists := c.Get(AuthUserKey)
@@ -67,3 +250,51 @@ func GetCurrentUserID(c *gin.Context) (int, bool) {
 	id, ok := userID.(int)
 	return id, ok
 }
+
+// GetCurrentSessionID returns the session of the current request
+func GetCurrentSessionID(c *gin.Context) (int, bool) {
+	sessionID, exists := c.Get(SessionIDKey)
+	if !exists {
+		return 0, false
+	}
+
+	id, ok := sessionID.(int)
+	return id, ok
+}
+
+// GetAccessToken returns the personal access token the request was
+// authenticated with, if any
+func GetAccessToken(c *gin.Context) (*models.PersonalAccessToken, bool) {
+	token, exists := c.Get(AccessTokenKey)
+	if !exists {
+		return nil, false
+	}
+
+	accessToken, ok := token.(*models.PersonalAccessToken)
+	return accessToken, ok
+}
+
+// GetTokenScopes returns the scopes of the personal access token or OAuth
+// access token the request was authenticated with. It returns false for
+// sessions, which are not limited by scope.
+func GetTokenScopes(c *gin.Context) ([]string, bool) {
+	scopes, exists := c.Get(TokenScopesKey)
+	if !exists {
+		return nil, false
+	}
+
+	list, ok := scopes.([]string)
+	return list, ok
+}
+
+// GetCredentialCheck returns the check for the credential the request was
+// authenticated with
+func GetCredentialCheck(c *gin.Context) (CredentialCheck, bool) {
+	check, exists := c.Get(CredentialCheckKey)
+	if !exists {
+		return nil, false
+	}
+
+	fn, ok := check.(CredentialCheck)
+	return fn, ok
+}
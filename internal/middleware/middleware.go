@@ -16,12 +16,16 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// sensitiveQueryParams carry credentials in URLs, such as WebSocket access
+// tokens, signed download links and OAuth callbacks
+var sensitiveQueryParams = []string{"access_token", "token", "code", "state", "signature"}
+
 func Logger() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		return fmt.Sprintf("[%s] %s %s %d %s %s\n",
 			param.TimeStamp.Format("2006-01-02 15:04:05"),
 			param.Method,
-			param.Path,
+			redactQuery(param.Path),
 			param.StatusCode,
 			param.Latency,
 			param.ClientIP,
@@ -29,10 +33,30 @@ func Logger() gin.HandlerFunc {
 	})
 }
 
+// redactQuery hides the values of sensitive query parameters in path
+func redactQuery(path string) string {
+	base, rawQuery, found := strings.Cut(path, "?")
+	if !found {
+		return path
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		// Do not guess which part of a malformed query is safe to log
+		return base + "?REDACTED"
+	}
+	for _, name := range sensitiveQueryParams {
+		if _, ok := query[name]; ok {
+			query.Set(name, "REDACTED")
+		}
+	}
+	return base + "?" + query.Encode()
+}
+
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization")
 
 		if c.Request.Method == "OPTIONS" {
@@ -0,0 +1,23 @@
+package middleware
+
+import "testing"
+
+func TestRedactQuery(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/v1/notes", "/api/v1/notes"},
+		{"/api/v1/notes?page=2", "/api/v1/notes?page=2"},
+		{"/api/v1/notes/1/collab?access_token=eyJhbGci", "/api/v1/notes/1/collab?access_token=REDACTED"},
+		{"/api/v1/auth/oidc/google/callback?code=abc&state=xyz", "/api/v1/auth/oidc/google/callback?code=REDACTED&state=REDACTED"},
+		{"/api/v1/exports/1/download?expires=1700000000&signature=deadbeef", "/api/v1/exports/1/download?expires=1700000000&signature=REDACTED"},
+		{"/api/v1/digest/unsubscribe?token=secret&token=again", "/api/v1/digest/unsubscribe?token=REDACTED"},
+		{"/api/v1/notes?token=%zz", "/api/v1/notes?REDACTED"},
+	}
+	for _, tt := range tests {
+		if got := redactQuery(tt.path); got != tt.want {
+			t.Errorf("redactQuery(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"daily-notes-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimiter counts requests per client IP in fixed windows
+type rateLimiter struct {
+	limit   int
+	window  time.Duration
+	cleanup time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*rateWindow
+	lastSweep time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimit allows each client IP limit requests per window and answers
+// 429 after that. Counters are kept in memory, so each instance limits on
+// its own; expired ones are dropped every cleanup interval.
+func RateLimit(limit int, window, cleanup time.Duration) gin.HandlerFunc {
+	l := &rateLimiter{
+		limit:   limit,
+		window:  window,
+		cleanup: cleanup,
+		now:     time.Now,
+		clients: make(map[string]*rateWindow),
+	}
+	return l.handle
+}
+
+func (l *rateLimiter) handle(c *gin.Context) {
+	if l.limit <= 0 {
+		c.Next()
+		return
+	}
+
+	retryAfter, ok := l.allow(c.ClientIP())
+	if !ok {
+		c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds()+0.5)))
+		response.TooManyRequests(c, "Too many requests, please try again later")
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// allow counts a request from ip. When the limit is reached it returns how
+// long until the window resets.
+func (l *rateLimiter) allow(ip string) (time.Duration, bool) {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= l.cleanup {
+		for key, w := range l.clients {
+			if now.Sub(w.start) >= l.window {
+				delete(l.clients, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	w := l.clients[ip]
+	if w == nil || now.Sub(w.start) >= l.window {
+		w = &rateWindow{start: now}
+		l.clients[ip] = w
+	}
+	if w.count >= l.limit {
+		return w.start.Add(l.window).Sub(now), false
+	}
+	w.count++
+	return 0, true
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimit(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		ip     string
+		offset time.Duration
+		want   int
+	}{
+		{"first", "192.0.2.1", 0, http.StatusOK},
+		{"second", "192.0.2.1", time.Minute, http.StatusOK},
+		{"over the limit", "192.0.2.1", 2 * time.Minute, http.StatusTooManyRequests},
+		{"other client", "192.0.2.2", 2 * time.Minute, http.StatusOK},
+		{"still limited", "192.0.2.1", 14 * time.Minute, http.StatusTooManyRequests},
+		{"next window", "192.0.2.1", 15 * time.Minute, http.StatusOK},
+	}
+
+	l := &rateLimiter{
+		limit:   2,
+		window:  15 * time.Minute,
+		cleanup: 30 * time.Minute,
+		clients: make(map[string]*rateWindow),
+	}
+	router := gin.New()
+	router.POST("/login", l.handle, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l.now = func() time.Time { return start.Add(tt.offset) }
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/login", nil)
+			req.RemoteAddr = tt.ip + ":1234"
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+				t.Error("Retry-After header missing")
+			}
+		})
+	}
+}
+
+func TestRateLimitSweepsExpiredClients(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	l := &rateLimiter{
+		limit:   1,
+		window:  time.Minute,
+		cleanup: 10 * time.Minute,
+		now:     func() time.Time { return now },
+		clients: make(map[string]*rateWindow),
+	}
+
+	l.allow("192.0.2.1")
+	now = now.Add(10 * time.Minute)
+	l.allow("192.0.2.2")
+
+	if _, ok := l.clients["192.0.2.1"]; ok {
+		t.Error("expired client was not swept")
+	}
+	if len(l.clients) != 1 {
+		t.Errorf("clients = %d, want 1", len(l.clients))
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"log"
+	"time"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const UserSettingsKey = "user_settings"
+
+// UserSettings loads the user's settings into the context so handlers can
+// render dates in their timezone. It must run after AuthMiddleware. A failed
+// lookup falls back to the defaults rather than failing the request.
+func UserSettings(settingsRepo repository.UserSettingsRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := GetCurrentUserID(c)
+		if !exists {
+			c.Next()
+			return
+		}
+
+		settings, err := settingsRepo.Get(userID)
+		if err != nil {
+			log.Printf("Failed to load settings for user %d: %v", userID, err)
+		}
+		if settings == nil {
+			settings = models.DefaultUserSettings(userID)
+		}
+
+		c.Set(UserSettingsKey, settings)
+		c.Next()
+	}
+}
+
+// GetUserSettings returns the settings loaded by UserSettings, or the
+// defaults when the middleware did not run
+func GetUserSettings(c *gin.Context) *models.UserSettings {
+	if value, exists := c.Get(UserSettingsKey); exists {
+		if settings, ok := value.(*models.UserSettings); ok {
+			return settings
+		}
+	}
+
+	userID, _ := GetCurrentUserID(c)
+	return models.DefaultUserSettings(userID)
+}
+
+// GetUserLocation returns the user's timezone
+func GetUserLocation(c *gin.Context) *time.Location {
+	return GetUserSettings(c).Location()
+}
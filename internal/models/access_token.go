@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ScopeNotesRead  = "notes:read"
+	ScopeNotesWrite = "notes:write"
+)
+
+// AccessTokenScopes lists every scope a personal access token can be granted
+var AccessTokenScopes = []string{ScopeNotesRead, ScopeNotesWrite}
+
+// AccessTokenPrefix marks personal access tokens apart from JWTs in the
+// Authorization header
+const AccessTokenPrefix = "pat_"
+
+// PersonalAccessToken is a long-lived, scoped credential for scripts and
+// integrations. Only the SHA-256 hash of the token is stored.
+type PersonalAccessToken struct {
+	ID          int        `json:"id" db:"id"`
+	UserID      int        `json:"user_id" db:"user_id"`
+	Name        string     `json:"name" db:"name"`
+	TokenPrefix string     `json:"token_prefix" db:"token_prefix"`
+	Scopes      []string   `json:"scopes" db:"scopes"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	Token       string     `json:"token,omitempty"` // only returned on create
+}
+
+// HasScope reports whether the token grants scope
+func (t *PersonalAccessToken) HasScope(scope string) bool {
+	return ScopesInclude(t.Scopes, scope)
+}
+
+// ScopesInclude reports whether granted covers scope. Write access to notes
+// includes reading them.
+func ScopesInclude(granted []string, scope string) bool {
+	for _, s := range granted {
+		if s == scope || (scope == ScopeNotesRead && s == ScopeNotesWrite) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidScope reports whether scope is one tokens can be granted
+func IsValidScope(scope string) bool {
+	for _, s := range AccessTokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired reports whether the token has passed its expiry
+func (t *PersonalAccessToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// Localize converts the timestamps to loc for rendering
+func (t *PersonalAccessToken) Localize(loc *time.Location) {
+	t.CreatedAt = t.CreatedAt.In(loc)
+	t.UpdatedAt = t.UpdatedAt.In(loc)
+	if t.ExpiresAt != nil {
+		expiresAt := t.ExpiresAt.In(loc)
+		t.ExpiresAt = &expiresAt
+	}
+	if t.LastUsedAt != nil {
+		lastUsedAt := t.LastUsedAt.In(loc)
+		t.LastUsedAt = &lastUsedAt
+	}
+}
+
+type CreateAccessTokenRequest struct {
+	Name   string   `json:"name" binding:"required,min=1,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=notes:read notes:write"`
+	// ExpiresInDays leaves the token valid forever when omitted
+	ExpiresInDays *int `json:"expires_in_days" binding:"omitempty,min=1,max=3650"`
+}
+
+type UpdateAccessTokenRequest struct {
+	Name   string   `json:"name" binding:"required,min=1,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=notes:read notes:write"`
+}
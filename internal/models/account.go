@@ -0,0 +1,68 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"daily-notes-api/pkg/signedurl"
+)
+
+const (
+	ExportStatusPending = "pending"
+	ExportStatusReady   = "ready"
+	ExportStatusFailed  = "failed"
+	ExportStatusExpired = "expired"
+)
+
+// ExportDownloadPath is the signed, login-free download route of an export
+const ExportDownloadPath = "/api/v1/exports/%d/download"
+
+// AccountExport is a GDPR data export. The archive is built in the
+// background and downloaded through a signed link until ExpiresAt.
+type AccountExport struct {
+	ID          int        `json:"id" db:"id"`
+	UserID      int        `json:"user_id" db:"user_id"`
+	Status      string     `json:"status" db:"status"`
+	FilePath    string     `json:"-" db:"file_path"`
+	SizeBytes   int64      `json:"size_bytes" db:"size_bytes"`
+	LastError   string     `json:"last_error,omitempty" db:"last_error"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	DownloadURL string     `json:"download_url,omitempty"`
+}
+
+// Localize converts the timestamps to loc for rendering
+func (e *AccountExport) Localize(loc *time.Location) {
+	e.CreatedAt = e.CreatedAt.In(loc)
+	e.UpdatedAt = e.UpdatedAt.In(loc)
+	if e.ExpiresAt != nil {
+		expiresAt := e.ExpiresAt.In(loc)
+		e.ExpiresAt = &expiresAt
+	}
+	if e.CompletedAt != nil {
+		completedAt := e.CompletedAt.In(loc)
+		e.CompletedAt = &completedAt
+	}
+}
+
+// SignedDownloadURL returns the download link of a ready export, valid
+// until the export expires
+func (e *AccountExport) SignedDownloadURL(secret, apiURL string) string {
+	if e.Status != ExportStatusReady || e.ExpiresAt == nil {
+		return ""
+	}
+	path := fmt.Sprintf(ExportDownloadPath, e.ID)
+	return signedurl.URL(secret, strings.TrimRight(apiURL, "/"), path, *e.ExpiresAt)
+}
+
+// DeleteAccountRequest re-checks the password before scheduling deletion
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type AccountDeletionResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	DigestFrequencyNone   = "none"
+	DigestFrequencyDaily  = "daily"
+	DigestFrequencyWeekly = "weekly"
+)
+
+type DigestSubscription struct {
+	UserID    int    `json:"user_id" db:"user_id"`
+	Frequency string `json:"frequency" db:"frequency"`
+	// Timezone is the account timezone from user_settings, reported so
+	// clients can show when the digest goes out
+	Timezone         string     `json:"timezone" db:"timezone"`
+	SendHour         int        `json:"send_hour" db:"send_hour"`
+	UnsubscribeToken string     `json:"-" db:"unsubscribe_token"`
+	LastSentAt       *time.Time `json:"last_sent_at,omitempty" db:"last_sent_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type UpdateDigestRequest struct {
+	Frequency string `json:"frequency" binding:"required,oneof=none daily weekly"`
+	SendHour  *int   `json:"send_hour" binding:"omitempty,min=0,max=23"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+// UserIdentity links a user to an account at an external OpenID Connect
+// provider, identified by the provider's subject claim
+type UserIdentity struct {
+	ID          int        `json:"id" db:"id"`
+	UserID      int        `json:"user_id" db:"user_id"`
+	Provider    string     `json:"provider" db:"provider"`
+	Subject     string     `json:"subject" db:"subject"`
+	Email       string     `json:"email" db:"email"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty" db:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// OIDCLoginState is kept between redirecting to a provider and its callback
+type OIDCLoginState struct {
+	State        string    `db:"state"`
+	Provider     string    `db:"provider"`
+	Nonce        string    `db:"nonce"`
+	CodeVerifier string    `db:"code_verifier"`
+	Device       string    `db:"device"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+// OIDCPendingLink is a provider login for an email that belongs to an
+// existing account. It is linked once the user confirms the account's
+// password.
+type OIDCPendingLink struct {
+	UserID        int       `db:"user_id"`
+	Provider      string    `db:"provider"`
+	Subject       string    `db:"subject"`
+	Email         string    `db:"email"`
+	EmailVerified bool      `db:"email_verified"`
+	Device        string    `db:"device"`
+	ExpiresAt     time.Time `db:"expires_at"`
+}
+
+type ConfirmOIDCLinkRequest struct {
+	LinkToken string `json:"link_token" binding:"required"`
+	Password  string `json:"password" binding:"required"`
+}
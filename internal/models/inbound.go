@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// InboundAddress is a user's secret email-to-note address
+type InboundAddress struct {
+	UserID    int       `json:"user_id" db:"user_id"`
+	Token     string    `json:"-" db:"token"`
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
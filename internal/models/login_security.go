@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	// LoginDelayAfter is the number of login attempts allowed before each
+	// further attempt has to wait
+	LoginDelayAfter = 3
+	MaxLoginDelay   = time.Minute
+)
+
+// LoginFailures counts the login attempts for a username since the last
+// successful one. Attempts are counted before the password is checked.
+type LoginFailures struct {
+	Username      string     `json:"username" db:"username"`
+	Attempts      int        `json:"attempts" db:"attempts"`
+	LastAttemptAt time.Time  `json:"last_attempt_at" db:"last_attempt_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty" db:"locked_until"`
+}
+
+// Locked reports whether the username is locked at now
+func (f *LoginFailures) Locked(now time.Time) bool {
+	return f.LockedUntil != nil && f.LockedUntil.After(now)
+}
+
+// Attempt counts a login attempt at now, unless it has to wait, in which
+// case it returns how long. The count starts over after a lockout expires.
+func (f *LoginFailures) Attempt(now time.Time) time.Duration {
+	if f.Locked(now) {
+		return f.LockedUntil.Sub(now)
+	}
+	if f.LockedUntil != nil {
+		f.Attempts = 0
+		f.LockedUntil = nil
+	}
+
+	if wait := f.LastAttemptAt.Add(LoginDelay(f.Attempts)).Sub(now); wait > 0 {
+		return wait
+	}
+	f.Attempts++
+	f.LastAttemptAt = now
+	return 0
+}
+
+// LoginDelay is how long the next attempt has to wait after attempts
+// unsuccessful ones: one second after the third, doubling up to a minute
+func LoginDelay(attempts int) time.Duration {
+	if attempts < LoginDelayAfter {
+		return 0
+	}
+	delay := time.Second << min(attempts-LoginDelayAfter, 6)
+	return min(delay, MaxLoginDelay)
+}
+
+// LoginHistory tells whether a login comes from a device and IP range the
+// user has logged in from before
+type LoginHistory struct {
+	HasLogins    bool
+	KnownDevice  bool
+	KnownIPRange bool
+}
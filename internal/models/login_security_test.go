@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{8, 32 * time.Second},
+		{9, time.Minute},
+		{50, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := LoginDelay(tt.attempts); got != tt.want {
+			t.Errorf("LoginDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestLoginFailuresAttempt(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	locked := now.Add(time.Minute)
+	expired := now.Add(-time.Minute)
+
+	tests := []struct {
+		name         string
+		failures     LoginFailures
+		wantWait     time.Duration
+		wantAttempts int
+	}{
+		{"first attempt", LoginFailures{}, 0, 1},
+		{"within free attempts", LoginFailures{Attempts: 2, LastAttemptAt: now}, 0, 3},
+		{"delayed", LoginFailures{Attempts: 3, LastAttemptAt: now}, time.Second, 3},
+		{"delay passed", LoginFailures{Attempts: 3, LastAttemptAt: now.Add(-time.Second)}, 0, 4},
+		{"locked", LoginFailures{Attempts: 10, LastAttemptAt: now, LockedUntil: &locked}, time.Minute, 10},
+		{"lockout expired", LoginFailures{Attempts: 10, LastAttemptAt: now, LockedUntil: &expired}, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.failures
+			if wait := f.Attempt(now); wait != tt.wantWait {
+				t.Errorf("Attempt() = %s, want %s", wait, tt.wantWait)
+			}
+			if f.Attempts != tt.wantAttempts {
+				t.Errorf("Attempts = %d, want %d", f.Attempts, tt.wantAttempts)
+			}
+			if tt.wantWait == 0 && (!f.LastAttemptAt.Equal(now) || f.LockedUntil != nil) {
+				t.Errorf("counted attempt left %+v", f)
+			}
+		})
+	}
+}
@@ -10,16 +10,31 @@ type Note struct {
 	Title     string    `json:"title" db:"title" binding:"required,min=1,max=255"`
 	Content   string    `json:"content" db:"content" binding:"required,min=1"`
 	Category  string    `json:"category" db:"category" binding:"max=100"`
+	Version   int       `json:"version" db:"version"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 	// Optional: Include user info
 	User *UserResponse `json:"user,omitempty"`
 }
 
+// Localize converts the timestamps to loc for rendering
+func (n *Note) Localize(loc *time.Location) {
+	n.CreatedAt = n.CreatedAt.In(loc)
+	n.UpdatedAt = n.UpdatedAt.In(loc)
+	if n.User != nil {
+		n.User.Localize(loc)
+	}
+}
+
 type CreateNoteRequest struct {
-	Title    string `json:"title" binding:"required,min=1,max=255"`
-	Content  string `json:"content" binding:"required,min=1"`
+	Title    string `json:"title" binding:"required_without_all=TemplateID TemplateKey,max=255"`
+	Content  string `json:"content" binding:"required_without_all=TemplateID TemplateKey"`
 	Category string `json:"category" binding:"max=100"`
+	// Optional: render title and content from a user template (TemplateID)
+	// or a built-in one (TemplateKey). Explicit Title/Content/Category win.
+	TemplateID  int               `json:"template_id,omitempty"`
+	TemplateKey string            `json:"template_key,omitempty"`
+	Variables   map[string]string `json:"variables,omitempty"`
 }
 
 type UpdateNoteRequest struct {
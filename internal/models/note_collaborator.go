@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// NoteCollaborator is a user the owner invited to edit a note in its
+// collaborative editing session
+type NoteCollaborator struct {
+	NoteID    int       `json:"note_id" db:"note_id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	Username  string    `json:"username" db:"username"`
+	FullName  string    `json:"full_name" db:"full_name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Localize converts the timestamps to loc for rendering
+func (c *NoteCollaborator) Localize(loc *time.Location) {
+	c.CreatedAt = c.CreatedAt.In(loc)
+}
+
+// SharedNote is a note another user shared with the current user
+type SharedNote struct {
+	ID            int       `json:"id" db:"id"`
+	Title         string    `json:"title" db:"title"`
+	OwnerID       int       `json:"owner_id" db:"owner_id"`
+	OwnerUsername string    `json:"owner_username" db:"owner_username"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Localize converts the timestamps to loc for rendering
+func (n *SharedNote) Localize(loc *time.Location) {
+	n.UpdatedAt = n.UpdatedAt.In(loc)
+}
+
+type AddCollaboratorRequest struct {
+	Username string `json:"username" binding:"required,max=50"`
+}
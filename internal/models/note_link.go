@@ -0,0 +1,27 @@
+package models
+
+// NoteLink is a [[wiki link]] from one note to another. TargetNoteID is nil
+// while no note matches the link text.
+type NoteLink struct {
+	SourceNoteID int    `json:"source_note_id"`
+	SourceTitle  string `json:"source_title"`
+	TargetNoteID *int   `json:"target_note_id"`
+	TargetTitle  string `json:"target_title"`
+}
+
+type NoteGraphNode struct {
+	ID       int    `json:"id"`
+	Title    string `json:"title"`
+	Category string `json:"category"`
+}
+
+type NoteGraphEdge struct {
+	Source int `json:"source"`
+	Target int `json:"target"`
+}
+
+// NoteGraph is the user's notes and the resolved links between them
+type NoteGraph struct {
+	Nodes []NoteGraphNode `json:"nodes"`
+	Edges []NoteGraphEdge `json:"edges"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// NoteRevision is a snapshot of a note saved by a collaborative editing
+// session. Revision is the session's operation count at the time.
+type NoteRevision struct {
+	ID        int       `json:"id" db:"id"`
+	NoteID    int       `json:"note_id" db:"note_id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	Version   int       `json:"version" db:"version"`
+	Revision  int       `json:"revision" db:"revision"`
+	Content   string    `json:"content" db:"content"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Localize converts the timestamps to loc for rendering
+func (r *NoteRevision) Localize(loc *time.Location) {
+	r.CreatedAt = r.CreatedAt.In(loc)
+}
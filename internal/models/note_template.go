@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+type NoteTemplate struct {
+	ID            int       `json:"id" db:"id"`
+	UserID        int       `json:"user_id" db:"user_id"`
+	Name          string    `json:"name" db:"name"`
+	Description   string    `json:"description" db:"description"`
+	TitleTemplate string    `json:"title_template" db:"title_template"`
+	BodyTemplate  string    `json:"body_template" db:"body_template"`
+	Category      string    `json:"category" db:"category"`
+	Prompts       []string  `json:"prompts" db:"prompts"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Localize converts the timestamps to loc for rendering
+func (t *NoteTemplate) Localize(loc *time.Location) {
+	t.CreatedAt = t.CreatedAt.In(loc)
+	t.UpdatedAt = t.UpdatedAt.In(loc)
+}
+
+type CreateNoteTemplateRequest struct {
+	Name          string   `json:"name" binding:"required,min=1,max=100"`
+	Description   string   `json:"description" binding:"max=255"`
+	TitleTemplate string   `json:"title_template" binding:"required,min=1,max=255"`
+	BodyTemplate  string   `json:"body_template" binding:"required,min=1"`
+	Category      string   `json:"category" binding:"max=100"`
+	Prompts       []string `json:"prompts" binding:"max=20,dive,max=255"`
+}
+
+type UpdateNoteTemplateRequest struct {
+	Name          string   `json:"name" binding:"required,min=1,max=100"`
+	Description   string   `json:"description" binding:"max=255"`
+	TitleTemplate string   `json:"title_template" binding:"required,min=1,max=255"`
+	BodyTemplate  string   `json:"body_template" binding:"required,min=1"`
+	Category      string   `json:"category" binding:"max=100"`
+	Prompts       []string `json:"prompts" binding:"max=20,dive,max=255"`
+}
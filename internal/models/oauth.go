@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+)
+
+// OAuthClient is a third-party application registered by a user. Public
+// clients (browser extensions, CLIs) have no secret and rely on PKCE alone.
+type OAuthClient struct {
+	ID           int       `json:"id" db:"id"`
+	UserID       int       `json:"user_id" db:"user_id"`
+	ClientID     string    `json:"client_id" db:"client_id"`
+	Name         string    `json:"name" db:"name"`
+	RedirectURIs []string  `json:"redirect_uris" db:"redirect_uris"`
+	Confidential bool      `json:"confidential" db:"confidential"`
+	SecretHash   string    `json:"-" db:"secret_hash"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	ClientSecret string    `json:"client_secret,omitempty"` // only returned on create
+}
+
+// AllowsRedirect reports whether uri exactly matches a registered redirect URI
+func (c *OAuthClient) AllowsRedirect(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// Localize converts the timestamps to loc for rendering
+func (c *OAuthClient) Localize(loc *time.Location) {
+	c.CreatedAt = c.CreatedAt.In(loc)
+	c.UpdatedAt = c.UpdatedAt.In(loc)
+}
+
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" binding:"required,min=1,max=100"`
+	RedirectURIs []string `json:"redirect_uris" binding:"required,min=1,max=10,dive,required,max=500"`
+	Confidential bool     `json:"confidential"`
+}
+
+// OAuthGrant is a user's consent for a client to access their notes. Every
+// access and refresh token belongs to one, so revoking it ends them all.
+type OAuthGrant struct {
+	ID         int        `json:"id" db:"id"`
+	ClientID   int        `json:"-" db:"client_id"`
+	ClientName string     `json:"client_name" db:"client_name"`
+	UserID     int        `json:"user_id" db:"user_id"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"-" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Localize converts the timestamps to loc for rendering
+func (g *OAuthGrant) Localize(loc *time.Location) {
+	g.CreatedAt = g.CreatedAt.In(loc)
+	if g.LastUsedAt != nil {
+		lastUsedAt := g.LastUsedAt.In(loc)
+		g.LastUsedAt = &lastUsedAt
+	}
+}
+
+// OAuthAuthorizationCode is issued after consent and exchanged once for
+// tokens by the client
+type OAuthAuthorizationCode struct {
+	ClientID      int       `db:"client_id"`
+	UserID        int       `db:"user_id"`
+	RedirectURI   string    `db:"redirect_uri"`
+	Scopes        []string  `db:"scopes"`
+	CodeChallenge string    `db:"code_challenge"`
+	ExpiresAt     time.Time `db:"expires_at"`
+}
+
+// OAuthTokenResponse is the RFC 6749 token endpoint response
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"daily-notes-api/pkg/email"
+)
+
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusDead    = "dead"
+)
+
+// OutboxMessage is a rendered email waiting to be delivered by the outbox worker
+type OutboxMessage struct {
+	ID            int        `json:"id" db:"id"`
+	Kind          string     `json:"kind" db:"kind"`
+	Recipient     string     `json:"recipient" db:"recipient"`
+	Subject       string     `json:"subject" db:"subject"`
+	HTMLBody      string     `json:"-" db:"html_body"`
+	TextBody      string     `json:"-" db:"text_body"`
+	Status        string     `json:"status" db:"status"`
+	Attempts      int        `json:"attempts" db:"attempts"`
+	LastError     string     `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty" db:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+
+	// ListUnsubscribe is set for bulk mail such as digests
+	ListUnsubscribe string `json:"-" db:"list_unsubscribe"`
+}
+
+// NewOutboxMessage queues a rendered email for the outbox worker
+func NewOutboxMessage(kind string, msg email.EmailData) *OutboxMessage {
+	return &OutboxMessage{
+		Kind:      kind,
+		Recipient: msg.To,
+		Subject:   msg.Subject,
+		HTMLBody:  msg.Body,
+		TextBody:  msg.TextBody,
+
+		ListUnsubscribe: msg.ListUnsubscribe,
+	}
+}
+
+type OutboxFilter struct {
+	Status string `form:"status"`
+	Page   int    `form:"page"`
+	Limit  int    `form:"limit"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+
+	"daily-notes-api/pkg/email"
+)
+
+func TestNewOutboxMessage(t *testing.T) {
+	msg := NewOutboxMessage("digest", email.EmailData{
+		To:              "ann@example.com",
+		Subject:         "Your daily summary",
+		Body:            "<p>Hi</p>",
+		TextBody:        "Hi",
+		ListUnsubscribe: "<https://notes.example/unsubscribe?token=abc>",
+	})
+
+	want := OutboxMessage{
+		Kind:            "digest",
+		Recipient:       "ann@example.com",
+		Subject:         "Your daily summary",
+		HTMLBody:        "<p>Hi</p>",
+		TextBody:        "Hi",
+		ListUnsubscribe: "<https://notes.example/unsubscribe?token=abc>",
+	}
+	if *msg != want {
+		t.Errorf("NewOutboxMessage = %+v, want %+v", *msg, want)
+	}
+}
@@ -0,0 +1,10 @@
+package models
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email,max=100"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required,max=100"`
+	NewPassword string `json:"new_password" binding:"required,max=100"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ReminderStatusPending   = "pending"
+	ReminderStatusSent      = "sent"
+	ReminderStatusCancelled = "cancelled"
+)
+
+type Reminder struct {
+	ID           int        `json:"id" db:"id"`
+	NoteID       int        `json:"note_id" db:"note_id"`
+	UserID       int        `json:"user_id" db:"user_id"`
+	StartsAt     time.Time  `json:"starts_at" db:"starts_at"`
+	RRule        string     `json:"rrule,omitempty" db:"rrule"`
+	OccurrenceAt time.Time  `json:"occurrence_at" db:"occurrence_at"`
+	RemindAt     time.Time  `json:"remind_at" db:"remind_at"`
+	Status       string     `json:"status" db:"status"`
+	SentCount    int        `json:"sent_count" db:"sent_count"`
+	Attempts     int        `json:"attempts" db:"attempts"`
+	LastError    string     `json:"last_error,omitempty" db:"last_error"`
+	LastSentAt   *time.Time `json:"last_sent_at,omitempty" db:"last_sent_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Localize converts the timestamps to loc for rendering
+func (r *Reminder) Localize(loc *time.Location) {
+	r.StartsAt = r.StartsAt.In(loc)
+	r.OccurrenceAt = r.OccurrenceAt.In(loc)
+	r.RemindAt = r.RemindAt.In(loc)
+	r.CreatedAt = r.CreatedAt.In(loc)
+	r.UpdatedAt = r.UpdatedAt.In(loc)
+	if r.LastSentAt != nil {
+		lastSentAt := r.LastSentAt.In(loc)
+		r.LastSentAt = &lastSentAt
+	}
+}
+
+// DueReminder is a claimed reminder joined with what is needed to email it
+type DueReminder struct {
+	Reminder
+	NoteTitle   string
+	NoteContent string
+	UserEmail   string
+	UserName    string
+	UserLocale  string
+	// UserTimezone is where recurrences are expanded, so "daily at 9:00"
+	// stays at 9:00 local time across DST changes
+	UserTimezone string
+}
+
+type CreateReminderRequest struct {
+	// RemindAt is the first (or only) time the reminder fires
+	RemindAt time.Time `json:"remind_at" binding:"required"`
+	// RRule optionally makes the reminder recurring, e.g. "FREQ=DAILY;COUNT=5"
+	RRule string `json:"rrule" binding:"max=255"`
+}
+
+type SnoozeReminderRequest struct {
+	Minutes int        `json:"minutes" binding:"omitempty,min=1,max=43200"`
+	Until   *time.Time `json:"until"`
+}
+
+type RemindersFilter struct {
+	Status string `form:"status"`
+}
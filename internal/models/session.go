@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// Session is one login on one device. Every JWT carries its session ID, so
+// revoking the session logs that device out.
+type Session struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"user_id" db:"user_id"`
+	Device     string     `json:"device" db:"device"`
+	UserAgent  string     `json:"user_agent" db:"user_agent"`
+	IPAddress  string     `json:"ip_address" db:"ip_address"`
+	LastSeenAt time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"-" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	Current    bool       `json:"current"`
+}
+
+// Localize converts the timestamps to loc for rendering
+func (s *Session) Localize(loc *time.Location) {
+	s.LastSeenAt = s.LastSeenAt.In(loc)
+	s.ExpiresAt = s.ExpiresAt.In(loc)
+	s.CreatedAt = s.CreatedAt.In(loc)
+}
@@ -0,0 +1,28 @@
+package models
+
+type CategoryCount struct {
+	Category string `json:"category"`
+	Count    int    `json:"count"`
+}
+
+// PeriodCount is the number of notes in a day (2006-01-02), a week (the
+// date it starts on) or a month (2006-01)
+type PeriodCount struct {
+	Period string `json:"period"`
+	Count  int    `json:"count"`
+}
+
+type Stats struct {
+	Timezone        string          `json:"timezone"`
+	TotalNotes      int             `json:"total_notes"`
+	TotalWords      int             `json:"total_words"`
+	TotalCharacters int             `json:"total_characters"`
+	CurrentStreak   int             `json:"current_streak"`
+	LongestStreak   int             `json:"longest_streak"`
+	NotesPerDay     []PeriodCount   `json:"notes_per_day"`
+	NotesPerWeek    []PeriodCount   `json:"notes_per_week"`
+	NotesPerMonth   []PeriodCount   `json:"notes_per_month"`
+	TopCategories   []CategoryCount `json:"top_categories"`
+	// HourHeatmap counts notes by weekday (0 = Sunday) and hour of day
+	HourHeatmap [7][24]int `json:"hour_heatmap"`
+}
@@ -0,0 +1,66 @@
+package models
+
+const (
+	NoteChangeUpsert = "upsert"
+	NoteChangeDelete = "delete"
+)
+
+const (
+	SyncOpCreate = "create"
+	SyncOpUpdate = "update"
+	SyncOpDelete = "delete"
+)
+
+const (
+	SyncStatusApplied  = "applied"
+	SyncStatusConflict = "conflict"
+	SyncStatusNotFound = "not_found"
+	SyncStatusInvalid  = "invalid"
+)
+
+// NoteChange is the latest change log entry of a note
+type NoteChange struct {
+	Seq    int64
+	NoteID int
+	Op     string
+}
+
+type SyncChangesResponse struct {
+	Changed   []*Note `json:"changed"`
+	Deleted   []int   `json:"deleted"`
+	NextToken string  `json:"next_token"`
+	HasMore   bool    `json:"has_more"`
+}
+
+// SyncMutation is one offline change pushed by a client. BaseVersion is the
+// note version the client edited; a mismatch is reported as a conflict.
+// Creates with the same ClientID are applied once, so pushes can be retried.
+type SyncMutation struct {
+	ClientID    string `json:"client_id" binding:"max=64"`
+	Op          string `json:"op" binding:"required,oneof=create update delete"`
+	ID          int    `json:"id"`
+	BaseVersion int    `json:"base_version"`
+	Title       string `json:"title" binding:"max=255"`
+	Content     string `json:"content"`
+	Category    string `json:"category" binding:"max=100"`
+}
+
+type SyncPushRequest struct {
+	Mutations []SyncMutation `json:"mutations" binding:"required,min=1,max=100,dive"`
+}
+
+type SyncMutationResult struct {
+	ClientID string `json:"client_id,omitempty"`
+	Op       string `json:"op"`
+	ID       int    `json:"id,omitempty"`
+	Status   string `json:"status"`
+	Message  string `json:"message,omitempty"`
+	Note     *Note  `json:"note,omitempty"`
+	// ServerNote is the current server copy when Status is conflict
+	ServerNote *Note `json:"server_note,omitempty"`
+}
+
+type SyncPushResponse struct {
+	Results []SyncMutationResult `json:"results"`
+	Token   string               `json:"token"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	TaskStatusOpen    = "open"
+	TaskStatusDone    = "done"
+	TaskStatusCarried = "carried"
+)
+
+// DailyNoteCategory marks the notes titled YYYY-MM-DD that unfinished tasks
+// are carried over between
+const DailyNoteCategory = "daily"
+
+// Task is a "- [ ]" item parsed from a note. Line is its zero-based line in
+// the note content.
+type Task struct {
+	ID          int        `json:"id" db:"id"`
+	UserID      int        `json:"user_id" db:"user_id"`
+	NoteID      int        `json:"note_id" db:"note_id"`
+	NoteTitle   string     `json:"note_title"`
+	Line        int        `json:"line" db:"line"`
+	Text        string     `json:"text" db:"text"`
+	Status      string     `json:"status" db:"status"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Localize converts the timestamps to loc for rendering
+func (t *Task) Localize(loc *time.Location) {
+	t.CreatedAt = t.CreatedAt.In(loc)
+	t.UpdatedAt = t.UpdatedAt.In(loc)
+	if t.CompletedAt != nil {
+		completedAt := t.CompletedAt.In(loc)
+		t.CompletedAt = &completedAt
+	}
+}
+
+type TasksFilter struct {
+	Status string `form:"status"`
+	NoteID int    `form:"note_id"`
+	Page   int    `form:"page"`
+	Limit  int    `form:"limit"`
+}
+
+// UpdateTaskRequest sets completion; without Completed the task is toggled
+type UpdateTaskRequest struct {
+	Completed *bool `json:"completed"`
+}
+
+type CarryOverTasksRequest struct {
+	// Timezone decides what "today" is; defaults to the user's timezone
+	Timezone string `json:"timezone"`
+}
+
+type CarryOverTasksResponse struct {
+	Note    *Note `json:"note"`
+	Carried int   `json:"carried"`
+}
@@ -4,26 +4,43 @@ import (
 	"time"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	ID           int       `json:"id" db:"id"`
 	Username     string    `json:"username" db:"username"`
 	Email        string    `json:"email" db:"email"`
 	PasswordHash string    `json:"-" db:"password_hash"`
 	FullName     string    `json:"full_name" db:"full_name"`
+	Role         string    `json:"role" db:"role"`
+	Locale       string    `json:"locale" db:"locale"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+
+	// DeletionScheduledAt is set while the account waits out its deletion
+	// grace period
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty" db:"deletion_scheduled_at"`
+
+	// EmailVerifiedAt is set when the app knows the user controls Email
+	EmailVerifiedAt *time.Time `json:"-" db:"email_verified_at"`
 }
 
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
 	Email    string `json:"email" binding:"required,email,max=100"`
-	Password string `json:"password" binding:"required,min=6,max=100"`
+	Password string `json:"password" binding:"required,max=100"`
 	FullName string `json:"full_name" binding:"required,min=2,max=100"`
+	Locale   string `json:"locale" binding:"omitempty,oneof=en id"`
 }
 
 type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
+	Username string `json:"username" binding:"required,max=100"`
 	Password string `json:"password" binding:"required"`
+	// DeviceName labels the session; the user agent is described otherwise
+	DeviceName string `json:"device_name" binding:"max=100"`
 }
 
 type LoginResponse struct {
@@ -36,7 +53,21 @@ type UserResponse struct {
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
 	FullName  string    `json:"full_name"`
+	Role      string    `json:"role,omitempty"`
+	Locale    string    `json:"locale,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
+
+	// DeletionScheduledAt tells clients to offer cancelling the deletion
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+}
+
+// Localize converts the timestamps to loc for rendering
+func (u *UserResponse) Localize(loc *time.Location) {
+	u.CreatedAt = u.CreatedAt.In(loc)
+	if u.DeletionScheduledAt != nil {
+		deletionScheduledAt := u.DeletionScheduledAt.In(loc)
+		u.DeletionScheduledAt = &deletionScheduledAt
+	}
 }
 
 func (u *User) ToResponse() *UserResponse {
@@ -45,6 +76,10 @@ func (u *User) ToResponse() *UserResponse {
 		Username:  u.Username,
 		Email:     u.Email,
 		FullName:  u.FullName,
+		Role:      u.Role,
+		Locale:    u.Locale,
 		CreatedAt: u.CreatedAt,
+
+		DeletionScheduledAt: u.DeletionScheduledAt,
 	}
 }
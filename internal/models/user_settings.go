@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	WeekStartMonday = "monday"
+	WeekStartSunday = "sunday"
+)
+
+// UserSettings collects the per-user preferences. Locale lives on users and
+// the digest preferences on digest_subscriptions; they are surfaced here so
+// clients have one place to read and write them.
+type UserSettings struct {
+	UserID          int               `json:"user_id" db:"user_id"`
+	Timezone        string            `json:"timezone" db:"timezone"`
+	Locale          string            `json:"locale"`
+	WeekStart       string            `json:"week_start" db:"week_start"`
+	DefaultCategory string            `json:"default_category" db:"default_category"`
+	DefaultPageSize int               `json:"default_page_size" db:"default_page_size"`
+	Digest          DigestPreferences `json:"digest"`
+}
+
+type DigestPreferences struct {
+	Frequency string `json:"frequency"`
+	SendHour  int    `json:"send_hour"`
+}
+
+// DefaultUserSettings are used until the user saves their own
+func DefaultUserSettings(userID int) *UserSettings {
+	return &UserSettings{
+		UserID:          userID,
+		Timezone:        "UTC",
+		Locale:          "en",
+		WeekStart:       WeekStartMonday,
+		DefaultPageSize: 10,
+		Digest: DigestPreferences{
+			Frequency: DigestFrequencyNone,
+			SendHour:  7,
+		},
+	}
+}
+
+// Location returns the user's timezone, falling back to UTC
+func (s *UserSettings) Location() *time.Location {
+	if loc, err := time.LoadLocation(s.Timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// UpdateUserSettingsRequest changes only the fields that are sent
+type UpdateUserSettingsRequest struct {
+	Timezone        *string                  `json:"timezone" binding:"omitempty,max=64"`
+	Locale          *string                  `json:"locale" binding:"omitempty,oneof=en id"`
+	WeekStart       *string                  `json:"week_start" binding:"omitempty,oneof=monday sunday"`
+	DefaultCategory *string                  `json:"default_category" binding:"omitempty,max=100"`
+	DefaultPageSize *int                     `json:"default_page_size" binding:"omitempty,min=1,max=100"`
+	Digest          *UpdateDigestPreferences `json:"digest"`
+}
+
+type UpdateDigestPreferences struct {
+	Frequency *string `json:"frequency" binding:"omitempty,oneof=none daily weekly"`
+	SendHour  *int    `json:"send_hour" binding:"omitempty,min=0,max=23"`
+}
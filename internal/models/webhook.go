@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	WebhookEventNoteCreated = "note.created"
+	WebhookEventNoteUpdated = "note.updated"
+	WebhookEventNoteDeleted = "note.deleted"
+)
+
+// WebhookEvents lists every event a webhook can subscribe to
+var WebhookEvents = []string{WebhookEventNoteCreated, WebhookEventNoteUpdated, WebhookEventNoteDeleted}
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+type Webhook struct {
+	ID           int        `json:"id" db:"id"`
+	UserID       int        `json:"user_id" db:"user_id"`
+	URL          string     `json:"url" db:"url"`
+	Secret       string     `json:"secret,omitempty" db:"secret"` // only returned on create
+	Events       []string   `json:"events" db:"events"`
+	Active       bool       `json:"active" db:"active"`
+	FailureCount int        `json:"failure_count" db:"failure_count"`
+	DisabledAt   *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Subscribes reports whether the webhook wants event
+func (w *Webhook) Subscribes(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=note.created note.updated note.deleted"`
+}
+
+type UpdateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=note.created note.updated note.deleted"`
+	// Active re-enables a webhook that was disabled after repeated failures
+	Active *bool `json:"active"`
+}
+
+// WebhookDelivery is one event queued for, or delivered to, a webhook
+type WebhookDelivery struct {
+	ID             int        `json:"id" db:"id"`
+	WebhookID      int        `json:"webhook_id" db:"webhook_id"`
+	Event          string     `json:"event" db:"event"`
+	Payload        string     `json:"payload" db:"payload"`
+	Status         string     `json:"status" db:"status"`
+	Attempts       int        `json:"attempts" db:"attempts"`
+	ResponseStatus *int       `json:"response_status,omitempty" db:"response_status"`
+	ResponseBody   string     `json:"response_body,omitempty" db:"response_body"`
+	LastError      string     `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// DueWebhookDelivery carries the target of a claimed delivery
+type DueWebhookDelivery struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+}
+
+type WebhookDeliveriesFilter struct {
+	Status string `form:"status"`
+	Page   int    `form:"page"`
+	Limit  int    `form:"limit"`
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/pkg/auth"
+)
+
+type AccessTokenRepository interface {
+	// Create generates the token and returns it in plain text once; only
+	// its hash is stored
+	Create(userID int, req *models.CreateAccessTokenRequest) (*models.PersonalAccessToken, error)
+	GetByID(id, userID int) (*models.PersonalAccessToken, error)
+	GetAll(userID int) ([]*models.PersonalAccessToken, error)
+	// GetByToken looks a presented token up by its hash, expired or not
+	GetByToken(token string) (*models.PersonalAccessToken, error)
+	Update(id, userID int, req *models.UpdateAccessTokenRequest) (*models.PersonalAccessToken, error)
+	Delete(id, userID int) error
+	Touch(id int, usedAt time.Time) error
+}
+
+type accessTokenRepository struct {
+	db *sql.DB
+}
+
+func NewAccessTokenRepository(db *sql.DB) AccessTokenRepository {
+	return &accessTokenRepository{db: db}
+}
+
+const accessTokenColumns = `id, user_id, name, token_prefix, scopes, expires_at, last_used_at, created_at, updated_at`
+
+// accessTokenPrefixLength is how much of the token is kept to recognise it
+const accessTokenPrefixLength = len(models.AccessTokenPrefix) + 8
+
+func scanAccessToken(row rowScanner) (*models.PersonalAccessToken, error) {
+	token := &models.PersonalAccessToken{}
+	var scopes string
+	var expiresAt, lastUsedAt sql.NullTime
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenPrefix, &scopes, &expiresAt,
+		&lastUsedAt, &token.CreatedAt, &token.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	token.Scopes = splitEvents(scopes)
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	return token, nil
+}
+
+func (r *accessTokenRepository) Create(userID int, req *models.CreateAccessTokenRequest) (*models.PersonalAccessToken, error) {
+	secret, err := generateToken(20)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+	plain := models.AccessTokenPrefix + secret
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays != nil {
+		t := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	// Scopes are stored comma separated, like webhook events
+	query := `INSERT INTO personal_access_tokens (user_id, name, token_prefix, token_hash, scopes, expires_at)
+              VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := r.db.Exec(query, userID, req.Name, plain[:accessTokenPrefixLength], auth.HashToken(plain),
+		joinEvents(req.Scopes), expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	token, err := r.GetByID(int(id), userID)
+	if err != nil || token == nil {
+		return token, err
+	}
+	token.Token = plain
+
+	return token, nil
+}
+
+func (r *accessTokenRepository) GetByID(id, userID int) (*models.PersonalAccessToken, error) {
+	query := `SELECT ` + accessTokenColumns + ` FROM personal_access_tokens WHERE id = ? AND user_id = ?`
+
+	token, err := scanAccessToken(r.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (r *accessTokenRepository) GetAll(userID int) ([]*models.PersonalAccessToken, error) {
+	query := `SELECT ` + accessTokenColumns + ` FROM personal_access_tokens WHERE user_id = ? ORDER BY id`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := make([]*models.PersonalAccessToken, 0)
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan access token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+func (r *accessTokenRepository) GetByToken(plain string) (*models.PersonalAccessToken, error) {
+	query := `SELECT ` + accessTokenColumns + ` FROM personal_access_tokens WHERE token_hash = ?`
+
+	token, err := scanAccessToken(r.db.QueryRow(query, auth.HashToken(plain)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (r *accessTokenRepository) Update(id, userID int, req *models.UpdateAccessTokenRequest) (*models.PersonalAccessToken, error) {
+	query := `UPDATE personal_access_tokens SET name = ?, scopes = ? WHERE id = ? AND user_id = ?`
+	if _, err := r.db.Exec(query, req.Name, joinEvents(req.Scopes), id, userID); err != nil {
+		return nil, fmt.Errorf("failed to update access token: %w", err)
+	}
+
+	return r.GetByID(id, userID)
+}
+
+func (r *accessTokenRepository) Delete(id, userID int) error {
+	query := `DELETE FROM personal_access_tokens WHERE id = ? AND user_id = ?`
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete access token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("access token not found or not owned by user")
+	}
+
+	return nil
+}
+
+func (r *accessTokenRepository) Touch(id int, usedAt time.Time) error {
+	query := `UPDATE personal_access_tokens SET last_used_at = ?, updated_at = updated_at WHERE id = ?`
+	if _, err := r.db.Exec(query, usedAt, id); err != nil {
+		return fmt.Errorf("failed to update access token: %w", err)
+	}
+	return nil
+}
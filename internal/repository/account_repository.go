@@ -0,0 +1,393 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"daily-notes-api/internal/models"
+)
+
+type AccountRepository interface {
+	CreateExport(userID int) (*models.AccountExport, error)
+	GetExport(id int) (*models.AccountExport, error)
+	GetExports(userID int) ([]*models.AccountExport, error)
+	HasPendingExport(userID int) (bool, error)
+
+	// ClaimPendingExports leases up to limit pending exports for building
+	ClaimPendingExports(now time.Time, lease time.Duration, limit int) ([]*models.AccountExport, error)
+	// CompleteExport marks the export ready and queues the given emails in
+	// the same transaction
+	CompleteExport(id int, filePath string, size int64, expiresAt time.Time, messages []*models.OutboxMessage) error
+	FailExport(id int, errMsg string) error
+	GetExpiredExports(now time.Time) ([]*models.AccountExport, error)
+	MarkExportExpired(id int) error
+
+	// ForEachNote and ForEachRevision stream a user's data into an export
+	ForEachNote(userID int, fn func(*models.Note) error) error
+	ForEachRevision(userID int, fn func(*models.NoteRevision) error) error
+
+	// ScheduleDeletion and CancelDeletion queue the given emails in the same
+	// transaction as the change
+	ScheduleDeletion(userID int, at time.Time, messages []*models.OutboxMessage) error
+	CancelDeletion(userID int, messages []*models.OutboxMessage) (bool, error)
+	GetDueDeletions(now time.Time, limit int) ([]*models.User, error)
+	// DeleteAccount removes the user and everything cascading from it. It
+	// returns false when the deletion was cancelled in the meantime.
+	DeleteAccount(userID int, now time.Time, messages []*models.OutboxMessage) (bool, error)
+}
+
+type accountRepository struct {
+	db *sql.DB
+}
+
+func NewAccountRepository(db *sql.DB) AccountRepository {
+	return &accountRepository{db: db}
+}
+
+const exportColumns = `id, user_id, status, file_path, size_bytes, last_error, expires_at, completed_at, created_at, updated_at`
+
+func scanAccountExport(row rowScanner) (*models.AccountExport, error) {
+	export := &models.AccountExport{}
+	var expiresAt, completedAt sql.NullTime
+	err := row.Scan(&export.ID, &export.UserID, &export.Status, &export.FilePath, &export.SizeBytes,
+		&export.LastError, &expiresAt, &completedAt, &export.CreatedAt, &export.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		export.ExpiresAt = &expiresAt.Time
+	}
+	if completedAt.Valid {
+		export.CompletedAt = &completedAt.Time
+	}
+	return export, nil
+}
+
+func (r *accountRepository) CreateExport(userID int) (*models.AccountExport, error) {
+	result, err := r.db.Exec(`INSERT INTO account_exports (user_id, status) VALUES (?, ?)`, userID, models.ExportStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return r.GetExport(int(id))
+}
+
+func (r *accountRepository) GetExport(id int) (*models.AccountExport, error) {
+	query := `SELECT ` + exportColumns + ` FROM account_exports WHERE id = ?`
+
+	export, err := scanAccountExport(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+
+	return export, nil
+}
+
+func (r *accountRepository) GetExports(userID int) ([]*models.AccountExport, error) {
+	query := `SELECT ` + exportColumns + ` FROM account_exports WHERE user_id = ? ORDER BY id DESC`
+	return r.queryExports(query, userID)
+}
+
+func (r *accountRepository) queryExports(query string, args ...interface{}) ([]*models.AccountExport, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exports: %w", err)
+	}
+	defer rows.Close()
+
+	exports := make([]*models.AccountExport, 0)
+	for rows.Next() {
+		export, err := scanAccountExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan export: %w", err)
+		}
+		exports = append(exports, export)
+	}
+
+	return exports, nil
+}
+
+func (r *accountRepository) HasPendingExport(userID int) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM account_exports WHERE user_id = ? AND status = ?`
+	if err := r.db.QueryRow(query, userID, models.ExportStatusPending).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check pending exports: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *accountRepository) ClaimPendingExports(now time.Time, lease time.Duration, limit int) ([]*models.AccountExport, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + exportColumns + ` FROM account_exports
+              WHERE status = ? AND (locked_until IS NULL OR locked_until < ?)
+              ORDER BY id
+              LIMIT ?
+              FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.Query(query, models.ExportStatusPending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending exports: %w", err)
+	}
+
+	var exports []*models.AccountExport
+	var ids []interface{}
+	for rows.Next() {
+		export, err := scanAccountExport(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan export: %w", err)
+		}
+		exports = append(exports, export)
+		ids = append(ids, export.ID)
+	}
+	rows.Close()
+
+	if len(exports) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := append([]interface{}{now.Add(lease)}, ids...)
+	if _, err := tx.Exec(`UPDATE account_exports SET locked_until = ? WHERE id IN (`+placeholders+`)`, args...); err != nil {
+		return nil, fmt.Errorf("failed to lease exports: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit export claim: %w", err)
+	}
+
+	return exports, nil
+}
+
+func (r *accountRepository) CompleteExport(id int, filePath string, size int64, expiresAt time.Time, messages []*models.OutboxMessage) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE account_exports
+              SET status = ?, file_path = ?, size_bytes = ?, expires_at = ?, completed_at = ?, locked_until = NULL
+              WHERE id = ?`
+	if _, err := tx.Exec(query, models.ExportStatusReady, filePath, size, expiresAt, time.Now(), id); err != nil {
+		return fmt.Errorf("failed to complete export: %w", err)
+	}
+
+	for _, msg := range messages {
+		if err := enqueueOutboxMessage(tx, msg); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit export: %w", err)
+	}
+
+	return nil
+}
+
+func (r *accountRepository) FailExport(id int, errMsg string) error {
+	if len(errMsg) > 1000 {
+		errMsg = errMsg[:1000]
+	}
+
+	query := `UPDATE account_exports SET status = ?, last_error = ?, locked_until = NULL WHERE id = ?`
+	if _, err := r.db.Exec(query, models.ExportStatusFailed, errMsg, id); err != nil {
+		return fmt.Errorf("failed to record export failure: %w", err)
+	}
+	return nil
+}
+
+func (r *accountRepository) GetExpiredExports(now time.Time) ([]*models.AccountExport, error) {
+	query := `SELECT ` + exportColumns + ` FROM account_exports WHERE status = ? AND expires_at <= ?`
+	return r.queryExports(query, models.ExportStatusReady, now)
+}
+
+func (r *accountRepository) MarkExportExpired(id int) error {
+	query := `UPDATE account_exports SET status = ?, file_path = '' WHERE id = ?`
+	if _, err := r.db.Exec(query, models.ExportStatusExpired, id); err != nil {
+		return fmt.Errorf("failed to expire export: %w", err)
+	}
+	return nil
+}
+
+func (r *accountRepository) ForEachNote(userID int, fn func(*models.Note) error) error {
+	query := `SELECT id, user_id, title, content, category, version, created_at, updated_at
+              FROM notes WHERE user_id = ? ORDER BY id`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get notes for export: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		note := &models.Note{}
+		err := rows.Scan(&note.ID, &note.UserID, &note.Title, &note.Content, &note.Category,
+			&note.Version, &note.CreatedAt, &note.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to scan note: %w", err)
+		}
+		if err := fn(note); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *accountRepository) ForEachRevision(userID int, fn func(*models.NoteRevision) error) error {
+	query := `SELECT id, note_id, user_id, version, revision, content, created_at
+              FROM note_revisions WHERE user_id = ? ORDER BY note_id, id`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get note revisions for export: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		revision := &models.NoteRevision{}
+		err := rows.Scan(&revision.ID, &revision.NoteID, &revision.UserID, &revision.Version,
+			&revision.Revision, &revision.Content, &revision.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to scan note revision: %w", err)
+		}
+		if err := fn(revision); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *accountRepository) ScheduleDeletion(userID int, at time.Time, messages []*models.OutboxMessage) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE users SET deletion_scheduled_at = ? WHERE id = ?`, at, userID); err != nil {
+		return fmt.Errorf("failed to schedule account deletion: %w", err)
+	}
+
+	for _, msg := range messages {
+		if err := enqueueOutboxMessage(tx, msg); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit account deletion: %w", err)
+	}
+
+	return nil
+}
+
+func (r *accountRepository) CancelDeletion(userID int, messages []*models.OutboxMessage) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE users SET deletion_scheduled_at = NULL WHERE id = ? AND deletion_scheduled_at IS NOT NULL`
+	result, err := tx.Exec(query, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel account deletion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	for _, msg := range messages {
+		if err := enqueueOutboxMessage(tx, msg); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit account deletion cancel: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *accountRepository) GetDueDeletions(now time.Time, limit int) ([]*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users
+              WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?
+              ORDER BY deletion_scheduled_at LIMIT ?`
+
+	rows, err := r.db.Query(query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due account deletions: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func (r *accountRepository) DeleteAccount(userID int, now time.Time, messages []*models.OutboxMessage) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Every table referencing users cascades, so this removes all the data
+	query := `DELETE FROM users WHERE id = ? AND deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?`
+	result, err := tx.Exec(query, userID, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	for _, msg := range messages {
+		if err := enqueueOutboxMessage(tx, msg); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit account deletion: %w", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"daily-notes-api/internal/models"
+)
+
+type DigestRepository interface {
+	GetByUserID(userID int) (*models.DigestSubscription, error)
+	Upsert(userID int, req *models.UpdateDigestRequest) (*models.DigestSubscription, error)
+	GetActive() ([]*models.DigestSubscription, error)
+	Unsubscribe(token string) (bool, error)
+
+	// ClaimSend records sentAt as the last send time and queues messages, but
+	// only if nobody else has sent since prevSentAt. It returns false when
+	// another instance won.
+	ClaimSend(userID int, prevSentAt *time.Time, sentAt time.Time, messages []*models.OutboxMessage) (bool, error)
+
+	GetNotesBetween(userID int, from, to time.Time) ([]*models.Note, error)
+	GetNoteTimestamps(userID int) ([]time.Time, error)
+}
+
+// digestSelect reads subscriptions with the account timezone from
+// user_settings. The digest's own timezone column is only the fallback for
+// accounts that never saved their settings.
+const digestSelect = `SELECT d.user_id, d.frequency, COALESCE(s.timezone, d.timezone), d.send_hour,
+                         d.unsubscribe_token, d.last_sent_at, d.created_at, d.updated_at
+                      FROM digest_subscriptions d
+                      LEFT JOIN user_settings s ON s.user_id = d.user_id`
+
+type digestRepository struct {
+	db *sql.DB
+}
+
+func NewDigestRepository(db *sql.DB) DigestRepository {
+	return &digestRepository{db: db}
+}
+
+func (r *digestRepository) GetByUserID(userID int) (*models.DigestSubscription, error) {
+	query := digestSelect + ` WHERE d.user_id = ?`
+
+	sub, err := scanDigestSubscription(r.db.QueryRow(query, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get digest subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+func (r *digestRepository) Upsert(userID int, req *models.UpdateDigestRequest) (*models.DigestSubscription, error) {
+	token, err := generateToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate unsubscribe token: %w", err)
+	}
+
+	sendHour := 7
+	if req.SendHour != nil {
+		sendHour = *req.SendHour
+	}
+
+	// The unsubscribe token is only generated once so links in old emails keep working
+	query := `INSERT INTO digest_subscriptions (user_id, frequency, send_hour, unsubscribe_token)
+              VALUES (?, ?, ?, ?)
+              ON DUPLICATE KEY UPDATE frequency = VALUES(frequency), send_hour = VALUES(send_hour)`
+	if _, err := r.db.Exec(query, userID, req.Frequency, sendHour, token); err != nil {
+		return nil, fmt.Errorf("failed to save digest subscription: %w", err)
+	}
+
+	return r.GetByUserID(userID)
+}
+
+func (r *digestRepository) GetActive() ([]*models.DigestSubscription, error) {
+	query := digestSelect + ` WHERE d.frequency != ?`
+
+	rows, err := r.db.Query(query, models.DigestFrequencyNone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digest subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []*models.DigestSubscription
+	for rows.Next() {
+		sub, err := scanDigestSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan digest subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, nil
+}
+
+func (r *digestRepository) Unsubscribe(token string) (bool, error) {
+	query := `UPDATE digest_subscriptions SET frequency = ? WHERE unsubscribe_token = ?`
+	result, err := r.db.Exec(query, models.DigestFrequencyNone, token)
+	if err != nil {
+		return false, fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		return true, nil
+	}
+
+	// Already unsubscribed rows are not "affected", so check the token exists
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM digest_subscriptions WHERE unsubscribe_token = ?`, token).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check unsubscribe token: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *digestRepository) ClaimSend(userID int, prevSentAt *time.Time, sentAt time.Time, messages []*models.OutboxMessage) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var result sql.Result
+	if prevSentAt == nil {
+		result, err = tx.Exec(`UPDATE digest_subscriptions SET last_sent_at = ? WHERE user_id = ? AND last_sent_at IS NULL`,
+			sentAt, userID)
+	} else {
+		result, err = tx.Exec(`UPDATE digest_subscriptions SET last_sent_at = ? WHERE user_id = ? AND last_sent_at = ?`,
+			sentAt, userID, *prevSentAt)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim digest send: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	for _, msg := range messages {
+		if err := enqueueOutboxMessage(tx, msg); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+func (r *digestRepository) GetNotesBetween(userID int, from, to time.Time) ([]*models.Note, error) {
+	query := `SELECT id, user_id, title, content, category, created_at, updated_at
+              FROM notes WHERE user_id = ? AND created_at >= ? AND created_at < ?
+              ORDER BY created_at`
+
+	rows, err := r.db.Query(query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes for digest: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []*models.Note
+	for rows.Next() {
+		note := &models.Note{}
+		var category sql.NullString
+		if err := rows.Scan(&note.ID, &note.UserID, &note.Title, &note.Content, &category,
+			&note.CreatedAt, &note.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+		note.Category = category.String
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
+
+func (r *digestRepository) GetNoteTimestamps(userID int) ([]time.Time, error) {
+	rows, err := r.db.Query(`SELECT created_at FROM notes WHERE user_id = ? ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note timestamps: %w", err)
+	}
+	defer rows.Close()
+
+	var timestamps []time.Time
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			return nil, fmt.Errorf("failed to scan note timestamp: %w", err)
+		}
+		timestamps = append(timestamps, t)
+	}
+
+	return timestamps, nil
+}
+
+func scanDigestSubscription(row rowScanner) (*models.DigestSubscription, error) {
+	sub := &models.DigestSubscription{}
+	var lastSentAt sql.NullTime
+
+	err := row.Scan(&sub.UserID, &sub.Frequency, &sub.Timezone, &sub.SendHour, &sub.UnsubscribeToken,
+		&lastSentAt, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastSentAt.Valid {
+		sub.LastSentAt = &lastSentAt.Time
+	}
+
+	return sub, nil
+}
+
+// generateToken returns n random bytes encoded as hex
+func generateToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/pkg/auth"
+)
+
+type IdentityRepository interface {
+	// GetUserID returns the user linked to the provider subject, or 0
+	GetUserID(provider, subject string) (int, error)
+	Link(userID int, provider, subject, email string) error
+	TouchLogin(provider, subject string, loginAt time.Time) error
+
+	SaveLoginState(state *models.OIDCLoginState) error
+	// TakeLoginState returns and deletes a login state so it can only be used
+	// once; expired states are not returned
+	TakeLoginState(state string) (*models.OIDCLoginState, error)
+
+	// CreatePendingLink stores link and returns the token that confirms it
+	CreatePendingLink(link *models.OIDCPendingLink) (string, error)
+	// TakePendingLink returns and deletes a pending link; expired links are
+	// not returned
+	TakePendingLink(token string) (*models.OIDCPendingLink, error)
+}
+
+type identityRepository struct {
+	db *sql.DB
+}
+
+func NewIdentityRepository(db *sql.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) GetUserID(provider, subject string) (int, error) {
+	query := `SELECT user_id FROM user_identities WHERE provider = ? AND subject = ?`
+
+	var userID int
+	if err := r.db.QueryRow(query, provider, subject).Scan(&userID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (r *identityRepository) Link(userID int, provider, subject, email string) error {
+	query := `INSERT INTO user_identities (user_id, provider, subject, email, last_login_at) VALUES (?, ?, ?, ?, ?)`
+	if _, err := r.db.Exec(query, userID, provider, subject, email, time.Now()); err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	return nil
+}
+
+func (r *identityRepository) TouchLogin(provider, subject string, loginAt time.Time) error {
+	query := `UPDATE user_identities SET last_login_at = ? WHERE provider = ? AND subject = ?`
+	if _, err := r.db.Exec(query, loginAt, provider, subject); err != nil {
+		return fmt.Errorf("failed to update identity: %w", err)
+	}
+	return nil
+}
+
+func (r *identityRepository) SaveLoginState(state *models.OIDCLoginState) error {
+	now := time.Now()
+
+	// Abandoned logins are cleaned up as new ones start
+	if _, err := r.db.Exec(`DELETE FROM oidc_login_states WHERE expires_at < ?`, now); err != nil {
+		return fmt.Errorf("failed to prune login states: %w", err)
+	}
+
+	query := `INSERT INTO oidc_login_states (state, provider, nonce, code_verifier, device, expires_at)
+              VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, state.State, state.Provider, state.Nonce, state.CodeVerifier, state.Device, state.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to save login state: %w", err)
+	}
+	return nil
+}
+
+func (r *identityRepository) TakeLoginState(state string) (*models.OIDCLoginState, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT state, provider, nonce, code_verifier, device, expires_at FROM oidc_login_states
+              WHERE state = ? FOR UPDATE`
+	s := &models.OIDCLoginState{}
+	err = tx.QueryRow(query, state).Scan(&s.State, &s.Provider, &s.Nonce, &s.CodeVerifier, &s.Device, &s.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get login state: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM oidc_login_states WHERE state = ?`, state); err != nil {
+		return nil, fmt.Errorf("failed to delete login state: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit login state: %w", err)
+	}
+
+	if !s.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	return s, nil
+}
+
+func (r *identityRepository) CreatePendingLink(link *models.OIDCPendingLink) (string, error) {
+	if _, err := r.db.Exec(`DELETE FROM oidc_pending_links WHERE expires_at < ?`, time.Now()); err != nil {
+		return "", fmt.Errorf("failed to prune pending links: %w", err)
+	}
+
+	token, err := generateToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate link token: %w", err)
+	}
+
+	query := `INSERT INTO oidc_pending_links (token_hash, user_id, provider, subject, email, email_verified, device, expires_at)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = r.db.Exec(query, auth.HashToken(token), link.UserID, link.Provider, link.Subject, link.Email,
+		link.EmailVerified, link.Device, link.ExpiresAt)
+	if err != nil {
+		return "", fmt.Errorf("failed to save pending link: %w", err)
+	}
+	return token, nil
+}
+
+func (r *identityRepository) TakePendingLink(token string) (*models.OIDCPendingLink, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hash := auth.HashToken(token)
+	query := `SELECT user_id, provider, subject, email, email_verified, device, expires_at FROM oidc_pending_links
+              WHERE token_hash = ? FOR UPDATE`
+	link := &models.OIDCPendingLink{}
+	err = tx.QueryRow(query, hash).Scan(&link.UserID, &link.Provider, &link.Subject, &link.Email,
+		&link.EmailVerified, &link.Device, &link.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get pending link: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM oidc_pending_links WHERE token_hash = ?`, hash); err != nil {
+		return nil, fmt.Errorf("failed to delete pending link: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit pending link: %w", err)
+	}
+
+	if !link.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	return link, nil
+}
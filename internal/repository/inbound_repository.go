@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"daily-notes-api/internal/models"
+)
+
+type InboundRepository interface {
+	GetByUserID(userID int) (*models.InboundAddress, error)
+	// GetOrCreate returns the user's address, generating a token on first use
+	GetOrCreate(userID int) (*models.InboundAddress, error)
+	// Rotate replaces the token, invalidating the previous address
+	Rotate(userID int) (*models.InboundAddress, error)
+	GetUserIDByToken(token string) (int, error)
+}
+
+type inboundRepository struct {
+	db *sql.DB
+}
+
+func NewInboundRepository(db *sql.DB) InboundRepository {
+	return &inboundRepository{db: db}
+}
+
+func (r *inboundRepository) GetByUserID(userID int) (*models.InboundAddress, error) {
+	query := `SELECT user_id, token, created_at, updated_at FROM inbound_addresses WHERE user_id = ?`
+
+	addr := &models.InboundAddress{}
+	err := r.db.QueryRow(query, userID).Scan(&addr.UserID, &addr.Token, &addr.CreatedAt, &addr.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get inbound address: %w", err)
+	}
+
+	return addr, nil
+}
+
+func (r *inboundRepository) GetOrCreate(userID int) (*models.InboundAddress, error) {
+	token, err := generateToken(12)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate inbound token: %w", err)
+	}
+
+	// INSERT IGNORE keeps an existing token, so concurrent calls agree
+	query := `INSERT IGNORE INTO inbound_addresses (user_id, token) VALUES (?, ?)`
+	if _, err := r.db.Exec(query, userID, token); err != nil {
+		return nil, fmt.Errorf("failed to create inbound address: %w", err)
+	}
+
+	return r.GetByUserID(userID)
+}
+
+func (r *inboundRepository) Rotate(userID int) (*models.InboundAddress, error) {
+	token, err := generateToken(12)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate inbound token: %w", err)
+	}
+
+	query := `INSERT INTO inbound_addresses (user_id, token) VALUES (?, ?)
+              ON DUPLICATE KEY UPDATE token = VALUES(token)`
+	if _, err := r.db.Exec(query, userID, token); err != nil {
+		return nil, fmt.Errorf("failed to rotate inbound address: %w", err)
+	}
+
+	return r.GetByUserID(userID)
+}
+
+func (r *inboundRepository) GetUserIDByToken(token string) (int, error) {
+	var userID int
+	err := r.db.QueryRow(`SELECT user_id FROM inbound_addresses WHERE token = ?`, token).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get inbound address: %w", err)
+	}
+
+	return userID, nil
+}
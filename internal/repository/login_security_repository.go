@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"daily-notes-api/internal/models"
+)
+
+type LoginSecurityRepository interface {
+	// TryAttempt counts an attempt to log in as username before its password
+	// is checked. Attempts for a username are serialized, so parallel
+	// requests cannot slip past the delay. An attempt that has to wait is
+	// not counted; the wait is returned instead.
+	TryAttempt(username string, now time.Time) (*models.LoginFailures, time.Duration, error)
+	// Lock locks the username until the given time and queues the given
+	// emails in the same transaction
+	Lock(username string, until time.Time, messages []*models.OutboxMessage) error
+	// ClearFailures resets the count after a successful login or an admin
+	// unlock, reporting whether there was anything to clear
+	ClearFailures(username string) (bool, error)
+
+	GetLoginHistory(userID int, device, ipRange string) (*models.LoginHistory, error)
+	// RecordLogin remembers the device and IP range and queues the given
+	// emails in the same transaction
+	RecordLogin(userID int, device, ipRange string, messages []*models.OutboxMessage) error
+}
+
+type loginSecurityRepository struct {
+	db *sql.DB
+}
+
+func NewLoginSecurityRepository(db *sql.DB) LoginSecurityRepository {
+	return &loginSecurityRepository{db: db}
+}
+
+// loginFailuresRetention is how long an idle, unlocked counter is kept
+const loginFailuresRetention = 24 * time.Hour
+
+func (r *loginSecurityRepository) TryAttempt(username string, now time.Time) (*models.LoginFailures, time.Duration, error) {
+	// Counters of usernames nobody tried for a while are pruned as attempts
+	// come in, so guessed usernames don't pile up
+	cutoff := now.Add(-loginFailuresRetention)
+	if _, err := r.db.Exec(`DELETE FROM login_failures WHERE last_attempt_at < ? AND (locked_until IS NULL OR locked_until < ?)`,
+		cutoff, now); err != nil {
+		return nil, 0, fmt.Errorf("failed to prune login failures: %w", err)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Make sure the row exists, so that FOR UPDATE has something to lock
+	_, err = tx.Exec(`INSERT INTO login_failures (username, attempts, last_attempt_at) VALUES (?, 0, ?)
+                      ON DUPLICATE KEY UPDATE username = username`, username, now)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count login attempt: %w", err)
+	}
+
+	failures := &models.LoginFailures{}
+	var lockedUntil sql.NullTime
+	err = tx.QueryRow(`SELECT username, attempts, last_attempt_at, locked_until FROM login_failures WHERE username = ? FOR UPDATE`,
+		username).Scan(&failures.Username, &failures.Attempts, &failures.LastAttemptAt, &lockedUntil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get login failures: %w", err)
+	}
+	if lockedUntil.Valid {
+		failures.LockedUntil = &lockedUntil.Time
+	}
+
+	if wait := failures.Attempt(now); wait > 0 {
+		return failures, wait, nil
+	}
+
+	_, err = tx.Exec(`UPDATE login_failures SET attempts = ?, last_attempt_at = ?, locked_until = ? WHERE username = ?`,
+		failures.Attempts, failures.LastAttemptAt, failures.LockedUntil, username)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count login attempt: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return failures, 0, nil
+}
+
+func (r *loginSecurityRepository) Lock(username string, until time.Time, messages []*models.OutboxMessage) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE login_failures SET locked_until = ? WHERE username = ?`, until, username); err != nil {
+		return fmt.Errorf("failed to lock account: %w", err)
+	}
+
+	for _, msg := range messages {
+		if err := enqueueOutboxMessage(tx, msg); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *loginSecurityRepository) ClearFailures(username string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM login_failures WHERE username = ?`, username)
+	if err != nil {
+		return false, fmt.Errorf("failed to clear login failures: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *loginSecurityRepository) GetLoginHistory(userID int, device, ipRange string) (*models.LoginHistory, error) {
+	query := `SELECT COUNT(*), COALESCE(SUM(device = ?), 0), COALESCE(SUM(ip_range = ?), 0)
+              FROM user_known_logins WHERE user_id = ?`
+
+	var total, devices, ipRanges int
+	if err := r.db.QueryRow(query, device, ipRange, userID).Scan(&total, &devices, &ipRanges); err != nil {
+		return nil, fmt.Errorf("failed to get login history: %w", err)
+	}
+
+	return &models.LoginHistory{
+		HasLogins:    total > 0,
+		KnownDevice:  devices > 0,
+		KnownIPRange: ipRanges > 0,
+	}, nil
+}
+
+func (r *loginSecurityRepository) RecordLogin(userID int, device, ipRange string, messages []*models.OutboxMessage) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO user_known_logins (user_id, device, ip_range, last_seen_at) VALUES (?, ?, ?, ?)
+              ON DUPLICATE KEY UPDATE last_seen_at = VALUES(last_seen_at)`
+	if _, err := tx.Exec(query, userID, device, ipRange, time.Now()); err != nil {
+		return fmt.Errorf("failed to record login: %w", err)
+	}
+
+	for _, msg := range messages {
+		if err := enqueueOutboxMessage(tx, msg); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"daily-notes-api/internal/models"
+)
+
+type NoteCollaboratorRepository interface {
+	// Add invites userID to a note owned by ownerID. It returns false when
+	// the owner has no such note.
+	Add(noteID, ownerID, userID int) (bool, error)
+	Remove(noteID, ownerID, userID int) (bool, error)
+	GetByNote(noteID, ownerID int) ([]*models.NoteCollaborator, error)
+	// GetOwnerID returns the owner of the note when userID owns it or
+	// collaborates on it, or 0
+	GetOwnerID(noteID, userID int) (int, error)
+	GetSharedWith(userID int) ([]*models.SharedNote, error)
+}
+
+type noteCollaboratorRepository struct {
+	db *sql.DB
+}
+
+func NewNoteCollaboratorRepository(db *sql.DB) NoteCollaboratorRepository {
+	return &noteCollaboratorRepository{db: db}
+}
+
+func (r *noteCollaboratorRepository) Add(noteID, ownerID, userID int) (bool, error) {
+	query := `INSERT INTO note_collaborators (note_id, user_id)
+              SELECT id, ? FROM notes WHERE id = ? AND user_id = ?
+              ON DUPLICATE KEY UPDATE note_id = note_collaborators.note_id`
+	if _, err := r.db.Exec(query, userID, noteID, ownerID); err != nil {
+		return false, fmt.Errorf("failed to add collaborator: %w", err)
+	}
+
+	// Adding someone twice affects no rows, so check ownership separately
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM notes WHERE id = ? AND user_id = ?)`, noteID, ownerID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check note: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *noteCollaboratorRepository) Remove(noteID, ownerID, userID int) (bool, error) {
+	query := `DELETE c FROM note_collaborators c JOIN notes n ON n.id = c.note_id
+              WHERE c.note_id = ? AND c.user_id = ? AND n.user_id = ?`
+	result, err := r.db.Exec(query, noteID, userID, ownerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove collaborator: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *noteCollaboratorRepository) GetByNote(noteID, ownerID int) ([]*models.NoteCollaborator, error) {
+	query := `SELECT c.note_id, c.user_id, u.username, u.full_name, c.created_at
+              FROM note_collaborators c
+              JOIN notes n ON n.id = c.note_id
+              JOIN users u ON u.id = c.user_id
+              WHERE c.note_id = ? AND n.user_id = ?
+              ORDER BY u.username`
+
+	rows, err := r.db.Query(query, noteID, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collaborators: %w", err)
+	}
+	defer rows.Close()
+
+	collaborators := []*models.NoteCollaborator{}
+	for rows.Next() {
+		collaborator := &models.NoteCollaborator{}
+		err := rows.Scan(&collaborator.NoteID, &collaborator.UserID, &collaborator.Username,
+			&collaborator.FullName, &collaborator.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan collaborator: %w", err)
+		}
+		collaborators = append(collaborators, collaborator)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate collaborators: %w", err)
+	}
+
+	return collaborators, nil
+}
+
+func (r *noteCollaboratorRepository) GetOwnerID(noteID, userID int) (int, error) {
+	query := `SELECT n.user_id FROM notes n
+              WHERE n.id = ? AND (n.user_id = ? OR EXISTS (
+                  SELECT 1 FROM note_collaborators c WHERE c.note_id = n.id AND c.user_id = ?))`
+
+	var ownerID int
+	if err := r.db.QueryRow(query, noteID, userID, userID).Scan(&ownerID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get note owner: %w", err)
+	}
+	return ownerID, nil
+}
+
+func (r *noteCollaboratorRepository) GetSharedWith(userID int) ([]*models.SharedNote, error) {
+	query := `SELECT n.id, n.title, n.user_id, u.username, n.updated_at
+              FROM note_collaborators c
+              JOIN notes n ON n.id = c.note_id
+              JOIN users u ON u.id = n.user_id
+              WHERE c.user_id = ?
+              ORDER BY n.updated_at DESC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shared notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := []*models.SharedNote{}
+	for rows.Next() {
+		note := &models.SharedNote{}
+		if err := rows.Scan(&note.ID, &note.Title, &note.OwnerID, &note.OwnerUsername, &note.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan shared note: %w", err)
+		}
+		notes = append(notes, note)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate shared notes: %w", err)
+	}
+
+	return notes, nil
+}
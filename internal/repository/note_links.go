@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"unicode/utf8"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/pkg/wikilink"
+)
+
+const maxLinkTitleLength = 255
+
+// syncNoteLinks replaces the links stored for a note with those in content
+func syncNoteLinks(tx *sql.Tx, userID, noteID int, content string) error {
+	if _, err := tx.Exec(`DELETE FROM note_links WHERE source_note_id = ?`, noteID); err != nil {
+		return fmt.Errorf("failed to clear note links: %w", err)
+	}
+
+	for _, link := range wikilink.Parse(content) {
+		if utf8.RuneCountInString(link.Target) > maxLinkTitleLength {
+			continue // cannot match any title
+		}
+
+		targetID, byID, err := resolveLinkTarget(tx, userID, link)
+		if err != nil {
+			return err
+		}
+
+		query := `INSERT INTO note_links (user_id, source_note_id, target_note_id, target_title, by_id) VALUES (?, ?, ?, ?, ?)`
+		if _, err := tx.Exec(query, userID, noteID, targetID, link.Target, byID); err != nil {
+			return fmt.Errorf("failed to create note link: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// resolveLinkTarget finds the user's note a link points to. Numeric links
+// match a note ID first and fall back to a note titled with the number.
+func resolveLinkTarget(tx *sql.Tx, userID int, link wikilink.Link) (sql.NullInt64, bool, error) {
+	var id int64
+	if link.ByID() {
+		err := tx.QueryRow(`SELECT id FROM notes WHERE id = ? AND user_id = ?`, link.ID, userID).Scan(&id)
+		if err == nil {
+			return sql.NullInt64{Int64: id, Valid: true}, true, nil
+		}
+		if err != sql.ErrNoRows {
+			return sql.NullInt64{}, false, fmt.Errorf("failed to resolve note link: %w", err)
+		}
+	}
+
+	err := tx.QueryRow(`SELECT id FROM notes WHERE user_id = ? AND title = ? ORDER BY id LIMIT 1`, userID, link.Target).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return sql.NullInt64{}, false, nil
+		}
+		return sql.NullInt64{}, false, fmt.Errorf("failed to resolve note link: %w", err)
+	}
+	return sql.NullInt64{Int64: id, Valid: true}, false, nil
+}
+
+// resolveDanglingLinks points unresolved title links at a note that now
+// carries their title
+func resolveDanglingLinks(tx *sql.Tx, userID, noteID int, title string) error {
+	query := `UPDATE note_links SET target_note_id = ?
+              WHERE user_id = ? AND target_note_id IS NULL AND by_id = FALSE AND target_title = ?`
+	if _, err := tx.Exec(query, noteID, userID, title); err != nil {
+		return fmt.Errorf("failed to resolve note links: %w", err)
+	}
+	return nil
+}
+
+// renameNoteLinks rewrites [[oldTitle]] to [[newTitle]] in the notes linking
+// to noteID by title, so the links keep working after a rename. The rewritten
+// notes get a new version and a change log entry like any other edit, and
+// their IDs are returned.
+func renameNoteLinks(tx *sql.Tx, userID, noteID int, oldTitle, newTitle string) ([]int, error) {
+	if !wikilink.Linkable(newTitle) {
+		return nil, nil // links stay resolved by ID until their notes are edited
+	}
+
+	query := `SELECT DISTINCT source_note_id FROM note_links
+              WHERE target_note_id = ? AND by_id = FALSE AND source_note_id <> ?`
+	rows, err := tx.Query(query, noteID, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linking notes: %w", err)
+	}
+
+	var sourceIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan linking note: %w", err)
+		}
+		sourceIDs = append(sourceIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate linking notes: %w", err)
+	}
+
+	var rewritten []int
+	for _, sourceID := range sourceIDs {
+		var content string
+		var version int
+		err := tx.QueryRow(`SELECT content, version FROM notes WHERE id = ? FOR UPDATE`, sourceID).Scan(&content, &version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock linking note: %w", err)
+		}
+
+		renamed := wikilink.Rename(content, oldTitle, newTitle)
+		if renamed == content {
+			continue
+		}
+
+		if _, err := tx.Exec(`UPDATE notes SET content = ?, version = version + 1 WHERE id = ?`, renamed, sourceID); err != nil {
+			return nil, fmt.Errorf("failed to rewrite note links: %w", err)
+		}
+		if err := recordNoteChange(tx, userID, sourceID, models.NoteChangeUpsert, version+1); err != nil {
+			return nil, err
+		}
+		if err := syncNoteTasks(tx, userID, sourceID, renamed); err != nil {
+			return nil, err
+		}
+		rewritten = append(rewritten, sourceID)
+	}
+
+	query = `UPDATE note_links SET target_title = ? WHERE target_note_id = ? AND by_id = FALSE`
+	if _, err := tx.Exec(query, newTitle, noteID); err != nil {
+		return nil, fmt.Errorf("failed to rename note links: %w", err)
+	}
+	return rewritten, nil
+}
+
+// ReindexLinks rebuilds note_links from note content, for notes written
+// before links were indexed. Each note is indexed in its own transaction
+// with its row locked, so concurrent edits are not overwritten.
+func (r *noteRepository) ReindexLinks() (int, error) {
+	rows, err := r.db.Query(`SELECT id FROM notes ORDER BY id`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get notes: %w", err)
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan note: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to iterate notes: %w", err)
+	}
+
+	indexed := 0
+	for _, id := range ids {
+		ok, err := r.reindexNoteLinks(id)
+		if err != nil {
+			return indexed, err
+		}
+		if ok {
+			indexed++
+		}
+	}
+	return indexed, nil
+}
+
+func (r *noteRepository) reindexNoteLinks(id int) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int
+	var content string
+	err = tx.QueryRow(`SELECT user_id, content FROM notes WHERE id = ? FOR UPDATE`, id).Scan(&userID, &content)
+	if err == sql.ErrNoRows {
+		return false, nil // deleted meanwhile
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to lock note: %w", err)
+	}
+
+	if err := syncNoteLinks(tx, userID, id, content); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit note links: %w", err)
+	}
+	return true, nil
+}
+
+func (r *noteRepository) GetLinks(noteID, userID int) ([]*models.NoteLink, error) {
+	return r.queryLinks(`l.source_note_id = ?`, noteID, userID)
+}
+
+func (r *noteRepository) GetBacklinks(noteID, userID int) ([]*models.NoteLink, error) {
+	return r.queryLinks(`l.target_note_id = ?`, noteID, userID)
+}
+
+func (r *noteRepository) queryLinks(condition string, noteID, userID int) ([]*models.NoteLink, error) {
+	query := `SELECT l.source_note_id, s.title, l.target_note_id, COALESCE(t.title, l.target_title)
+              FROM note_links l
+              JOIN notes s ON s.id = l.source_note_id
+              LEFT JOIN notes t ON t.id = l.target_note_id
+              WHERE ` + condition + ` AND l.user_id = ?
+              ORDER BY l.id`
+
+	rows, err := r.db.Query(query, noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []*models.NoteLink{}
+	for rows.Next() {
+		link := &models.NoteLink{}
+		var targetID sql.NullInt64
+		if err := rows.Scan(&link.SourceNoteID, &link.SourceTitle, &targetID, &link.TargetTitle); err != nil {
+			return nil, fmt.Errorf("failed to scan note link: %w", err)
+		}
+		if targetID.Valid {
+			id := int(targetID.Int64)
+			link.TargetNoteID = &id
+		}
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate note links: %w", err)
+	}
+
+	return links, nil
+}
+
+func (r *noteRepository) GetGraph(userID int) (*models.NoteGraph, error) {
+	graph := &models.NoteGraph{Nodes: []models.NoteGraphNode{}, Edges: []models.NoteGraphEdge{}}
+
+	rows, err := r.db.Query(`SELECT id, title, category FROM notes WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get graph notes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var node models.NoteGraphNode
+		if err := rows.Scan(&node.ID, &node.Title, &node.Category); err != nil {
+			return nil, fmt.Errorf("failed to scan graph note: %w", err)
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate graph notes: %w", err)
+	}
+
+	query := `SELECT DISTINCT source_note_id, target_note_id FROM note_links
+              WHERE user_id = ? AND target_note_id IS NOT NULL
+              ORDER BY source_note_id, target_note_id`
+	edgeRows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get graph links: %w", err)
+	}
+	defer edgeRows.Close()
+
+	for edgeRows.Next() {
+		var edge models.NoteGraphEdge
+		if err := edgeRows.Scan(&edge.Source, &edge.Target); err != nil {
+			return nil, fmt.Errorf("failed to scan graph link: %w", err)
+		}
+		graph.Edges = append(graph.Edges, edge)
+	}
+	if err := edgeRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate graph links: %w", err)
+	}
+
+	return graph, nil
+}
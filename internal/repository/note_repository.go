@@ -10,11 +10,34 @@ import (
 
 type NoteRepository interface {
 	Create(userID int, note *models.CreateNoteRequest) (*models.Note, error)
+	// CreateForClient creates a note tagged with an offline client's ID. When
+	// the user already has a note with clientID it returns that note and
+	// false instead.
+	CreateForClient(userID int, clientID string, note *models.CreateNoteRequest) (*models.Note, bool, error)
 	GetByID(id, userID int) (*models.Note, error)
+	// GetByTitle returns the oldest note with title in category
+	GetByTitle(userID int, category, title string) (*models.Note, error)
 	GetAll(userID int, filter *models.NotesFilter) ([]*models.Note, int, error)
-	Update(id, userID int, note *models.UpdateNoteRequest) (*models.Note, error)
+	// Update also returns the notes whose [[links]] to the note were
+	// rewritten because its title changed
+	Update(id, userID int, note *models.UpdateNoteRequest) (*models.Note, []*models.Note, error)
 	Delete(id, userID int) error
 	GetCategories(userID int) ([]string, error)
+
+	// Sync support: the change log and version-checked writes
+	GetChangesSince(userID int, since int64, limit int) ([]*models.NoteChange, error)
+	GetLatestChangeSeq(userID int) (int64, error)
+	GetByIDs(userID int, ids []int) ([]*models.Note, error)
+	UpdateIfVersion(id, userID, baseVersion int, req *models.UpdateNoteRequest) (*models.Note, []*models.Note, error)
+	DeleteIfVersion(id, userID, baseVersion int) error
+
+	// [[Wiki links]] parsed from note content
+	GetLinks(noteID, userID int) ([]*models.NoteLink, error)
+	GetBacklinks(noteID, userID int) ([]*models.NoteLink, error)
+	GetGraph(userID int) (*models.NoteGraph, error)
+	// ReindexLinks rebuilds the stored links of every note from its content
+	// and returns the number of notes indexed
+	ReindexLinks() (int, error)
 }
 
 type noteRepository struct {
@@ -26,22 +49,74 @@ func NewNoteRepository(db *sql.DB) NoteRepository {
 }
 
 func (r *noteRepository) Create(userID int, req *models.CreateNoteRequest) (*models.Note, error) {
-	query := `INSERT INTO notes (user_id, title, content, category) VALUES (?, ?, ?, ?)`
-	result, err := r.db.Exec(query, userID, req.Title, req.Content, req.Category)
+	note, _, err := r.create(userID, sql.NullString{}, req)
+	return note, err
+}
+
+func (r *noteRepository) CreateForClient(userID int, clientID string, req *models.CreateNoteRequest) (*models.Note, bool, error) {
+	return r.create(userID, sql.NullString{String: clientID, Valid: true}, req)
+}
+
+func (r *noteRepository) create(userID int, clientID sql.NullString, req *models.CreateNoteRequest) (*models.Note, bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The change counter lock also serializes retried pushes, so the
+	// client ID lookup cannot race with the insert
+	if err := lockNoteChanges(tx, userID); err != nil {
+		return nil, false, err
+	}
+
+	if clientID.Valid {
+		var existingID int
+		err := tx.QueryRow(`SELECT id FROM notes WHERE user_id = ? AND client_id = ?`, userID, clientID).Scan(&existingID)
+		if err == nil {
+			note, err := r.GetByID(existingID, userID)
+			return note, false, err
+		}
+		if err != sql.ErrNoRows {
+			return nil, false, fmt.Errorf("failed to check client id: %w", err)
+		}
+	}
+
+	query := `INSERT INTO notes (user_id, client_id, title, content, category) VALUES (?, ?, ?, ?, ?)`
+	result, err := tx.Exec(query, userID, clientID, req.Title, req.Content, req.Category)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create note: %w", err)
+		return nil, false, fmt.Errorf("failed to create note: %w", err)
 	}
 
 	id, err := result.LastInsertId()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+		return nil, false, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	if err := recordNoteChange(tx, userID, int(id), models.NoteChangeUpsert, 1); err != nil {
+		return nil, false, err
+	}
+
+	if err := resolveDanglingLinks(tx, userID, int(id), req.Title); err != nil {
+		return nil, false, err
+	}
+	if err := syncNoteLinks(tx, userID, int(id), req.Content); err != nil {
+		return nil, false, err
+	}
+	if err := syncNoteTasks(tx, userID, int(id), req.Content); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit note: %w", err)
 	}
 
-	return r.GetByID(int(id), userID)
+	note, err := r.GetByID(int(id), userID)
+	return note, true, err
 }
 
 func (r *noteRepository) GetByID(id, userID int) (*models.Note, error) {
-	query := `SELECT n.id, n.user_id, n.title, n.content, n.category, n.created_at, n.updated_at,
+	query := `SELECT n.id, n.user_id, n.title, n.content, n.category, n.version, n.created_at, n.updated_at,
                      u.id, u.username, u.email, u.full_name, u.created_at
               FROM notes n
               LEFT JOIN users u ON n.user_id = u.id
@@ -51,7 +126,7 @@ func (r *noteRepository) GetByID(id, userID int) (*models.Note, error) {
 	user := &models.UserResponse{}
 
 	err := r.db.QueryRow(query, id, userID).Scan(
-		&note.ID, &note.UserID, &note.Title, &note.Content, &note.Category,
+		&note.ID, &note.UserID, &note.Title, &note.Content, &note.Category, &note.Version,
 		&note.CreatedAt, &note.UpdatedAt,
 		&user.ID, &user.Username, &user.Email, &user.FullName, &user.CreatedAt,
 	)
@@ -66,6 +141,19 @@ func (r *noteRepository) GetByID(id, userID int) (*models.Note, error) {
 	return note, nil
 }
 
+func (r *noteRepository) GetByTitle(userID int, category, title string) (*models.Note, error) {
+	var id int
+	query := `SELECT id FROM notes WHERE user_id = ? AND category = ? AND title = ? ORDER BY id LIMIT 1`
+	if err := r.db.QueryRow(query, userID, category, title).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+
+	return r.GetByID(id, userID)
+}
+
 func (r *noteRepository) GetAll(userID int, filter *models.NotesFilter) ([]*models.Note, int, error) {
 	var conditions []string
 	var args []interface{}
@@ -93,7 +181,7 @@ func (r *noteRepository) GetAll(userID int, filter *models.NotesFilter) ([]*mode
 
 	// Get notes with pagination
 	offset := (filter.Page - 1) * filter.Limit
-	selectQuery := `SELECT n.id, n.user_id, n.title, n.content, n.category, n.created_at, n.updated_at,
+	selectQuery := `SELECT n.id, n.user_id, n.title, n.content, n.category, n.version, n.created_at, n.updated_at,
                            u.id, u.username, u.email, u.full_name, u.created_at ` +
 		baseQuery + whereClause +
 		` ORDER BY n.created_at DESC LIMIT ? OFFSET ?`
@@ -111,7 +199,7 @@ func (r *noteRepository) GetAll(userID int, filter *models.NotesFilter) ([]*mode
 		user := &models.UserResponse{}
 
 		err := rows.Scan(
-			&note.ID, &note.UserID, &note.Title, &note.Content, &note.Category,
+			&note.ID, &note.UserID, &note.Title, &note.Content, &note.Category, &note.Version,
 			&note.CreatedAt, &note.UpdatedAt,
 			&user.ID, &user.Username, &user.Email, &user.FullName, &user.CreatedAt,
 		)
@@ -126,42 +214,12 @@ func (r *noteRepository) GetAll(userID int, filter *models.NotesFilter) ([]*mode
 	return notes, total, nil
 }
 
-func (r *noteRepository) Update(id, userID int, req *models.UpdateNoteRequest) (*models.Note, error) {
-	query := `UPDATE notes SET title = ?, content = ?, category = ? WHERE id = ? AND user_id = ?`
-	result, err := r.db.Exec(query, req.Title, req.Content, req.Category, id, userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update note: %w", err)
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return nil, nil // Note not found or not owned by user
-	}
-
-	return r.GetByID(id, userID)
+func (r *noteRepository) Update(id, userID int, req *models.UpdateNoteRequest) (*models.Note, []*models.Note, error) {
+	return r.update(id, userID, 0, req)
 }
 
 func (r *noteRepository) Delete(id, userID int) error {
-	query := `DELETE FROM notes WHERE id = ? AND user_id = ?`
-	result, err := r.db.Exec(query, id, userID)
-	if err != nil {
-		return fmt.Errorf("failed to delete note: %w", err)
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("note not found or not owned by user")
-	}
-
-	return nil
+	return r.delete(id, userID, 0)
 }
 
 func (r *noteRepository) GetCategories(userID int) ([]string, error) {
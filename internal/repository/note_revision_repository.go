@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"daily-notes-api/internal/models"
+)
+
+type NoteRevisionRepository interface {
+	Create(revision *models.NoteRevision) error
+	// GetByNoteID returns the newest revisions of a note first
+	GetByNoteID(noteID, userID, limit int) ([]*models.NoteRevision, error)
+}
+
+type noteRevisionRepository struct {
+	db *sql.DB
+}
+
+func NewNoteRevisionRepository(db *sql.DB) NoteRevisionRepository {
+	return &noteRevisionRepository{db: db}
+}
+
+func (r *noteRevisionRepository) Create(revision *models.NoteRevision) error {
+	query := `INSERT INTO note_revisions (note_id, user_id, version, revision, content) VALUES (?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, revision.NoteID, revision.UserID, revision.Version, revision.Revision, revision.Content)
+	if err != nil {
+		return fmt.Errorf("failed to create note revision: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	revision.ID = int(id)
+
+	return nil
+}
+
+func (r *noteRevisionRepository) GetByNoteID(noteID, userID, limit int) ([]*models.NoteRevision, error) {
+	query := `SELECT id, note_id, user_id, version, revision, content, created_at
+              FROM note_revisions WHERE note_id = ? AND user_id = ?
+              ORDER BY id DESC LIMIT ?`
+
+	rows, err := r.db.Query(query, noteID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []*models.NoteRevision
+	for rows.Next() {
+		revision := &models.NoteRevision{}
+		err := rows.Scan(&revision.ID, &revision.NoteID, &revision.UserID, &revision.Version,
+			&revision.Revision, &revision.Content, &revision.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan note revision: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate note revisions: %w", err)
+	}
+
+	return revisions, nil
+}
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"daily-notes-api/internal/models"
+)
+
+// ErrVersionConflict is returned when a note changed since the version the
+// caller based its edit on
+var ErrVersionConflict = errors.New("note version conflict")
+
+// lockNoteChanges locks the user's change log counter until tx ends. Every
+// transaction that writes notes takes it first, so a user's writes commit in
+// sequence order and a sync reader cannot skip a change that commits late.
+// Taking it before any note row also keeps the lock order the same
+// everywhere.
+func lockNoteChanges(tx *sql.Tx, userID int) error {
+	query := `INSERT INTO note_change_counters (user_id, seq) VALUES (?, 0)
+              ON DUPLICATE KEY UPDATE seq = seq`
+	if _, err := tx.Exec(query, userID); err != nil {
+		return fmt.Errorf("failed to create note change counter: %w", err)
+	}
+
+	var seq int64
+	if err := tx.QueryRow(`SELECT seq FROM note_change_counters WHERE user_id = ? FOR UPDATE`, userID).Scan(&seq); err != nil {
+		return fmt.Errorf("failed to lock note change counter: %w", err)
+	}
+	return nil
+}
+
+// recordNoteChange appends to the change log read by the sync API. It must
+// run in the transaction that changed the note, after lockNoteChanges, so the
+// log cannot miss it.
+func recordNoteChange(tx execer, userID, noteID int, op string, version int) error {
+	if _, err := tx.Exec(`UPDATE note_change_counters SET seq = seq + 1 WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to advance note change counter: %w", err)
+	}
+
+	query := `INSERT INTO note_changes (user_id, seq, note_id, op, version)
+              SELECT user_id, seq, ?, ?, ? FROM note_change_counters WHERE user_id = ?`
+	result, err := tx.Exec(query, noteID, op, version, userID)
+	if err != nil {
+		return fmt.Errorf("failed to record note change: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected != 1 {
+		return fmt.Errorf("failed to record note change: no change counter for user %d", userID)
+	}
+	return nil
+}
+
+// update changes a note and bumps its version. A non-zero baseVersion must
+// match the stored version, otherwise ErrVersionConflict is returned. It
+// also returns the notes rewritten by renameNoteLinks.
+func (r *noteRepository) update(id, userID, baseVersion int, req *models.UpdateNoteRequest) (*models.Note, []*models.Note, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockNoteChanges(tx, userID); err != nil {
+		return nil, nil, err
+	}
+
+	version, err := lockNoteVersion(tx, id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version == 0 {
+		return nil, nil, nil // Note not found or not owned by user
+	}
+	if baseVersion != 0 && baseVersion != version {
+		return nil, nil, ErrVersionConflict
+	}
+
+	var oldTitle string
+	if err := tx.QueryRow(`SELECT title FROM notes WHERE id = ?`, id).Scan(&oldTitle); err != nil {
+		return nil, nil, fmt.Errorf("failed to get note title: %w", err)
+	}
+
+	query := `UPDATE notes SET title = ?, content = ?, category = ?, version = version + 1 WHERE id = ? AND user_id = ?`
+	if _, err := tx.Exec(query, req.Title, req.Content, req.Category, id, userID); err != nil {
+		return nil, nil, fmt.Errorf("failed to update note: %w", err)
+	}
+
+	if err := recordNoteChange(tx, userID, id, models.NoteChangeUpsert, version+1); err != nil {
+		return nil, nil, err
+	}
+
+	var rewrittenIDs []int
+	if oldTitle != req.Title {
+		if rewrittenIDs, err = renameNoteLinks(tx, userID, id, oldTitle, req.Title); err != nil {
+			return nil, nil, err
+		}
+		if err := resolveDanglingLinks(tx, userID, id, req.Title); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := syncNoteLinks(tx, userID, id, req.Content); err != nil {
+		return nil, nil, err
+	}
+	if err := syncNoteTasks(tx, userID, id, req.Content); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit note: %w", err)
+	}
+
+	note, err := r.GetByID(id, userID)
+	if err != nil || len(rewrittenIDs) == 0 {
+		return note, nil, err
+	}
+	rewritten, err := r.GetByIDs(userID, rewrittenIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return note, rewritten, nil
+}
+
+// delete removes a note and leaves a tombstone in the change log. A non-zero
+// baseVersion must match the stored version.
+func (r *noteRepository) delete(id, userID, baseVersion int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockNoteChanges(tx, userID); err != nil {
+		return err
+	}
+
+	version, err := lockNoteVersion(tx, id, userID)
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		return fmt.Errorf("note not found or not owned by user")
+	}
+	if baseVersion != 0 && baseVersion != version {
+		return ErrVersionConflict
+	}
+
+	if _, err := tx.Exec(`DELETE FROM notes WHERE id = ? AND user_id = ?`, id, userID); err != nil {
+		return fmt.Errorf("failed to delete note: %w", err)
+	}
+
+	if err := recordNoteChange(tx, userID, id, models.NoteChangeDelete, version+1); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit note deletion: %w", err)
+	}
+
+	return nil
+}
+
+// lockNoteVersion locks the note row and returns its version, or 0 when the
+// user has no such note
+func lockNoteVersion(tx *sql.Tx, id, userID int) (int, error) {
+	var version int
+	err := tx.QueryRow(`SELECT version FROM notes WHERE id = ? AND user_id = ? FOR UPDATE`, id, userID).Scan(&version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to lock note: %w", err)
+	}
+	return version, nil
+}
+
+func (r *noteRepository) UpdateIfVersion(id, userID, baseVersion int, req *models.UpdateNoteRequest) (*models.Note, []*models.Note, error) {
+	return r.update(id, userID, baseVersion, req)
+}
+
+func (r *noteRepository) DeleteIfVersion(id, userID, baseVersion int) error {
+	return r.delete(id, userID, baseVersion)
+}
+
+func (r *noteRepository) GetChangesSince(userID int, since int64, limit int) ([]*models.NoteChange, error) {
+	query := `SELECT c.seq, c.note_id, c.op
+              FROM note_changes c
+              JOIN (SELECT user_id, note_id, MAX(seq) AS seq FROM note_changes
+                    WHERE user_id = ? AND seq > ? GROUP BY user_id, note_id) latest
+                ON latest.user_id = c.user_id AND latest.seq = c.seq
+              ORDER BY c.seq
+              LIMIT ?`
+
+	rows, err := r.db.Query(query, userID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*models.NoteChange
+	for rows.Next() {
+		change := &models.NoteChange{}
+		if err := rows.Scan(&change.Seq, &change.NoteID, &change.Op); err != nil {
+			return nil, fmt.Errorf("failed to scan note change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+func (r *noteRepository) GetLatestChangeSeq(userID int) (int64, error) {
+	var seq sql.NullInt64
+	if err := r.db.QueryRow(`SELECT MAX(seq) FROM note_changes WHERE user_id = ?`, userID).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("failed to get latest change: %w", err)
+	}
+	return seq.Int64, nil
+}
+
+func (r *noteRepository) GetByIDs(userID int, ids []int) ([]*models.Note, error) {
+	if len(ids) == 0 {
+		return []*models.Note{}, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := []interface{}{userID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	query := `SELECT id, user_id, title, content, category, version, created_at, updated_at
+              FROM notes WHERE user_id = ? AND id IN (` + placeholders + `)`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := make([]*models.Note, 0, len(ids))
+	for rows.Next() {
+		note := &models.Note{}
+		err := rows.Scan(&note.ID, &note.UserID, &note.Title, &note.Content, &note.Category, &note.Version,
+			&note.CreatedAt, &note.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"daily-notes-api/internal/models"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// openTestDB connects to the migrated database in TEST_DATABASE_DSN, for
+// example root@tcp(localhost:3306)/daily_notes_test?parseTime=true&loc=UTC.
+// Tests that need it are skipped when it is not set.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// createTestUser inserts a user that is deleted, with everything it owns,
+// when the test ends
+func createTestUser(t *testing.T, db *sql.DB, name string) int {
+	t.Helper()
+	username := fmt.Sprintf("%s%d", name, time.Now().UnixNano()%1e9)
+	result, err := db.Exec(`INSERT INTO users (username, email, password_hash, full_name) VALUES (?, ?, '', ?)`,
+		username, username+"@example.com", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM notes WHERE user_id = ?`, id)
+		db.Exec(`DELETE FROM users WHERE id = ?`, id)
+	})
+	return int(id)
+}
+
+func TestGetChangesSinceOnlyReturnsOwnChanges(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewNoteRepository(db)
+
+	// Both users' change logs start at seq 1, so their sequence numbers
+	// overlap
+	ann := createTestUser(t, db, "ann")
+	bob := createTestUser(t, db, "bob")
+
+	notes := map[int][]int{}
+	for _, userID := range []int{ann, bob, ann, bob, bob} {
+		note, err := repo.Create(userID, &models.CreateNoteRequest{Title: "Note", Content: "Content"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		notes[userID] = append(notes[userID], note.ID)
+	}
+
+	tests := []struct {
+		name    string
+		userID  int
+		since   int64
+		limit   int
+		wantIDs []int
+	}{
+		{"ann from the start", ann, 0, 10, notes[ann]},
+		{"bob from the start", bob, 0, 10, notes[bob]},
+		{"bob after his first change", bob, 1, 10, notes[bob][1:]},
+		{"limit counts only own changes", ann, 0, 1, notes[ann][:1]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := repo.GetChangesSince(tt.userID, tt.since, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []int
+			for _, change := range changes {
+				got = append(got, change.NoteID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("note IDs = %v, want %v", got, tt.wantIDs)
+			}
+		})
+	}
+}
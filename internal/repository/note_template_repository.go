@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"daily-notes-api/internal/models"
+)
+
+type NoteTemplateRepository interface {
+	Create(userID int, req *models.CreateNoteTemplateRequest) (*models.NoteTemplate, error)
+	GetByID(id, userID int) (*models.NoteTemplate, error)
+	GetAll(userID int) ([]*models.NoteTemplate, error)
+	Update(id, userID int, req *models.UpdateNoteTemplateRequest) (*models.NoteTemplate, error)
+	Delete(id, userID int) error
+}
+
+type noteTemplateRepository struct {
+	db *sql.DB
+}
+
+func NewNoteTemplateRepository(db *sql.DB) NoteTemplateRepository {
+	return &noteTemplateRepository{db: db}
+}
+
+func (r *noteTemplateRepository) Create(userID int, req *models.CreateNoteTemplateRequest) (*models.NoteTemplate, error) {
+	prompts, err := json.Marshal(req.Prompts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode prompts: %w", err)
+	}
+
+	query := `INSERT INTO note_templates (user_id, name, description, title_template, body_template, category, prompts)
+              VALUES (?, ?, ?, ?, ?, ?, ?)`
+	result, err := r.db.Exec(query, userID, req.Name, req.Description, req.TitleTemplate, req.BodyTemplate, req.Category, string(prompts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create note template: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return r.GetByID(int(id), userID)
+}
+
+func (r *noteTemplateRepository) GetByID(id, userID int) (*models.NoteTemplate, error) {
+	query := `SELECT id, user_id, name, description, title_template, body_template, category, prompts, created_at, updated_at
+              FROM note_templates WHERE id = ? AND user_id = ?`
+
+	tmpl, err := scanNoteTemplate(r.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get note template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+func (r *noteTemplateRepository) GetAll(userID int) ([]*models.NoteTemplate, error) {
+	query := `SELECT id, user_id, name, description, title_template, body_template, category, prompts, created_at, updated_at
+              FROM note_templates WHERE user_id = ? ORDER BY name`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []*models.NoteTemplate
+	for rows.Next() {
+		tmpl, err := scanNoteTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan note template: %w", err)
+		}
+		templates = append(templates, tmpl)
+	}
+
+	return templates, nil
+}
+
+func (r *noteTemplateRepository) Update(id, userID int, req *models.UpdateNoteTemplateRequest) (*models.NoteTemplate, error) {
+	prompts, err := json.Marshal(req.Prompts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode prompts: %w", err)
+	}
+
+	existing, err := r.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, nil
+	}
+
+	query := `UPDATE note_templates SET name = ?, description = ?, title_template = ?, body_template = ?, category = ?, prompts = ?
+              WHERE id = ? AND user_id = ?`
+	_, err = r.db.Exec(query, req.Name, req.Description, req.TitleTemplate, req.BodyTemplate, req.Category, string(prompts), id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update note template: %w", err)
+	}
+
+	return r.GetByID(id, userID)
+}
+
+func (r *noteTemplateRepository) Delete(id, userID int) error {
+	query := `DELETE FROM note_templates WHERE id = ? AND user_id = ?`
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete note template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("note template not found or not owned by user")
+	}
+
+	return nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanNoteTemplate(row rowScanner) (*models.NoteTemplate, error) {
+	tmpl := &models.NoteTemplate{}
+	var prompts sql.NullString
+
+	err := row.Scan(
+		&tmpl.ID, &tmpl.UserID, &tmpl.Name, &tmpl.Description, &tmpl.TitleTemplate,
+		&tmpl.BodyTemplate, &tmpl.Category, &prompts, &tmpl.CreatedAt, &tmpl.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl.Prompts = []string{}
+	if prompts.Valid && prompts.String != "" {
+		if err := json.Unmarshal([]byte(prompts.String), &tmpl.Prompts); err != nil {
+			return nil, fmt.Errorf("failed to decode prompts: %w", err)
+		}
+	}
+
+	return tmpl, nil
+}
@@ -0,0 +1,445 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/pkg/auth"
+)
+
+type OAuthRepository interface {
+	// CreateClient registers a client and returns its secret in plain text
+	// once; only the hash is stored
+	CreateClient(userID int, req *models.CreateOAuthClientRequest) (*models.OAuthClient, error)
+	GetClients(userID int) ([]*models.OAuthClient, error)
+	GetClientByClientID(clientID string) (*models.OAuthClient, error)
+	DeleteClient(id, userID int) error
+
+	// CreateAuthorizationCode stores a code and returns it in plain text
+	CreateAuthorizationCode(code *models.OAuthAuthorizationCode) (string, error)
+	// TakeAuthorizationCode returns and deletes a code so it can only be
+	// exchanged once; expired codes are not returned
+	TakeAuthorizationCode(code string) (*models.OAuthAuthorizationCode, error)
+
+	// CreateGrant records consent and its first refresh token
+	CreateGrant(clientID, userID int, scopes []string, refreshExpiresAt time.Time) (*models.OAuthGrant, string, error)
+	// RotateRefreshToken exchanges a refresh token for a new one. Presenting
+	// a token that was already rotated revokes the grant, since it means the
+	// token leaked.
+	RotateRefreshToken(token string, clientID int, refreshExpiresAt time.Time) (*models.OAuthGrant, string, error)
+	// GetGrantByRefreshToken returns the active grant of an unused refresh
+	// token
+	GetGrantByRefreshToken(token string) (*models.OAuthGrant, error)
+	GetActiveGrant(id, userID int) (*models.OAuthGrant, error)
+	GetActiveGrants(userID int) ([]*models.OAuthGrant, error)
+	TouchGrant(id int, usedAt time.Time) error
+	RevokeGrant(id, userID int) (bool, error)
+	RevokeClientGrant(id, clientID int) error
+}
+
+type oauthRepository struct {
+	db *sql.DB
+}
+
+func NewOAuthRepository(db *sql.DB) OAuthRepository {
+	return &oauthRepository{db: db}
+}
+
+const oauthClientColumns = `id, user_id, client_id, name, redirect_uris, confidential, secret_hash, created_at, updated_at`
+
+const oauthGrantColumns = `g.id, g.client_id, c.name, g.user_id, g.scopes, g.last_used_at, g.revoked_at, g.created_at`
+
+// oauthCodeTTL is how long a client has to exchange an authorization code
+const oauthCodeTTL = 5 * time.Minute
+
+func scanOAuthClient(row rowScanner) (*models.OAuthClient, error) {
+	client := &models.OAuthClient{}
+	var redirectURIs string
+	err := row.Scan(&client.ID, &client.UserID, &client.ClientID, &client.Name, &redirectURIs, &client.Confidential,
+		&client.SecretHash, &client.CreatedAt, &client.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(redirectURIs), &client.RedirectURIs); err != nil {
+		return nil, fmt.Errorf("invalid redirect uris: %w", err)
+	}
+	return client, nil
+}
+
+func scanOAuthGrant(row rowScanner) (*models.OAuthGrant, error) {
+	grant := &models.OAuthGrant{}
+	var scopes string
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&grant.ID, &grant.ClientID, &grant.ClientName, &grant.UserID, &scopes, &lastUsedAt, &revokedAt,
+		&grant.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	grant.Scopes = splitEvents(scopes)
+	if lastUsedAt.Valid {
+		grant.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		grant.RevokedAt = &revokedAt.Time
+	}
+	return grant, nil
+}
+
+func (r *oauthRepository) CreateClient(userID int, req *models.CreateOAuthClientRequest) (*models.OAuthClient, error) {
+	clientID, err := generateToken(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate client id: %w", err)
+	}
+
+	var secret, secretHash string
+	if req.Confidential {
+		secret, err = generateToken(32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate client secret: %w", err)
+		}
+		secret = "cs_" + secret
+		secretHash = auth.HashToken(secret)
+	}
+
+	// Redirect URIs may contain commas, so they are stored as JSON
+	redirectURIs, err := json.Marshal(req.RedirectURIs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode redirect uris: %w", err)
+	}
+
+	query := `INSERT INTO oauth_clients (user_id, client_id, name, redirect_uris, confidential, secret_hash)
+              VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := r.db.Exec(query, userID, clientID, req.Name, string(redirectURIs), req.Confidential, secretHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create oauth client: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	client, err := scanOAuthClient(r.db.QueryRow(`SELECT `+oauthClientColumns+` FROM oauth_clients WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oauth client: %w", err)
+	}
+	client.ClientSecret = secret
+
+	return client, nil
+}
+
+func (r *oauthRepository) GetClients(userID int) ([]*models.OAuthClient, error) {
+	query := `SELECT ` + oauthClientColumns + ` FROM oauth_clients WHERE user_id = ? ORDER BY id`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oauth clients: %w", err)
+	}
+	defer rows.Close()
+
+	clients := make([]*models.OAuthClient, 0)
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan oauth client: %w", err)
+		}
+		clients = append(clients, client)
+	}
+
+	return clients, nil
+}
+
+func (r *oauthRepository) GetClientByClientID(clientID string) (*models.OAuthClient, error) {
+	query := `SELECT ` + oauthClientColumns + ` FROM oauth_clients WHERE client_id = ?`
+
+	client, err := scanOAuthClient(r.db.QueryRow(query, clientID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get oauth client: %w", err)
+	}
+
+	return client, nil
+}
+
+func (r *oauthRepository) DeleteClient(id, userID int) error {
+	query := `DELETE FROM oauth_clients WHERE id = ? AND user_id = ?`
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete oauth client: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("oauth client not found or not owned by user")
+	}
+
+	return nil
+}
+
+func (r *oauthRepository) CreateAuthorizationCode(code *models.OAuthAuthorizationCode) (string, error) {
+	plain, err := generateToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate authorization code: %w", err)
+	}
+
+	now := time.Now()
+	if _, err := r.db.Exec(`DELETE FROM oauth_authorization_codes WHERE expires_at < ?`, now); err != nil {
+		return "", fmt.Errorf("failed to prune authorization codes: %w", err)
+	}
+
+	query := `INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at)
+              VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err = r.db.Exec(query, auth.HashToken(plain), code.ClientID, code.UserID, code.RedirectURI,
+		joinEvents(code.Scopes), code.CodeChallenge, now.Add(oauthCodeTTL))
+	if err != nil {
+		return "", fmt.Errorf("failed to create authorization code: %w", err)
+	}
+
+	return plain, nil
+}
+
+func (r *oauthRepository) TakeAuthorizationCode(plain string) (*models.OAuthAuthorizationCode, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hash := auth.HashToken(plain)
+	query := `SELECT client_id, user_id, redirect_uri, scopes, code_challenge, expires_at
+              FROM oauth_authorization_codes WHERE code_hash = ? FOR UPDATE`
+	code := &models.OAuthAuthorizationCode{}
+	var scopes string
+	err = tx.QueryRow(query, hash).Scan(&code.ClientID, &code.UserID, &code.RedirectURI, &scopes,
+		&code.CodeChallenge, &code.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get authorization code: %w", err)
+	}
+	code.Scopes = splitEvents(scopes)
+
+	if _, err := tx.Exec(`DELETE FROM oauth_authorization_codes WHERE code_hash = ?`, hash); err != nil {
+		return nil, fmt.Errorf("failed to delete authorization code: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit authorization code: %w", err)
+	}
+
+	if !code.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	return code, nil
+}
+
+func (r *oauthRepository) CreateGrant(clientID, userID int, scopes []string, refreshExpiresAt time.Time) (*models.OAuthGrant, string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `INSERT INTO oauth_grants (client_id, user_id, scopes, last_used_at) VALUES (?, ?, ?, ?)`
+	result, err := tx.Exec(query, clientID, userID, joinEvents(scopes), now)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create grant: %w", err)
+	}
+
+	grantID, err := result.LastInsertId()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	refreshToken, err := insertRefreshToken(tx, int(grantID), refreshExpiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	grant, err := scanOAuthGrant(tx.QueryRow(`SELECT `+oauthGrantColumns+` FROM oauth_grants g
+              JOIN oauth_clients c ON c.id = g.client_id WHERE g.id = ?`, grantID))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get grant: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, "", fmt.Errorf("failed to commit grant: %w", err)
+	}
+
+	return grant, refreshToken, nil
+}
+
+func insertRefreshToken(tx *sql.Tx, grantID int, expiresAt time.Time) (string, error) {
+	plain, err := generateToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	plain = "rt_" + plain
+
+	query := `INSERT INTO oauth_refresh_tokens (grant_id, token_hash, expires_at) VALUES (?, ?, ?)`
+	if _, err := tx.Exec(query, grantID, auth.HashToken(plain), expiresAt); err != nil {
+		return "", fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return plain, nil
+}
+
+func (r *oauthRepository) RotateRefreshToken(plain string, clientID int, refreshExpiresAt time.Time) (*models.OAuthGrant, string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var tokenID, grantID int
+	var expiresAt time.Time
+	var usedAt sql.NullTime
+	query := `SELECT id, grant_id, expires_at, used_at FROM oauth_refresh_tokens WHERE token_hash = ? FOR UPDATE`
+	err = tx.QueryRow(query, auth.HashToken(plain)).Scan(&tokenID, &grantID, &expiresAt, &usedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	grant, err := scanOAuthGrant(tx.QueryRow(`SELECT `+oauthGrantColumns+` FROM oauth_grants g
+              JOIN oauth_clients c ON c.id = g.client_id WHERE g.id = ? FOR UPDATE`, grantID))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get grant: %w", err)
+	}
+	if grant.ClientID != clientID || grant.RevokedAt != nil {
+		return nil, "", nil
+	}
+
+	now := time.Now()
+	if usedAt.Valid {
+		if _, err := tx.Exec(`UPDATE oauth_grants SET revoked_at = ? WHERE id = ?`, now, grantID); err != nil {
+			return nil, "", fmt.Errorf("failed to revoke grant: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, "", fmt.Errorf("failed to commit grant revocation: %w", err)
+		}
+		return nil, "", nil
+	}
+	if !expiresAt.After(now) {
+		return nil, "", nil
+	}
+
+	if _, err := tx.Exec(`UPDATE oauth_refresh_tokens SET used_at = ? WHERE id = ?`, now, tokenID); err != nil {
+		return nil, "", fmt.Errorf("failed to use refresh token: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE oauth_grants SET last_used_at = ? WHERE id = ?`, now, grantID); err != nil {
+		return nil, "", fmt.Errorf("failed to update grant: %w", err)
+	}
+
+	refreshToken, err := insertRefreshToken(tx, grantID, refreshExpiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, "", fmt.Errorf("failed to commit refresh token: %w", err)
+	}
+
+	return grant, refreshToken, nil
+}
+
+func (r *oauthRepository) GetGrantByRefreshToken(plain string) (*models.OAuthGrant, error) {
+	query := `SELECT ` + oauthGrantColumns + ` FROM oauth_refresh_tokens t
+              JOIN oauth_grants g ON g.id = t.grant_id
+              JOIN oauth_clients c ON c.id = g.client_id
+              WHERE t.token_hash = ? AND t.used_at IS NULL AND t.expires_at > ? AND g.revoked_at IS NULL`
+
+	grant, err := scanOAuthGrant(r.db.QueryRow(query, auth.HashToken(plain), time.Now()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get grant: %w", err)
+	}
+
+	return grant, nil
+}
+
+func (r *oauthRepository) GetActiveGrant(id, userID int) (*models.OAuthGrant, error) {
+	query := `SELECT ` + oauthGrantColumns + ` FROM oauth_grants g
+              JOIN oauth_clients c ON c.id = g.client_id
+              WHERE g.id = ? AND g.user_id = ? AND g.revoked_at IS NULL`
+
+	grant, err := scanOAuthGrant(r.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get grant: %w", err)
+	}
+
+	return grant, nil
+}
+
+func (r *oauthRepository) GetActiveGrants(userID int) ([]*models.OAuthGrant, error) {
+	query := `SELECT ` + oauthGrantColumns + ` FROM oauth_grants g
+              JOIN oauth_clients c ON c.id = g.client_id
+              WHERE g.user_id = ? AND g.revoked_at IS NULL
+              ORDER BY g.created_at DESC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grants: %w", err)
+	}
+	defer rows.Close()
+
+	grants := make([]*models.OAuthGrant, 0)
+	for rows.Next() {
+		grant, err := scanOAuthGrant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan grant: %w", err)
+		}
+		grants = append(grants, grant)
+	}
+
+	return grants, nil
+}
+
+func (r *oauthRepository) TouchGrant(id int, usedAt time.Time) error {
+	query := `UPDATE oauth_grants SET last_used_at = ? WHERE id = ?`
+	if _, err := r.db.Exec(query, usedAt, id); err != nil {
+		return fmt.Errorf("failed to update grant: %w", err)
+	}
+	return nil
+}
+
+func (r *oauthRepository) RevokeGrant(id, userID int) (bool, error) {
+	query := `UPDATE oauth_grants SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`
+	result, err := r.db.Exec(query, time.Now(), id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke grant: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *oauthRepository) RevokeClientGrant(id, clientID int) error {
+	query := `UPDATE oauth_grants SET revoked_at = ? WHERE id = ? AND client_id = ? AND revoked_at IS NULL`
+	if _, err := r.db.Exec(query, time.Now(), id, clientID); err != nil {
+		return fmt.Errorf("failed to revoke grant: %w", err)
+	}
+	return nil
+}
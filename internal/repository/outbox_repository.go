@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"daily-notes-api/internal/models"
+)
+
+type OutboxRepository interface {
+	Enqueue(msg *models.OutboxMessage) error
+	GetAll(filter *models.OutboxFilter) ([]*models.OutboxMessage, int, error)
+	Requeue(id int) (*models.OutboxMessage, error)
+
+	// ClaimDue leases up to limit messages that are due for a delivery attempt
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]*models.OutboxMessage, error)
+	MarkSent(id int, sentAt time.Time) error
+	MarkFailed(id int, errMsg string, nextAttemptAt time.Time) error
+	MarkDead(id int, errMsg string) error
+}
+
+type outboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx, so outbox rows can be written
+// inside another repository's transaction
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// enqueueOutboxMessage inserts msg using the given connection or transaction
+func enqueueOutboxMessage(db execer, msg *models.OutboxMessage) error {
+	nextAttemptAt := msg.NextAttemptAt
+	if nextAttemptAt.IsZero() {
+		nextAttemptAt = time.Now()
+	}
+
+	query := `INSERT INTO email_outbox (kind, recipient, subject, html_body, text_body, list_unsubscribe, status, next_attempt_at)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := db.Exec(query, msg.Kind, msg.Recipient, msg.Subject, msg.HTMLBody, msg.TextBody, msg.ListUnsubscribe,
+		models.OutboxStatusPending, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	return nil
+}
+
+const outboxColumns = `id, kind, recipient, subject, html_body, text_body, list_unsubscribe, status, attempts, last_error,
+                       next_attempt_at, sent_at, created_at, updated_at`
+
+func (r *outboxRepository) Enqueue(msg *models.OutboxMessage) error {
+	return enqueueOutboxMessage(r.db, msg)
+}
+
+func (r *outboxRepository) GetAll(filter *models.OutboxFilter) ([]*models.OutboxMessage, int, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM email_outbox"+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to get count: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	query := `SELECT ` + outboxColumns + ` FROM email_outbox` + whereClause + ` ORDER BY id DESC LIMIT ? OFFSET ?`
+	args = append(args, filter.Limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make([]*models.OutboxMessage, 0)
+	for rows.Next() {
+		msg, err := scanOutboxMessage(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, total, nil
+}
+
+func (r *outboxRepository) getByID(id int) (*models.OutboxMessage, error) {
+	msg, err := scanOutboxMessage(r.db.QueryRow(`SELECT `+outboxColumns+` FROM email_outbox WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get outbox message: %w", err)
+	}
+	return msg, nil
+}
+
+// Requeue resets a dead message so the worker tries it again from scratch
+func (r *outboxRepository) Requeue(id int) (*models.OutboxMessage, error) {
+	query := `UPDATE email_outbox SET status = ?, attempts = 0, next_attempt_at = ?, locked_until = NULL
+              WHERE id = ? AND status = ?`
+	result, err := r.db.Exec(query, models.OutboxStatusPending, time.Now(), id, models.OutboxStatusDead)
+	if err != nil {
+		return nil, fmt.Errorf("failed to requeue outbox message: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, nil
+	}
+
+	return r.getByID(id)
+}
+
+func (r *outboxRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]*models.OutboxMessage, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + outboxColumns + ` FROM email_outbox
+              WHERE status = ? AND next_attempt_at <= ? AND (locked_until IS NULL OR locked_until < ?)
+              ORDER BY next_attempt_at
+              LIMIT ?
+              FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.Query(query, models.OutboxStatusPending, now, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due outbox messages: %w", err)
+	}
+
+	var messages []*models.OutboxMessage
+	var ids []interface{}
+	for rows.Next() {
+		msg, err := scanOutboxMessage(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		messages = append(messages, msg)
+		ids = append(ids, msg.ID)
+	}
+	rows.Close()
+
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := append([]interface{}{now.Add(lease)}, ids...)
+	if _, err := tx.Exec(`UPDATE email_outbox SET locked_until = ? WHERE id IN (`+placeholders+`)`, args...); err != nil {
+		return nil, fmt.Errorf("failed to lease outbox messages: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit outbox lease: %w", err)
+	}
+
+	return messages, nil
+}
+
+func (r *outboxRepository) MarkSent(id int, sentAt time.Time) error {
+	query := `UPDATE email_outbox SET status = ?, attempts = attempts + 1, last_error = '', sent_at = ?, locked_until = NULL
+              WHERE id = ?`
+	if _, err := r.db.Exec(query, models.OutboxStatusSent, sentAt, id); err != nil {
+		return fmt.Errorf("failed to mark outbox message sent: %w", err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) MarkFailed(id int, errMsg string, nextAttemptAt time.Time) error {
+	query := `UPDATE email_outbox SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?, locked_until = NULL
+              WHERE id = ?`
+	if _, err := r.db.Exec(query, truncateError(errMsg), nextAttemptAt, id); err != nil {
+		return fmt.Errorf("failed to mark outbox message failed: %w", err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) MarkDead(id int, errMsg string) error {
+	query := `UPDATE email_outbox SET status = ?, attempts = attempts + 1, last_error = ?, locked_until = NULL
+              WHERE id = ?`
+	if _, err := r.db.Exec(query, models.OutboxStatusDead, truncateError(errMsg), id); err != nil {
+		return fmt.Errorf("failed to mark outbox message dead: %w", err)
+	}
+	return nil
+}
+
+func scanOutboxMessage(row rowScanner) (*models.OutboxMessage, error) {
+	msg := &models.OutboxMessage{}
+	var htmlBody, textBody sql.NullString
+	var sentAt sql.NullTime
+
+	err := row.Scan(&msg.ID, &msg.Kind, &msg.Recipient, &msg.Subject, &htmlBody, &textBody, &msg.ListUnsubscribe, &msg.Status,
+		&msg.Attempts, &msg.LastError, &msg.NextAttemptAt, &sentAt, &msg.CreatedAt, &msg.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	msg.HTMLBody = htmlBody.String
+	msg.TextBody = textBody.String
+	if sentAt.Valid {
+		msg.SentAt = &sentAt.Time
+	}
+
+	return msg, nil
+}
+
+func truncateError(errMsg string) string {
+	if len(errMsg) > 1000 {
+		return errMsg[:1000]
+	}
+	return errMsg
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"daily-notes-api/pkg/auth"
+)
+
+type PasswordResetRepository interface {
+	// Create replaces the user's pending reset with a new one and returns
+	// its token
+	Create(userID int, expiresAt time.Time) (string, error)
+	// GetUserID returns the user a valid token resets, or 0
+	GetUserID(token string) (int, error)
+	// Reset sets the password of the token's user and uses up the token. It
+	// returns the user ID, or 0 if the token is unknown, used or expired.
+	Reset(token, newPasswordHash string) (int, error)
+}
+
+type passwordResetRepository struct {
+	db *sql.DB
+}
+
+func NewPasswordResetRepository(db *sql.DB) PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+func (r *passwordResetRepository) Create(userID int, expiresAt time.Time) (string, error) {
+	if _, err := r.db.Exec(`DELETE FROM password_resets WHERE expires_at < ? OR user_id = ?`, time.Now(), userID); err != nil {
+		return "", fmt.Errorf("failed to prune password resets: %w", err)
+	}
+
+	token, err := generateToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	query := `INSERT INTO password_resets (token_hash, user_id, expires_at) VALUES (?, ?, ?)`
+	if _, err := r.db.Exec(query, auth.HashToken(token), userID, expiresAt); err != nil {
+		return "", fmt.Errorf("failed to save password reset: %w", err)
+	}
+	return token, nil
+}
+
+func (r *passwordResetRepository) GetUserID(token string) (int, error) {
+	query := `SELECT user_id FROM password_resets WHERE token_hash = ? AND expires_at > ?`
+
+	var userID int
+	if err := r.db.QueryRow(query, auth.HashToken(token), time.Now()).Scan(&userID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get password reset: %w", err)
+	}
+	return userID, nil
+}
+
+func (r *passwordResetRepository) Reset(token, newPasswordHash string) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int
+	var expiresAt time.Time
+	query := `SELECT user_id, expires_at FROM password_resets WHERE token_hash = ? FOR UPDATE`
+	if err := tx.QueryRow(query, auth.HashToken(token)).Scan(&userID, &expiresAt); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get password reset: %w", err)
+	}
+	if !expiresAt.After(time.Now()) {
+		return 0, nil
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET password_hash = ? WHERE id = ?`, newPasswordHash, userID); err != nil {
+		return 0, fmt.Errorf("failed to reset password: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM password_resets WHERE user_id = ?`, userID); err != nil {
+		return 0, fmt.Errorf("failed to delete password resets: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit password reset: %w", err)
+	}
+	return userID, nil
+}
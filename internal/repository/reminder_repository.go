@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"daily-notes-api/internal/models"
+)
+
+type ReminderRepository interface {
+	Create(reminder *models.Reminder) (*models.Reminder, error)
+	GetByID(id, userID int) (*models.Reminder, error)
+	GetByNote(noteID, userID int) ([]*models.Reminder, error)
+	GetAll(userID int, filter *models.RemindersFilter) ([]*models.Reminder, error)
+	Snooze(id, userID int, until time.Time) (*models.Reminder, error)
+	Cancel(id, userID int) error
+
+	// ClaimDue leases up to limit due reminders so that no other worker picks
+	// them up until the lease expires. A reminder whose worker dies before
+	// MarkSent is retried after the lease, which gives at-least-once delivery.
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]*models.DueReminder, error)
+	// MarkSent advances the reminder and queues its email in one transaction
+	MarkSent(id int, sentAt time.Time, next *time.Time, messages []*models.OutboxMessage) error
+	MarkFailed(id int, errMsg string, retryAt time.Time) error
+}
+
+type reminderRepository struct {
+	db *sql.DB
+}
+
+func NewReminderRepository(db *sql.DB) ReminderRepository {
+	return &reminderRepository{db: db}
+}
+
+const reminderColumns = `r.id, r.note_id, r.user_id, r.starts_at, r.rrule, r.occurrence_at, r.remind_at, r.status,
+                         r.sent_count, r.attempts, r.last_error, r.last_sent_at, r.created_at, r.updated_at`
+
+func (r *reminderRepository) Create(reminder *models.Reminder) (*models.Reminder, error) {
+	query := `INSERT INTO note_reminders (note_id, user_id, starts_at, rrule, occurrence_at, remind_at, status)
+              VALUES (?, ?, ?, ?, ?, ?, ?)`
+	result, err := r.db.Exec(query, reminder.NoteID, reminder.UserID, reminder.StartsAt, reminder.RRule,
+		reminder.OccurrenceAt, reminder.RemindAt, models.ReminderStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reminder: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return r.GetByID(int(id), reminder.UserID)
+}
+
+func (r *reminderRepository) GetByID(id, userID int) (*models.Reminder, error) {
+	query := `SELECT ` + reminderColumns + ` FROM note_reminders r WHERE r.id = ? AND r.user_id = ?`
+
+	reminder, err := scanReminder(r.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get reminder: %w", err)
+	}
+
+	return reminder, nil
+}
+
+func (r *reminderRepository) GetByNote(noteID, userID int) ([]*models.Reminder, error) {
+	query := `SELECT ` + reminderColumns + ` FROM note_reminders r
+              WHERE r.note_id = ? AND r.user_id = ? ORDER BY r.remind_at`
+	return r.queryReminders(query, noteID, userID)
+}
+
+func (r *reminderRepository) GetAll(userID int, filter *models.RemindersFilter) ([]*models.Reminder, error) {
+	conditions := []string{"r.user_id = ?"}
+	args := []interface{}{userID}
+
+	if filter != nil && filter.Status != "" {
+		conditions = append(conditions, "r.status = ?")
+		args = append(args, filter.Status)
+	}
+
+	query := `SELECT ` + reminderColumns + ` FROM note_reminders r WHERE ` +
+		strings.Join(conditions, " AND ") + ` ORDER BY r.remind_at`
+	return r.queryReminders(query, args...)
+}
+
+func (r *reminderRepository) queryReminders(query string, args ...interface{}) ([]*models.Reminder, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminders: %w", err)
+	}
+	defer rows.Close()
+
+	reminders := make([]*models.Reminder, 0)
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, nil
+}
+
+func (r *reminderRepository) Snooze(id, userID int, until time.Time) (*models.Reminder, error) {
+	query := `UPDATE note_reminders SET remind_at = ?, locked_until = NULL
+              WHERE id = ? AND user_id = ? AND status = ?`
+	result, err := r.db.Exec(query, until, id, userID, models.ReminderStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snooze reminder: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, nil
+	}
+
+	return r.GetByID(id, userID)
+}
+
+func (r *reminderRepository) Cancel(id, userID int) error {
+	query := `UPDATE note_reminders SET status = ?, locked_until = NULL
+              WHERE id = ? AND user_id = ? AND status = ?`
+	result, err := r.db.Exec(query, models.ReminderStatusCancelled, id, userID, models.ReminderStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to cancel reminder: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("pending reminder not found or not owned by user")
+	}
+
+	return nil
+}
+
+func (r *reminderRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]*models.DueReminder, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + reminderColumns + `, n.title, n.content, u.email, u.full_name, u.locale,
+                     COALESCE(us.timezone, 'UTC')
+              FROM note_reminders r
+              JOIN notes n ON n.id = r.note_id
+              JOIN users u ON u.id = r.user_id
+              LEFT JOIN user_settings us ON us.user_id = r.user_id
+              WHERE r.status = ? AND r.remind_at <= ? AND (r.locked_until IS NULL OR r.locked_until < ?)
+              ORDER BY r.remind_at
+              LIMIT ?
+              FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.Query(query, models.ReminderStatusPending, now, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due reminders: %w", err)
+	}
+
+	var due []*models.DueReminder
+	var ids []interface{}
+	for rows.Next() {
+		d := &models.DueReminder{}
+		var lastSentAt sql.NullTime
+		err := rows.Scan(
+			&d.ID, &d.NoteID, &d.UserID, &d.StartsAt, &d.RRule, &d.OccurrenceAt, &d.RemindAt, &d.Status,
+			&d.SentCount, &d.Attempts, &d.LastError, &lastSentAt, &d.CreatedAt, &d.UpdatedAt,
+			&d.NoteTitle, &d.NoteContent, &d.UserEmail, &d.UserName, &d.UserLocale,
+			&d.UserTimezone,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan due reminder: %w", err)
+		}
+		if lastSentAt.Valid {
+			d.LastSentAt = &lastSentAt.Time
+		}
+		due = append(due, d)
+		ids = append(ids, d.ID)
+	}
+	rows.Close()
+
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := append([]interface{}{now.Add(lease)}, ids...)
+	if _, err := tx.Exec(`UPDATE note_reminders SET locked_until = ? WHERE id IN (`+placeholders+`)`, args...); err != nil {
+		return nil, fmt.Errorf("failed to lease reminders: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit reminder lease: %w", err)
+	}
+
+	return due, nil
+}
+
+func (r *reminderRepository) MarkSent(id int, sentAt time.Time, next *time.Time, messages []*models.OutboxMessage) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if next == nil {
+		_, err = tx.Exec(`UPDATE note_reminders
+                          SET status = ?, sent_count = sent_count + 1, attempts = 0, last_error = '',
+                              last_sent_at = ?, locked_until = NULL
+                          WHERE id = ?`, models.ReminderStatusSent, sentAt, id)
+	} else {
+		_, err = tx.Exec(`UPDATE note_reminders
+                          SET occurrence_at = ?, remind_at = ?, sent_count = sent_count + 1, attempts = 0,
+                              last_error = '', last_sent_at = ?, locked_until = NULL
+                          WHERE id = ?`, *next, *next, sentAt, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mark reminder sent: %w", err)
+	}
+
+	for _, msg := range messages {
+		if err := enqueueOutboxMessage(tx, msg); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *reminderRepository) MarkFailed(id int, errMsg string, retryAt time.Time) error {
+	if len(errMsg) > 500 {
+		errMsg = errMsg[:500]
+	}
+
+	query := `UPDATE note_reminders SET attempts = attempts + 1, last_error = ?, remind_at = ?, locked_until = NULL
+              WHERE id = ?`
+	if _, err := r.db.Exec(query, errMsg, retryAt, id); err != nil {
+		return fmt.Errorf("failed to mark reminder failed: %w", err)
+	}
+	return nil
+}
+
+func scanReminder(row rowScanner) (*models.Reminder, error) {
+	reminder := &models.Reminder{}
+	var lastSentAt sql.NullTime
+
+	err := row.Scan(
+		&reminder.ID, &reminder.NoteID, &reminder.UserID, &reminder.StartsAt, &reminder.RRule,
+		&reminder.OccurrenceAt, &reminder.RemindAt, &reminder.Status, &reminder.SentCount,
+		&reminder.Attempts, &reminder.LastError, &lastSentAt, &reminder.CreatedAt, &reminder.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastSentAt.Valid {
+		reminder.LastSentAt = &lastSentAt.Time
+	}
+
+	return reminder, nil
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"daily-notes-api/internal/models"
+)
+
+type SessionRepository interface {
+	// Create records a new login and prunes the user's ended sessions
+	Create(session *models.Session) (*models.Session, error)
+	// GetActive returns the session unless it was revoked or has expired
+	GetActive(id, userID int) (*models.Session, error)
+	GetAllActive(userID int) ([]*models.Session, error)
+	Touch(id int, ipAddress string, seenAt time.Time) error
+	Revoke(id, userID int) (bool, error)
+	// RevokeOthers ends every session of the user except keepID
+	RevokeOthers(userID, keepID int) (int, error)
+}
+
+type sessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+const sessionColumns = `id, user_id, device, user_agent, ip_address, last_seen_at, expires_at, revoked_at, created_at`
+
+func scanSession(row rowScanner) (*models.Session, error) {
+	session := &models.Session{}
+	var revokedAt sql.NullTime
+	err := row.Scan(&session.ID, &session.UserID, &session.Device, &session.UserAgent, &session.IPAddress,
+		&session.LastSeenAt, &session.ExpiresAt, &revokedAt, &session.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	return session, nil
+}
+
+func (r *sessionRepository) Create(session *models.Session) (*models.Session, error) {
+	now := time.Now()
+
+	prune := `DELETE FROM user_sessions WHERE user_id = ? AND (expires_at < ? OR revoked_at IS NOT NULL)`
+	if _, err := r.db.Exec(prune, session.UserID, now); err != nil {
+		return nil, fmt.Errorf("failed to prune sessions: %w", err)
+	}
+
+	query := `INSERT INTO user_sessions (user_id, device, user_agent, ip_address, last_seen_at, expires_at)
+              VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := r.db.Exec(query, session.UserID, session.Device, session.UserAgent, session.IPAddress,
+		now, session.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return r.GetActive(int(id), session.UserID)
+}
+
+func (r *sessionRepository) GetActive(id, userID int) (*models.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM user_sessions
+              WHERE id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?`
+
+	session, err := scanSession(r.db.QueryRow(query, id, userID, time.Now()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (r *sessionRepository) GetAllActive(userID int) ([]*models.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM user_sessions
+              WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+              ORDER BY last_seen_at DESC`
+
+	rows, err := r.db.Query(query, userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]*models.Session, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+func (r *sessionRepository) Touch(id int, ipAddress string, seenAt time.Time) error {
+	query := `UPDATE user_sessions SET last_seen_at = ?, ip_address = ? WHERE id = ?`
+	if _, err := r.db.Exec(query, seenAt, ipAddress, id); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+func (r *sessionRepository) Revoke(id, userID int) (bool, error) {
+	query := `UPDATE user_sessions SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`
+	result, err := r.db.Exec(query, time.Now(), id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *sessionRepository) RevokeOthers(userID, keepID int) (int, error) {
+	query := `UPDATE user_sessions SET revoked_at = ? WHERE user_id = ? AND id != ? AND revoked_at IS NULL`
+	result, err := r.db.Exec(query, time.Now(), userID, keepID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"daily-notes-api/internal/models"
+)
+
+type StatsRepository interface {
+	// GetTotals returns the number of notes and their word and character counts
+	GetTotals(userID int) (notes, words, characters int, err error)
+	GetTopCategories(userID, limit int) ([]models.CategoryCount, error)
+
+	// GetNotesPerDay and GetHourHeatmap place notes in local days and hours
+	// by shifting them offsetSeconds from UTC. The offset is the zone's
+	// current one, so notes from the other side of a DST change may move
+	// by an hour.
+	GetNotesPerDay(userID, offsetSeconds int) ([]models.PeriodCount, error)
+	GetHourHeatmap(userID, offsetSeconds int) ([7][24]int, error)
+}
+
+type statsRepository struct {
+	db *sql.DB
+}
+
+func NewStatsRepository(db *sql.DB) StatsRepository {
+	return &statsRepository{db: db}
+}
+
+func (r *statsRepository) GetTotals(userID int) (int, int, int, error) {
+	// Words are runs of non-space characters: collapse whitespace, then count
+	// the separators that remain
+	query := `SELECT COUNT(*),
+                     COALESCE(SUM(CASE WHEN t.normalized = '' THEN 0
+                         ELSE CHAR_LENGTH(t.normalized) - CHAR_LENGTH(REPLACE(t.normalized, ' ', '')) + 1 END), 0),
+                     COALESCE(SUM(t.characters), 0)
+              FROM (SELECT TRIM(REGEXP_REPLACE(content, '[[:space:]]+', ' ')) AS normalized,
+                           CHAR_LENGTH(content) AS characters
+                    FROM notes WHERE user_id = ?) t`
+
+	var notes, words, characters int
+	if err := r.db.QueryRow(query, userID).Scan(&notes, &words, &characters); err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to get note totals: %w", err)
+	}
+
+	return notes, words, characters, nil
+}
+
+func (r *statsRepository) GetTopCategories(userID, limit int) ([]models.CategoryCount, error) {
+	query := `SELECT category, COUNT(*) AS total FROM notes
+              WHERE user_id = ? AND category != ''
+              GROUP BY category ORDER BY total DESC, category LIMIT ?`
+
+	rows, err := r.db.Query(query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := make([]models.CategoryCount, 0)
+	for rows.Next() {
+		var category models.CategoryCount
+		if err := rows.Scan(&category.Category, &category.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan category count: %w", err)
+		}
+		categories = append(categories, category)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate category counts: %w", err)
+	}
+
+	return categories, nil
+}
+
+func (r *statsRepository) GetNotesPerDay(userID, offsetSeconds int) ([]models.PeriodCount, error) {
+	// The connection's time zone is UTC, so adding the offset gives local time
+	query := `SELECT DATE_FORMAT(DATE_ADD(created_at, INTERVAL ? SECOND), '%Y-%m-%d') AS day, COUNT(*)
+              FROM notes WHERE user_id = ?
+              GROUP BY day ORDER BY day`
+
+	rows, err := r.db.Query(query, offsetSeconds, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes per day: %w", err)
+	}
+	defer rows.Close()
+
+	var days []models.PeriodCount
+	for rows.Next() {
+		var day models.PeriodCount
+		if err := rows.Scan(&day.Period, &day.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan notes per day: %w", err)
+		}
+		days = append(days, day)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate notes per day: %w", err)
+	}
+
+	return days, nil
+}
+
+func (r *statsRepository) GetHourHeatmap(userID, offsetSeconds int) ([7][24]int, error) {
+	var heatmap [7][24]int
+
+	// DAYOFWEEK counts from 1 = Sunday
+	query := `SELECT DAYOFWEEK(DATE_ADD(created_at, INTERVAL ? SECOND)) - 1 AS weekday,
+                     HOUR(DATE_ADD(created_at, INTERVAL ? SECOND)) AS hour, COUNT(*)
+              FROM notes WHERE user_id = ?
+              GROUP BY weekday, hour`
+
+	rows, err := r.db.Query(query, offsetSeconds, offsetSeconds, userID)
+	if err != nil {
+		return heatmap, fmt.Errorf("failed to get hour heat map: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var weekday, hour, count int
+		if err := rows.Scan(&weekday, &hour, &count); err != nil {
+			return heatmap, fmt.Errorf("failed to scan hour heat map: %w", err)
+		}
+		heatmap[weekday][hour] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return heatmap, fmt.Errorf("failed to iterate hour heat map: %w", err)
+	}
+
+	return heatmap, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"daily-notes-api/internal/models"
+)
+
+func TestStatsGroupByLocalDayAndHour(t *testing.T) {
+	db := openTestDB(t)
+	notes := NewNoteRepository(db)
+	stats := NewStatsRepository(db)
+
+	userID := createTestUser(t, db, "ann")
+	for _, createdAt := range []string{"2026-03-01 16:30:00", "2026-03-01 17:30:00", "2026-03-02 02:00:00"} {
+		note, err := notes.Create(userID, &models.CreateNoteRequest{Title: "Note", Content: "Content"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`UPDATE notes SET created_at = ? WHERE id = ?`, createdAt, note.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		offset   int
+		wantDays string
+		// weekday (0 = Sunday) and hour of each expected heat map cell
+		wantCells map[[2]int]int
+	}{
+		{"UTC", 0, "[{2026-03-01 2} {2026-03-02 1}]", map[[2]int]int{{0, 16}: 1, {0, 17}: 1, {1, 2}: 1}},
+		{"Jakarta", 7 * 3600, "[{2026-03-01 1} {2026-03-02 2}]", map[[2]int]int{{0, 23}: 1, {1, 0}: 1, {1, 9}: 1}},
+		{"New York", -5 * 3600, "[{2026-03-01 3}]", map[[2]int]int{{0, 11}: 1, {0, 12}: 1, {0, 21}: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days, err := stats.GetNotesPerDay(userID, tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, day := range days {
+				got = append(got, fmt.Sprintf("{%s %d}", day.Period, day.Count))
+			}
+			if fmt.Sprint(got) != tt.wantDays {
+				t.Errorf("notes per day = %v, want %s", got, tt.wantDays)
+			}
+
+			heatmap, err := stats.GetHourHeatmap(userID, tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for weekday := range heatmap {
+				for hour, count := range heatmap[weekday] {
+					if want := tt.wantCells[[2]int{weekday, hour}]; count != want {
+						t.Errorf("heat map[%d][%d] = %d, want %d", weekday, hour, count, want)
+					}
+				}
+			}
+		})
+	}
+}
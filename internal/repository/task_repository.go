@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/pkg/tasklist"
+)
+
+type TaskRepository interface {
+	GetAll(userID int, filter *models.TasksFilter) ([]*models.Task, int, error)
+	GetByID(id, userID int) (*models.Task, error)
+	// GetUnfinishedBefore returns open tasks of daily notes dated before date
+	GetUnfinishedBefore(userID int, date string) ([]*models.Task, error)
+}
+
+type taskRepository struct {
+	db *sql.DB
+}
+
+func NewTaskRepository(db *sql.DB) TaskRepository {
+	return &taskRepository{db: db}
+}
+
+const taskColumns = `t.id, t.user_id, t.note_id, n.title, t.line, t.text, t.status, t.completed_at, t.created_at, t.updated_at`
+
+func (r *taskRepository) GetAll(userID int, filter *models.TasksFilter) ([]*models.Task, int, error) {
+	conditions := []string{"t.user_id = ?"}
+	args := []interface{}{userID}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "t.status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.NoteID != 0 {
+		conditions = append(conditions, "t.note_id = ?")
+		args = append(args, filter.NoteID)
+	}
+
+	whereClause := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM tasks t"+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to get count: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	query := `SELECT ` + taskColumns + ` FROM tasks t JOIN notes n ON n.id = t.note_id` + whereClause +
+		` ORDER BY n.created_at DESC, t.line LIMIT ? OFFSET ?`
+	args = append(args, filter.Limit, offset)
+
+	tasks, err := r.queryTasks(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return tasks, total, nil
+}
+
+func (r *taskRepository) GetByID(id, userID int) (*models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks t JOIN notes n ON n.id = t.note_id WHERE t.id = ? AND t.user_id = ?`
+
+	tasks, err := r.queryTasks(query, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+	return tasks[0], nil
+}
+
+func (r *taskRepository) GetUnfinishedBefore(userID int, date string) ([]*models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks t JOIN notes n ON n.id = t.note_id
+              WHERE t.user_id = ? AND t.status = ? AND n.category = ?
+                AND n.title REGEXP '^[0-9]{4}-[0-9]{2}-[0-9]{2}$' AND n.title < ?
+              ORDER BY n.title, t.line`
+	return r.queryTasks(query, userID, models.TaskStatusOpen, models.DailyNoteCategory, date)
+}
+
+func (r *taskRepository) queryTasks(query string, args ...interface{}) ([]*models.Task, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := make([]*models.Task, 0)
+	for rows.Next() {
+		task := &models.Task{}
+		var completedAt sql.NullTime
+		err := rows.Scan(&task.ID, &task.UserID, &task.NoteID, &task.NoteTitle, &task.Line, &task.Text,
+			&task.Status, &completedAt, &task.CreatedAt, &task.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		if completedAt.Valid {
+			task.CompletedAt = &completedAt.Time
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+type storedTask struct {
+	id     int
+	line   int
+	status string
+}
+
+// syncNoteTasks updates the tasks parsed from a note. Items are matched to
+// stored tasks by text, in order, so task IDs survive unrelated edits.
+func syncNoteTasks(tx *sql.Tx, userID, noteID int, content string) error {
+	rows, err := tx.Query(`SELECT id, line, text, status FROM tasks WHERE note_id = ? ORDER BY line`, noteID)
+	if err != nil {
+		return fmt.Errorf("failed to get note tasks: %w", err)
+	}
+
+	existing := make(map[string][]storedTask)
+	for rows.Next() {
+		var task storedTask
+		var text string
+		if err := rows.Scan(&task.id, &task.line, &text, &task.status); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan note task: %w", err)
+		}
+		existing[text] = append(existing[text], task)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate note tasks: %w", err)
+	}
+
+	for _, item := range tasklist.Parse(content) {
+		status := string(item.Status)
+
+		if matches := existing[item.Text]; len(matches) > 0 {
+			task := matches[0]
+			existing[item.Text] = matches[1:]
+			if task.line == item.Line && task.status == status {
+				continue
+			}
+
+			query := `UPDATE tasks SET line = ?, status = ?,
+                      completed_at = CASE WHEN ? = ? THEN COALESCE(completed_at, CURRENT_TIMESTAMP) ELSE NULL END
+                      WHERE id = ?`
+			if _, err := tx.Exec(query, item.Line, status, status, models.TaskStatusDone, task.id); err != nil {
+				return fmt.Errorf("failed to update task: %w", err)
+			}
+			continue
+		}
+
+		query := `INSERT INTO tasks (user_id, note_id, line, text, status, completed_at)
+                  VALUES (?, ?, ?, ?, ?, CASE WHEN ? = ? THEN CURRENT_TIMESTAMP ELSE NULL END)`
+		if _, err := tx.Exec(query, userID, noteID, item.Line, item.Text, status, status, models.TaskStatusDone); err != nil {
+			return fmt.Errorf("failed to create task: %w", err)
+		}
+	}
+
+	for _, remaining := range existing {
+		for _, task := range remaining {
+			if _, err := tx.Exec(`DELETE FROM tasks WHERE id = ?`, task.id); err != nil {
+				return fmt.Errorf("failed to delete task: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -3,20 +3,30 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"daily-notes-api/internal/models"
-	"daily-notes-api/pkg/auth"
 )
 
 type UserRepository interface {
-	Create(req *models.RegisterRequest) (*models.User, error)
+	Create(req *models.RegisterRequest, passwordHash string) (*models.User, error)
+	// CreateWithOutbox creates the user and queues the given emails in the
+	// same transaction, so the emails exist if and only if the user does
+	CreateWithOutbox(req *models.RegisterRequest, passwordHash string, messages []*models.OutboxMessage) (*models.User, error)
 	GetByUsername(username string) (*models.User, error)
 	GetByEmail(email string) (*models.User, error)
 	GetByID(id int) (*models.User, error)
-	UpdateProfile(userID int, fullName, email string) (*models.User, error)
+	UpdateProfile(userID int, fullName, email, locale string) (*models.User, error)
 	ChangePassword(userID int, newPasswordHash string) error
+	// RehashPassword replaces oldHash with an upgraded hash of the same
+	// password, unless the password was changed in the meantime
+	RehashPassword(userID int, oldHash, newHash string) error
 	UsernameExists(username string) (bool, error)
 	EmailExists(email string) (bool, error)
+	SetRole(userID int, role string) error
+	// MarkEmailVerified records that the user controls email, unless the
+	// account's email has changed since
+	MarkEmailVerified(userID int, email string, at time.Time) error
 }
 
 type userRepository struct {
@@ -27,15 +37,46 @@ func NewUserRepository(db *sql.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(req *models.RegisterRequest) (*models.User, error) {
-	// Hash password
-	passwordHash, err := auth.HashPassword(req.Password)
+const userColumns = `id, username, email, password_hash, full_name, role, locale, deletion_scheduled_at, email_verified_at,
+                      created_at, updated_at`
+
+func scanUser(row rowScanner) (*models.User, error) {
+	user := &models.User{}
+	var deletionScheduledAt, emailVerifiedAt sql.NullTime
+	err := row.Scan(
+		&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.FullName, &user.Role, &user.Locale,
+		&deletionScheduledAt, &emailVerifiedAt, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if deletionScheduledAt.Valid {
+		user.DeletionScheduledAt = &deletionScheduledAt.Time
+	}
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+	return user, nil
+}
+
+func (r *userRepository) Create(req *models.RegisterRequest, passwordHash string) (*models.User, error) {
+	return r.CreateWithOutbox(req, passwordHash, nil)
+}
+
+func (r *userRepository) CreateWithOutbox(req *models.RegisterRequest, passwordHash string, messages []*models.OutboxMessage) (*models.User, error) {
+	tx, err := r.db.Begin()
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	query := `INSERT INTO users (username, email, password_hash, full_name) VALUES (?, ?, ?, ?)`
-	result, err := r.db.Exec(query, req.Username, req.Email, passwordHash, req.FullName)
+	locale := req.Locale
+	if locale == "" {
+		locale = "en"
+	}
+
+	query := `INSERT INTO users (username, email, password_hash, full_name, locale) VALUES (?, ?, ?, ?, ?)`
+	result, err := tx.Exec(query, req.Username, req.Email, passwordHash, req.FullName, locale)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -45,18 +86,23 @@ func (r *userRepository) Create(req *models.RegisterRequest) (*models.User, erro
 		return nil, fmt.Errorf("failed to get last insert id: %w", err)
 	}
 
+	for _, msg := range messages {
+		if err := enqueueOutboxMessage(tx, msg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit user creation: %w", err)
+	}
+
 	return r.GetByID(int(id))
 }
 
 func (r *userRepository) GetByUsername(username string) (*models.User, error) {
-	query := `SELECT id, username, email, password_hash, full_name, created_at, updated_at 
-              FROM users WHERE username = ?`
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = ?`
 
-	user := &models.User{}
-	err := r.db.QueryRow(query, username).Scan(
-		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.FullName, &user.CreatedAt, &user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.QueryRow(query, username))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -68,14 +114,9 @@ func (r *userRepository) GetByUsername(username string) (*models.User, error) {
 }
 
 func (r *userRepository) GetByEmail(email string) (*models.User, error) {
-	query := `SELECT id, username, email, password_hash, full_name, created_at, updated_at 
-              FROM users WHERE email = ?`
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = ?`
 
-	user := &models.User{}
-	err := r.db.QueryRow(query, email).Scan(
-		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.FullName, &user.CreatedAt, &user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.QueryRow(query, email))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -87,14 +128,9 @@ func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 }
 
 func (r *userRepository) GetByID(id int) (*models.User, error) {
-	query := `SELECT id, username, email, password_hash, full_name, created_at, updated_at 
-              FROM users WHERE id = ?`
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = ?`
 
-	user := &models.User{}
-	err := r.db.QueryRow(query, id).Scan(
-		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.FullName, &user.CreatedAt, &user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -105,9 +141,12 @@ func (r *userRepository) GetByID(id int) (*models.User, error) {
 	return user, nil
 }
 
-func (r *userRepository) UpdateProfile(userID int, fullName, email string) (*models.User, error) {
-	query := `UPDATE users SET full_name = ?, email = ? WHERE id = ?`
-	result, err := r.db.Exec(query, fullName, email, userID)
+func (r *userRepository) UpdateProfile(userID int, fullName, email, locale string) (*models.User, error) {
+	// A changed email is no longer verified. MySQL assigns left to right, so
+	// the old email is compared before it is overwritten.
+	query := `UPDATE users SET email_verified_at = IF(email = ?, email_verified_at, NULL), full_name = ?, email = ?, locale = ?
+              WHERE id = ?`
+	result, err := r.db.Exec(query, email, fullName, email, locale, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update user profile: %w", err)
 	}
@@ -143,6 +182,40 @@ func (r *userRepository) ChangePassword(userID int, newPasswordHash string) erro
 	return nil
 }
 
+func (r *userRepository) SetRole(userID int, role string) error {
+	result, err := r.db.Exec(`UPDATE users SET role = ? WHERE id = ?`, role, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set role: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (r *userRepository) MarkEmailVerified(userID int, email string, at time.Time) error {
+	query := `UPDATE users SET email_verified_at = ?, updated_at = updated_at WHERE id = ? AND email = ?`
+	if _, err := r.db.Exec(query, at, userID, email); err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+	return nil
+}
+
+func (r *userRepository) RehashPassword(userID int, oldHash, newHash string) error {
+	query := `UPDATE users SET password_hash = ?, updated_at = updated_at WHERE id = ? AND password_hash = ?`
+	if _, err := r.db.Exec(query, newHash, userID, oldHash); err != nil {
+		return fmt.Errorf("failed to rehash password: %w", err)
+	}
+	return nil
+}
+
 func (r *userRepository) UsernameExists(username string) (bool, error) {
 	query := `SELECT COUNT(*) FROM users WHERE username = ?`
 	var count int
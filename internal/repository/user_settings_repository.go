@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"daily-notes-api/internal/models"
+)
+
+type UserSettingsRepository interface {
+	// Get returns the user's settings with defaults for anything not saved yet
+	Get(userID int) (*models.UserSettings, error)
+	Save(settings *models.UserSettings) error
+}
+
+type userSettingsRepository struct {
+	db *sql.DB
+}
+
+func NewUserSettingsRepository(db *sql.DB) UserSettingsRepository {
+	return &userSettingsRepository{db: db}
+}
+
+func (r *userSettingsRepository) Get(userID int) (*models.UserSettings, error) {
+	defaults := models.DefaultUserSettings(userID)
+
+	// Before user_settings existed the digest timezone was the only one a
+	// user could set, so it is the fallback for accounts without a row
+	query := `SELECT u.id, COALESCE(s.timezone, d.timezone, ?), u.locale,
+                     COALESCE(s.week_start, ?), COALESCE(s.default_category, ''),
+                     COALESCE(s.default_page_size, ?), COALESCE(d.frequency, ?), COALESCE(d.send_hour, ?)
+              FROM users u
+              LEFT JOIN user_settings s ON s.user_id = u.id
+              LEFT JOIN digest_subscriptions d ON d.user_id = u.id
+              WHERE u.id = ?`
+
+	settings := &models.UserSettings{}
+	err := r.db.QueryRow(query, defaults.Timezone, defaults.WeekStart, defaults.DefaultPageSize,
+		defaults.Digest.Frequency, defaults.Digest.SendHour, userID).Scan(
+		&settings.UserID, &settings.Timezone, &settings.Locale,
+		&settings.WeekStart, &settings.DefaultCategory,
+		&settings.DefaultPageSize, &settings.Digest.Frequency, &settings.Digest.SendHour,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// Save writes the settings, the user's locale and the digest preferences in
+// one transaction. The digest reads the account timezone from user_settings.
+func (r *userSettingsRepository) Save(settings *models.UserSettings) error {
+	token, err := generateToken(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate unsubscribe token: %w", err)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO user_settings (user_id, timezone, week_start, default_category, default_page_size)
+              VALUES (?, ?, ?, ?, ?)
+              ON DUPLICATE KEY UPDATE timezone = VALUES(timezone), week_start = VALUES(week_start),
+                  default_category = VALUES(default_category), default_page_size = VALUES(default_page_size)`
+	if _, err := tx.Exec(query, settings.UserID, settings.Timezone, settings.WeekStart,
+		settings.DefaultCategory, settings.DefaultPageSize); err != nil {
+		return fmt.Errorf("failed to save user settings: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET locale = ? WHERE id = ?`, settings.Locale, settings.UserID); err != nil {
+		return fmt.Errorf("failed to update locale: %w", err)
+	}
+
+	query = `INSERT INTO digest_subscriptions (user_id, frequency, send_hour, unsubscribe_token)
+             VALUES (?, ?, ?, ?)
+             ON DUPLICATE KEY UPDATE frequency = VALUES(frequency), send_hour = VALUES(send_hour)`
+	if _, err := tx.Exec(query, settings.UserID, settings.Digest.Frequency,
+		settings.Digest.SendHour, token); err != nil {
+		return fmt.Errorf("failed to save digest subscription: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,413 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"daily-notes-api/internal/models"
+)
+
+type WebhookRepository interface {
+	Create(userID int, req *models.CreateWebhookRequest) (*models.Webhook, error)
+	GetByID(id, userID int) (*models.Webhook, error)
+	GetAll(userID int) ([]*models.Webhook, error)
+	Update(id, userID int, req *models.UpdateWebhookRequest) (*models.Webhook, error)
+	Delete(id, userID int) error
+
+	// Enqueue queues payload for every active webhook of the user that
+	// subscribes to event, returning the number of deliveries created
+	Enqueue(userID int, event, payload string) (int, error)
+	GetDeliveries(webhookID int, filter *models.WebhookDeliveriesFilter) ([]*models.WebhookDelivery, int, error)
+	// Redeliver queues a fresh copy of a past delivery
+	Redeliver(deliveryID, webhookID int) (*models.WebhookDelivery, error)
+
+	// ClaimDue leases up to limit pending deliveries of active webhooks
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]*models.DueWebhookDelivery, error)
+	MarkSucceeded(id int, responseStatus int, responseBody string, deliveredAt time.Time) error
+	// MarkFailed records a failed attempt; a nil nextAttemptAt gives up
+	MarkFailed(id int, responseStatus *int, responseBody, errMsg string, nextAttemptAt *time.Time) error
+
+	// RecordSuccess resets the webhook's consecutive failure count
+	RecordSuccess(webhookID int) error
+	// RecordFailure counts a failed attempt and disables the webhook once
+	// disableAfter consecutive attempts have failed. It reports whether the
+	// webhook was disabled by this call.
+	RecordFailure(webhookID, disableAfter int) (bool, error)
+}
+
+type webhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+const webhookColumns = `id, user_id, url, secret, events, active, failure_count, disabled_at, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, webhook_id, event, payload, status, attempts, response_status, response_body,
+                                last_error, next_attempt_at, delivered_at, created_at, updated_at`
+
+func (r *webhookRepository) Create(userID int, req *models.CreateWebhookRequest) (*models.Webhook, error) {
+	secret, err := generateToken(24)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	secret = "whsec_" + secret
+
+	query := `INSERT INTO webhooks (user_id, url, secret, events) VALUES (?, ?, ?, ?)`
+	result, err := r.db.Exec(query, userID, req.URL, secret, joinEvents(req.Events))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return r.GetByID(int(id), userID)
+}
+
+func (r *webhookRepository) GetByID(id, userID int) (*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = ? AND user_id = ?`
+
+	webhook, err := scanWebhook(r.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (r *webhookRepository) GetAll(userID int) ([]*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE user_id = ? ORDER BY id`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]*models.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
+
+func (r *webhookRepository) Update(id, userID int, req *models.UpdateWebhookRequest) (*models.Webhook, error) {
+	query := `UPDATE webhooks SET url = ?, events = ? WHERE id = ? AND user_id = ?`
+	args := []interface{}{req.URL, joinEvents(req.Events), id, userID}
+
+	if req.Active != nil {
+		if *req.Active {
+			// Re-enabling starts the failure count over
+			query = `UPDATE webhooks SET url = ?, events = ?, active = TRUE, failure_count = 0, disabled_at = NULL
+                     WHERE id = ? AND user_id = ?`
+		} else {
+			query = `UPDATE webhooks SET url = ?, events = ?, active = FALSE WHERE id = ? AND user_id = ?`
+		}
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return r.GetByID(id, userID)
+}
+
+func (r *webhookRepository) Delete(id, userID int) error {
+	query := `DELETE FROM webhooks WHERE id = ? AND user_id = ?`
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook not found or not owned by user")
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) Enqueue(userID int, event, payload string) (int, error) {
+	query := `INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt_at)
+              SELECT id, ?, ?, ?, ? FROM webhooks
+              WHERE user_id = ? AND active = TRUE AND FIND_IN_SET(?, events) > 0`
+	result, err := r.db.Exec(query, event, payload, models.WebhookDeliveryPending, time.Now(), userID, event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+func (r *webhookRepository) GetDeliveries(webhookID int, filter *models.WebhookDeliveriesFilter) ([]*models.WebhookDelivery, int, error) {
+	conditions := []string{"webhook_id = ?"}
+	args := []interface{}{webhookID}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	whereClause := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM webhook_deliveries"+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to get count: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries` + whereClause + ` ORDER BY id DESC LIMIT ? OFFSET ?`
+	args = append(args, filter.Limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*models.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, total, nil
+}
+
+func (r *webhookRepository) Redeliver(deliveryID, webhookID int) (*models.WebhookDelivery, error) {
+	query := `INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt_at)
+              SELECT webhook_id, event, payload, ?, ? FROM webhook_deliveries WHERE id = ? AND webhook_id = ?`
+	result, err := r.db.Exec(query, models.WebhookDeliveryPending, time.Now(), deliveryID, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeliver webhook delivery: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, nil
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	delivery, err := scanWebhookDelivery(r.db.QueryRow(`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+func (r *webhookRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]*models.DueWebhookDelivery, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.response_status, d.response_body,
+                     d.last_error, d.next_attempt_at, d.delivered_at, d.created_at, d.updated_at, w.url, w.secret
+              FROM webhook_deliveries d
+              JOIN webhooks w ON w.id = d.webhook_id
+              WHERE d.status = ? AND d.next_attempt_at <= ? AND (d.locked_until IS NULL OR d.locked_until < ?)
+                AND w.active = TRUE
+              ORDER BY d.next_attempt_at
+              LIMIT ?
+              FOR UPDATE OF d SKIP LOCKED`
+
+	rows, err := tx.Query(query, models.WebhookDeliveryPending, now, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due webhook deliveries: %w", err)
+	}
+
+	var deliveries []*models.DueWebhookDelivery
+	var ids []interface{}
+	for rows.Next() {
+		d := &models.DueWebhookDelivery{}
+		var responseStatus sql.NullInt64
+		var deliveredAt sql.NullTime
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &responseStatus,
+			&d.ResponseBody, &d.LastError, &d.NextAttemptAt, &deliveredAt, &d.CreatedAt, &d.UpdatedAt, &d.URL, &d.Secret)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		if responseStatus.Valid {
+			status := int(responseStatus.Int64)
+			d.ResponseStatus = &status
+		}
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, d)
+		ids = append(ids, d.ID)
+	}
+	rows.Close()
+
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := append([]interface{}{now.Add(lease)}, ids...)
+	if _, err := tx.Exec(`UPDATE webhook_deliveries SET locked_until = ? WHERE id IN (`+placeholders+`)`, args...); err != nil {
+		return nil, fmt.Errorf("failed to lease webhook deliveries: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit webhook delivery lease: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) MarkSucceeded(id int, responseStatus int, responseBody string, deliveredAt time.Time) error {
+	query := `UPDATE webhook_deliveries
+              SET status = ?, attempts = attempts + 1, response_status = ?, response_body = ?, last_error = '',
+                  delivered_at = ?, locked_until = NULL
+              WHERE id = ?`
+	_, err := r.db.Exec(query, models.WebhookDeliverySucceeded, responseStatus, truncateError(responseBody), deliveredAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivery succeeded: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) MarkFailed(id int, responseStatus *int, responseBody, errMsg string, nextAttemptAt *time.Time) error {
+	status := models.WebhookDeliveryPending
+	next := time.Now()
+	if nextAttemptAt != nil {
+		next = *nextAttemptAt
+	} else {
+		status = models.WebhookDeliveryFailed
+	}
+
+	query := `UPDATE webhook_deliveries
+              SET status = ?, attempts = attempts + 1, response_status = ?, response_body = ?, last_error = ?,
+                  next_attempt_at = ?, locked_until = NULL
+              WHERE id = ?`
+	_, err := r.db.Exec(query, status, responseStatus, truncateError(responseBody), truncateError(errMsg), next, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivery failed: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) RecordSuccess(webhookID int) error {
+	if _, err := r.db.Exec(`UPDATE webhooks SET failure_count = 0 WHERE id = ? AND failure_count > 0`, webhookID); err != nil {
+		return fmt.Errorf("failed to reset webhook failures: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) RecordFailure(webhookID, disableAfter int) (bool, error) {
+	if _, err := r.db.Exec(`UPDATE webhooks SET failure_count = failure_count + 1 WHERE id = ?`, webhookID); err != nil {
+		return false, fmt.Errorf("failed to record webhook failure: %w", err)
+	}
+
+	query := `UPDATE webhooks SET active = FALSE, disabled_at = ?
+              WHERE id = ? AND active = TRUE AND failure_count >= ?`
+	result, err := r.db.Exec(query, time.Now(), webhookID, disableAfter)
+	if err != nil {
+		return false, fmt.Errorf("failed to disable webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	var events string
+	var disabledAt sql.NullTime
+
+	err := row.Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret, &events, &webhook.Active,
+		&webhook.FailureCount, &disabledAt, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Events = splitEvents(events)
+	if disabledAt.Valid {
+		webhook.DisabledAt = &disabledAt.Time
+	}
+
+	return webhook, nil
+}
+
+func scanWebhookDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	d := &models.WebhookDelivery{}
+	var responseStatus sql.NullInt64
+	var deliveredAt sql.NullTime
+
+	err := row.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &responseStatus,
+		&d.ResponseBody, &d.LastError, &d.NextAttemptAt, &deliveredAt, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if responseStatus.Valid {
+		status := int(responseStatus.Int64)
+		d.ResponseStatus = &status
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+
+	return d, nil
+}
+
+// Events are stored comma separated so FIND_IN_SET can match them
+func joinEvents(events []string) string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, e := range events {
+		if !seen[e] {
+			seen[e] = true
+			unique = append(unique, e)
+		}
+	}
+	return strings.Join(unique, ",")
+}
+
+func splitEvents(events string) []string {
+	if events == "" {
+		return []string{}
+	}
+	return strings.Split(events, ",")
+}
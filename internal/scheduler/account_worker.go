@@ -0,0 +1,389 @@
+package scheduler
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/email"
+)
+
+const (
+	exportBatchSize   = 5
+	exportLease       = 30 * time.Minute
+	deletionBatchSize = 20
+)
+
+// AccountWorker builds requested data exports, removes them once their link
+// expires and deletes accounts whose grace period has passed
+type AccountWorker struct {
+	accountRepo   repository.AccountRepository
+	userRepo      repository.UserRepository
+	settingsRepo  repository.UserSettingsRepository
+	templateRepo  repository.NoteTemplateRepository
+	reminderRepo  repository.ReminderRepository
+	emailService  *email.EmailService
+	interval      time.Duration
+	exportDir     string
+	exportTTL     time.Duration
+	signingSecret string
+	appName       string
+	appURL        string
+	apiURL        string
+}
+
+func NewAccountWorker(accountRepo repository.AccountRepository, userRepo repository.UserRepository, settingsRepo repository.UserSettingsRepository, templateRepo repository.NoteTemplateRepository, reminderRepo repository.ReminderRepository, emailService *email.EmailService, interval time.Duration, exportDir string, exportTTL time.Duration, signingSecret, appName, appURL, apiURL string) *AccountWorker {
+	return &AccountWorker{
+		accountRepo:   accountRepo,
+		userRepo:      userRepo,
+		settingsRepo:  settingsRepo,
+		templateRepo:  templateRepo,
+		reminderRepo:  reminderRepo,
+		emailService:  emailService,
+		interval:      interval,
+		exportDir:     exportDir,
+		exportTTL:     exportTTL,
+		signingSecret: signingSecret,
+		appName:       appName,
+		appURL:        strings.TrimRight(appURL, "/"),
+		apiURL:        strings.TrimRight(apiURL, "/"),
+	}
+}
+
+// Start runs the polling loop until ctx is cancelled
+func (w *AccountWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	log.Printf("Account worker started (interval %s)", w.interval)
+	for {
+		w.runOnce(time.Now())
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Account worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *AccountWorker) runOnce(now time.Time) {
+	exports, err := w.accountRepo.ClaimPendingExports(now, exportLease, exportBatchSize)
+	if err != nil {
+		log.Printf("Failed to claim data exports: %v", err)
+	}
+	for _, export := range exports {
+		if err := w.buildExport(export); err != nil {
+			log.Printf("Failed to build data export %d: %v", export.ID, err)
+			if err := w.accountRepo.FailExport(export.ID, err.Error()); err != nil {
+				log.Printf("Failed to record data export %d failure: %v", export.ID, err)
+			}
+		}
+	}
+
+	w.removeExpiredExports(now)
+	w.deleteDueAccounts(now)
+}
+
+func (w *AccountWorker) buildExport(export *models.AccountExport) error {
+	user, err := w.userRepo.GetByID(export.UserID)
+	if err != nil {
+		return err
+	}
+	settings, err := w.settingsRepo.Get(export.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil || settings == nil {
+		return fmt.Errorf("user %d no longer exists", export.UserID)
+	}
+
+	if err := os.MkdirAll(w.exportDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+	filePath := filepath.Join(w.exportDir, fmt.Sprintf("export-%d-%d.zip", export.UserID, export.ID))
+
+	size, err := w.writeArchive(filePath, user, settings)
+	if err != nil {
+		os.Remove(filePath)
+		return err
+	}
+
+	// DATETIME has second precision; truncate so the signed expiry matches
+	// what is stored
+	expiresAt := time.Now().Add(w.exportTTL).Truncate(time.Second)
+	export.Status = models.ExportStatusReady
+	export.ExpiresAt = &expiresAt
+
+	var messages []*models.OutboxMessage
+	if w.emailService != nil {
+		msg, err := w.emailService.BuildExportReadyEmail(email.AccountEmailData{
+			Locale:      user.Locale,
+			UserName:    user.FullName,
+			Email:       user.Email,
+			AppName:     w.appName,
+			AppURL:      w.appURL,
+			DownloadURL: export.SignedDownloadURL(w.signingSecret, w.apiURL),
+			ExpiresAt:   expiresAt.In(settings.Location()).Format("Mon, 02 Jan 2006 15:04 MST"),
+		})
+		if err != nil {
+			log.Printf("Failed to build export email for user %d: %v", user.ID, err)
+		} else {
+			messages = append(messages, models.NewOutboxMessage("export_ready", msg))
+		}
+	}
+
+	if err := w.accountRepo.CompleteExport(export.ID, filePath, size, expiresAt, messages); err != nil {
+		os.Remove(filePath)
+		return err
+	}
+
+	log.Printf("Data export %d for user %d is ready (%d bytes)", export.ID, export.UserID, size)
+	return nil
+}
+
+// writeArchive writes the user's data as a zip of JSON documents plus one
+// Markdown file per note, returning the archive size
+func (w *AccountWorker) writeArchive(filePath string, user *models.User, settings *models.UserSettings) (int64, error) {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create export archive: %w", err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+
+	profile := struct {
+		*models.UserResponse
+		Settings *models.UserSettings `json:"settings"`
+	}{user.ToResponse(), settings}
+	if err := writeJSON(archive, "profile.json", profile); err != nil {
+		return 0, err
+	}
+
+	notes, err := newJSONArray(archive, "notes.json")
+	if err != nil {
+		return 0, err
+	}
+	err = w.accountRepo.ForEachNote(user.ID, func(note *models.Note) error {
+		if err := notes.add(note); err != nil {
+			return err
+		}
+		return writeNoteMarkdown(archive, note)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := notes.close(); err != nil {
+		return 0, err
+	}
+
+	revisions, err := newJSONArray(archive, "revisions.json")
+	if err != nil {
+		return 0, err
+	}
+	err = w.accountRepo.ForEachRevision(user.ID, func(revision *models.NoteRevision) error {
+		return revisions.add(revision)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := revisions.close(); err != nil {
+		return 0, err
+	}
+
+	templates, err := w.templateRepo.GetAll(user.ID)
+	if err != nil {
+		return 0, err
+	}
+	if err := writeJSON(archive, "templates.json", templates); err != nil {
+		return 0, err
+	}
+
+	reminders, err := w.reminderRepo.GetAll(user.ID, nil)
+	if err != nil {
+		return 0, err
+	}
+	if err := writeJSON(archive, "reminders.json", reminders); err != nil {
+		return 0, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return 0, fmt.Errorf("failed to finish export archive: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat export archive: %w", err)
+	}
+	return info.Size(), nil
+}
+
+func writeJSON(archive *zip.Writer, name string, v interface{}) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to export: %w", name, err)
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeNoteMarkdown(archive *zip.Writer, note *models.Note) error {
+	name := fmt.Sprintf("notes/%d-%s.md", note.ID, slug(note.Title))
+	f, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to export: %w", name, err)
+	}
+	_, err = fmt.Fprintf(f, "# %s\n\nCategory: %s\nCreated: %s\nUpdated: %s\n\n%s\n",
+		note.Title, note.Category, note.CreatedAt.Format(time.RFC3339), note.UpdatedAt.Format(time.RFC3339), note.Content)
+	return err
+}
+
+// slug keeps letters and digits from title so it is safe as a file name
+func slug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= 80 {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "note"
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// jsonArray streams a JSON array into the archive one element at a time,
+// so large accounts are not held in memory
+type jsonArray struct {
+	w     io.Writer
+	count int
+}
+
+func newJSONArray(archive *zip.Writer, name string) (*jsonArray, error) {
+	f, err := archive.Create(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add %s to export: %w", name, err)
+	}
+	if _, err := io.WriteString(f, "["); err != nil {
+		return nil, err
+	}
+	return &jsonArray{w: f}, nil
+}
+
+func (a *jsonArray) add(v interface{}) error {
+	data, err := json.MarshalIndent(v, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	sep := ",\n  "
+	if a.count == 0 {
+		sep = "\n  "
+	}
+	a.count++
+	if _, err := io.WriteString(a.w, sep); err != nil {
+		return err
+	}
+	_, err = a.w.Write(data)
+	return err
+}
+
+func (a *jsonArray) close() error {
+	end := "\n]\n"
+	if a.count == 0 {
+		end = "]\n"
+	}
+	_, err := io.WriteString(a.w, end)
+	return err
+}
+
+func (w *AccountWorker) removeExpiredExports(now time.Time) {
+	exports, err := w.accountRepo.GetExpiredExports(now)
+	if err != nil {
+		log.Printf("Failed to get expired data exports: %v", err)
+		return
+	}
+
+	for _, export := range exports {
+		if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove data export %d: %v", export.ID, err)
+			continue
+		}
+		if err := w.accountRepo.MarkExportExpired(export.ID); err != nil {
+			log.Printf("Failed to mark data export %d expired: %v", export.ID, err)
+		}
+	}
+}
+
+func (w *AccountWorker) deleteDueAccounts(now time.Time) {
+	users, err := w.accountRepo.GetDueDeletions(now, deletionBatchSize)
+	if err != nil {
+		log.Printf("Failed to get due account deletions: %v", err)
+		return
+	}
+
+	for _, user := range users {
+		// Export rows cascade with the user, so find their files beforehand
+		exports, err := w.accountRepo.GetExports(user.ID)
+		if err != nil {
+			log.Printf("Failed to get data exports of user %d: %v", user.ID, err)
+			continue
+		}
+
+		var messages []*models.OutboxMessage
+		if w.emailService != nil {
+			msg, err := w.emailService.BuildAccountDeletedEmail(email.AccountEmailData{
+				Locale:   user.Locale,
+				UserName: user.FullName,
+				Email:    user.Email,
+				AppName:  w.appName,
+				AppURL:   w.appURL,
+			})
+			if err != nil {
+				log.Printf("Failed to build account deleted email for user %d: %v", user.ID, err)
+			} else {
+				messages = append(messages, models.NewOutboxMessage("account_deleted", msg))
+			}
+		}
+
+		deleted, err := w.accountRepo.DeleteAccount(user.ID, now, messages)
+		if err != nil {
+			log.Printf("Failed to delete account %d: %v", user.ID, err)
+			continue
+		}
+		if !deleted {
+			continue
+		}
+
+		for _, export := range exports {
+			if export.FilePath == "" {
+				continue
+			}
+			if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+				log.Printf("Failed to remove data export %d of deleted user %d: %v", export.ID, user.ID, err)
+			}
+		}
+		log.Printf("Deleted account %d after its grace period", user.ID)
+	}
+}
@@ -0,0 +1,201 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/email"
+	"daily-notes-api/pkg/streak"
+)
+
+// DigestScheduler sends opt-in daily and weekly note summaries. Daily
+// digests cover the previous local day; weekly digests go out on Mondays
+// and cover the previous seven days.
+type DigestScheduler struct {
+	digestRepo   repository.DigestRepository
+	userRepo     repository.UserRepository
+	emailService *email.EmailService
+	interval     time.Duration
+	appName      string
+	appURL       string
+	apiURL       string
+}
+
+func NewDigestScheduler(digestRepo repository.DigestRepository, userRepo repository.UserRepository, emailService *email.EmailService, interval time.Duration, appName, appURL, apiURL string) *DigestScheduler {
+	return &DigestScheduler{
+		digestRepo:   digestRepo,
+		userRepo:     userRepo,
+		emailService: emailService,
+		interval:     interval,
+		appName:      appName,
+		appURL:       strings.TrimRight(appURL, "/"),
+		apiURL:       strings.TrimRight(apiURL, "/"),
+	}
+}
+
+// Start runs the polling loop until ctx is cancelled
+func (s *DigestScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	log.Printf("Digest scheduler started (interval %s)", s.interval)
+	for {
+		s.runOnce(time.Now())
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Digest scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *DigestScheduler) runOnce(now time.Time) {
+	subs, err := s.digestRepo.GetActive()
+	if err != nil {
+		log.Printf("Failed to load digest subscriptions: %v", err)
+		return
+	}
+
+	for _, sub := range subs {
+		from, to, due := digestWindow(sub, now)
+		if !due {
+			continue
+		}
+
+		if err := s.send(sub, from, to, now); err != nil {
+			log.Printf("Failed to queue %s digest for user %d: %v", sub.Frequency, sub.UserID, err)
+		}
+	}
+}
+
+// digestWindow decides whether sub is due at now and which local period the
+// digest covers
+func digestWindow(sub *models.DigestSubscription, now time.Time) (from, to time.Time, due bool) {
+	loc, err := time.LoadLocation(sub.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	local := now.In(loc)
+	if local.Hour() < sub.SendHour {
+		return from, to, false
+	}
+
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	if sub.LastSentAt != nil && !sub.LastSentAt.In(loc).Before(today) {
+		return from, to, false
+	}
+
+	switch sub.Frequency {
+	case models.DigestFrequencyDaily:
+		return today.AddDate(0, 0, -1), today, true
+	case models.DigestFrequencyWeekly:
+		if local.Weekday() != time.Monday {
+			return from, to, false
+		}
+		return today.AddDate(0, 0, -7), today, true
+	default:
+		return from, to, false
+	}
+}
+
+func (s *DigestScheduler) send(sub *models.DigestSubscription, from, to, now time.Time) error {
+	user, err := s.userRepo.GetByID(sub.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("user not found")
+	}
+
+	notes, err := s.digestRepo.GetNotesBetween(sub.UserID, from, to)
+	if err != nil {
+		return err
+	}
+
+	timestamps, err := s.digestRepo.GetNoteTimestamps(sub.UserID)
+	if err != nil {
+		return err
+	}
+
+	loc := from.Location()
+	current, longest := streak.Compute(streak.Days(timestamps, loc), now.In(loc))
+
+	data := email.DigestEmailData{
+		Locale:         user.Locale,
+		UserName:       user.FullName,
+		Email:          user.Email,
+		AppName:        s.appName,
+		AppURL:         s.appURL,
+		PeriodLabel:    sub.Frequency,
+		PeriodStart:    from.Format("Mon, 02 Jan 2006"),
+		PeriodEnd:      to.AddDate(0, 0, -1).Format("Mon, 02 Jan 2006"),
+		TotalNotes:     len(notes),
+		Categories:     countCategories(notes),
+		CurrentStreak:  current,
+		LongestStreak:  longest,
+		UnsubscribeURL: fmt.Sprintf("%s/api/v1/digest/unsubscribe?token=%s", s.apiURL, sub.UnsubscribeToken),
+	}
+
+	for _, note := range notes {
+		data.Notes = append(data.Notes, email.DigestNote{
+			Title:     note.Title,
+			URL:       fmt.Sprintf("%s/notes/%d", s.appURL, note.ID),
+			CreatedAt: note.CreatedAt.In(loc).Format("Mon 15:04"),
+		})
+	}
+
+	msg, err := s.emailService.BuildDigestEmail(data)
+	if err != nil {
+		return err
+	}
+
+	// Only the instance that claims this period queues the digest.
+	// DATETIME has second precision, so compare against a truncated value.
+	sentAt := now.Truncate(time.Second)
+	messages := []*models.OutboxMessage{models.NewOutboxMessage("digest", msg)}
+	claimed, err := s.digestRepo.ClaimSend(sub.UserID, sub.LastSentAt, sentAt, messages)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return nil
+	}
+
+	log.Printf("Queued %s digest for %s", sub.Frequency, user.Email)
+	return nil
+}
+
+// countCategories groups notes by category, most used first
+func countCategories(notes []*models.Note) []email.DigestCategory {
+	counts := make(map[string]int)
+	for _, note := range notes {
+		name := note.Category
+		if name == "" {
+			name = "Uncategorized"
+		}
+		counts[name]++
+	}
+
+	categories := make([]email.DigestCategory, 0, len(counts))
+	for name, count := range counts {
+		categories = append(categories, email.DigestCategory{Name: name, Count: count})
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Count != categories[j].Count {
+			return categories[i].Count > categories[j].Count
+		}
+		return categories[i].Name < categories[j].Name
+	})
+
+	return categories
+}
@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/email"
+)
+
+const (
+	outboxBatchSize = 50
+	outboxLease     = 2 * time.Minute
+	outboxBaseDelay = 30 * time.Second
+	outboxMaxDelay  = 6 * time.Hour
+)
+
+// OutboxWorker delivers queued emails, retrying with exponential backoff and
+// marking a message dead after maxAttempts failures
+type OutboxWorker struct {
+	outboxRepo   repository.OutboxRepository
+	emailService *email.EmailService
+	interval     time.Duration
+	maxAttempts  int
+}
+
+func NewOutboxWorker(outboxRepo repository.OutboxRepository, emailService *email.EmailService, interval time.Duration, maxAttempts int) *OutboxWorker {
+	return &OutboxWorker{
+		outboxRepo:   outboxRepo,
+		emailService: emailService,
+		interval:     interval,
+		maxAttempts:  maxAttempts,
+	}
+}
+
+// Start runs the polling loop until ctx is cancelled
+func (w *OutboxWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	log.Printf("Email outbox worker started (interval %s, max attempts %d)", w.interval, w.maxAttempts)
+	for {
+		w.runOnce()
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Email outbox worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *OutboxWorker) runOnce() {
+	messages, err := w.outboxRepo.ClaimDue(time.Now(), outboxLease, outboxBatchSize)
+	if err != nil {
+		log.Printf("Failed to claim outbox messages: %v", err)
+		return
+	}
+
+	for _, msg := range messages {
+		w.deliver(msg)
+	}
+}
+
+func (w *OutboxWorker) deliver(msg *models.OutboxMessage) {
+	emailData := email.EmailData{
+		To:       msg.Recipient,
+		Subject:  msg.Subject,
+		Body:     msg.HTMLBody,
+		TextBody: msg.TextBody,
+		IsHTML:   msg.HTMLBody != "",
+
+		ListUnsubscribe: msg.ListUnsubscribe,
+	}
+	if !emailData.IsHTML {
+		emailData.Body = msg.TextBody
+	}
+
+	err := w.emailService.SendEmail(emailData)
+	if err == nil {
+		if err := w.outboxRepo.MarkSent(msg.ID, time.Now()); err != nil {
+			log.Printf("Failed to mark outbox message %d sent: %v", msg.ID, err)
+		}
+		log.Printf("Outbox %s email %d sent to %s", msg.Kind, msg.ID, msg.Recipient)
+		return
+	}
+
+	attempts := msg.Attempts + 1
+	if attempts >= w.maxAttempts || email.IsPermanent(err) {
+		log.Printf("Outbox %s email %d to %s is dead after %d attempt(s): %v", msg.Kind, msg.ID, msg.Recipient, attempts, err)
+		if err := w.outboxRepo.MarkDead(msg.ID, err.Error()); err != nil {
+			log.Printf("Failed to mark outbox message %d dead: %v", msg.ID, err)
+		}
+		return
+	}
+
+	nextAttemptAt := time.Now().Add(outboxBackoff(attempts))
+	log.Printf("Outbox %s email %d to %s failed (attempt %d), retrying at %s: %v",
+		msg.Kind, msg.ID, msg.Recipient, attempts, nextAttemptAt.Format(time.RFC3339), err)
+	if err := w.outboxRepo.MarkFailed(msg.ID, err.Error(), nextAttemptAt); err != nil {
+		log.Printf("Failed to record outbox message %d failure: %v", msg.ID, err)
+	}
+}
+
+// outboxBackoff doubles the delay after every failed attempt
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxBaseDelay
+	for i := 1; i < attempts && delay < outboxMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > outboxMaxDelay {
+		delay = outboxMaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,144 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/email"
+	"daily-notes-api/pkg/rrule"
+)
+
+const (
+	reminderBatchSize = 50
+	reminderLease     = 2 * time.Minute
+	reminderMaxRetry  = 30 * time.Minute
+)
+
+// ReminderScheduler polls for due reminders and queues their emails in the
+// outbox
+type ReminderScheduler struct {
+	reminderRepo repository.ReminderRepository
+	emailService *email.EmailService
+	interval     time.Duration
+	appName      string
+	appURL       string
+}
+
+func NewReminderScheduler(reminderRepo repository.ReminderRepository, emailService *email.EmailService, interval time.Duration, appName, appURL string) *ReminderScheduler {
+	return &ReminderScheduler{
+		reminderRepo: reminderRepo,
+		emailService: emailService,
+		interval:     interval,
+		appName:      appName,
+		appURL:       appURL,
+	}
+}
+
+// Start runs the polling loop until ctx is cancelled
+func (s *ReminderScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	log.Printf("Reminder scheduler started (interval %s)", s.interval)
+	for {
+		s.runOnce()
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Reminder scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ReminderScheduler) runOnce() {
+	due, err := s.reminderRepo.ClaimDue(time.Now(), reminderLease, reminderBatchSize)
+	if err != nil {
+		log.Printf("Failed to claim due reminders: %v", err)
+		return
+	}
+
+	for _, reminder := range due {
+		s.deliver(reminder)
+	}
+}
+
+func (s *ReminderScheduler) deliver(reminder *models.DueReminder) {
+	data := email.ReminderEmailData{
+		Locale:    reminder.UserLocale,
+		UserName:  reminder.UserName,
+		Email:     reminder.UserEmail,
+		AppName:   s.appName,
+		NoteTitle: reminder.NoteTitle,
+		Excerpt:   email.Excerpt(reminder.NoteContent, 300),
+		NoteURL:   fmt.Sprintf("%s/notes/%d", strings.TrimRight(s.appURL, "/"), reminder.NoteID),
+	}
+
+	msg, err := s.emailService.BuildReminderEmail(data)
+	if err != nil {
+		retryAt := time.Now().Add(retryDelay(reminder.Attempts))
+		log.Printf("Failed to render reminder %d (attempt %d): %v", reminder.ID, reminder.Attempts+1, err)
+		if err := s.reminderRepo.MarkFailed(reminder.ID, err.Error(), retryAt); err != nil {
+			log.Printf("Failed to record reminder %d failure: %v", reminder.ID, err)
+		}
+		return
+	}
+
+	loc, err := time.LoadLocation(reminder.UserTimezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	next := NextOccurrence(&reminder.Reminder, loc, time.Now())
+	messages := []*models.OutboxMessage{models.NewOutboxMessage("reminder", msg)}
+	if err := s.reminderRepo.MarkSent(reminder.ID, time.Now(), next, messages); err != nil {
+		// The lease expires and the reminder is picked up again
+		log.Printf("Failed to queue reminder %d: %v", reminder.ID, err)
+		return
+	}
+
+	log.Printf("Reminder %d queued for %s", reminder.ID, reminder.UserEmail)
+}
+
+// NextOccurrence returns the first occurrence after both the reminder's
+// current one and now, expanding the rule in loc, or nil when the reminder is
+// one-off or its series is exhausted. Occurrences missed while the scheduler
+// was behind are skipped rather than sent late one after another.
+func NextOccurrence(reminder *models.Reminder, loc *time.Location, now time.Time) *time.Time {
+	if reminder.RRule == "" {
+		return nil
+	}
+
+	rule, err := rrule.Parse(reminder.RRule)
+	if err != nil {
+		log.Printf("Invalid rrule on reminder %d: %v", reminder.ID, err)
+		return nil
+	}
+
+	after := reminder.OccurrenceAt
+	if now.After(after) {
+		after = now
+	}
+	next, ok := rule.Next(reminder.StartsAt.In(loc), after.In(loc))
+	if !ok {
+		return nil
+	}
+	return &next
+}
+
+// retryDelay backs off exponentially from one minute up to reminderMaxRetry
+func retryDelay(attempts int) time.Duration {
+	delay := time.Minute
+	for i := 0; i < attempts && delay < reminderMaxRetry; i++ {
+		delay *= 2
+	}
+	if delay > reminderMaxRetry {
+		delay = reminderMaxRetry
+	}
+	return delay
+}
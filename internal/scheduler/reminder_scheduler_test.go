@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"daily-notes-api/internal/models"
+)
+
+func TestNextOccurrence(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name       string
+		rrule      string
+		occurrence time.Time
+		now        time.Time
+		want       *time.Time
+	}{
+		{"one-off", "", start, start, nil},
+		{"on time", "FREQ=DAILY", start, start.Add(time.Minute), ptr(start.Add(day))},
+		// The scheduler was down for a week: send one reminder, then resume
+		// from the next occurrence instead of catching up day by day
+		{"behind schedule", "FREQ=DAILY", start, start.Add(7*day + time.Hour), ptr(start.Add(8 * day))},
+		{"series ended while behind", "FREQ=DAILY;COUNT=3", start, start.Add(7 * day), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reminder := &models.Reminder{RRule: tt.rrule, StartsAt: start, OccurrenceAt: tt.occurrence}
+			got := NextOccurrence(reminder, time.UTC, tt.now)
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("NextOccurrence() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"daily-notes-api/internal/models"
+	"daily-notes-api/internal/repository"
+	"daily-notes-api/pkg/webhook"
+)
+
+const (
+	webhookBatchSize = 50
+	webhookLease     = 2 * time.Minute
+	webhookBaseDelay = 30 * time.Second
+	webhookMaxDelay  = 6 * time.Hour
+)
+
+// WebhookWorker posts queued webhook deliveries, retrying with exponential
+// backoff. A delivery fails for good after maxAttempts, and a webhook is
+// disabled after disableAfter consecutive failed attempts.
+type WebhookWorker struct {
+	webhookRepo  repository.WebhookRepository
+	sender       *webhook.Sender
+	interval     time.Duration
+	maxAttempts  int
+	disableAfter int
+}
+
+func NewWebhookWorker(webhookRepo repository.WebhookRepository, sender *webhook.Sender, interval time.Duration, maxAttempts, disableAfter int) *WebhookWorker {
+	return &WebhookWorker{
+		webhookRepo:  webhookRepo,
+		sender:       sender,
+		interval:     interval,
+		maxAttempts:  maxAttempts,
+		disableAfter: disableAfter,
+	}
+}
+
+// Start runs the polling loop until ctx is cancelled
+func (w *WebhookWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	log.Printf("Webhook worker started (interval %s, max attempts %d)", w.interval, w.maxAttempts)
+	for {
+		w.runOnce()
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Webhook worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *WebhookWorker) runOnce() {
+	deliveries, err := w.webhookRepo.ClaimDue(time.Now(), webhookLease, webhookBatchSize)
+	if err != nil {
+		log.Printf("Failed to claim webhook deliveries: %v", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		w.deliver(delivery)
+	}
+}
+
+func (w *WebhookWorker) deliver(delivery *models.DueWebhookDelivery) {
+	result, err := w.sender.Send(&webhook.Request{
+		URL:        delivery.URL,
+		Secret:     delivery.Secret,
+		Event:      delivery.Event,
+		DeliveryID: delivery.ID,
+		Payload:    []byte(delivery.Payload),
+	})
+	if err == nil {
+		if err := w.webhookRepo.MarkSucceeded(delivery.ID, result.StatusCode, result.Body, time.Now()); err != nil {
+			log.Printf("Failed to mark webhook delivery %d succeeded: %v", delivery.ID, err)
+		}
+		if err := w.webhookRepo.RecordSuccess(delivery.WebhookID); err != nil {
+			log.Printf("Failed to reset webhook %d failures: %v", delivery.WebhookID, err)
+		}
+		return
+	}
+
+	var responseStatus *int
+	if result.StatusCode != 0 {
+		responseStatus = &result.StatusCode
+	}
+
+	attempts := delivery.Attempts + 1
+	var nextAttemptAt *time.Time
+	if attempts < w.maxAttempts {
+		next := time.Now().Add(webhookBackoff(attempts))
+		nextAttemptAt = &next
+		log.Printf("Webhook delivery %d to %s failed (attempt %d), retrying at %s: %v",
+			delivery.ID, delivery.URL, attempts, next.Format(time.RFC3339), err)
+	} else {
+		log.Printf("Webhook delivery %d to %s failed after %d attempt(s): %v", delivery.ID, delivery.URL, attempts, err)
+	}
+
+	if err := w.webhookRepo.MarkFailed(delivery.ID, responseStatus, result.Body, err.Error(), nextAttemptAt); err != nil {
+		log.Printf("Failed to record webhook delivery %d failure: %v", delivery.ID, err)
+	}
+
+	disabled, err := w.webhookRepo.RecordFailure(delivery.WebhookID, w.disableAfter)
+	if err != nil {
+		log.Printf("Failed to record webhook %d failure: %v", delivery.WebhookID, err)
+	}
+	if disabled {
+		log.Printf("Webhook %d disabled after %d consecutive failures", delivery.WebhookID, w.disableAfter)
+	}
+}
+
+// webhookBackoff doubles the delay after every failed attempt
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookBaseDelay
+	for i := 1; i < attempts && delay < webhookMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxDelay {
+		delay = webhookMaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS note_templates (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    title_template VARCHAR(255) NOT NULL,
+    body_template TEXT NOT NULL,
+    category VARCHAR(100) NOT NULL DEFAULT '',
+    prompts TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_user_id (user_id),
+    CONSTRAINT fk_note_templates_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
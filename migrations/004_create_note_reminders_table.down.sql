@@ -0,0 +1 @@
+DROP TABLE IF EXISTS note_reminders;
\ No newline at end of file
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS note_reminders (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    note_id INT NOT NULL,
+    user_id INT NOT NULL,
+    starts_at DATETIME NOT NULL,
+    rrule VARCHAR(255) NOT NULL DEFAULT '',
+    occurrence_at DATETIME NOT NULL,
+    remind_at DATETIME NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    sent_count INT NOT NULL DEFAULT 0,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error VARCHAR(500) NOT NULL DEFAULT '',
+    last_sent_at DATETIME NULL,
+    locked_until DATETIME NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_note_id (note_id),
+    INDEX idx_user_id (user_id),
+    INDEX idx_status_remind_at (status, remind_at),
+    CONSTRAINT fk_note_reminders_note_id FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE,
+    CONSTRAINT fk_note_reminders_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS digest_subscriptions;
\ No newline at end of file
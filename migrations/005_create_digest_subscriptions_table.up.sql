@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS digest_subscriptions (
+    user_id INT PRIMARY KEY,
+    frequency VARCHAR(10) NOT NULL DEFAULT 'none',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    send_hour TINYINT NOT NULL DEFAULT 7,
+    unsubscribe_token VARCHAR(64) NOT NULL,
+    last_sent_at DATETIME NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_unsubscribe_token (unsubscribe_token),
+    INDEX idx_frequency (frequency),
+    CONSTRAINT fk_digest_subscriptions_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
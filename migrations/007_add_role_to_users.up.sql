@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user' AFTER full_name;
+
+-- The seeded administrator account gets admin privileges
+UPDATE users SET role = 'admin' WHERE username = 'admin';
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN locale VARCHAR(10) NOT NULL DEFAULT 'en' AFTER role;
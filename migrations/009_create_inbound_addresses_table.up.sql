@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS inbound_addresses (
+    user_id INT PRIMARY KEY,
+    token VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_token (token),
+    CONSTRAINT fk_inbound_addresses_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
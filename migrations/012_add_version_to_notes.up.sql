@@ -0,0 +1 @@
+ALTER TABLE notes ADD COLUMN version INT NOT NULL DEFAULT 1 AFTER category;
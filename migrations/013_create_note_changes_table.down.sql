@@ -0,0 +1 @@
+DROP TABLE IF EXISTS note_changes;
\ No newline at end of file
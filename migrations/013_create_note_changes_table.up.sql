@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS note_changes (
+    seq BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    note_id INT NOT NULL,
+    op VARCHAR(10) NOT NULL,
+    version INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_user_id_seq (user_id, seq),
+    CONSTRAINT fk_note_changes_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Existing notes start the change log so a first sync returns everything
+INSERT INTO note_changes (user_id, note_id, op, version)
+SELECT user_id, id, 'upsert', version FROM notes ORDER BY id;
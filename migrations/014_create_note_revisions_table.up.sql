@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS note_revisions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    note_id INT NOT NULL,
+    user_id INT NOT NULL,
+    version INT NOT NULL,
+    revision INT NOT NULL,
+    content MEDIUMTEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_note_id_id (note_id, id),
+    CONSTRAINT fk_note_revisions_note_id FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE,
+    CONSTRAINT fk_note_revisions_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
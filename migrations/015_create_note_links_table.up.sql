@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS note_links (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    source_note_id INT NOT NULL,
+    target_note_id INT NULL,
+    target_title VARCHAR(255) NOT NULL,
+    by_id BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_source_note_id (source_note_id),
+    INDEX idx_target_note_id (target_note_id),
+    INDEX idx_user_id_target_title (user_id, target_title),
+    CONSTRAINT fk_note_links_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_note_links_source_note_id FOREIGN KEY (source_note_id) REFERENCES notes(id) ON DELETE CASCADE,
+    CONSTRAINT fk_note_links_target_note_id FOREIGN KEY (target_note_id) REFERENCES notes(id) ON DELETE SET NULL
+);
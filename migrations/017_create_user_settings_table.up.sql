@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id INT PRIMARY KEY,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    week_start VARCHAR(10) NOT NULL DEFAULT 'monday',
+    default_category VARCHAR(100) NOT NULL DEFAULT '',
+    default_page_size INT NOT NULL DEFAULT 10,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user_settings_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Users who already picked a digest timezone keep it as their account timezone
+INSERT IGNORE INTO user_settings (user_id, timezone)
+SELECT user_id, timezone FROM digest_subscriptions;
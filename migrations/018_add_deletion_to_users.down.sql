@@ -0,0 +1 @@
+ALTER TABLE users DROP INDEX idx_deletion_scheduled_at, DROP COLUMN deletion_scheduled_at;
\ No newline at end of file
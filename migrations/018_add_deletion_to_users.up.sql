@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN deletion_scheduled_at DATETIME NULL AFTER locale,
+    ADD INDEX idx_deletion_scheduled_at (deletion_scheduled_at);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS account_exports (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    file_path VARCHAR(500) NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    last_error VARCHAR(1000) NOT NULL DEFAULT '',
+    locked_until DATETIME NULL,
+    expires_at DATETIME NULL,
+    completed_at DATETIME NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_user_id_created_at (user_id, created_at),
+    INDEX idx_status (status),
+    CONSTRAINT fk_account_exports_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS login_failures (
+    user_id INT PRIMARY KEY,
+    attempts INT NOT NULL DEFAULT 0,
+    last_failed_at DATETIME NOT NULL,
+    locked_until DATETIME NULL,
+    CONSTRAINT fk_login_failures_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_known_logins (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    device VARCHAR(100) NOT NULL,
+    ip_range VARCHAR(50) NOT NULL,
+    last_seen_at DATETIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_user_device_ip_range (user_id, device, ip_range),
+    CONSTRAINT fk_user_known_logins_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+ALTER TABLE email_outbox DROP COLUMN list_unsubscribe;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE email_outbox ADD COLUMN list_unsubscribe VARCHAR(1000) NOT NULL DEFAULT '' AFTER text_body;
@@ -0,0 +1,2 @@
+-- Admin privileges are not restored for the seeded account
+SELECT 1;
\ No newline at end of file
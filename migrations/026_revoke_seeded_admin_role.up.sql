@@ -0,0 +1,4 @@
+-- The seeded account has a published password, so it must not keep admin
+-- privileges. Administrators are granted with BOOTSTRAP_ADMIN instead.
+UPDATE users SET role = 'user'
+WHERE username = 'admin' AND password_hash = '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS oidc_pending_links;
+ALTER TABLE users DROP COLUMN email_verified_at;
\ No newline at end of file
@@ -0,0 +1,19 @@
+-- Set when this app knows the user controls the address, e.g. because an
+-- identity provider verified it. Cleared whenever the email changes.
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME NULL AFTER email;
+
+-- Identity provider logins waiting for the user to confirm linking them to
+-- an existing account with its password
+CREATE TABLE IF NOT EXISTS oidc_pending_links (
+    token_hash CHAR(64) PRIMARY KEY,
+    user_id INT NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(100) NOT NULL,
+    email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    device VARCHAR(100) NOT NULL DEFAULT '',
+    expires_at DATETIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_expires_at (expires_at),
+    CONSTRAINT fk_oidc_pending_links_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS login_failures;
+
+CREATE TABLE login_failures (
+    user_id INT PRIMARY KEY,
+    attempts INT NOT NULL DEFAULT 0,
+    last_failed_at DATETIME NOT NULL,
+    locked_until DATETIME NULL,
+    CONSTRAINT fk_login_failures_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
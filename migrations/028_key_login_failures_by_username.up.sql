@@ -0,0 +1,11 @@
+-- Failed logins are counted per submitted username, whether or not an
+-- account has it, so throttling does not reveal which accounts exist
+DROP TABLE IF EXISTS login_failures;
+
+CREATE TABLE login_failures (
+    username VARCHAR(100) PRIMARY KEY,
+    attempts INT NOT NULL DEFAULT 0,
+    last_attempt_at DATETIME NOT NULL,
+    locked_until DATETIME NULL,
+    INDEX idx_last_attempt_at (last_attempt_at)
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS note_collaborators;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS note_collaborators (
+    note_id INT NOT NULL,
+    user_id INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (note_id, user_id),
+    INDEX idx_user_id (user_id),
+    CONSTRAINT fk_note_collaborators_note_id FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE,
+    CONSTRAINT fk_note_collaborators_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
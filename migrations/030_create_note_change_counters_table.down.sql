@@ -0,0 +1,7 @@
+-- Sequence numbers are only unique per user now, so seq is indexed rather
+-- than made the primary key again
+ALTER TABLE note_changes
+    ADD INDEX idx_seq (seq),
+    MODIFY seq BIGINT NOT NULL AUTO_INCREMENT;
+
+DROP TABLE IF EXISTS note_change_counters;
\ No newline at end of file
@@ -0,0 +1,19 @@
+-- Change log sequence numbers are allocated per user from a counter row that
+-- stays locked until the writing transaction commits. With AUTO_INCREMENT a
+-- transaction could commit a lower seq after a reader had already moved past
+-- it, and that change was never synced.
+CREATE TABLE IF NOT EXISTS note_change_counters (
+    user_id INT PRIMARY KEY,
+    seq BIGINT NOT NULL DEFAULT 0,
+    CONSTRAINT fk_note_change_counters_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Existing sequence numbers stay valid as sync tokens
+INSERT INTO note_change_counters (user_id, seq)
+SELECT id, COALESCE((SELECT MAX(seq) FROM note_changes WHERE note_changes.user_id = users.id), 0) FROM users;
+
+ALTER TABLE note_changes
+    MODIFY seq BIGINT NOT NULL,
+    DROP PRIMARY KEY,
+    ADD PRIMARY KEY (user_id, seq),
+    DROP INDEX idx_user_id_seq;
@@ -0,0 +1,3 @@
+ALTER TABLE notes
+    DROP INDEX uq_notes_user_client_id,
+    DROP COLUMN client_id;
\ No newline at end of file
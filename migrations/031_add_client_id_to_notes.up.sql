@@ -0,0 +1,5 @@
+-- Offline clients tag the notes they create so a retried sync push does not
+-- create them twice
+ALTER TABLE notes
+    ADD COLUMN client_id VARCHAR(64) NULL AFTER user_id,
+    ADD UNIQUE KEY uq_notes_user_client_id (user_id, client_id);
@@ -1,10 +1,14 @@
 package auth
 
 import (
+	"crypto"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"daily-notes-api/internal/config"
+	"daily-notes-api/pkg/jwks"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -12,27 +16,158 @@ import (
 type JWTClaims struct {
 	UserID   int    `json:"user_id"`
 	Username string `json:"username"`
+	// SessionID ties the token to a session that can be revoked
+	SessionID int `json:"sid,omitempty"`
+
+	// OAuth access tokens are tied to a grant instead, and carry the client
+	// and scopes they were issued for
+	GrantID  int    `json:"gid,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+
 	jwt.RegisteredClaims
 }
 
+// Scopes returns the space separated scope claim as a list
+func (c *JWTClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// JWTManager signs tokens with HS256 and JWTSecret, or with RS256 or EdDSA
+// and a private key. Asymmetric tokens carry the key id in their kid header,
+// so retired keys can keep verifying tokens while a new one signs.
 type JWTManager struct {
-	secretKey string
-	expiry    time.Duration
+	method     jwt.SigningMethod
+	signingKey interface{}
+	kid        string
+	expiry     time.Duration
+
+	// Keys accepted on tokens with a kid header, by kid
+	verificationKeys map[string]verificationKey
+	// Secret accepted on HS256 tokens without a kid, issued before a
+	// switch to asymmetric signing
+	secret []byte
+	keySet jwks.Set
+}
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+}
+
+func NewJWTManager(cfg *config.Config) (*JWTManager, error) {
+	j := &JWTManager{
+		expiry:           time.Hour * time.Duration(cfg.JWTExpiryHours),
+		verificationKeys: make(map[string]verificationKey),
+		keySet:           jwks.Set{Keys: []jwks.Key{}},
+	}
+
+	switch cfg.JWTAlgorithm {
+	case "HS256":
+		j.method = jwt.SigningMethodHS256
+		j.signingKey = []byte(cfg.JWTSecret)
+		j.secret = []byte(cfg.JWTSecret)
+		if len(cfg.JWTVerificationKeyFiles) > 0 {
+			return nil, errors.New("verification keys need JWT_ALGORITHM RS256 or EdDSA")
+		}
+		return j, nil
+
+	case "RS256", "EdDSA":
+		if cfg.JWTSigningKeyFile == "" {
+			return nil, fmt.Errorf("JWT_SIGNING_KEY_FILE is required for %s", cfg.JWTAlgorithm)
+		}
+		signer, err := loadPrivateKey(cfg.JWTSigningKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		kid, err := j.addVerificationKey(signer.Public())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.JWTSigningKeyFile, err)
+		}
+		j.method = j.verificationKeys[kid].method
+		if j.method.Alg() != cfg.JWTAlgorithm {
+			return nil, fmt.Errorf("%s: key does not match JWT_ALGORITHM %s", cfg.JWTSigningKeyFile, cfg.JWTAlgorithm)
+		}
+		j.signingKey = signer
+		j.kid = kid
+
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q", cfg.JWTAlgorithm)
+	}
+
+	for _, path := range cfg.JWTVerificationKeyFiles {
+		pub, err := loadPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := j.addVerificationKey(pub); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	// Keep sessions from before the switch to asymmetric signing valid
+	if cfg.JWTSecret != config.DefaultJWTSecret {
+		j.secret = []byte(cfg.JWTSecret)
+	}
+
+	return j, nil
+}
+
+func (j *JWTManager) addVerificationKey(pub crypto.PublicKey) (string, error) {
+	method, err := signingMethodFor(pub)
+	if err != nil {
+		return "", err
+	}
+	key, err := jwks.FromPublicKey(pub, method.Alg())
+	if err != nil {
+		return "", err
+	}
+	if _, exists := j.verificationKeys[key.Kid]; !exists {
+		j.verificationKeys[key.Kid] = verificationKey{method: method, key: pub}
+		j.keySet.Keys = append(j.keySet.Keys, key)
+	}
+	return key.Kid, nil
+}
+
+// KeySet returns the public keys tokens are verified with. It is empty for
+// HS256.
+func (j *JWTManager) KeySet() jwks.Set {
+	return j.keySet
+}
+
+// Expiry is how long generated tokens are valid
+func (j *JWTManager) Expiry() time.Duration {
+	return j.expiry
 }
 
-func NewJWTManager(cfg *config.Config) *JWTManager {
-	return &JWTManager{
-		secretKey: cfg.JWTSecret,
-		expiry:    time.Hour * time.Duration(cfg.JWTExpiryHours),
+func (j *JWTManager) GenerateToken(userID int, username string, sessionID int) (string, error) {
+	claims := JWTClaims{
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.expiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "daily-notes-api",
+			Subject:   username,
+		},
 	}
+
+	return j.sign(claims)
 }
 
-func (j *JWTManager) GenerateToken(userID int, username string) (string, error) {
+// GenerateOAuthToken issues an access token for a third-party client,
+// limited to scopes and valid for expiry
+func (j *JWTManager) GenerateOAuthToken(userID int, username, clientID string, grantID int, scopes []string, expiry time.Duration) (string, error) {
 	claims := JWTClaims{
 		UserID:   userID,
 		Username: username,
+		GrantID:  grantID,
+		ClientID: clientID,
+		Scope:    strings.Join(scopes, " "),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.expiry)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "daily-notes-api",
@@ -40,17 +175,19 @@ func (j *JWTManager) GenerateToken(userID int, username string) (string, error)
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+	return j.sign(claims)
+}
+
+func (j *JWTManager) sign(claims JWTClaims) (string, error) {
+	token := jwt.NewWithClaims(j.method, claims)
+	if j.kid != "" {
+		token.Header["kid"] = j.kid
+	}
+	return token.SignedString(j.signingKey)
 }
 
 func (j *JWTManager) ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
-		}
-		return []byte(j.secretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, j.verificationKey)
 
 	if err != nil {
 		return nil, err
@@ -62,3 +199,24 @@ func (j *JWTManager) ValidateToken(tokenString string) (*JWTClaims, error) {
 
 	return nil, errors.New("invalid token")
 }
+
+// verificationKey picks the key for a token by its kid header. The key also
+// fixes the algorithm, so a token cannot pick a weaker one.
+func (j *JWTManager) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if j.secret == nil || token.Method != jwt.SigningMethodHS256 {
+			return nil, errors.New("invalid signing method")
+		}
+		return j.secret, nil
+	}
+
+	key, ok := j.verificationKeys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method != key.method {
+		return nil, errors.New("invalid signing method")
+	}
+	return key.key, nil
+}
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"daily-notes-api/internal/config"
+	"daily-notes-api/pkg/jwks"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeKey writes key to a PEM file in dir and returns its path
+func writeKey(t *testing.T, dir, name string, key interface{}) string {
+	t.Helper()
+	var block *pem.Block
+	switch key := key.(type) {
+	case crypto.Signer:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	default:
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func jwtConfig(algorithm, secret, signingKeyFile string, verificationKeyFiles ...string) *config.Config {
+	return &config.Config{
+		JWTSecret:               secret,
+		JWTExpiryHours:          1,
+		JWTAlgorithm:            algorithm,
+		JWTSigningKeyFile:       signingKeyFile,
+		JWTVerificationKeyFiles: verificationKeyFiles,
+	}
+}
+
+func newTestJWTManager(t *testing.T, cfg *config.Config) *JWTManager {
+	t.Helper()
+	j, err := NewJWTManager(cfg)
+	if err != nil {
+		t.Fatalf("NewJWTManager: %v", err)
+	}
+	return j
+}
+
+func thumbprint(t *testing.T, pub crypto.PublicKey, alg string) string {
+	t.Helper()
+	key, err := jwks.FromPublicKey(pub, alg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key.Kid
+}
+
+func TestJWTManagerKidSelection(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, retiredKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, strayKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaPath := writeKey(t, dir, "rsa.pem", rsaKey)
+	edPath := writeKey(t, dir, "ed25519.pem", edKey)
+	retiredPath := writeKey(t, dir, "retired.pem", retiredKey)
+	retiredPubPath := writeKey(t, dir, "retired.pub", retiredKey.Public())
+	strayPath := writeKey(t, dir, "stray.pem", strayKey)
+
+	hs256Legacy := newTestJWTManager(t, jwtConfig("HS256", "legacy-secret", ""))
+	hs256Default := newTestJWTManager(t, jwtConfig("HS256", config.DefaultJWTSecret, ""))
+	rs256 := newTestJWTManager(t, jwtConfig("RS256", "legacy-secret", rsaPath))
+	eddsa := newTestJWTManager(t, jwtConfig("EdDSA", "legacy-secret", edPath, retiredPubPath))
+	eddsaNoLegacy := newTestJWTManager(t, jwtConfig("EdDSA", config.DefaultJWTSecret, edPath))
+	retired := newTestJWTManager(t, jwtConfig("EdDSA", config.DefaultJWTSecret, retiredPath))
+	stray := newTestJWTManager(t, jwtConfig("EdDSA", config.DefaultJWTSecret, strayPath))
+
+	tests := []struct {
+		name     string
+		issuer   *JWTManager
+		verifier *JWTManager
+		wantKid  string
+		wantErr  bool
+	}{
+		{"HS256 round trip", hs256Legacy, hs256Legacy, "", false},
+		{"RS256 round trip", rs256, rs256, thumbprint(t, rsaKey.Public(), "RS256"), false},
+		{"EdDSA round trip", eddsa, eddsa, thumbprint(t, edKey.Public(), "EdDSA"), false},
+		{"retired key still verifies", retired, eddsa, thumbprint(t, retiredKey.Public(), "EdDSA"), false},
+		{"unknown kid", stray, eddsa, thumbprint(t, strayKey.Public(), "EdDSA"), true},
+		{"legacy HS256 token after switch", hs256Legacy, eddsa, "", false},
+		{"HS256 token with the default secret", hs256Default, eddsaNoLegacy, "", true},
+		{"HS256 token with another secret", hs256Default, hs256Legacy, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.issuer.GenerateToken(7, "alice", 3)
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &JWTClaims{})
+			if err != nil {
+				t.Fatalf("ParseUnverified: %v", err)
+			}
+			if kid, _ := parsed.Header["kid"].(string); kid != tt.wantKid {
+				t.Errorf("kid = %q, want %q", kid, tt.wantKid)
+			}
+
+			claims, err := tt.verifier.ValidateToken(token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateToken error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (claims.UserID != 7 || claims.SessionID != 3) {
+				t.Errorf("claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestJWTManagerRejectsAlgorithmMismatch(t *testing.T) {
+	dir := t.TempDir()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j := newTestJWTManager(t, jwtConfig("EdDSA", "legacy-secret", writeKey(t, dir, "ed25519.pem", edKey)))
+
+	// An HS256 token naming the EdDSA key id must not be checked against it
+	claims := JWTClaims{
+		UserID: 7,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = thumbprint(t, edKey.Public(), "EdDSA")
+	signed, err := token.SignedString([]byte("legacy-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := j.ValidateToken(signed); err == nil {
+		t.Error("ValidateToken accepted an HS256 token with an EdDSA kid")
+	}
+}
+
+func TestNewJWTManagerValidatesConfig(t *testing.T) {
+	dir := t.TempDir()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smallRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPath := writeKey(t, dir, "ed25519.pem", edKey)
+	smallPath := writeKey(t, dir, "small.pem", smallRSA)
+	garbagePath := filepath.Join(dir, "garbage.pem")
+	if err := os.WriteFile(garbagePath, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     *config.Config
+		wantErr bool
+	}{
+		{"HS256", jwtConfig("HS256", "secret", ""), false},
+		{"EdDSA", jwtConfig("EdDSA", "secret", edPath), false},
+		{"unknown algorithm", jwtConfig("HS512", "secret", ""), true},
+		{"HS256 with verification keys", jwtConfig("HS256", "secret", "", edPath), true},
+		{"missing signing key", jwtConfig("EdDSA", "secret", ""), true},
+		{"key does not match algorithm", jwtConfig("RS256", "secret", edPath), true},
+		{"RSA key too small", jwtConfig("RS256", "secret", smallPath), true},
+		{"signing key not PEM", jwtConfig("EdDSA", "secret", garbagePath), true},
+		{"missing verification key", jwtConfig("EdDSA", "secret", edPath, filepath.Join(dir, "missing.pem")), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJWTManager(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewJWTManager error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,17 +1,212 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"daily-notes-api/internal/config"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword generates a bcrypt hash of the password
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// passwordScheme is one password hashing algorithm
+type passwordScheme interface {
+	// Recognizes reports whether encoded was produced by this scheme
+	Recognizes(encoded string) bool
+	Hash(password string) (string, error)
+	Verify(password, encoded string) bool
+	// Current reports whether encoded uses this scheme's current parameters
+	Current(encoded string) bool
+}
+
+// PasswordHasher hashes new passwords with the configured algorithm and
+// verifies hashes of every supported one, so existing bcrypt hashes keep
+// working after the switch to Argon2id
+type PasswordHasher struct {
+	preferred passwordScheme
+	schemes   []passwordScheme
+
+	// slots bounds concurrent hashing, so a burst of logins cannot allocate
+	// Argon2id memory without limit
+	slots chan struct{}
+}
+
+func NewPasswordHasher(cfg *config.Config) (*PasswordHasher, error) {
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	if cfg.Argon2MemoryKiB < 8*uint32(cfg.Argon2Parallelism) || cfg.Argon2Iterations < 1 || cfg.Argon2Parallelism < 1 {
+		return nil, errors.New("invalid Argon2id parameters")
+	}
+	if cfg.PasswordHashConcurrency < 1 {
+		return nil, errors.New("PASSWORD_HASH_CONCURRENCY must be at least 1")
+	}
+
+	argon := &argon2idScheme{
+		memory:      cfg.Argon2MemoryKiB,
+		iterations:  cfg.Argon2Iterations,
+		parallelism: cfg.Argon2Parallelism,
+	}
+	bcryptScheme := &bcryptScheme{cost: cfg.BcryptCost}
+
+	h := &PasswordHasher{
+		schemes: []passwordScheme{argon, bcryptScheme},
+		slots:   make(chan struct{}, cfg.PasswordHashConcurrency),
+	}
+	switch cfg.PasswordHashAlgorithm {
+	case "argon2id":
+		h.preferred = argon
+	case "bcrypt":
+		h.preferred = bcryptScheme
+	default:
+		return nil, fmt.Errorf("unsupported PASSWORD_HASH_ALGORITHM %q", cfg.PasswordHashAlgorithm)
+	}
+	return h, nil
+}
+
+// Hash hashes password with the configured algorithm
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	h.slots <- struct{}{}
+	defer func() { <-h.slots }()
+
+	return h.preferred.Hash(password)
+}
+
+// Verify reports whether password matches encoded, and whether a matching
+// hash should be replaced by a fresh Hash because it uses another algorithm
+// or outdated parameters
+func (h *PasswordHasher) Verify(password, encoded string) (match, rehash bool) {
+	for _, scheme := range h.schemes {
+		if !scheme.Recognizes(encoded) {
+			continue
+		}
+		if !h.verify(scheme, password, encoded) {
+			return false, false
+		}
+		return true, scheme != h.preferred || !scheme.Current(encoded)
+	}
+	return false, false
+}
+
+func (h *PasswordHasher) verify(scheme passwordScheme, password, encoded string) bool {
+	h.slots <- struct{}{}
+	defer func() { <-h.slots }()
+
+	return scheme.Verify(password, encoded)
+}
+
+// argon2idScheme stores hashes in the PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+type argon2idScheme struct {
+	memory      uint32 // KiB
+	iterations  uint32
+	parallelism uint8
+}
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+type argon2Hash struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (s *argon2idScheme) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (s *argon2idScheme) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, s.iterations, s.memory, s.parallelism, argon2KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, s.memory, s.iterations, s.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (s *argon2idScheme) Verify(password, encoded string) bool {
+	h, err := parseArgon2Hash(encoded)
+	if err != nil {
+		return false
+	}
+
+	key := argon2.IDKey([]byte(password), h.salt, h.iterations, h.memory, h.parallelism, uint32(len(h.key)))
+	return subtle.ConstantTimeCompare(key, h.key) == 1
+}
+
+func (s *argon2idScheme) Current(encoded string) bool {
+	h, err := parseArgon2Hash(encoded)
+	if err != nil {
+		return false
+	}
+	return h.memory == s.memory && h.iterations == s.iterations && h.parallelism == s.parallelism &&
+		len(h.salt) == argon2SaltLength && len(h.key) == argon2KeyLength
+}
+
+func parseArgon2Hash(encoded string) (*argon2Hash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, errors.New("unsupported argon2id version")
+	}
+
+	h := &argon2Hash{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.iterations, &h.parallelism); err != nil {
+		return nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+	if h.iterations < 1 || h.parallelism < 1 {
+		return nil, errors.New("invalid argon2id parameters")
+	}
+
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	if h.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(h.key) == 0 {
+		return nil, errors.New("invalid argon2id key")
+	}
+	return h, nil
+}
+
+// bcryptScheme is kept for hashes created before Argon2id. Bcrypt only
+// uses the first 72 bytes of a password and rejects longer ones.
+type bcryptScheme struct {
+	cost int
+}
+
+func (s *bcryptScheme) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (s *bcryptScheme) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), s.cost)
 	return string(bytes), err
 }
 
-// CheckPasswordHash compares a password with a hash
-func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+func (s *bcryptScheme) Verify(password, encoded string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) == nil
+}
+
+func (s *bcryptScheme) Current(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err == nil && cost == s.cost
 }
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"daily-notes-api/internal/config"
+)
+
+func hasherConfig(algorithm string, memoryKiB uint32, cost int) *config.Config {
+	return &config.Config{
+		PasswordHashAlgorithm: algorithm,
+		Argon2MemoryKiB:       memoryKiB,
+		Argon2Iterations:      1,
+		Argon2Parallelism:     1,
+		BcryptCost:            cost,
+
+		PasswordHashConcurrency: 2,
+	}
+}
+
+func newTestHasher(t *testing.T, cfg *config.Config) *PasswordHasher {
+	t.Helper()
+	h, err := NewPasswordHasher(cfg)
+	if err != nil {
+		t.Fatalf("NewPasswordHasher: %v", err)
+	}
+	return h
+}
+
+func TestPasswordHasherVerify(t *testing.T) {
+	argon := hasherConfig("argon2id", 64, 4)
+	argonStronger := hasherConfig("argon2id", 128, 4)
+	bcrypt := hasherConfig("bcrypt", 64, 4)
+	bcryptStronger := hasherConfig("bcrypt", 64, 5)
+
+	tests := []struct {
+		name       string
+		hashWith   *config.Config
+		verifyWith *config.Config
+		password   string
+		wantMatch  bool
+		wantRehash bool
+	}{
+		{"argon2id current", argon, argon, "secret", true, false},
+		{"argon2id wrong password", argon, argon, "wrong", false, false},
+		{"argon2id outdated memory", argon, argonStronger, "secret", true, true},
+		{"bcrypt current", bcrypt, bcrypt, "secret", true, false},
+		{"bcrypt wrong password", bcrypt, bcrypt, "wrong", false, false},
+		{"bcrypt outdated cost", bcrypt, bcryptStronger, "secret", true, true},
+		{"bcrypt migrated to argon2id", bcrypt, argon, "secret", true, true},
+		{"argon2id with bcrypt preferred", argon, bcrypt, "secret", true, true},
+		{"wrong password is never rehashed", bcrypt, argon, "wrong", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := newTestHasher(t, tt.hashWith).Hash("secret")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+
+			match, rehash := newTestHasher(t, tt.verifyWith).Verify(tt.password, encoded)
+			if match != tt.wantMatch || rehash != tt.wantRehash {
+				t.Errorf("Verify = (%v, %v), want (%v, %v)", match, rehash, tt.wantMatch, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherRejectsMalformedHashes(t *testing.T) {
+	h := newTestHasher(t, hasherConfig("argon2id", 64, 4))
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"empty", ""},
+		{"plain text", "secret"},
+		{"unknown scheme", "$scrypt$ln=15,r=8,p=1$c2FsdA$a2V5"},
+		{"argon2id missing parts", "$argon2id$v=19$m=64,t=1,p=1$c2FsdA"},
+		{"argon2id wrong version", "$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHRzYWx0$a2V5"},
+		{"argon2id zero iterations", "$argon2id$v=19$m=64,t=0,p=1$c2FsdHNhbHRzYWx0$a2V5"},
+		{"argon2id bad salt", "$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5"},
+		{"argon2id empty key", "$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0$"},
+		{"bcrypt truncated", "$2a$04$abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if match, rehash := h.Verify("secret", tt.encoded); match || rehash {
+				t.Errorf("Verify = (%v, %v), want (false, false)", match, rehash)
+			}
+		})
+	}
+}
+
+func TestNewPasswordHasherValidatesConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *config.Config
+		wantErr bool
+	}{
+		{"argon2id", hasherConfig("argon2id", 64, 10), false},
+		{"bcrypt", hasherConfig("bcrypt", 64, 10), false},
+		{"unknown algorithm", hasherConfig("md5", 64, 10), true},
+		{"bcrypt cost too low", hasherConfig("bcrypt", 64, 3), true},
+		{"bcrypt cost too high", hasherConfig("bcrypt", 64, 32), true},
+		{"argon2id memory below 8 KiB per lane", hasherConfig("argon2id", 7, 10), true},
+		{"argon2id zero iterations", &config.Config{
+			PasswordHashAlgorithm: "argon2id", Argon2MemoryKiB: 64, Argon2Parallelism: 1, BcryptCost: 10, PasswordHashConcurrency: 1,
+		}, true},
+		{"argon2id zero parallelism", &config.Config{
+			PasswordHashAlgorithm: "argon2id", Argon2MemoryKiB: 64, Argon2Iterations: 1, BcryptCost: 10, PasswordHashConcurrency: 1,
+		}, true},
+		{"no hashing concurrency", &config.Config{
+			PasswordHashAlgorithm: "argon2id", Argon2MemoryKiB: 64, Argon2Iterations: 1, Argon2Parallelism: 1, BcryptCost: 10,
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPasswordHasher(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewPasswordHasher error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherLimitsConcurrency(t *testing.T) {
+	cfg := hasherConfig("argon2id", 64, 4)
+	cfg.PasswordHashConcurrency = 1
+	h := newTestHasher(t, cfg)
+	encoded, err := h.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Occupy the only slot, as a hash in progress would
+	h.slots <- struct{}{}
+
+	done := make(chan bool)
+	go func() {
+		match, _ := h.Verify("secret", encoded)
+		done <- match
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Verify ran while another hash held the only slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	<-h.slots
+	select {
+	case match := <-done:
+		if !match {
+			t.Error("Verify = false after the slot was released")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Verify did not run after the slot was released")
+	}
+}
@@ -135,12 +135,19 @@ func (cs *CacheService) GenerateCategoriesKey(userID int) string {
 	return fmt.Sprintf("notes:categories:user:%d", userID)
 }
 
+// GenerateStatsKey creates a cache key for a user's statistics as seen in a
+// timezone on a given day, with weeks starting on weekStart
+func (cs *CacheService) GenerateStatsKey(userID int, timezone, weekStart, date string) string {
+	return fmt.Sprintf("notes:stats:user:%d:tz:%s:week:%s:date:%s", userID, timezone, weekStart, date)
+}
+
 // InvalidateUserNotesCache removes all note-related cache for a user
 func (cs *CacheService) InvalidateUserNotesCache(ctx context.Context, userID int) error {
 	patterns := []string{
 		fmt.Sprintf("notes:list:user:%d:*", userID),
 		fmt.Sprintf("notes:detail:*:user:%d", userID),
 		fmt.Sprintf("notes:categories:user:%d", userID),
+		fmt.Sprintf("notes:stats:user:%d:*", userID),
 	}
 
 	for _, pattern := range patterns {
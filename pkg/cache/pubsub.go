@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var errNoClient = errors.New("cache client not available")
+
+// StreamEntry is one entry of a Redis stream
+type StreamEntry struct {
+	ID   string
+	Data string
+}
+
+// Message is a pub/sub message
+type Message struct {
+	Channel string
+	Payload string
+}
+
+// Client exposes the underlying Redis client for features that need more
+// than key/value caching, or nil when the cache is unavailable
+func (cs *CacheService) Client() *redis.Client {
+	if cs == nil {
+		return nil
+	}
+	return cs.client
+}
+
+// Publish sends payload to every subscriber of channel
+func (cs *CacheService) Publish(ctx context.Context, channel, payload string) error {
+	if cs == nil || cs.client == nil {
+		return errNoClient
+	}
+
+	return cs.client.Publish(ctx, channel, payload).Err()
+}
+
+// PSubscribe delivers messages for channels matching pattern until ctx is
+// cancelled, then closes the returned channel
+func (cs *CacheService) PSubscribe(ctx context.Context, pattern string) (<-chan Message, error) {
+	if cs == nil || cs.client == nil {
+		return nil, errNoClient
+	}
+
+	pubsub := cs.client.PSubscribe(ctx, pattern)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	out := make(chan Message, 64)
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				select {
+				case out <- Message{Channel: msg.Channel, Payload: msg.Payload}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// StreamAppend adds data to a capped stream and returns the new entry ID
+func (cs *CacheService) StreamAppend(ctx context.Context, key, data string, maxLen int64) (string, error) {
+	if cs == nil || cs.client == nil {
+		return "", errNoClient
+	}
+
+	return cs.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: maxLen,
+		Approx: true,
+		Values: map[string]interface{}{"data": data},
+	}).Result()
+}
+
+// StreamHas reports whether the stream still holds the entry with id
+func (cs *CacheService) StreamHas(ctx context.Context, key, id string) (bool, error) {
+	if cs == nil || cs.client == nil {
+		return false, errNoClient
+	}
+
+	messages, err := cs.client.XRangeN(ctx, key, id, id, 1).Result()
+	if err != nil {
+		return false, err
+	}
+	return len(messages) > 0, nil
+}
+
+// StreamAfter returns up to count entries newer than afterID
+func (cs *CacheService) StreamAfter(ctx context.Context, key, afterID string, count int64) ([]StreamEntry, error) {
+	if cs == nil || cs.client == nil {
+		return nil, errNoClient
+	}
+
+	messages, err := cs.client.XRangeN(ctx, key, "("+afterID, "+", count).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]StreamEntry, 0, len(messages))
+	for _, msg := range messages {
+		data, _ := msg.Values["data"].(string)
+		entries = append(entries, StreamEntry{ID: msg.ID, Data: data})
+	}
+	return entries, nil
+}
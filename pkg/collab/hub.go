@@ -0,0 +1,789 @@
+// Package collab hosts real-time editing sessions for note content. Editors
+// exchange ot operations through a Hub; a Store orders them, in Redis when
+// several API instances serve the same note, and snapshots are written back
+// to the note periodically through a Persister.
+package collab
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"daily-notes-api/pkg/ot"
+)
+
+var (
+	// ErrSessionExpired means the shared session state is gone; clients must
+	// reconnect to start a new session from the stored note
+	ErrSessionExpired = errors.New("collaboration session expired")
+	// ErrStale is returned by Persister.Save when the note changed outside
+	// the session
+	ErrStale = errors.New("note changed outside the editing session")
+	// ErrInvalidRevision means a client based an operation on a revision the
+	// document has not reached
+	ErrInvalidRevision = errors.New("operation revision is ahead of the document")
+)
+
+const (
+	MessageInit     = "init"
+	MessageOp       = "op"
+	MessageAck      = "ack"
+	MessageCursor   = "cursor"
+	MessagePresence = "presence"
+	MessageLeave    = "leave"
+	MessageError    = "error"
+
+	envelopeOp       = "op"
+	envelopePresence = "presence"
+	envelopeLeave    = "leave"
+	envelopeRevoke   = "revoke"
+
+	// serverClientID marks operations made by the server itself, such as
+	// merging an update saved through the REST API
+	serverClientID = "server"
+
+	clientBuffer     = 256
+	maxAppendRetries = 20
+	lockTTL          = 30 * time.Second
+	storeTimeout     = 10 * time.Second
+)
+
+// Persister loads notes into sessions and writes snapshots back
+type Persister interface {
+	// Load returns the stored note, or nil when it no longer exists
+	Load(ctx context.Context, noteID, ownerID int) (*Snapshot, error)
+	// Save writes content if the note is still at baseVersion and returns
+	// the new version, or ErrStale
+	Save(ctx context.Context, noteID, ownerID int, content string, baseVersion, rev int) (int, error)
+}
+
+// ClientMessage is sent by editors
+type ClientMessage struct {
+	Type         string        `json:"type"`
+	Rev          int           `json:"rev"`
+	Op           *ot.Operation `json:"op,omitempty"`
+	Position     int           `json:"position"`
+	SelectionEnd int           `json:"selection_end"`
+}
+
+// ServerMessage is sent to editors
+type ServerMessage struct {
+	Type     string        `json:"type"`
+	ClientID string        `json:"client_id,omitempty"`
+	Rev      int           `json:"rev"`
+	Content  *string       `json:"content,omitempty"`
+	Op       *ot.Operation `json:"op,omitempty"`
+	Presence []*Presence   `json:"presence,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Client is one editor connection. The transport writes Messages to the
+// socket until Done is closed, then calls Hub.Leave.
+type Client struct {
+	ID     string
+	UserID int
+	Name   string
+
+	noteID    int
+	presence  Presence
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Messages returns encoded ServerMessages to deliver
+func (c *Client) Messages() <-chan []byte {
+	return c.send
+}
+
+// Done is closed when the hub drops the client
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Client) close() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
+
+func (c *Client) enqueue(msg *ServerMessage) {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Failed to encode collab message: %v", err)
+		return
+	}
+
+	select {
+	case c.send <- raw:
+	default:
+		// The editor cannot keep up; dropping it makes it reconnect and
+		// resync instead of silently missing operations
+		log.Printf("Collab client %s is too slow, disconnecting", c.ID)
+		c.close()
+	}
+}
+
+// document is the local replica of a session
+type document struct {
+	mu      sync.Mutex
+	noteID  int
+	ownerID int
+	loaded  bool
+	closed  bool
+	base    string // content at revision 0
+	content string
+	history []Record
+	clients map[string]*Client
+}
+
+func (d *document) rev() int {
+	return len(d.history)
+}
+
+// contentAt rebuilds the content as of rev
+func (d *document) contentAt(rev int) (string, error) {
+	content := d.base
+	for _, rec := range d.history[:rev] {
+		var err error
+		if content, err = rec.Op.Apply(content); err != nil {
+			return "", err
+		}
+	}
+	return content, nil
+}
+
+// advance applies records that follow the current revision, acknowledging
+// the originating client and forwarding the operation to everyone else
+func (d *document) advance(records []Record) error {
+	for _, rec := range records {
+		if rec.Rev != d.rev()+1 {
+			continue
+		}
+
+		content, err := rec.Op.Apply(d.content)
+		if err != nil {
+			return fmt.Errorf("failed to apply revision %d: %w", rec.Rev, err)
+		}
+		d.content = content
+		d.history = append(d.history, rec)
+
+		for _, client := range d.clients {
+			d.transformPresence(&client.presence)
+			if client.ID == rec.ClientID {
+				client.enqueue(&ServerMessage{Type: MessageAck, Rev: rec.Rev})
+			} else {
+				client.enqueue(&ServerMessage{Type: MessageOp, Rev: rec.Rev, Op: rec.Op, ClientID: rec.ClientID})
+			}
+		}
+	}
+	return nil
+}
+
+// transformPresence moves a cursor placed at p.Rev across the operations
+// since, so it stays on the same text. Cursors from a revision this replica
+// has not reached are left alone.
+func (d *document) transformPresence(p *Presence) {
+	if p.Rev < 0 || p.Rev > d.rev() {
+		return
+	}
+	for _, rec := range d.history[p.Rev:] {
+		p.Position = ot.TransformIndex(p.Position, rec.Op)
+		p.SelectionEnd = ot.TransformIndex(p.SelectionEnd, rec.Op)
+	}
+	p.Rev = d.rev()
+
+	length := utf8.RuneCountInString(d.content)
+	p.Position = clamp(p.Position, 0, length)
+	p.SelectionEnd = clamp(p.SelectionEnd, 0, length)
+}
+
+func (d *document) notify(msg *ServerMessage, except string) {
+	for _, client := range d.clients {
+		if client.ID != except {
+			client.enqueue(msg)
+		}
+	}
+}
+
+// Hub manages the sessions with editors connected to this instance
+type Hub struct {
+	store     Store
+	persister Persister
+	interval  time.Duration
+	instance  string
+
+	mu   sync.Mutex
+	docs map[int]*document
+}
+
+// NewHub returns a hub that saves dirty documents every interval
+func NewHub(store Store, persister Persister, interval time.Duration) *Hub {
+	return &Hub{
+		store:     store,
+		persister: persister,
+		interval:  interval,
+		instance:  randomID(),
+		docs:      make(map[int]*document),
+	}
+}
+
+// Run relays changes from other instances and persists sessions until ctx
+// is cancelled, then saves every open document one last time
+func (h *Hub) Run(ctx context.Context) {
+	go h.listen(ctx)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.persistAll(false)
+			return
+		case <-ticker.C:
+			h.persistAll(true)
+		}
+	}
+}
+
+func (h *Hub) listen(ctx context.Context) {
+	for ctx.Err() == nil {
+		envelopes, err := h.store.Listen(ctx)
+		if err != nil {
+			log.Printf("Collab hub failed to listen: %v", err)
+		} else {
+			for env := range envelopes {
+				h.relay(env)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (h *Hub) relay(env Envelope) {
+	if env.Instance == h.instance {
+		return
+	}
+
+	doc := h.lookup(env.NoteID)
+	if doc == nil {
+		return
+	}
+
+	doc.mu.Lock()
+	defer doc.mu.Unlock()
+	if doc.closed || !doc.loaded {
+		return
+	}
+
+	switch env.Kind {
+	case envelopeOp:
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		defer cancel()
+		if err := h.catchUp(ctx, doc); err != nil {
+			h.fail(doc, err)
+		}
+	case envelopePresence:
+		if env.Presence != nil {
+			if env.Presence.Rev > doc.rev() {
+				ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+				defer cancel()
+				if err := h.catchUp(ctx, doc); err != nil {
+					h.fail(doc, err)
+					return
+				}
+			}
+			doc.transformPresence(env.Presence)
+			doc.notify(&ServerMessage{Type: MessagePresence, Rev: doc.rev(), Presence: []*Presence{env.Presence}}, "")
+		}
+	case envelopeLeave:
+		doc.notify(&ServerMessage{Type: MessageLeave, Rev: doc.rev(), ClientID: env.ClientID}, "")
+	case envelopeRevoke:
+		h.dropUser(doc, env.UserID)
+	}
+}
+
+func (h *Hub) lookup(noteID int) *document {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.docs[noteID]
+}
+
+// Join connects an editor to the note's session, starting one from the
+// stored note if needed. The caller must have checked ownerID owns the note.
+func (h *Hub) Join(ctx context.Context, noteID, ownerID, userID int, name string) (*Client, error) {
+	client := &Client{
+		ID:     randomID(),
+		UserID: userID,
+		Name:   name,
+		noteID: noteID,
+		send:   make(chan []byte, clientBuffer),
+		done:   make(chan struct{}),
+	}
+	client.presence = Presence{ClientID: client.ID, UserID: userID, Name: name}
+
+	for {
+		h.mu.Lock()
+		doc := h.docs[noteID]
+		if doc == nil {
+			doc = &document{noteID: noteID, ownerID: ownerID, clients: make(map[string]*Client)}
+			h.docs[noteID] = doc
+		}
+		h.mu.Unlock()
+
+		doc.mu.Lock()
+		if doc.closed {
+			// Lost a race with the last editor leaving; use a fresh document
+			doc.mu.Unlock()
+			continue
+		}
+		err := h.join(ctx, doc, client)
+		doc.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+}
+
+func (h *Hub) join(ctx context.Context, doc *document, client *Client) error {
+	// Presence goes first so the session cannot be released while loading
+	client.presence.SeenAt = time.Now()
+	if err := h.store.SetPresence(ctx, doc.noteID, &client.presence); err != nil {
+		return err
+	}
+
+	var err error
+	if doc.loaded {
+		err = h.catchUp(ctx, doc)
+	} else {
+		err = h.load(ctx, doc)
+	}
+	if err != nil {
+		h.store.RemovePresence(ctx, doc.noteID, client.ID)
+		if len(doc.clients) == 0 {
+			h.remove(doc)
+		}
+		return err
+	}
+
+	client.presence.Rev = doc.rev()
+	doc.clients[client.ID] = client
+
+	presence, err := h.store.Presence(ctx, doc.noteID)
+	if err != nil {
+		log.Printf("Failed to read presence for note %d: %v", doc.noteID, err)
+	}
+	for _, p := range presence {
+		doc.transformPresence(p)
+	}
+	content := doc.content
+	client.enqueue(&ServerMessage{Type: MessageInit, ClientID: client.ID, Rev: doc.rev(), Content: &content, Presence: presence})
+
+	h.announce(ctx, doc, client)
+	return nil
+}
+
+// load builds the local replica from the shared session
+func (h *Hub) load(ctx context.Context, doc *document) error {
+	stored, err := h.persister.Load(ctx, doc.noteID, doc.ownerID)
+	if err != nil {
+		return err
+	}
+	if stored == nil {
+		return fmt.Errorf("note %d not found", doc.noteID)
+	}
+
+	snapshot, err := h.store.Open(ctx, doc.noteID, stored)
+	if err != nil {
+		return err
+	}
+	records, err := h.store.Since(ctx, doc.noteID, 0)
+	if err != nil {
+		return err
+	}
+
+	doc.base = snapshot.Content
+	doc.content = snapshot.Content
+	doc.history = nil
+	if err := doc.advance(records); err != nil {
+		return err
+	}
+	doc.loaded = true
+
+	if snapshot.Version != stored.Version {
+		// Saved through the REST API while the session was idle
+		return h.adopt(ctx, doc, snapshot.PersistedRev, stored)
+	}
+	return nil
+}
+
+// adopt merges a note version saved outside the session into the document.
+// The difference between the last persisted revision and the stored content
+// is rebased over the session's later edits like any other operation.
+func (h *Hub) adopt(ctx context.Context, doc *document, persistedRev int, stored *Snapshot) error {
+	persisted, err := doc.contentAt(persistedRev)
+	if err != nil {
+		return err
+	}
+
+	if op := ot.Diff(persisted, stored.Content); !op.IsNoop() {
+		if err := h.submit(ctx, doc, serverClientID, 0, persistedRev, op); err != nil {
+			return err
+		}
+	}
+
+	if doc.content == stored.Content {
+		return h.store.MarkPersisted(ctx, doc.noteID, doc.rev(), stored.Version)
+	}
+	// The session has edits on top; the next save writes them over this version
+	return h.store.MarkPersisted(ctx, doc.noteID, persistedRev, stored.Version)
+}
+
+func (h *Hub) catchUp(ctx context.Context, doc *document) error {
+	records, err := h.store.Since(ctx, doc.noteID, doc.rev())
+	if err != nil {
+		return err
+	}
+	return doc.advance(records)
+}
+
+// submit rebases op from rev onto the latest revision and appends it
+func (h *Hub) submit(ctx context.Context, doc *document, clientID string, userID, rev int, op *ot.Operation) error {
+	if err := h.catchUp(ctx, doc); err != nil {
+		return err
+	}
+	if rev < 0 || rev > doc.rev() {
+		return ErrInvalidRevision
+	}
+
+	var err error
+	for _, prior := range doc.history[rev:] {
+		if op, _, err = ot.Transform(op, prior.Op); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; attempt < maxAppendRetries; attempt++ {
+		if op.BaseLen != utf8.RuneCountInString(doc.content) {
+			return ot.ErrLengthMismatch
+		}
+
+		rec := &Record{Rev: doc.rev() + 1, ClientID: clientID, UserID: userID, Op: op}
+		appended, err := h.store.Append(ctx, doc.noteID, rec)
+		if err != nil {
+			return err
+		}
+		if appended {
+			if err := doc.advance([]Record{*rec}); err != nil {
+				return err
+			}
+			h.broadcast(ctx, &Envelope{NoteID: doc.noteID, Kind: envelopeOp, Rev: rec.Rev})
+			return nil
+		}
+
+		// An editor on another instance got this revision first
+		records, err := h.store.Since(ctx, doc.noteID, doc.rev())
+		if err != nil {
+			return err
+		}
+		for _, prior := range records {
+			if op, _, err = ot.Transform(op, prior.Op); err != nil {
+				return err
+			}
+		}
+		if err := doc.advance(records); err != nil {
+			return err
+		}
+	}
+	return errors.New("too many concurrent edits, try again")
+}
+
+// Receive handles a raw message from client. Errors that concern only this
+// client are reported back to it.
+func (h *Hub) Receive(ctx context.Context, client *Client, raw []byte) {
+	var msg ClientMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		client.enqueue(&ServerMessage{Type: MessageError, Error: "Invalid message: " + err.Error()})
+		return
+	}
+
+	doc := h.lookup(client.noteID)
+	if doc == nil {
+		client.close()
+		return
+	}
+
+	doc.mu.Lock()
+	defer doc.mu.Unlock()
+	if _, ok := doc.clients[client.ID]; doc.closed || !ok {
+		// Dropped by the hub while the message was in flight
+		client.close()
+		return
+	}
+
+	switch msg.Type {
+	case MessageOp:
+		if msg.Op == nil {
+			client.enqueue(&ServerMessage{Type: MessageError, Rev: doc.rev(), Error: "Operation is required"})
+			return
+		}
+		if err := h.submit(ctx, doc, client.ID, client.UserID, msg.Rev, msg.Op); err != nil {
+			if errors.Is(err, ot.ErrLengthMismatch) || errors.Is(err, ot.ErrIncompatible) || errors.Is(err, ErrInvalidRevision) {
+				client.enqueue(&ServerMessage{Type: MessageError, Rev: doc.rev(), Error: err.Error()})
+				return
+			}
+			h.fail(doc, err)
+		}
+	case MessageCursor:
+		// The cursor refers to the client's revision; bring it up to date
+		// with the operations it has not seen yet
+		if msg.Rev > doc.rev() {
+			if err := h.catchUp(ctx, doc); err != nil {
+				h.fail(doc, err)
+				return
+			}
+		}
+		if msg.Rev < 0 || msg.Rev > doc.rev() {
+			client.enqueue(&ServerMessage{Type: MessageError, Rev: doc.rev(), Error: ErrInvalidRevision.Error()})
+			return
+		}
+		client.presence.Rev = msg.Rev
+		client.presence.Position = max(msg.Position, 0)
+		client.presence.SelectionEnd = max(msg.SelectionEnd, 0)
+		doc.transformPresence(&client.presence)
+		client.presence.SeenAt = time.Now()
+		if err := h.store.SetPresence(ctx, doc.noteID, &client.presence); err != nil {
+			log.Printf("Failed to store presence for note %d: %v", doc.noteID, err)
+		}
+		h.announce(ctx, doc, client)
+	default:
+		client.enqueue(&ServerMessage{Type: MessageError, Rev: doc.rev(), Error: "Unknown message type " + msg.Type})
+	}
+}
+
+// announce sends client's presence to every other editor
+func (h *Hub) announce(ctx context.Context, doc *document, client *Client) {
+	presence := client.presence
+	doc.notify(&ServerMessage{Type: MessagePresence, Rev: doc.rev(), Presence: []*Presence{&presence}}, client.ID)
+	h.broadcast(ctx, &Envelope{NoteID: doc.noteID, Kind: envelopePresence, Presence: &presence})
+}
+
+// Leave disconnects client. The last editor to leave saves the document and
+// ends the session.
+func (h *Hub) Leave(client *Client) {
+	client.close()
+
+	doc := h.lookup(client.noteID)
+	if doc == nil {
+		return
+	}
+
+	doc.mu.Lock()
+	defer doc.mu.Unlock()
+	if _, ok := doc.clients[client.ID]; !ok {
+		return
+	}
+	h.detach(doc, client)
+}
+
+// Revoke disconnects every editor userID has on the note, on this instance
+// and on every other one, after their access was taken away
+func (h *Hub) Revoke(ctx context.Context, noteID, userID int) {
+	if doc := h.lookup(noteID); doc != nil {
+		doc.mu.Lock()
+		if !doc.closed {
+			h.dropUser(doc, userID)
+		}
+		doc.mu.Unlock()
+	}
+	h.broadcast(ctx, &Envelope{NoteID: noteID, Kind: envelopeRevoke, UserID: userID})
+}
+
+// dropUser disconnects the local editors of userID; the caller holds doc.mu
+func (h *Hub) dropUser(doc *document, userID int) {
+	for _, client := range doc.clients {
+		if client.UserID == userID {
+			client.enqueue(&ServerMessage{Type: MessageError, Rev: doc.rev(), Error: "Your access to this note was removed"})
+			client.close()
+			h.detach(doc, client)
+		}
+	}
+}
+
+// detach removes client from doc and tells the other editors. The last
+// editor to go saves the document and ends the session. The caller holds
+// doc.mu.
+func (h *Hub) detach(doc *document, client *Client) {
+	delete(doc.clients, client.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	if err := h.store.RemovePresence(ctx, doc.noteID, client.ID); err != nil {
+		log.Printf("Failed to remove presence for note %d: %v", doc.noteID, err)
+	}
+	doc.notify(&ServerMessage{Type: MessageLeave, Rev: doc.rev(), ClientID: client.ID}, "")
+	h.broadcast(ctx, &Envelope{NoteID: doc.noteID, Kind: envelopeLeave, ClientID: client.ID})
+
+	if len(doc.clients) > 0 {
+		return
+	}
+
+	if err := h.persist(ctx, doc); err != nil {
+		log.Printf("Failed to save collab session for note %d: %v", doc.noteID, err)
+	}
+	h.remove(doc)
+	if err := h.store.Release(ctx, doc.noteID); err != nil {
+		log.Printf("Failed to release collab session for note %d: %v", doc.noteID, err)
+	}
+}
+
+func (h *Hub) persistAll(refreshPresence bool) {
+	h.mu.Lock()
+	docs := make([]*document, 0, len(h.docs))
+	for _, doc := range h.docs {
+		docs = append(docs, doc)
+	}
+	h.mu.Unlock()
+
+	for _, doc := range docs {
+		doc.mu.Lock()
+		if !doc.closed && doc.loaded {
+			ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+			if err := h.persist(ctx, doc); err != nil {
+				h.fail(doc, err)
+			} else if refreshPresence {
+				for _, client := range doc.clients {
+					client.presence.SeenAt = time.Now()
+					h.store.SetPresence(ctx, doc.noteID, &client.presence)
+				}
+			}
+			cancel()
+		}
+		doc.mu.Unlock()
+	}
+}
+
+// persist saves the document if it has revisions newer than the stored
+// note, and merges in updates saved through the REST API. Only one instance
+// persists a note at a time.
+func (h *Hub) persist(ctx context.Context, doc *document) error {
+	locked, err := h.store.Lock(ctx, doc.noteID, lockTTL)
+	if err != nil || !locked {
+		return err
+	}
+	defer h.store.Unlock(ctx, doc.noteID)
+
+	if err := h.catchUp(ctx, doc); err != nil {
+		return err
+	}
+	version, persistedRev, err := h.store.Meta(ctx, doc.noteID)
+	if err != nil {
+		return err
+	}
+
+	if doc.rev() <= persistedRev {
+		_, err := h.reload(ctx, doc, version, persistedRev)
+		return err
+	}
+
+	for attempt := 0; doc.rev() > persistedRev; attempt++ {
+		rev := doc.rev()
+		newVersion, err := h.persister.Save(ctx, doc.noteID, doc.ownerID, doc.content, version, rev)
+		if err == nil {
+			return h.store.MarkPersisted(ctx, doc.noteID, rev, newVersion)
+		}
+		if !errors.Is(err, ErrStale) || attempt > 0 {
+			return err
+		}
+
+		if exists, err := h.reload(ctx, doc, version, persistedRev); err != nil || !exists {
+			return err
+		}
+		if version, persistedRev, err = h.store.Meta(ctx, doc.noteID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reload merges the stored note into doc when it moved past version. It
+// ends the session and reports false when the note was deleted.
+func (h *Hub) reload(ctx context.Context, doc *document, version, persistedRev int) (bool, error) {
+	stored, err := h.persister.Load(ctx, doc.noteID, doc.ownerID)
+	if err != nil {
+		return false, err
+	}
+	if stored == nil {
+		h.shutdown(doc, "The note was deleted")
+		return false, nil
+	}
+	if stored.Version == version {
+		return true, nil
+	}
+	return true, h.adopt(ctx, doc, persistedRev, stored)
+}
+
+// fail logs err and, when the session state is no longer trustworthy,
+// disconnects local editors so they reload
+func (h *Hub) fail(doc *document, err error) {
+	log.Printf("Collab session for note %d failed: %v", doc.noteID, err)
+	if errors.Is(err, ErrSessionExpired) || errors.Is(err, ot.ErrLengthMismatch) || errors.Is(err, ot.ErrIncompatible) {
+		h.shutdown(doc, "The editing session ended, please reconnect")
+	}
+}
+
+// shutdown disconnects every local editor of doc; the caller holds doc.mu
+func (h *Hub) shutdown(doc *document, reason string) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	for _, client := range doc.clients {
+		h.store.RemovePresence(ctx, doc.noteID, client.ID)
+		client.enqueue(&ServerMessage{Type: MessageError, Rev: doc.rev(), Error: reason})
+		client.close()
+	}
+	doc.clients = make(map[string]*Client)
+	h.remove(doc)
+}
+
+// remove forgets doc; the caller holds doc.mu
+func (h *Hub) remove(doc *document) {
+	doc.closed = true
+
+	h.mu.Lock()
+	if h.docs[doc.noteID] == doc {
+		delete(h.docs, doc.noteID)
+	}
+	h.mu.Unlock()
+}
+
+func (h *Hub) broadcast(ctx context.Context, env *Envelope) {
+	env.Instance = h.instance
+	if err := h.store.Broadcast(ctx, env); err != nil {
+		log.Printf("Failed to broadcast collab %s for note %d: %v", env.Kind, env.NoteID, err)
+	}
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
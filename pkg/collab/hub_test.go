@@ -0,0 +1,193 @@
+package collab
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"daily-notes-api/pkg/ot"
+)
+
+type fakePersister struct {
+	content string
+}
+
+func (p *fakePersister) Load(ctx context.Context, noteID, ownerID int) (*Snapshot, error) {
+	return &Snapshot{Content: p.content, Version: 1}, nil
+}
+
+func (p *fakePersister) Save(ctx context.Context, noteID, ownerID int, content string, baseVersion, rev int) (int, error) {
+	p.content = content
+	return baseVersion + 1, nil
+}
+
+func join(t *testing.T, hub *Hub, userID int) *Client {
+	t.Helper()
+	client, err := hub.Join(context.Background(), 1, 1, userID, "user")
+	if err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	t.Cleanup(func() { hub.Leave(client) })
+	return client
+}
+
+func send(t *testing.T, hub *Hub, client *Client, msg ClientMessage) {
+	t.Helper()
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	hub.Receive(context.Background(), client, raw)
+}
+
+// next returns the next message of type typ sent to client
+func next(t *testing.T, client *Client, typ string) *ServerMessage {
+	t.Helper()
+	for {
+		select {
+		case raw := <-client.Messages():
+			var msg ServerMessage
+			if err := json.Unmarshal(raw, &msg); err != nil {
+				t.Fatalf("invalid message %s: %v", raw, err)
+			}
+			if msg.Type == typ {
+				return &msg
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no %s message", typ)
+		}
+	}
+}
+
+func presenceOf(t *testing.T, msg *ServerMessage, clientID string) *Presence {
+	t.Helper()
+	for _, p := range msg.Presence {
+		if p.ClientID == clientID {
+			return p
+		}
+	}
+	t.Fatalf("no presence for %s in %+v", clientID, msg.Presence)
+	return nil
+}
+
+func TestHubTransformsCursors(t *testing.T) {
+	tests := []struct {
+		name      string
+		cursorRev int
+		position  int
+		edit      *ot.Operation
+		wantPos   int
+	}{
+		{"insert before the cursor", 0, 6, ot.New().Insert(">> ").Retain(11), 9},
+		{"insert after the cursor", 0, 6, ot.New().Retain(11).Insert("!"), 6},
+		{"delete before the cursor", 0, 6, ot.New().Delete(6).Retain(5), 0},
+		// A cursor sent before the client saw the edit is moved across it
+		{"stale cursor", -1, 6, ot.New().Insert(">> ").Retain(11), 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(NewMemoryStore(), &fakePersister{content: "hello world"}, time.Minute)
+			editor, other := join(t, hub, 1), join(t, hub, 2)
+			next(t, other, MessageInit)
+
+			if tt.cursorRev >= 0 {
+				send(t, hub, other, ClientMessage{Type: MessageCursor, Rev: tt.cursorRev, Position: tt.position, SelectionEnd: tt.position})
+				send(t, hub, editor, ClientMessage{Type: MessageOp, Rev: 0, Op: tt.edit})
+			} else {
+				next(t, editor, MessagePresence) // other joining
+				send(t, hub, editor, ClientMessage{Type: MessageOp, Rev: 0, Op: tt.edit})
+				send(t, hub, other, ClientMessage{Type: MessageCursor, Rev: 0, Position: tt.position, SelectionEnd: tt.position})
+				presence := presenceOf(t, next(t, editor, MessagePresence), other.ID)
+				if presence.Position != tt.wantPos || presence.Rev != 1 {
+					t.Errorf("announced presence = %+v, want position %d at rev 1", presence, tt.wantPos)
+				}
+			}
+
+			// Editors joining later see the cursor where the text moved to
+			init := next(t, join(t, hub, 3), MessageInit)
+			presence := presenceOf(t, init, other.ID)
+			if presence.Position != tt.wantPos || presence.SelectionEnd != tt.wantPos {
+				t.Errorf("presence = %+v, want position %d", presence, tt.wantPos)
+			}
+		})
+	}
+}
+
+func TestHubRejectsCursorFromFutureRevision(t *testing.T) {
+	hub := NewHub(NewMemoryStore(), &fakePersister{content: "hello"}, time.Minute)
+	client := join(t, hub, 1)
+	next(t, client, MessageInit)
+
+	send(t, hub, client, ClientMessage{Type: MessageCursor, Rev: 5, Position: 1})
+	if msg := next(t, client, MessageError); msg.Error != ErrInvalidRevision.Error() {
+		t.Errorf("error = %q, want %q", msg.Error, ErrInvalidRevision)
+	}
+}
+
+// broadcastStore records the envelopes sent to other instances
+type broadcastStore struct {
+	*MemoryStore
+	sent []Envelope
+}
+
+func (s *broadcastStore) Broadcast(ctx context.Context, env *Envelope) error {
+	s.sent = append(s.sent, *env)
+	return nil
+}
+
+func TestHubRevokeDisconnectsUser(t *testing.T) {
+	tests := []struct {
+		name          string
+		revoke        func(hub *Hub)
+		wantBroadcast int
+	}{
+		{"on this instance", func(hub *Hub) { hub.Revoke(context.Background(), 1, 2) }, 1},
+		{"from another instance", func(hub *Hub) {
+			hub.relay(Envelope{Instance: "other", NoteID: 1, Kind: envelopeRevoke, UserID: 2})
+		}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &broadcastStore{MemoryStore: NewMemoryStore()}
+			persister := &fakePersister{content: "hello"}
+			hub := NewHub(store, persister, time.Minute)
+			owner, revoked, second := join(t, hub, 1), join(t, hub, 2), join(t, hub, 2)
+			next(t, owner, MessageInit)
+			next(t, revoked, MessageInit)
+
+			tt.revoke(hub)
+
+			for _, client := range []*Client{revoked, second} {
+				select {
+				case <-client.Done():
+				case <-time.After(time.Second):
+					t.Fatalf("client %s of the revoked user is still connected", client.ID)
+				}
+			}
+			if msg := next(t, revoked, MessageError); msg.Error != "Your access to this note was removed" {
+				t.Errorf("error = %q", msg.Error)
+			}
+			if msg := next(t, owner, MessageLeave); msg.ClientID != revoked.ID && msg.ClientID != second.ID {
+				t.Errorf("leave for %q, want a revoked client", msg.ClientID)
+			}
+
+			// Edits sent before the socket closed are ignored
+			send(t, hub, revoked, ClientMessage{Type: MessageOp, Rev: 0, Op: ot.New().Retain(5).Insert("!")})
+			hub.Leave(owner)
+			if persister.content != "hello" {
+				t.Errorf("content = %q, want the revoked edit dropped", persister.content)
+			}
+
+			var revokes int
+			for _, env := range store.sent {
+				if env.Kind == envelopeRevoke {
+					revokes++
+				}
+			}
+			if revokes != tt.wantBroadcast {
+				t.Errorf("broadcast %d revoke envelopes, want %d", revokes, tt.wantBroadcast)
+			}
+		})
+	}
+}
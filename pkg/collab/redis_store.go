@@ -0,0 +1,275 @@
+package collab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyPrefix     = "collab:note:"
+	eventsChannel = "collab:events"
+
+	// sessionTTL bounds how long an abandoned session survives in Redis
+	sessionTTL = time.Hour
+	// presenceTTL hides editors whose instance stopped refreshing them
+	presenceTTL = time.Minute
+)
+
+// openScript creates the session hash from the seed unless it exists
+var openScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	redis.call('HSET', KEYS[1], 'content', ARGV[1], 'version', ARGV[2], 'persisted_rev', 0)
+	redis.call('DEL', KEYS[2])
+end
+redis.call('EXPIRE', KEYS[1], ARGV[3])
+redis.call('EXPIRE', KEYS[2], ARGV[3])
+return redis.call('HMGET', KEYS[1], 'content', 'version', 'persisted_rev')
+`)
+
+// appendScript pushes a record when it carries the next revision. It returns
+// 1 on success, 0 when the revision is taken and -1 when the session expired.
+var appendScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+if redis.call('LLEN', KEYS[2]) + 1 ~= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('RPUSH', KEYS[2], ARGV[2])
+redis.call('EXPIRE', KEYS[1], ARGV[3])
+redis.call('EXPIRE', KEYS[2], ARGV[3])
+return 1
+`)
+
+// releaseScript drops a session that nobody edits and that is fully persisted
+var releaseScript = redis.NewScript(`
+if redis.call('HLEN', KEYS[3]) > 0 then
+	return 0
+end
+local persisted = tonumber(redis.call('HGET', KEYS[1], 'persisted_rev') or '0')
+if persisted ~= redis.call('LLEN', KEYS[2]) then
+	return 0
+end
+redis.call('DEL', KEYS[1], KEYS[2], KEYS[3], KEYS[4])
+return 1
+`)
+
+// RedisStore shares sessions between API instances. Operations are appended
+// to a list whose length is the document revision, so concurrent editors on
+// different instances are ordered by Redis.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func docKey(noteID int) string {
+	return keyPrefix + strconv.Itoa(noteID)
+}
+
+func opsKey(noteID int) string {
+	return docKey(noteID) + ":ops"
+}
+
+func presenceKey(noteID int) string {
+	return docKey(noteID) + ":presence"
+}
+
+func lockKey(noteID int) string {
+	return docKey(noteID) + ":lock"
+}
+
+func (s *RedisStore) Open(ctx context.Context, noteID int, seed *Snapshot) (*Snapshot, error) {
+	keys := []string{docKey(noteID), opsKey(noteID)}
+	values, err := openScript.Run(ctx, s.client, keys, seed.Content, seed.Version, int(sessionTTL.Seconds())).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open collab session: %w", err)
+	}
+	if len(values) != 3 {
+		return nil, fmt.Errorf("unexpected collab session state")
+	}
+
+	content, _ := values[0].(string)
+	version, _ := strconv.Atoi(fmt.Sprint(values[1]))
+	persistedRev, _ := strconv.Atoi(fmt.Sprint(values[2]))
+	return &Snapshot{Content: content, Version: version, PersistedRev: persistedRev}, nil
+}
+
+func (s *RedisStore) Since(ctx context.Context, noteID, rev int) ([]Record, error) {
+	raw, err := s.client.LRange(ctx, opsKey(noteID), int64(rev), -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collab operations: %w", err)
+	}
+
+	records := make([]Record, 0, len(raw))
+	for _, item := range raw {
+		var rec Record
+		if err := json.Unmarshal([]byte(item), &rec); err != nil {
+			return nil, fmt.Errorf("failed to decode collab operation: %w", err)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func (s *RedisStore) Append(ctx context.Context, noteID int, rec *Record) (bool, error) {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode collab operation: %w", err)
+	}
+
+	keys := []string{docKey(noteID), opsKey(noteID)}
+	result, err := appendScript.Run(ctx, s.client, keys, rec.Rev, string(raw), int(sessionTTL.Seconds())).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to append collab operation: %w", err)
+	}
+	if result < 0 {
+		return false, ErrSessionExpired
+	}
+	return result == 1, nil
+}
+
+func (s *RedisStore) Meta(ctx context.Context, noteID int) (int, int, error) {
+	values, err := s.client.HMGet(ctx, docKey(noteID), "version", "persisted_rev").Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read collab session: %w", err)
+	}
+	if values[0] == nil {
+		return 0, 0, ErrSessionExpired
+	}
+
+	version, _ := strconv.Atoi(fmt.Sprint(values[0]))
+	persistedRev, _ := strconv.Atoi(fmt.Sprint(values[1]))
+	return version, persistedRev, nil
+}
+
+func (s *RedisStore) MarkPersisted(ctx context.Context, noteID, rev, version int) error {
+	if err := s.client.HSet(ctx, docKey(noteID), "version", version, "persisted_rev", rev).Err(); err != nil {
+		return fmt.Errorf("failed to mark collab session persisted: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Lock(ctx context.Context, noteID int, ttl time.Duration) (bool, error) {
+	ok, err := s.client.SetNX(ctx, lockKey(noteID), 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to lock collab session: %w", err)
+	}
+	return ok, nil
+}
+
+func (s *RedisStore) Unlock(ctx context.Context, noteID int) error {
+	if err := s.client.Del(ctx, lockKey(noteID)).Err(); err != nil {
+		return fmt.Errorf("failed to unlock collab session: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) SetPresence(ctx context.Context, noteID int, p *Presence) error {
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to encode presence: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.HSet(ctx, presenceKey(noteID), p.ClientID, raw)
+	pipe.Expire(ctx, presenceKey(noteID), sessionTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store presence: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) RemovePresence(ctx context.Context, noteID int, clientID string) error {
+	if err := s.client.HDel(ctx, presenceKey(noteID), clientID).Err(); err != nil {
+		return fmt.Errorf("failed to remove presence: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Presence(ctx context.Context, noteID int) ([]*Presence, error) {
+	raw, err := s.client.HGetAll(ctx, presenceKey(noteID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read presence: %w", err)
+	}
+
+	cutoff := time.Now().Add(-presenceTTL)
+	var list []*Presence
+	for clientID, item := range raw {
+		var p Presence
+		if err := json.Unmarshal([]byte(item), &p); err != nil {
+			continue
+		}
+		if p.SeenAt.Before(cutoff) {
+			// Left behind by an instance that went away
+			s.client.HDel(ctx, presenceKey(noteID), clientID)
+			continue
+		}
+		list = append(list, &p)
+	}
+	return list, nil
+}
+
+func (s *RedisStore) Release(ctx context.Context, noteID int) error {
+	keys := []string{docKey(noteID), opsKey(noteID), presenceKey(noteID), lockKey(noteID)}
+	if err := releaseScript.Run(ctx, s.client, keys).Err(); err != nil {
+		return fmt.Errorf("failed to release collab session: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Broadcast(ctx context.Context, env *Envelope) error {
+	raw, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("failed to encode collab envelope: %w", err)
+	}
+	if err := s.client.Publish(ctx, eventsChannel, raw).Err(); err != nil {
+		return fmt.Errorf("failed to publish collab envelope: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Listen(ctx context.Context) (<-chan Envelope, error) {
+	pubsub := s.client.Subscribe(ctx, eventsChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to collab events: %w", err)
+	}
+
+	out := make(chan Envelope, 256)
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				var env Envelope
+				if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+					log.Printf("Collab dropped malformed envelope: %v", err)
+					continue
+				}
+				select {
+				case out <- env:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
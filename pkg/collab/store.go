@@ -0,0 +1,237 @@
+package collab
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"daily-notes-api/pkg/ot"
+)
+
+// Record is one operation in a document's history. Rev is the revision the
+// operation produced; the first edit of a session has Rev 1.
+type Record struct {
+	Rev      int           `json:"rev"`
+	ClientID string        `json:"client_id"`
+	UserID   int           `json:"user_id"`
+	Op       *ot.Operation `json:"op"`
+}
+
+// Snapshot is the persisted state a session started from
+type Snapshot struct {
+	Content string
+	// Version is the note version last loaded or saved
+	Version int
+	// PersistedRev is the last revision written back to the note
+	PersistedRev int
+}
+
+// Presence describes a connected editor and their cursor
+type Presence struct {
+	ClientID     string    `json:"client_id"`
+	UserID       int       `json:"user_id"`
+	Name         string    `json:"name"`
+	Rev          int       `json:"rev"`
+	Position     int       `json:"position"`
+	SelectionEnd int       `json:"selection_end"`
+	SeenAt       time.Time `json:"seen_at"`
+}
+
+// Envelope carries changes between API instances
+type Envelope struct {
+	Instance string    `json:"instance"`
+	NoteID   int       `json:"note_id"`
+	Kind     string    `json:"kind"` // op, presence, leave or revoke
+	Rev      int       `json:"rev,omitempty"`
+	Presence *Presence `json:"presence,omitempty"`
+	ClientID string    `json:"client_id,omitempty"`
+	UserID   int       `json:"user_id,omitempty"`
+}
+
+// Store holds the shared state of documents being edited. Appends are
+// linearized by the store, which is what keeps every instance in agreement
+// on the order of operations.
+type Store interface {
+	// Open returns the document's base snapshot, creating it from seed when
+	// no session exists
+	Open(ctx context.Context, noteID int, seed *Snapshot) (*Snapshot, error)
+	// Since returns the records after rev, in order
+	Since(ctx context.Context, noteID, rev int) ([]Record, error)
+	// Append stores rec if rec.Rev is the next revision. It returns false
+	// when another editor got there first.
+	Append(ctx context.Context, noteID int, rec *Record) (bool, error)
+
+	Meta(ctx context.Context, noteID int) (version, persistedRev int, err error)
+	MarkPersisted(ctx context.Context, noteID, rev, version int) error
+	// Lock serializes persistence of a note across instances
+	Lock(ctx context.Context, noteID int, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, noteID int) error
+
+	SetPresence(ctx context.Context, noteID int, p *Presence) error
+	RemovePresence(ctx context.Context, noteID int, clientID string) error
+	Presence(ctx context.Context, noteID int) ([]*Presence, error)
+
+	// Release drops the session once nobody is editing and every revision
+	// has been persisted
+	Release(ctx context.Context, noteID int) error
+
+	Broadcast(ctx context.Context, env *Envelope) error
+	// Listen receives envelopes broadcast by every instance until ctx ends
+	Listen(ctx context.Context) (<-chan Envelope, error)
+}
+
+// MemoryStore keeps documents in process. It is used when Redis is disabled
+// and only supports a single API instance.
+type MemoryStore struct {
+	mu   sync.Mutex
+	docs map[int]*memoryDoc
+}
+
+type memoryDoc struct {
+	opened   bool
+	snapshot Snapshot
+	records  []Record
+	locked   bool
+	presence map[string]*Presence
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{docs: make(map[int]*memoryDoc)}
+}
+
+func (s *MemoryStore) doc(noteID int) *memoryDoc {
+	d := s.docs[noteID]
+	if d == nil {
+		d = &memoryDoc{presence: make(map[string]*Presence)}
+		s.docs[noteID] = d
+	}
+	return d
+}
+
+func (s *MemoryStore) Open(ctx context.Context, noteID int, seed *Snapshot) (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.doc(noteID)
+	if !d.opened {
+		d.opened = true
+		d.snapshot = *seed
+	}
+	snapshot := d.snapshot
+	return &snapshot, nil
+}
+
+func (s *MemoryStore) Since(ctx context.Context, noteID, rev int) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := s.doc(noteID).records
+	if rev >= len(records) {
+		return nil, nil
+	}
+	return append([]Record(nil), records[rev:]...), nil
+}
+
+func (s *MemoryStore) Append(ctx context.Context, noteID int, rec *Record) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.doc(noteID)
+	if rec.Rev != len(d.records)+1 {
+		return false, nil
+	}
+	d.records = append(d.records, *rec)
+	return true, nil
+}
+
+func (s *MemoryStore) Meta(ctx context.Context, noteID int) (int, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.doc(noteID)
+	return d.snapshot.Version, d.snapshot.PersistedRev, nil
+}
+
+func (s *MemoryStore) MarkPersisted(ctx context.Context, noteID, rev, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.doc(noteID)
+	d.snapshot.Version = version
+	d.snapshot.PersistedRev = rev
+	return nil
+}
+
+func (s *MemoryStore) Lock(ctx context.Context, noteID int, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.doc(noteID)
+	if d.locked {
+		return false, nil
+	}
+	d.locked = true
+	return true, nil
+}
+
+func (s *MemoryStore) Unlock(ctx context.Context, noteID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.doc(noteID).locked = false
+	return nil
+}
+
+func (s *MemoryStore) SetPresence(ctx context.Context, noteID int, p *Presence) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *p
+	s.doc(noteID).presence[p.ClientID] = &copied
+	return nil
+}
+
+func (s *MemoryStore) RemovePresence(ctx context.Context, noteID int, clientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.doc(noteID).presence, clientID)
+	return nil
+}
+
+func (s *MemoryStore) Presence(ctx context.Context, noteID int) ([]*Presence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []*Presence
+	for _, p := range s.doc(noteID).presence {
+		copied := *p
+		list = append(list, &copied)
+	}
+	return list, nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, noteID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.docs[noteID]
+	if ok && len(d.presence) == 0 && d.snapshot.PersistedRev == len(d.records) {
+		delete(s.docs, noteID)
+	}
+	return nil
+}
+
+// Broadcast is a no-op: with a single instance there is nobody to tell
+func (s *MemoryStore) Broadcast(ctx context.Context, env *Envelope) error {
+	return nil
+}
+
+func (s *MemoryStore) Listen(ctx context.Context) (<-chan Envelope, error) {
+	ch := make(chan Envelope)
+	go func() {
+		<-ctx.Done()
+		close(ch)
+	}()
+	return ch, nil
+}
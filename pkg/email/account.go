@@ -0,0 +1,37 @@
+package email
+
+// AccountEmailData feeds the export and account deletion emails. Dates are
+// preformatted in the user's timezone.
+type AccountEmailData struct {
+	Locale       string
+	UserName     string
+	Email        string
+	AppName      string
+	AppURL       string
+	DownloadURL  string // export_ready only
+	ExpiresAt    string // export_ready only
+	DeletionDate string // deletion_scheduled only
+}
+
+// BuildExportReadyEmail renders the email linking to a finished data export
+func (es *EmailService) BuildExportReadyEmail(data AccountEmailData) (EmailData, error) {
+	return es.render("export_ready", data.Locale, data.Email, data)
+}
+
+// BuildDeletionScheduledEmail renders the email confirming that the account
+// will be deleted after the grace period
+func (es *EmailService) BuildDeletionScheduledEmail(data AccountEmailData) (EmailData, error) {
+	return es.render("deletion_scheduled", data.Locale, data.Email, data)
+}
+
+// BuildDeletionCancelledEmail renders the email confirming that a scheduled
+// deletion was cancelled
+func (es *EmailService) BuildDeletionCancelledEmail(data AccountEmailData) (EmailData, error) {
+	return es.render("deletion_cancelled", data.Locale, data.Email, data)
+}
+
+// BuildAccountDeletedEmail renders the farewell email sent once the account
+// and its data are gone
+func (es *EmailService) BuildAccountDeletedEmail(data AccountEmailData) (EmailData, error) {
+	return es.render("account_deleted", data.Locale, data.Email, data)
+}
@@ -0,0 +1,40 @@
+package email
+
+type DigestCategory struct {
+	Name  string
+	Count int
+}
+
+type DigestNote struct {
+	Title     string
+	URL       string
+	CreatedAt string
+}
+
+type DigestEmailData struct {
+	Locale         string
+	UserName       string
+	Email          string
+	AppName        string
+	AppURL         string
+	PeriodLabel    string // "daily" or "weekly"
+	PeriodStart    string
+	PeriodEnd      string
+	TotalNotes     int
+	Categories     []DigestCategory
+	Notes          []DigestNote
+	CurrentStreak  int
+	LongestStreak  int
+	UnsubscribeURL string
+}
+
+// BuildDigestEmail renders a daily or weekly summary of the user's notes
+func (es *EmailService) BuildDigestEmail(data DigestEmailData) (EmailData, error) {
+	emailData, err := es.render("digest", data.Locale, data.Email, data)
+	if err != nil {
+		return EmailData{}, err
+	}
+	emailData.ListUnsubscribe = data.UnsubscribeURL
+
+	return emailData, nil
+}
@@ -0,0 +1,33 @@
+package email
+
+import (
+	"testing"
+
+	"daily-notes-api/internal/config"
+)
+
+func TestSenderAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		appURL  string
+		want    string
+		wantErr bool
+	}{
+		{"configured", "Notes <notes@example.com>", "https://notes.example", "Notes <notes@example.com>", false},
+		{"defaults to the app host", "", "https://notes.example:8443/app", `"Daily Notes" <noreply@notes.example>`, false},
+		{"defaults without app URL", "", "", `"Daily Notes" <noreply@localhost>`, false},
+		{"invalid", "not an address", "https://notes.example", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := senderAddress(&config.Config{SMTPFrom: tt.from, AppName: "Daily Notes", AppURL: tt.appURL})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("senderAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("senderAddress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Attachment is a file carried by an email. Set ContentID to embed it inline
+// (for an HTML body referencing "cid:<ContentID>") instead of attaching it.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string
+	Data        []byte
+}
+
+// Inline reports whether the attachment is an inline part of the HTML body
+func (a Attachment) Inline() bool {
+	return a.ContentID != ""
+}
+
+// Message is the input to MIMEBuilder. Address fields accept either a bare
+// address or a "Name <address>" string.
+type Message struct {
+	From            string
+	To              []string
+	CC              []string
+	BCC             []string
+	ReplyTo         string
+	Subject         string
+	TextBody        string
+	HTMLBody        string
+	Attachments     []Attachment
+	ListUnsubscribe string // URL; also enables RFC 8058 one-click unsubscribe
+}
+
+// MIMEBuilder composes RFC 5322 messages. Its clock, boundary and
+// Message-ID sources can be replaced to produce byte-for-byte stable output.
+type MIMEBuilder struct {
+	Now       func() time.Time
+	Boundary  func() string
+	MessageID func(domain string) string
+}
+
+// NewMIMEBuilder returns a builder using the wall clock and random boundaries
+func NewMIMEBuilder() *MIMEBuilder {
+	return &MIMEBuilder{
+		Now:       time.Now,
+		Boundary:  randomBoundary,
+		MessageID: randomMessageID,
+	}
+}
+
+// Build renders msg. Bcc recipients are never written to the headers; the
+// caller passes them to the transport as envelope recipients only.
+func (b *MIMEBuilder) Build(msg *Message) ([]byte, error) {
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %w", msg.From, err)
+	}
+	if len(msg.To) == 0 && len(msg.CC) == 0 && len(msg.BCC) == 0 {
+		return nil, errors.New("email has no recipients")
+	}
+
+	var buf bytes.Buffer
+	h := &headerWriter{w: &buf}
+
+	h.set("From", from.String())
+	if err := h.addresses("To", msg.To); err != nil {
+		return nil, err
+	}
+	if err := h.addresses("Cc", msg.CC); err != nil {
+		return nil, err
+	}
+	if msg.ReplyTo != "" {
+		if err := h.addresses("Reply-To", []string{msg.ReplyTo}); err != nil {
+			return nil, err
+		}
+	}
+	h.set("Subject", encodeHeader(msg.Subject))
+	h.set("Date", b.Now().Format(time.RFC1123Z))
+	h.set("Message-ID", "<"+b.MessageID(addressDomain(from.Address))+">")
+	if msg.ListUnsubscribe != "" {
+		h.set("List-Unsubscribe", "<"+msg.ListUnsubscribe+">")
+		h.set("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	h.set("MIME-Version", "1.0")
+
+	var inline, attached []Attachment
+	for _, a := range msg.Attachments {
+		if a.Inline() {
+			inline = append(inline, a)
+		} else {
+			attached = append(attached, a)
+		}
+	}
+
+	root := b.bodyPart(msg.TextBody, msg.HTMLBody, inline)
+	if len(attached) > 0 {
+		parts := []mimePart{root}
+		for _, a := range attached {
+			parts = append(parts, attachmentPart(a, "attachment"))
+		}
+		root = multipartPart("mixed", parts)
+	}
+
+	if err := b.writePart(&buf, root, h); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mimePart is a leaf (body set) or a multipart container (children set)
+type mimePart struct {
+	header   textproto.MIMEHeader
+	body     []byte
+	subtype  string
+	children []mimePart
+}
+
+func (b *MIMEBuilder) bodyPart(text, html string, inline []Attachment) mimePart {
+	var body mimePart
+	switch {
+	case text != "" && html != "":
+		body = multipartPart("alternative", []mimePart{textPart("plain", text), textPart("html", html)})
+	case html != "":
+		body = textPart("html", html)
+	default:
+		body = textPart("plain", text)
+	}
+
+	// Inline images belong next to the HTML they are referenced from
+	if len(inline) > 0 {
+		parts := []mimePart{body}
+		for _, a := range inline {
+			parts = append(parts, attachmentPart(a, "inline"))
+		}
+		body = multipartPart("related", parts)
+	}
+	return body
+}
+
+func textPart(subtype, content string) mimePart {
+	var encoded bytes.Buffer
+	qp := quotedprintable.NewWriter(&encoded)
+	qp.Write([]byte(normalizeNewlines(content)))
+	qp.Close()
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "text/"+subtype+"; charset=UTF-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return mimePart{header: header, body: encoded.Bytes()}
+}
+
+func attachmentPart(a Attachment, disposition string) mimePart {
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "base64")
+	if a.Filename != "" {
+		header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+	} else {
+		header.Set("Content-Disposition", disposition)
+	}
+	if a.Inline() {
+		header.Set("Content-ID", "<"+a.ContentID+">")
+	}
+	return mimePart{header: header, body: wrapBase64(a.Data)}
+}
+
+func multipartPart(subtype string, children []mimePart) mimePart {
+	return mimePart{subtype: subtype, children: children}
+}
+
+// writePart writes p's headers through h followed by its body
+func (b *MIMEBuilder) writePart(w io.Writer, p mimePart, h *headerWriter) error {
+	if p.subtype == "" {
+		for _, key := range []string{"Content-Type", "Content-Transfer-Encoding", "Content-Disposition", "Content-ID"} {
+			if v := p.header.Get(key); v != "" {
+				h.set(key, v)
+			}
+		}
+		h.end()
+		_, err := w.Write(p.body)
+		return err
+	}
+
+	boundary := b.Boundary()
+	h.set("Content-Type", fmt.Sprintf("multipart/%s; boundary=%q", p.subtype, boundary))
+	h.end()
+
+	return b.writeChildren(w, p, boundary)
+}
+
+// writeChildren writes the parts of a nested multipart using boundary
+func (b *MIMEBuilder) writeChildren(w io.Writer, p mimePart, boundary string) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return fmt.Errorf("invalid boundary: %w", err)
+	}
+	for _, child := range p.children {
+		if child.subtype != "" {
+			childBoundary := b.Boundary()
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Type", fmt.Sprintf("multipart/%s; boundary=%q", child.subtype, childBoundary))
+			pw, err := mw.CreatePart(header)
+			if err != nil {
+				return err
+			}
+			if err := b.writeChildren(pw, child, childBoundary); err != nil {
+				return err
+			}
+			continue
+		}
+
+		pw, err := mw.CreatePart(child.header)
+		if err != nil {
+			return err
+		}
+		if _, err := pw.Write(child.body); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// headerWriter writes top-level headers in the order they are set
+type headerWriter struct {
+	w io.Writer
+}
+
+func (h *headerWriter) set(key, value string) {
+	fmt.Fprintf(h.w, "%s: %s\r\n", key, value)
+}
+
+func (h *headerWriter) end() {
+	io.WriteString(h.w, "\r\n")
+}
+
+func (h *headerWriter) addresses(key string, list []string) error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	formatted := make([]string, 0, len(list))
+	for _, s := range list {
+		addr, err := mail.ParseAddress(s)
+		if err != nil {
+			return fmt.Errorf("invalid %s address %q: %w", strings.ToLower(key), s, err)
+		}
+		formatted = append(formatted, addr.String())
+	}
+	h.set(key, strings.Join(formatted, ", "))
+	return nil
+}
+
+// encodeHeader applies RFC 2047 encoding when value is not plain ASCII
+func encodeHeader(value string) string {
+	return mime.QEncoding.Encode("UTF-8", value)
+}
+
+// normalizeNewlines converts bare LF to CRLF as required on the wire
+func normalizeNewlines(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+// wrapBase64 encodes data in 76 character lines
+func wrapBase64(data []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	var buf bytes.Buffer
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76])
+		buf.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded)
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+func addressDomain(address string) string {
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
+
+func randomBoundary() string {
+	return "=_" + randomHex(16)
+}
+
+func randomMessageID(domain string) string {
+	return fmt.Sprintf("%d.%s@%s", time.Now().UnixNano(), randomHex(8), domain)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,168 @@
+package email_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"daily-notes-api/pkg/email"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// stableBuilder numbers boundaries and uses a fixed clock and Message-ID
+func stableBuilder() *email.MIMEBuilder {
+	boundaries := 0
+	return &email.MIMEBuilder{
+		Now: func() time.Time { return time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC) },
+		Boundary: func() string {
+			boundaries++
+			return fmt.Sprintf("=_boundary%d", boundaries)
+		},
+		MessageID: func(domain string) string { return "fixed@" + domain },
+	}
+}
+
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".eml")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("failed to update %s: %v", path, err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s (run with -update to create it): %v", path, err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("message differs from %s:\n%s", path, got)
+	}
+}
+
+func TestMIMEBuilderGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *email.Message
+	}{
+		{
+			name: "plain",
+			msg: &email.Message{
+				From:     "Daily Notes <noreply@notes.example>",
+				To:       []string{"ann@example.com"},
+				Subject:  "Plain text",
+				TextBody: "Hello Ann,\nthis line is plain text.\n",
+			},
+		},
+		{
+			name: "alternative",
+			msg: &email.Message{
+				From:     "noreply@notes.example",
+				To:       []string{"Zoë Müller <zoe@example.com>"},
+				CC:       []string{"ann@example.com", "Bob <bob@example.com>"},
+				BCC:      []string{"audit@notes.example"},
+				ReplyTo:  "support@notes.example",
+				Subject:  "Grüße aus Köln",
+				TextBody: "Long lines are wrapped by quoted-printable so that no line is longer than seventy-six characters.",
+				HTMLBody: "<p>Gr\u00fc\u00dfe = greetings</p>",
+			},
+		},
+		{
+			name: "attachments",
+			msg: &email.Message{
+				From:     "noreply@notes.example",
+				To:       []string{"ann@example.com"},
+				Subject:  "Your export",
+				TextBody: "See attached.",
+				HTMLBody: `<p><img src="cid:logo"> See attached.</p>`,
+				Attachments: []email.Attachment{
+					{Filename: "logo.png", ContentType: "image/png", ContentID: "logo", Data: []byte("\x89PNG fake image")},
+					{Filename: "notes export.json", ContentType: "application/json", Data: []byte(strings.Repeat(`{"title":"note"}`, 8))},
+				},
+			},
+		},
+		{
+			name: "unsubscribe",
+			msg: &email.Message{
+				From:            "noreply@notes.example",
+				To:              []string{"ann@example.com"},
+				Subject:         "Your weekly digest",
+				HTMLBody:        "<p>Three notes this week</p>",
+				ListUnsubscribe: "https://api.notes.example/api/v1/digest/unsubscribe?token=abc",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := stableBuilder().Build(tt.msg)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if strings.Contains(string(raw), "audit@notes.example") {
+				t.Errorf("Bcc recipient leaked into the message")
+			}
+			assertGolden(t, tt.name, raw)
+		})
+	}
+}
+
+func TestMIMEBuilderRejectsInvalidMessages(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *email.Message
+	}{
+		{"missing from", &email.Message{To: []string{"ann@example.com"}}},
+		{"no recipients", &email.Message{From: "noreply@notes.example"}},
+		{"invalid recipient", &email.Message{From: "noreply@notes.example", To: []string{"not an address"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := stableBuilder().Build(tt.msg); err == nil {
+				t.Errorf("Build() succeeded, want an error")
+			}
+		})
+	}
+}
+
+type captureTransport struct {
+	sent []*email.Envelope
+}
+
+func (t *captureTransport) Send(env *email.Envelope) error {
+	t.sent = append(t.sent, env)
+	return nil
+}
+
+func (t *captureTransport) Name() string {
+	return "capture"
+}
+
+func TestSendWelcomeEmailGolden(t *testing.T) {
+	transport := &captureTransport{}
+	service := email.NewEmailServiceWithTransport(transport, email.NewTemplateRenderer(""), "Daily Notes <noreply@notes.example>")
+	service.SetMIMEBuilder(stableBuilder())
+
+	err := service.SendWelcomeEmail(email.WelcomeEmailData{
+		UserName: "Ann",
+		Email:    "ann@example.com",
+		AppName:  "Daily Notes",
+		AppURL:   "https://notes.example",
+	})
+	if err != nil {
+		t.Fatalf("SendWelcomeEmail() error = %v", err)
+	}
+	if len(transport.sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(transport.sent))
+	}
+
+	env := transport.sent[0]
+	if env.From != "noreply@notes.example" {
+		t.Errorf("envelope From = %q, want the bare address", env.From)
+	}
+	assertGolden(t, "welcome", env.Raw)
+}
@@ -0,0 +1,25 @@
+package email
+
+type ReminderEmailData struct {
+	Locale    string
+	UserName  string
+	Email     string
+	AppName   string
+	NoteTitle string
+	Excerpt   string
+	NoteURL   string
+}
+
+// BuildReminderEmail renders a note reminder with an excerpt and a deep link
+func (es *EmailService) BuildReminderEmail(data ReminderEmailData) (EmailData, error) {
+	return es.render("reminder", data.Locale, data.Email, data)
+}
+
+// Excerpt shortens note content for use in emails
+func Excerpt(content string, maxRunes int) string {
+	runes := []rune(content)
+	if len(runes) <= maxRunes {
+		return content
+	}
+	return string(runes[:maxRunes]) + "…"
+}
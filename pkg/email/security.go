@@ -0,0 +1,44 @@
+package email
+
+// SecurityEmailData feeds the account lockout and new login emails. Times
+// are preformatted.
+type SecurityEmailData struct {
+	Locale      string
+	UserName    string
+	Email       string
+	AppName     string
+	AppURL      string
+	Device      string
+	IPAddress   string
+	Time        string // when the login or lockout happened
+	LockedUntil string // account_locked only
+}
+
+// BuildAccountLockedEmail renders the email sent when repeated failed logins
+// lock the account
+func (es *EmailService) BuildAccountLockedEmail(data SecurityEmailData) (EmailData, error) {
+	return es.render("account_locked", data.Locale, data.Email, data)
+}
+
+// BuildNewLoginEmail renders the email sent after a login from a device or
+// network the user has not used before
+func (es *EmailService) BuildNewLoginEmail(data SecurityEmailData) (EmailData, error) {
+	return es.render("new_login", data.Locale, data.Email, data)
+}
+
+// PasswordResetEmailData feeds the password reset email
+type PasswordResetEmailData struct {
+	Locale         string
+	UserName       string
+	Email          string
+	AppName        string
+	AppURL         string
+	ResetURL       string
+	ExpiresMinutes int
+}
+
+// BuildPasswordResetEmail renders the email with the link to reset a
+// forgotten password
+func (es *EmailService) BuildPasswordResetEmail(data PasswordResetEmailData) (EmailData, error) {
+	return es.render("password_reset", data.Locale, data.Email, data)
+}
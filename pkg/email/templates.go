@@ -0,0 +1,219 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Email templates live in templates/ and are embedded into the binary. Each
+// email has a <locale>/<name>.html and <locale>/<name>.txt; the text file also
+// defines the "subject" block. Both are wrapped by layout.html/layout.txt,
+// with the locale's common.* providing the footer and partials/*.html any
+// shared snippets. Setting EMAIL_TEMPLATE_DIR to a directory with the same
+// layout overrides individual files without a redeploy.
+//
+//go:embed templates
+var embeddedTemplates embed.FS
+
+const DefaultLocale = "en"
+
+// SupportedLocales lists the locales that have a full set of templates
+var SupportedLocales = []string{"en", "id"}
+
+var templateFuncs = map[string]interface{}{
+	// dict builds a map for passing several values to a partial
+	"dict": func(pairs ...interface{}) (map[string]interface{}, error) {
+		if len(pairs)%2 != 0 {
+			return nil, errors.New("dict needs key/value pairs")
+		}
+		m := make(map[string]interface{}, len(pairs)/2)
+		for i := 0; i < len(pairs); i += 2 {
+			key, ok := pairs[i].(string)
+			if !ok {
+				return nil, errors.New("dict keys must be strings")
+			}
+			m[key] = pairs[i+1]
+		}
+		return m, nil
+	},
+}
+
+// TemplateRenderer renders localized email templates
+type TemplateRenderer struct {
+	fsys fs.FS
+}
+
+// NewTemplateRenderer uses the embedded templates, overridden file by file by
+// overrideDir when it is not empty
+func NewTemplateRenderer(overrideDir string) *TemplateRenderer {
+	embedded, _ := fs.Sub(embeddedTemplates, "templates")
+
+	var fsys fs.FS = embedded
+	if overrideDir != "" {
+		fsys = overlayFS{upper: os.DirFS(overrideDir), lower: embedded}
+	}
+
+	return &TemplateRenderer{fsys: fsys}
+}
+
+// RenderedEmail is the output of a template: subject plus both bodies
+type RenderedEmail struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Render executes the named email in the closest supported locale
+func (r *TemplateRenderer) Render(name, locale string, data interface{}) (*RenderedEmail, error) {
+	locale = r.resolveLocale(name, locale)
+
+	htmlBody, err := r.renderHTML(name, locale, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s/%s.html: %w", locale, name, err)
+	}
+
+	subject, textBody, err := r.renderText(name, locale, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s/%s.txt: %w", locale, name, err)
+	}
+
+	return &RenderedEmail{
+		Subject: subject,
+		HTML:    htmlBody,
+		Text:    textBody,
+	}, nil
+}
+
+// NormalizeLocale maps values such as "id-ID" or "EN_us" onto a supported
+// locale, falling back to DefaultLocale
+func NormalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		locale = locale[:i]
+	}
+
+	for _, supported := range SupportedLocales {
+		if locale == supported {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+func (r *TemplateRenderer) resolveLocale(name, locale string) string {
+	locale = NormalizeLocale(locale)
+	if _, err := fs.Stat(r.fsys, path.Join(locale, name+".html")); err != nil {
+		return DefaultLocale
+	}
+	return locale
+}
+
+func (r *TemplateRenderer) renderHTML(name, locale string, data interface{}) (string, error) {
+	files, err := r.templateFiles(name, locale, ".html")
+	if err != nil {
+		return "", err
+	}
+
+	t := htmltemplate.New(name).Funcs(templateFuncs)
+	for _, file := range files {
+		content, err := fs.ReadFile(r.fsys, file)
+		if err != nil {
+			return "", err
+		}
+		if _, err := t.New(file).Parse(string(content)); err != nil {
+			return "", err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (r *TemplateRenderer) renderText(name, locale string, data interface{}) (string, string, error) {
+	files, err := r.templateFiles(name, locale, ".txt")
+	if err != nil {
+		return "", "", err
+	}
+
+	t := texttemplate.New(name).Funcs(templateFuncs)
+	for _, file := range files {
+		content, err := fs.ReadFile(r.fsys, file)
+		if err != nil {
+			return "", "", err
+		}
+		if _, err := t.New(file).Parse(string(content)); err != nil {
+			return "", "", err
+		}
+	}
+
+	var subject bytes.Buffer
+	if err := t.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", err
+	}
+
+	var body bytes.Buffer
+	if err := t.ExecuteTemplate(&body, "layout", data); err != nil {
+		return "", "", err
+	}
+
+	return strings.TrimSpace(subject.String()), strings.TrimSpace(body.String()) + "\n", nil
+}
+
+// templateFiles lists the files making up one email, in parse order: later
+// files may redefine blocks declared by earlier ones
+func (r *TemplateRenderer) templateFiles(name, locale, ext string) ([]string, error) {
+	files := []string{"layout" + ext}
+
+	partials, err := fs.Glob(r.fsys, "partials/*"+ext)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(partials)
+	files = append(files, partials...)
+
+	return append(files, path.Join(locale, "common"+ext), path.Join(locale, name+ext)), nil
+}
+
+// overlayFS serves files from upper when present there, otherwise from lower
+type overlayFS struct {
+	upper fs.FS
+	lower fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if f, err := o.upper.Open(name); err == nil {
+		return f, nil
+	}
+	return o.lower.Open(name)
+}
+
+// Glob merges matches from both layers so extra partials can be added on disk
+func (o overlayFS) Glob(pattern string) ([]string, error) {
+	seen := make(map[string]bool)
+	var matches []string
+	for _, layer := range []fs.FS{o.upper, o.lower} {
+		found, err := fs.Glob(layer, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range found {
+			if !seen[m] {
+				seen[m] = true
+				matches = append(matches, m)
+			}
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,64 @@
+package email
+
+import (
+	"fmt"
+
+	"daily-notes-api/internal/config"
+)
+
+// Envelope is a fully composed email handed to a Transport. Raw holds the
+// RFC 5322 message; the structured fields are there for transports that
+// submit through an API instead of SMTP.
+type Envelope struct {
+	From        string
+	To          []string
+	Cc          []string
+	Bcc         []string
+	ReplyTo     string
+	Subject     string
+	TextBody    string
+	HTMLBody    string
+	Attachments []Attachment
+	Headers     map[string]string // extra headers, e.g. List-Unsubscribe
+	Raw         []byte
+}
+
+// Recipients returns the bare addresses of every To, Cc and Bcc recipient
+func (env *Envelope) Recipients() []string {
+	var rcpts []string
+	for _, list := range [][]string{env.To, env.Cc, env.Bcc} {
+		for _, s := range list {
+			rcpts = append(rcpts, envelopeAddress(s))
+		}
+	}
+	return rcpts
+}
+
+// Transport delivers composed emails
+type Transport interface {
+	Send(env *Envelope) error
+	Name() string
+}
+
+const (
+	TransportSMTP = "smtp"
+	TransportLog  = "log"
+	TransportFile = "file"
+	TransportHTTP = "http"
+)
+
+// NewTransport builds the transport selected by EMAIL_TRANSPORT
+func NewTransport(cfg *config.Config) (Transport, error) {
+	switch cfg.EmailTransport {
+	case TransportSMTP, "":
+		return NewSMTPTransport(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPSecurity)
+	case TransportLog:
+		return NewLogTransport(nil), nil
+	case TransportFile:
+		return NewFileTransport(cfg.EmailFileDir)
+	case TransportHTTP:
+		return NewHTTPTransport(cfg.EmailHTTPURL), nil
+	default:
+		return nil, fmt.Errorf("unknown email transport %q", cfg.EmailTransport)
+	}
+}
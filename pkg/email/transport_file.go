@@ -0,0 +1,48 @@
+package email
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileTransport writes every email as an .eml file into a directory, which
+// any mail client can open
+type FileTransport struct {
+	dir string
+}
+
+func NewFileTransport(dir string) (*FileTransport, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("file transport needs EMAIL_FILE_DIR")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	return &FileTransport{dir: dir}, nil
+}
+
+func (t *FileTransport) Name() string {
+	return TransportFile
+}
+
+func (t *FileTransport) Send(env *Envelope) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to generate file name: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000000"), hex.EncodeToString(suffix))
+	path := filepath.Join(t.dir, name)
+
+	if err := os.WriteFile(path, env.Raw, 0o644); err != nil {
+		return fmt.Errorf("failed to write email file: %w", err)
+	}
+
+	return nil
+}
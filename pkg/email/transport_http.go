@@ -0,0 +1,114 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"time"
+)
+
+// HTTPTransport submits emails to a local mail catcher's HTTP send API, in
+// the JSON format used by Mailpit's POST /api/v1/send
+type HTTPTransport struct {
+	url    string
+	client *http.Client
+}
+
+type httpAddress struct {
+	Email string `json:"Email"`
+	Name  string `json:"Name,omitempty"`
+}
+
+type httpAttachment struct {
+	Content     string `json:"Content"`
+	Filename    string `json:"Filename"`
+	ContentType string `json:"ContentType,omitempty"`
+	ContentID   string `json:"ContentID,omitempty"`
+}
+
+type httpSendRequest struct {
+	From        httpAddress       `json:"From"`
+	To          []httpAddress     `json:"To"`
+	Cc          []httpAddress     `json:"Cc,omitempty"`
+	Bcc         []string          `json:"Bcc,omitempty"`
+	ReplyTo     []httpAddress     `json:"ReplyTo,omitempty"`
+	Subject     string            `json:"Subject"`
+	Text        string            `json:"Text,omitempty"`
+	HTML        string            `json:"HTML,omitempty"`
+	Attachments []httpAttachment  `json:"Attachments,omitempty"`
+	Headers     map[string]string `json:"Headers,omitempty"`
+}
+
+func NewHTTPTransport(url string) *HTTPTransport {
+	if url == "" {
+		url = "http://localhost:8025/api/v1/send"
+	}
+
+	return &HTTPTransport{
+		url:    url,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (t *HTTPTransport) Name() string {
+	return TransportHTTP
+}
+
+func (t *HTTPTransport) Send(env *Envelope) error {
+	req := httpSendRequest{
+		From:    parseHTTPAddress(env.From),
+		Subject: env.Subject,
+		Text:    env.TextBody,
+		HTML:    env.HTMLBody,
+		Headers: env.Headers,
+	}
+	for _, to := range env.To {
+		req.To = append(req.To, parseHTTPAddress(to))
+	}
+	for _, cc := range env.Cc {
+		req.Cc = append(req.Cc, parseHTTPAddress(cc))
+	}
+	for _, bcc := range env.Bcc {
+		req.Bcc = append(req.Bcc, envelopeAddress(bcc))
+	}
+	if env.ReplyTo != "" {
+		req.ReplyTo = []httpAddress{parseHTTPAddress(env.ReplyTo)}
+	}
+	for _, a := range env.Attachments {
+		req.Attachments = append(req.Attachments, httpAttachment{
+			Content:     base64.StdEncoding.EncodeToString(a.Data),
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			ContentID:   a.ContentID,
+		})
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode email: %w", err)
+	}
+
+	resp, err := t.client.Post(t.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to submit email: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("mail API returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+func parseHTTPAddress(s string) httpAddress {
+	if addr, err := mail.ParseAddress(s); err == nil {
+		return httpAddress{Email: addr.Address, Name: addr.Name}
+	}
+	return httpAddress{Email: s}
+}
@@ -0,0 +1,37 @@
+package email
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// LogTransport prints emails instead of sending them, for local development
+type LogTransport struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewLogTransport writes to w, or to stdout when w is nil
+func NewLogTransport(w io.Writer) *LogTransport {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &LogTransport{w: w}
+}
+
+func (t *LogTransport) Name() string {
+	return TransportLog
+}
+
+func (t *LogTransport) Send(env *Envelope) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	separator := strings.Repeat("=", 72)
+	_, err := fmt.Fprintf(t.w, "%s\nMAIL FROM: %s\nRCPT TO: %s\n%s\n%s\n%s\n",
+		separator, env.From, strings.Join(env.Recipients(), ", "), separator, env.Raw, separator)
+	return err
+}
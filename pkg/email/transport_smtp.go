@@ -0,0 +1,113 @@
+package email
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+const (
+	SMTPSecuritySTARTTLS = "starttls"
+	SMTPSecurityTLS      = "tls"
+	SMTPSecurityNone     = "none"
+)
+
+// SMTPTransport sends through an SMTP server using implicit TLS (usually
+// port 465), STARTTLS (usually 587) or, for local relays only, plain text
+type SMTPTransport struct {
+	host     string
+	port     int
+	username string
+	password string
+	security string
+	timeout  time.Duration
+}
+
+func NewSMTPTransport(host string, port int, username, password, security string) (*SMTPTransport, error) {
+	if security == "" {
+		security = SMTPSecuritySTARTTLS
+	}
+
+	switch security {
+	case SMTPSecuritySTARTTLS, SMTPSecurityTLS, SMTPSecurityNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP security mode %q", security)
+	}
+
+	return &SMTPTransport{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		security: security,
+		timeout:  30 * time.Second,
+	}, nil
+}
+
+func (t *SMTPTransport) Name() string {
+	return TransportSMTP + "+" + t.security
+}
+
+func (t *SMTPTransport) Send(env *Envelope) error {
+	addr := net.JoinHostPort(t.host, strconv.Itoa(t.port))
+	tlsConfig := &tls.Config{ServerName: t.host}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: t.timeout}
+	if t.security == SMTPSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(2 * t.timeout))
+
+	client, err := smtp.NewClient(conn, t.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if t.security == SMTPSecuritySTARTTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	if t.username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted
+		// connection to anything but localhost
+		if err := client.Auth(smtp.PlainAuth("", t.username, t.password, t.host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := client.Mail(env.From); err != nil {
+		return err
+	}
+	for _, rcpt := range env.Recipients() {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(env.Raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
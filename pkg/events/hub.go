@@ -0,0 +1,286 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"daily-notes-api/pkg/cache"
+)
+
+// Event is a change notification for one user. IDs are Redis stream IDs
+// ("<ms>-<seq>"), or IDs of the same form without Redis, and increase
+// monotonically per user.
+type Event struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// ErrResyncRequired is returned by Since when lastID is no longer in the
+// backlog, e.g. because it was trimmed or the server restarted without
+// Redis. Events may have been missed, so the client has to reload its state.
+var ErrResyncRequired = errors.New("event backlog does not reach the last event ID")
+
+const (
+	channelPrefix = "events:user:"
+	streamPrefix  = "events:stream:user:"
+
+	// backlogSize is how many events per user are kept for Last-Event-ID resume
+	backlogSize   = 1000
+	memoryBacklog = 200
+	replayLimit   = 500
+	subscriberBuf = 64
+)
+
+// Hub fans events out to the streams connected to this instance. With Redis
+// available, events are published on a per-user channel so every API instance
+// sees them, and kept in a capped stream for replay; without Redis it works
+// in-process only.
+type Hub struct {
+	cache *cache.CacheService
+
+	mu   sync.Mutex
+	subs map[int]map[chan Event]struct{}
+
+	// in-process backlog, used when Redis is disabled
+	lastMs  uint64
+	seq     uint64
+	backlog map[int][]Event
+}
+
+func NewHub(cacheService *cache.CacheService) *Hub {
+	return &Hub{
+		cache:   cacheService,
+		subs:    make(map[int]map[chan Event]struct{}),
+		backlog: make(map[int][]Event),
+	}
+}
+
+// Run relays events published by any instance to local subscribers until ctx
+// is cancelled. It is a no-op without Redis.
+func (h *Hub) Run(ctx context.Context) {
+	if h.cache == nil {
+		return
+	}
+
+	for ctx.Err() == nil {
+		messages, err := h.cache.PSubscribe(ctx, channelPrefix+"*")
+		if err != nil {
+			log.Printf("Event hub failed to subscribe: %v", err)
+		} else {
+			log.Printf("Event hub subscribed to Redis")
+			for msg := range messages {
+				h.relay(msg)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (h *Hub) relay(msg cache.Message) {
+	userID, err := strconv.Atoi(strings.TrimPrefix(msg.Channel, channelPrefix))
+	if err != nil {
+		return
+	}
+
+	var event Event
+	if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+		log.Printf("Event hub dropped malformed event on %s: %v", msg.Channel, err)
+		return
+	}
+
+	h.dispatch(userID, event)
+}
+
+// Publish records an event for userID and notifies their open streams
+func (h *Hub) Publish(ctx context.Context, userID int, eventType string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	event := Event{Type: eventType, Data: raw}
+
+	if h.cache == nil {
+		h.mu.Lock()
+		event.ID = h.nextID()
+		backlog := append(h.backlog[userID], event)
+		if len(backlog) > memoryBacklog {
+			backlog = backlog[len(backlog)-memoryBacklog:]
+		}
+		h.backlog[userID] = backlog
+		h.mu.Unlock()
+
+		h.dispatch(userID, event)
+		return nil
+	}
+
+	stored, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	id, err := h.cache.StreamAppend(ctx, streamPrefix+strconv.Itoa(userID), string(stored), backlogSize)
+	if err != nil {
+		return fmt.Errorf("failed to store event: %w", err)
+	}
+	event.ID = id
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	// Local subscribers are reached through the Redis subscription as well
+	if err := h.cache.Publish(ctx, channelPrefix+strconv.Itoa(userID), string(payload)); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
+
+// nextID returns a Redis style ID for an in-process event. IDs start from
+// the clock rather than zero, so they keep increasing across restarts.
+// Callers hold h.mu.
+func (h *Hub) nextID() string {
+	ms := uint64(time.Now().UnixMilli())
+	if ms > h.lastMs {
+		h.lastMs = ms
+		h.seq = 0
+	} else {
+		h.seq++
+	}
+	return fmt.Sprintf("%d-%d", h.lastMs, h.seq)
+}
+
+// Subscribe registers a stream for userID. The channel is closed when the
+// subscriber falls too far behind; the client is expected to reconnect with
+// Last-Event-ID. Call the returned function to unsubscribe.
+func (h *Hub) Subscribe(userID int) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuf)
+
+	h.mu.Lock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[chan Event]struct{})
+	}
+	h.subs[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[userID][ch]; ok {
+			delete(h.subs[userID], ch)
+			close(ch)
+		}
+		if len(h.subs[userID]) == 0 {
+			delete(h.subs, userID)
+		}
+	}
+}
+
+// Since returns the user's events after lastID, oldest first. It returns
+// ErrResyncRequired when lastID is unknown.
+func (h *Hub) Since(ctx context.Context, userID int, lastID string) ([]Event, error) {
+	if h.cache == nil {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		backlog := h.backlog[userID]
+		for i, event := range backlog {
+			if event.ID == lastID {
+				return append([]Event(nil), backlog[i+1:]...), nil
+			}
+		}
+		return nil, ErrResyncRequired
+	}
+
+	key := streamPrefix + strconv.Itoa(userID)
+	found, err := h.cache.StreamHas(ctx, key, lastID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event backlog: %w", err)
+	}
+	if !found {
+		return nil, ErrResyncRequired
+	}
+
+	entries, err := h.cache.StreamAfter(ctx, key, lastID, replayLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event backlog: %w", err)
+	}
+
+	events := make([]Event, 0, len(entries))
+	for _, entry := range entries {
+		var event Event
+		if err := json.Unmarshal([]byte(entry.Data), &event); err != nil {
+			continue
+		}
+		event.ID = entry.ID
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (h *Hub) dispatch(userID int, event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subs[userID] {
+		select {
+		case ch <- event:
+		default:
+			// Too slow: drop the subscriber rather than block everyone
+			delete(h.subs[userID], ch)
+			close(ch)
+		}
+	}
+}
+
+// After reports whether event ID a is newer than b. An empty b is older than
+// everything.
+func After(a, b string) bool {
+	if b == "" {
+		return true
+	}
+
+	aMs, aSeq, okA := parseID(a)
+	bMs, bSeq, okB := parseID(b)
+	if !okA || !okB {
+		return a > b
+	}
+	if aMs != bMs {
+		return aMs > bMs
+	}
+	return aSeq > bSeq
+}
+
+// ValidID reports whether id has the "<ms>-<seq>" form
+func ValidID(id string) bool {
+	_, _, ok := parseID(id)
+	return ok
+}
+
+func parseID(id string) (uint64, uint64, bool) {
+	msPart, seqPart, found := strings.Cut(id, "-")
+	if !found {
+		return 0, 0, false
+	}
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return ms, seq, true
+}
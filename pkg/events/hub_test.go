@@ -0,0 +1,97 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHubInProcessIDs(t *testing.T) {
+	h := NewHub(nil)
+	ctx := context.Background()
+	before := uint64(time.Now().UnixMilli())
+
+	var last string
+	for i := 0; i < 5; i++ {
+		if err := h.Publish(ctx, 1, "note.updated", map[string]int{"id": i}); err != nil {
+			t.Fatal(err)
+		}
+		id := h.backlog[1][i].ID
+		if !ValidID(id) || !After(id, last) {
+			t.Fatalf("event %d has ID %q after %q", i, id, last)
+		}
+		last = id
+	}
+
+	ms, _, _ := strings.Cut(h.backlog[1][0].ID, "-")
+	if n, _ := strconv.ParseUint(ms, 10, 64); n < before {
+		t.Errorf("first ID %q is not based on the clock", h.backlog[1][0].ID)
+	}
+}
+
+func TestHubSince(t *testing.T) {
+	h := NewHub(nil)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := h.Publish(ctx, 1, "note.updated", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Publish(ctx, 2, "note.updated", 0); err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{h.backlog[1][0].ID, h.backlog[1][1].ID, h.backlog[1][2].ID}
+
+	tests := []struct {
+		name    string
+		lastID  string
+		want    []string
+		wantErr error
+	}{
+		{"from first", ids[0], ids[1:], nil},
+		{"up to date", ids[2], nil, nil},
+		{"another user's ID", h.backlog[2][0].ID, nil, ErrResyncRequired},
+		{"before a restart", "1-0", nil, ErrResyncRequired},
+		{"from the future", "99999999999999-0", nil, ErrResyncRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.Since(ctx, 1, tt.lastID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Since error = %v, want %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Since returned %d events, want %d", len(got), len(tt.want))
+			}
+			for i, event := range got {
+				if event.ID != tt.want[i] {
+					t.Errorf("event %d ID = %q, want %q", i, event.ID, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHubSinceTrimmedBacklog(t *testing.T) {
+	h := NewHub(nil)
+	ctx := context.Background()
+	for i := 0; i <= memoryBacklog; i++ {
+		if err := h.Publish(ctx, 1, "note.updated", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldest := h.backlog[1][0].ID
+
+	if _, err := h.Since(ctx, 1, oldest); err != nil {
+		t.Fatalf("Since(oldest) error = %v", err)
+	}
+	if err := h.Publish(ctx, 1, "note.updated", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Since(ctx, 1, oldest); !errors.Is(err, ErrResyncRequired) {
+		t.Errorf("Since(trimmed) error = %v, want %v", err, ErrResyncRequired)
+	}
+}
@@ -0,0 +1,45 @@
+package inbound
+
+import (
+	"net/mail"
+	"strings"
+)
+
+// AddressPrefix starts the local part of every inbound address
+const AddressPrefix = "u-"
+
+// FormatAddress builds the secret address for token, e.g. u-<token>@domain
+func FormatAddress(token, domain string) string {
+	return AddressPrefix + token + "@" + domain
+}
+
+// ParseAddress extracts the token and optional +category from an inbound
+// address. It returns ok=false for addresses on other domains or without
+// the u- prefix.
+func ParseAddress(address, domain string) (token, category string, ok bool) {
+	if addr, err := mail.ParseAddress(address); err == nil {
+		address = addr.Address
+	}
+
+	at := strings.LastIndex(address, "@")
+	if at <= 0 || !strings.EqualFold(address[at+1:], domain) {
+		return "", "", false
+	}
+
+	local := address[:at]
+	if !strings.HasPrefix(strings.ToLower(local), AddressPrefix) {
+		return "", "", false
+	}
+	local = local[len(AddressPrefix):]
+
+	if plus := strings.Index(local, "+"); plus >= 0 {
+		category = strings.TrimSpace(local[plus+1:])
+		local = local[:plus]
+	}
+
+	token = strings.ToLower(local)
+	if token == "" {
+		return "", "", false
+	}
+	return token, category, true
+}
@@ -0,0 +1,55 @@
+package inbound
+
+import "testing"
+
+func TestParseAddress(t *testing.T) {
+	const domain = "in.notes.example"
+
+	tests := []struct {
+		name         string
+		address      string
+		wantToken    string
+		wantCategory string
+		wantOK       bool
+	}{
+		{"plain", "u-abc123@in.notes.example", "abc123", "", true},
+		{"plus category", "u-abc123+work@in.notes.example", "abc123", "work", true},
+		{"category keeps its case", "u-abc123+Work Log@in.notes.example", "abc123", "Work Log", true},
+		{"empty category", "u-abc123+@in.notes.example", "abc123", "", true},
+		{"only the first plus splits", "u-abc123+work+home@in.notes.example", "abc123", "work+home", true},
+		{"mixed case", "U-ABC123@In.Notes.Example", "abc123", "", true},
+		{"mixed case with category", "U-Abc123+Ideas@IN.NOTES.EXAMPLE", "abc123", "Ideas", true},
+		{"display name", `"Notes" <u-abc123+work@in.notes.example>`, "abc123", "work", true},
+		{"empty", "", "", "", false},
+		{"no at sign", "u-abc123", "", "", false},
+		{"no local part", "@in.notes.example", "", "", false},
+		{"no token", "u-@in.notes.example", "", "", false},
+		{"no token with category", "u-+work@in.notes.example", "", "", false},
+		{"missing prefix", "abc123@in.notes.example", "", "", false},
+		{"other domain", "u-abc123@notes.example", "", "", false},
+		{"domain suffix", "u-abc123@in.notes.example.evil.test", "", "", false},
+		{"subdomain", "u-abc123@x.in.notes.example", "", "", false},
+		{"second at sign", "u-abc123@in.notes.example@evil.test", "", "", false},
+		{"unclosed angle bracket", "Notes <u-abc123@evil.test", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, category, ok := ParseAddress(tt.address, domain)
+			if token != tt.wantToken || category != tt.wantCategory || ok != tt.wantOK {
+				t.Errorf("ParseAddress(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					tt.address, token, category, ok, tt.wantToken, tt.wantCategory, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFormatAddressRoundTrip(t *testing.T) {
+	address := FormatAddress("abc123", "in.notes.example")
+	if address != "u-abc123@in.notes.example" {
+		t.Errorf("FormatAddress = %q", address)
+	}
+	if token, _, ok := ParseAddress(address, "in.notes.example"); !ok || token != "abc123" {
+		t.Errorf("ParseAddress(%q) = (%q, %v)", address, token, ok)
+	}
+}
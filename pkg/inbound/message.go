@@ -0,0 +1,187 @@
+package inbound
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// Email is the part of an inbound message that becomes a note
+type Email struct {
+	From       string
+	Recipients []string // To, Cc and delivery headers, in that order
+	Subject    string
+	Text       string
+	HTML       string
+}
+
+// Body returns the plain text part, or the HTML part converted to text
+func (e *Email) Body() string {
+	if strings.TrimSpace(e.Text) != "" {
+		return strings.TrimSpace(e.Text)
+	}
+	return HTMLToText(e.HTML)
+}
+
+var wordDecoder = &mime.WordDecoder{}
+
+// maxDepth bounds nested multiparts so a hostile message cannot recurse forever
+const maxDepth = 10
+
+// Parse reads a raw RFC 822 message
+func Parse(raw []byte) (*Email, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid email: %w", err)
+	}
+
+	subject, err := wordDecoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	email := &Email{
+		From:    msg.Header.Get("From"),
+		Subject: strings.TrimSpace(subject),
+	}
+
+	for _, key := range []string{"To", "Cc", "Delivered-To", "X-Original-To", "Envelope-To"} {
+		list, err := msg.Header.AddressList(key)
+		if err != nil {
+			continue
+		}
+		for _, addr := range list {
+			email.Recipients = append(email.Recipients, addr.Address)
+		}
+	}
+
+	if err := email.readPart(mail.Header(msg.Header), msg.Body, 0); err != nil {
+		return nil, err
+	}
+	return email, nil
+}
+
+type headerGetter interface {
+	Get(key string) string
+}
+
+// readPart keeps the first text/plain and text/html parts it finds, skipping
+// attachments
+func (e *Email) readPart(header headerGetter, body io.Reader, depth int) error {
+	if depth > maxDepth {
+		return errors.New("email nests too many parts")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("invalid multipart body: %w", err)
+			}
+			if err := e.readPart(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	if disposition, _, _ := mime.ParseMediaType(header.Get("Content-Disposition")); disposition == "attachment" {
+		return nil
+	}
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return nil
+	}
+
+	content, err := io.ReadAll(decodeTransfer(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("failed to decode body: %w", err)
+	}
+	text := toUTF8(params["charset"], content)
+
+	if mediaType == "text/plain" && e.Text == "" {
+		e.Text = text
+	} else if mediaType == "text/html" && e.HTML == "" {
+		e.HTML = text
+	}
+	return nil
+}
+
+func decodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, newlineStripper{r})
+	default:
+		return r
+	}
+}
+
+// newlineStripper drops CR and LF so base64 bodies can be decoded as a stream
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (s newlineStripper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	out := p[:0]
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			out = append(out, b)
+		}
+	}
+	return len(out), err
+}
+
+// toUTF8 converts Latin-1 bodies; everything else is assumed to be UTF-8
+func toUTF8(charset string, content []byte) string {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "windows-1252":
+		runes := make([]rune, len(content))
+		for i, b := range content {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	default:
+		return strings.ToValidUTF8(string(content), "�")
+	}
+}
+
+var (
+	htmlDropPattern  = regexp.MustCompile(`(?is)<(script|style|head)[^>]*>.*?</(script|style|head)>`)
+	htmlBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|h[1-6]|li|tr)>`)
+	htmlTagPattern   = regexp.MustCompile(`<[^>]*>`)
+	blankLines       = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText reduces an HTML body to readable plain text
+func HTMLToText(s string) string {
+	s = htmlDropPattern.ReplaceAllString(s, "")
+	s = htmlBreakPattern.ReplaceAllString(s, "\n")
+	s = htmlTagPattern.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	s = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLines.ReplaceAllString(s, "\n\n"))
+}
@@ -0,0 +1,229 @@
+package inbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknownRecipient is returned by a RecipientFunc to reject an address
+var ErrUnknownRecipient = errors.New("unknown recipient")
+
+// RecipientFunc checks an address at RCPT TO time
+type RecipientFunc func(address string) error
+
+// DeliverFunc receives the accepted recipients and the raw message
+type DeliverFunc func(recipients []string, raw []byte) error
+
+// SMTPServer is a minimal receive-only SMTP listener. It accepts mail for
+// addresses approved by Recipient and passes each message to Deliver; it
+// never relays.
+type SMTPServer struct {
+	Addr      string
+	Hostname  string
+	MaxBytes  int64
+	Recipient RecipientFunc
+	Deliver   DeliverFunc
+
+	wg sync.WaitGroup
+}
+
+const (
+	smtpReadTimeout   = 5 * time.Minute
+	smtpMaxRecipients = 50
+)
+
+// ListenAndServe accepts connections until ctx is cancelled
+func (s *SMTPServer) ListenAndServe(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.Addr, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	log.Printf("Inbound SMTP listener started on %s", s.Addr)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				s.wg.Wait()
+				log.Printf("Inbound SMTP listener stopped")
+				return nil
+			}
+			log.Printf("Inbound SMTP accept failed: %v", err)
+			continue
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.serve(conn)
+		}()
+	}
+}
+
+type smtpSession struct {
+	from       string
+	recipients []string
+}
+
+func (s *SMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	reply := func(code int, msg string) bool {
+		return tp.PrintfLine("%d %s", code, msg) == nil
+	}
+
+	hostname := s.Hostname
+	if hostname == "" {
+		hostname = "localhost"
+	}
+
+	if !reply(220, hostname+" ESMTP ready") {
+		return
+	}
+
+	var session smtpSession
+	for {
+		conn.SetReadDeadline(time.Now().Add(smtpReadTimeout))
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			verb, arg = line[:i], strings.TrimSpace(line[i+1:])
+		}
+
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			session = smtpSession{}
+			reply(250, hostname)
+		case "EHLO":
+			session = smtpSession{}
+			tp.PrintfLine("250-%s", hostname)
+			if s.MaxBytes > 0 {
+				tp.PrintfLine("250-SIZE %d", s.MaxBytes)
+			}
+			reply(250, "8BITMIME")
+		case "MAIL":
+			addr, ok := pathArg(arg, "FROM:")
+			if !ok {
+				reply(501, "Syntax: MAIL FROM:<address>")
+				continue
+			}
+			session = smtpSession{from: addr}
+			reply(250, "OK")
+		case "RCPT":
+			addr, ok := pathArg(arg, "TO:")
+			if !ok || addr == "" {
+				reply(501, "Syntax: RCPT TO:<address>")
+				continue
+			}
+			if len(session.recipients) >= smtpMaxRecipients {
+				reply(452, "Too many recipients")
+				continue
+			}
+			if err := s.Recipient(addr); err != nil {
+				if errors.Is(err, ErrUnknownRecipient) {
+					reply(550, "No such user")
+				} else {
+					reply(451, "Temporary failure, try again later")
+				}
+				continue
+			}
+			session.recipients = append(session.recipients, addr)
+			reply(250, "OK")
+		case "DATA":
+			if len(session.recipients) == 0 {
+				reply(503, "RCPT first")
+				continue
+			}
+			reply(354, "End data with <CR><LF>.<CR><LF>")
+
+			raw, err := s.readData(tp)
+			if err != nil {
+				if errors.Is(err, errMessageTooLarge) {
+					reply(552, "Message exceeds size limit")
+					session = smtpSession{}
+					continue
+				}
+				return
+			}
+
+			if err := s.Deliver(session.recipients, raw); err != nil {
+				log.Printf("Inbound email delivery failed: %v", err)
+				reply(451, "Delivery failed, try again later")
+			} else {
+				reply(250, "OK: queued")
+			}
+			session = smtpSession{}
+		case "RSET":
+			session = smtpSession{}
+			reply(250, "OK")
+		case "NOOP":
+			reply(250, "OK")
+		case "QUIT":
+			reply(221, "Bye")
+			return
+		default:
+			reply(502, "Command not implemented")
+		}
+	}
+}
+
+var errMessageTooLarge = errors.New("message too large")
+
+// readData reads the dot-terminated message body, discarding anything past
+// MaxBytes so the client still sees a proper reply
+func (s *SMTPServer) readData(tp *textproto.Conn) ([]byte, error) {
+	r := tp.DotReader()
+	if s.MaxBytes <= 0 {
+		return io.ReadAll(r)
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(r, s.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(raw)) > s.MaxBytes {
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return nil, err
+		}
+		return nil, errMessageTooLarge
+	}
+	return raw, nil
+}
+
+// pathArg parses "FROM:<addr> PARAMS" style arguments
+func pathArg(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	rest := strings.TrimSpace(arg[len(prefix):])
+
+	if strings.HasPrefix(rest, "<") {
+		end := strings.IndexByte(rest, '>')
+		if end < 0 {
+			return "", false
+		}
+		return rest[1:end], true
+	}
+	if i := strings.IndexByte(rest, ' '); i >= 0 {
+		rest = rest[:i]
+	}
+	return rest, true
+}
@@ -0,0 +1,150 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	set := Set{Keys: []Key{
+		{Kty: "RSA", Kid: "enc", Use: "enc"},
+		{Kty: "RSA", Kid: "a", Use: "sig"},
+		{Kty: "EC", Kid: "b"},
+	}}
+	single := Set{Keys: []Key{{Kty: "RSA", Kid: "only"}, {Kty: "RSA", Kid: "enc-only", Use: "enc"}}}
+
+	tests := []struct {
+		name    string
+		set     Set
+		kid     string
+		want    string
+		wantErr bool
+	}{
+		{"by kid", set, "a", "a", false},
+		{"use omitted", set, "b", "b", false},
+		{"encryption key skipped", set, "enc", "", true},
+		{"unknown kid", set, "c", "", true},
+		{"empty kid is ambiguous", set, "", "", true},
+		{"empty kid with a single signing key", single, "", "only", false},
+		{"empty set", Set{}, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := tt.set.Lookup(tt.kid)
+			if tt.wantErr {
+				if !errors.Is(err, ErrKeyNotFound) {
+					t.Errorf("Lookup(%q) error = %v, want ErrKeyNotFound", tt.kid, err)
+				}
+				return
+			}
+			if err != nil || key.Kid != tt.want {
+				t.Errorf("Lookup(%q) = %+v, %v, want kid %q", tt.kid, key, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromPublicKeyRoundTrip(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p521, _ := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name string
+		pub  crypto.PublicKey
+		alg  string
+	}{
+		{"RSA", &rsaKey.PublicKey, "RS256"},
+		{"P-256", &p256.PublicKey, "ES256"},
+		{"P-521", &p521.PublicKey, "ES512"},
+		{"Ed25519", edPub, "EdDSA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := FromPublicKey(tt.pub, tt.alg)
+			if err != nil {
+				t.Fatalf("FromPublicKey() error = %v", err)
+			}
+			if key.Use != "sig" || key.Alg != tt.alg || key.Kid == "" {
+				t.Errorf("FromPublicKey() = %+v", key)
+			}
+
+			decoded, err := key.PublicKey()
+			if err != nil {
+				t.Fatalf("PublicKey() error = %v", err)
+			}
+			if !decoded.(interface{ Equal(crypto.PublicKey) bool }).Equal(tt.pub) {
+				t.Errorf("PublicKey() did not round-trip")
+			}
+		})
+	}
+}
+
+func TestThumbprint(t *testing.T) {
+	tests := []struct {
+		name string
+		key  Key
+		want string
+	}{
+		{
+			// RFC 7638 section 3.1
+			name: "RSA",
+			key: Key{
+				Kty: "RSA",
+				N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+				E:   "AQAB",
+				Alg: "RS256",
+				Kid: "2011-04-29",
+			},
+			want: "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs",
+		},
+		{
+			// RFC 8037 appendix A.3
+			name: "Ed25519",
+			key:  Key{Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+			want: "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.key.Thumbprint()
+			if err != nil || got != tt.want {
+				t.Errorf("Thumbprint() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublicKeyRejectsInvalidKeys(t *testing.T) {
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	valid, _ := FromPublicKey(&p256.PublicKey, "ES256")
+	offCurve := valid
+	offCurve.Y = valid.X
+
+	tests := []struct {
+		name string
+		key  Key
+	}{
+		{"unknown type", Key{Kty: "oct"}},
+		{"RSA without modulus", Key{Kty: "RSA", E: "AQAB"}},
+		{"RSA exponent of one", Key{Kty: "RSA", N: "AQAB", E: "AQ"}},
+		{"unsupported curve", Key{Kty: "EC", Crv: "secp256k1", X: valid.X, Y: valid.Y}},
+		{"point off the curve", offCurve},
+		{"short Ed25519 key", Key{Kty: "OKP", Crv: "Ed25519", X: "AQAB"}},
+		{"X25519", Key{Kty: "OKP", Crv: "X25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.key.PublicKey(); err == nil {
+				t.Errorf("PublicKey() succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package notetemplate
+
+// Builtin is a template that ships with the binary
+type Builtin struct {
+	Key           string   `json:"key"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	TitleTemplate string   `json:"title_template"`
+	BodyTemplate  string   `json:"body_template"`
+	Category      string   `json:"category"`
+	Prompts       []string `json:"prompts"`
+}
+
+var builtins = []Builtin{
+	{
+		Key:           "daily-journal",
+		Name:          "Daily Journal",
+		Description:   "Morning journal with goals, blockers and gratitude",
+		TitleTemplate: "Journal - {{.Weekday}}, {{.Date}}",
+		BodyTemplate: `# {{.Weekday}}, {{.Date}}
+
+Good morning{{with .User.FullName}}, {{.}}{{end}}!
+{{range .Prompts}}
+## {{.}}
+
+- 
+{{end}}`,
+		Category: "journal",
+		Prompts:  []string{"Goals for today", "Blockers", "Gratitude"},
+	},
+	{
+		Key:           "standup",
+		Name:          "Daily Standup",
+		Description:   "Yesterday, today and blockers for team standups",
+		TitleTemplate: "Standup {{.Date}}",
+		BodyTemplate: `{{range .Prompts}}## {{.}}
+
+- 
+
+{{end}}`,
+		Category: "work",
+		Prompts:  []string{"Yesterday", "Today", "Blockers"},
+	},
+	{
+		Key:           "weekly-review",
+		Name:          "Weekly Review",
+		Description:   "End of week reflection",
+		TitleTemplate: `Weekly Review - {{date "02 Jan 2006" .Now}}`,
+		BodyTemplate: `# Week ending {{.Date}}
+{{range .Prompts}}
+## {{.}}
+
+- 
+{{end}}`,
+		Category: "review",
+		Prompts:  []string{"Wins", "Lessons learned", "Focus for next week"},
+	},
+	{
+		Key:           "meeting",
+		Name:          "Meeting Notes",
+		Description:   "Attendees, agenda and action items",
+		TitleTemplate: `{{with var .Vars "topic"}}{{.}}{{else}}Meeting{{end}} - {{.Date}}`,
+		BodyTemplate: `**Date:** {{.Date}} {{.Time}}
+**Author:** {{.User.FullName}}
+{{range .Prompts}}
+## {{.}}
+
+- 
+{{end}}`,
+		Category: "meeting",
+		Prompts:  []string{"Attendees", "Agenda", "Action items"},
+	},
+}
+
+// Builtins returns all built-in templates
+func Builtins() []Builtin {
+	result := make([]Builtin, len(builtins))
+	copy(result, builtins)
+	return result
+}
+
+// GetBuiltin looks up a built-in template by key
+func GetBuiltin(key string) (Builtin, bool) {
+	for _, b := range builtins {
+		if b.Key == key {
+			return b, true
+		}
+	}
+	return Builtin{}, false
+}
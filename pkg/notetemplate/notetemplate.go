@@ -0,0 +1,212 @@
+package notetemplate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// MaxOutputBytes caps a rendered template at what notes.content, a TEXT
+// column, can hold
+const MaxOutputBytes = 65535
+
+// executeTimeout bounds how long a template may run
+var executeTimeout = time.Second
+
+var (
+	ErrOutputTooLarge = errors.New("template output is too large")
+	ErrTimeout        = errors.New("template took too long to render")
+)
+
+// User holds the user fields available to templates as {{.User.*}}
+type User struct {
+	ID       int
+	Username string
+	Email    string
+	FullName string
+}
+
+// Data is the value templates are executed against
+type Data struct {
+	Date    string
+	Weekday string
+	Time    string
+	Now     time.Time
+	User    User
+	Prompts []string
+	Vars    map[string]string
+}
+
+// NewData builds template data for the given moment
+func NewData(now time.Time, user User, prompts []string, vars map[string]string) Data {
+	if vars == nil {
+		vars = map[string]string{}
+	}
+
+	return Data{
+		Date:    now.Format("2006-01-02"),
+		Weekday: now.Weekday().String(),
+		Time:    now.Format("15:04"),
+		Now:     now,
+		User:    user,
+		Prompts: prompts,
+		Vars:    vars,
+	}
+}
+
+var funcs = template.FuncMap{
+	// date formats a time with a Go layout, e.g. {{date "Monday, 02 Jan 2006" .Now}}
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"var": func(vars map[string]string, key string) string {
+		return vars[key]
+	},
+}
+
+// Validate checks that a template body parses and can only do work bounded
+// by its data: range is limited to fields such as .Prompts, cannot be
+// nested, and templates cannot define or call other templates
+func Validate(name, text string) error {
+	_, err := parseTemplate(name, text)
+	return err
+}
+
+// Render executes the title and body templates against data
+func Render(titleTmpl, bodyTmpl string, data Data) (string, string, error) {
+	title, err := execute("title", titleTmpl, data)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to render title: %w", err)
+	}
+
+	body, err := execute("body", bodyTmpl, data)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to render body: %w", err)
+	}
+
+	return strings.TrimSpace(title), strings.TrimSpace(body), nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Templates()) > 1 {
+		return nil, fmt.Errorf("define and block are not allowed")
+	}
+	if err := checkNodes(t.Root, false); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// checkNodes rejects constructs whose cost does not depend on the data:
+// ranges over integers or computed values, nested ranges and template calls
+func checkNodes(node parse.Node, inRange bool) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkNodes(child, inRange); err != nil {
+				return err
+			}
+		}
+	case *parse.RangeNode:
+		if inRange {
+			return fmt.Errorf("line %d: range cannot be nested", n.Line)
+		}
+		if !isDataField(n.Pipe) {
+			return fmt.Errorf("line %d: range is only allowed over a field such as .Prompts", n.Line)
+		}
+		if err := checkNodes(n.List, true); err != nil {
+			return err
+		}
+		return checkNodes(n.ElseList, true)
+	case *parse.IfNode:
+		if err := checkNodes(n.List, inRange); err != nil {
+			return err
+		}
+		return checkNodes(n.ElseList, inRange)
+	case *parse.WithNode:
+		if err := checkNodes(n.List, inRange); err != nil {
+			return err
+		}
+		return checkNodes(n.ElseList, inRange)
+	case *parse.TemplateNode:
+		return fmt.Errorf("line %d: template calls are not allowed", n.Line)
+	}
+	return nil
+}
+
+// isDataField reports whether pipe is a plain field like .Prompts or
+// $.Vars, which holds a bounded collection from the template data
+func isDataField(pipe *parse.PipeNode) bool {
+	if len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.FieldNode:
+		return true
+	case *parse.VariableNode:
+		return len(arg.Ident) > 1 && arg.Ident[0] == "$"
+	}
+	return false
+}
+
+func execute(name, text string, data Data) (string, error) {
+	t, err := parseTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+
+	// text/template cannot be cancelled, so a template that overruns is
+	// abandoned and stopped at its next write
+	w := &limitedWriter{max: MaxOutputBytes}
+	done := make(chan error, 1)
+	go func() { done <- t.Execute(w, data) }()
+
+	timer := time.NewTimer(executeTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		if errors.Is(err, ErrOutputTooLarge) {
+			return "", ErrOutputTooLarge
+		}
+		if err != nil {
+			return "", err
+		}
+		return w.buf.String(), nil
+	case <-timer.C:
+		w.aborted.Store(true)
+		return "", ErrTimeout
+	}
+}
+
+// limitedWriter fails once more than max bytes are written or the template
+// has been abandoned
+type limitedWriter struct {
+	buf     bytes.Buffer
+	max     int
+	aborted atomic.Bool
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.aborted.Load() {
+		return 0, ErrTimeout
+	}
+	if w.buf.Len()+len(p) > w.max {
+		return 0, ErrOutputTooLarge
+	}
+	return w.buf.Write(p)
+}
@@ -0,0 +1,130 @@
+package notetemplate
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testData() Data {
+	now := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	return NewData(now, User{ID: 1, Username: "ann", FullName: "Ann Lee"},
+		[]string{"Goals", "Blockers"}, map[string]string{"project": "apollo"})
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{"fields", "{{.Date}} {{.User.FullName}}", false},
+		{"functions", `{{upper .Weekday}} {{date "02 Jan" .Now}} {{var .Vars "project"}}`, false},
+		{"range over prompts", "{{range .Prompts}}- {{.}}\n{{end}}", false},
+		{"range over vars with else", "{{range $k, $v := .Vars}}{{$k}}={{$v}}{{else}}none{{end}}", false},
+		{"range from root variable", "{{with .User}}{{range $.Prompts}}{{.}}{{end}}{{end}}", false},
+		{"syntax error", "{{.Date", true},
+		{"range over integer", "{{range 1000000000}}xxxxxxxx{{end}}", true},
+		{"range over variable", "{{$n := 1000000000}}{{range $n}}x{{end}}", true},
+		{"range over dot", "{{with 1000000000}}{{range .}}x{{end}}{{end}}", true},
+		{"range over function result", `{{range len .Prompts}}x{{end}}`, true},
+		{"nested range", "{{range .Prompts}}{{range $.Prompts}}x{{end}}{{end}}", true},
+		{"nested range in else", "{{range .Prompts}}{{else}}{{range .Prompts}}x{{end}}{{end}}", true},
+		{"nested range inside if", "{{range .Prompts}}{{if .}}{{range $.Prompts}}x{{end}}{{end}}{{end}}", true},
+		{"define", `{{define "x"}}{{template "x"}}{{end}}`, true},
+		{"template call", `{{template "body"}}`, true},
+		{"block", `{{block "x" .}}x{{end}}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate("body", tt.text); (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	title, body, err := Render("Journal {{.Date}}", "{{range .Prompts}}## {{.}}\n{{end}}{{var .Vars \"project\"}}", testData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "Journal 2026-03-02" {
+		t.Errorf("title = %q", title)
+	}
+	if want := "## Goals\n## Blockers\napollo"; body != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestRenderRejectsUnsafeStoredTemplates(t *testing.T) {
+	// Templates stored before Validate checked ranges are checked again
+	_, _, err := Render("Title", "{{range 1000000000}}xxxxxxxx{{end}}", testData())
+	if err == nil {
+		t.Fatal("Render accepted a range over an integer")
+	}
+}
+
+func TestRenderLimitsOutput(t *testing.T) {
+	data := testData()
+	data.Prompts = []string{strings.Repeat("x", MaxOutputBytes/2), strings.Repeat("y", MaxOutputBytes/2)}
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr error
+	}{
+		{"within the limit", "{{range .Prompts}}{{.}}{{end}}", nil},
+		{"over the limit", "{{range .Prompts}}{{.}}{{.}}{{end}}", ErrOutputTooLarge},
+		{"wide printf", `{{range .Prompts}}{{printf "%0999999d" 1}}{{end}}`, ErrOutputTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Render("Title", tt.body, data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Render error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderTimesOut(t *testing.T) {
+	defer func(timeout time.Duration) { executeTimeout = timeout }(executeTimeout)
+	executeTimeout = time.Millisecond
+
+	data := testData()
+	for i := 0; i < 1000000; i++ {
+		data.Vars[strconv.Itoa(i)] = ""
+	}
+
+	if _, _, err := Render("Title", "{{range .Vars}}{{end}}", data); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Render error = %v, want %v", err, ErrTimeout)
+	}
+}
+
+func TestBuiltinsRender(t *testing.T) {
+	for _, b := range Builtins() {
+		t.Run(b.Key, func(t *testing.T) {
+			if err := Validate("title", b.TitleTemplate); err != nil {
+				t.Fatalf("title: %v", err)
+			}
+			if err := Validate("body", b.BodyTemplate); err != nil {
+				t.Fatalf("body: %v", err)
+			}
+
+			data := testData()
+			data.Prompts = b.Prompts
+			title, body, err := Render(b.TitleTemplate, b.BodyTemplate, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if title == "" || body == "" {
+				t.Errorf("Render = (%q, %q), want a title and body", title, body)
+			}
+		})
+	}
+}
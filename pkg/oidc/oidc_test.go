@@ -0,0 +1,103 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"daily-notes-api/pkg/oidc"
+	"daily-notes-api/pkg/oidc/oidctest"
+	"daily-notes-api/pkg/pkce"
+)
+
+func TestExchange(t *testing.T) {
+	server := oidctest.NewServer(t, "notes-app")
+	provider := oidc.NewProvider(oidc.Config{
+		Name:        "mock",
+		Issuer:      server.URL,
+		ClientID:    "notes-app",
+		RedirectURL: "https://notes.example/callback",
+	}, server.Client())
+
+	tests := []struct {
+		name          string
+		identity      oidctest.Identity
+		wrongVerifier bool
+		wantErr       bool
+		wantVerified  bool
+	}{
+		{"verified email", oidctest.Identity{Subject: "u1", Email: "ann@example.com", EmailVerified: true}, false, false, true},
+		{"email_verified as string", oidctest.Identity{Subject: "u2", Email: "ann@example.com", EmailVerified: "true"}, false, false, true},
+		{"email_verified omitted", oidctest.Identity{Subject: "u3", Email: "ann@example.com"}, false, false, false},
+		{"wrong nonce", oidctest.Identity{Subject: "u4", Nonce: "replayed"}, false, true, false},
+		{"wrong audience", oidctest.Identity{Subject: "u5", Audience: "other-app"}, false, true, false},
+		{"expired", oidctest.Identity{Subject: "u6", ExpiresIn: -time.Hour}, false, true, false},
+		{"missing subject", oidctest.Identity{Email: "ann@example.com"}, false, true, false},
+		{"wrong code verifier", oidctest.Identity{Subject: "u7"}, true, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			verifier, _ := pkce.NewVerifier()
+			authURL, err := provider.AuthCodeURL(ctx, "state", "nonce-"+tt.name, verifier)
+			if err != nil {
+				t.Fatalf("AuthCodeURL() error = %v", err)
+			}
+
+			code, state := server.Authorize(t, authURL, tt.identity)
+			if state != "state" {
+				t.Errorf("state = %q, want %q", state, "state")
+			}
+			if tt.wrongVerifier {
+				verifier, _ = pkce.NewVerifier()
+			}
+
+			claims, err := provider.Exchange(ctx, code, verifier, "nonce-"+tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Exchange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if claims.Subject != tt.identity.Subject || claims.Email != tt.identity.Email {
+				t.Errorf("Exchange() claims = %+v", claims)
+			}
+			if claims.EmailVerified != tt.wantVerified {
+				t.Errorf("EmailVerified = %v, want %v", claims.EmailVerified, tt.wantVerified)
+			}
+		})
+	}
+}
+
+func TestExchangeNonceMismatch(t *testing.T) {
+	server := oidctest.NewServer(t, "notes-app")
+	provider := oidc.NewProvider(oidc.Config{Name: "mock", Issuer: server.URL, ClientID: "notes-app"}, server.Client())
+
+	verifier, _ := pkce.NewVerifier()
+	authURL, err := provider.AuthCodeURL(context.Background(), "state", "nonce", verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	code, _ := server.Authorize(t, authURL, oidctest.Identity{Subject: "u1"})
+
+	if _, err := provider.Exchange(context.Background(), code, verifier, "other-nonce"); !errors.Is(err, oidc.ErrNonceMismatch) {
+		t.Errorf("Exchange() error = %v, want ErrNonceMismatch", err)
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"issuer":"https://evil.example","authorization_endpoint":"a","token_endpoint":"t","jwks_uri":"j"}`))
+	}))
+	defer server.Close()
+
+	provider := oidc.NewProvider(oidc.Config{Name: "mock", Issuer: server.URL, ClientID: "notes-app"}, server.Client())
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("AuthCodeURL() error = %v, want issuer mismatch", err)
+	}
+}
@@ -0,0 +1,182 @@
+// Package oidctest runs a mock OpenID Connect provider for tests. It serves
+// discovery, a key set and a token endpoint that issues signed ID tokens for
+// the identities registered with Authorize.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"daily-notes-api/pkg/jwks"
+	"daily-notes-api/pkg/pkce"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Identity is what the provider asserts about the user who logs in
+type Identity struct {
+	Subject           string
+	Email             string
+	EmailVerified     interface{} // bool, string or nil to omit the claim
+	Name              string
+	PreferredUsername string
+
+	// Audience overrides the client id the token is issued to
+	Audience string
+	// Nonce overrides the nonce from the authorization request
+	Nonce string
+	// ExpiresIn overrides the default token lifetime of five minutes
+	ExpiresIn time.Duration
+}
+
+// Server is a mock provider. Its URL is the issuer.
+type Server struct {
+	*httptest.Server
+	ClientID string
+
+	key *rsa.PrivateKey
+	jwk jwks.Key
+
+	mu    sync.Mutex
+	codes map[string]*authorization
+}
+
+type authorization struct {
+	identity  Identity
+	nonce     string
+	challenge string
+}
+
+// NewServer starts a provider that issues tokens to clientID. It is closed
+// when the test ends.
+func NewServer(t testing.TB, clientID string) *Server {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate provider key: %v", err)
+	}
+	jwk, err := jwks.FromPublicKey(&key.PublicKey, "RS256")
+	if err != nil {
+		t.Fatalf("failed to build provider key set: %v", err)
+	}
+
+	s := &Server{ClientID: clientID, key: key, jwk: jwk, codes: make(map[string]*authorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/jwks", s.keys)
+	mux.HandleFunc("/token", s.token)
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Authorize plays the user logging in at the URL returned by
+// Provider.AuthCodeURL. It returns the code and state the provider would
+// redirect back with.
+func (s *Server) Authorize(t testing.TB, authURL string, identity Identity) (code, state string) {
+	t.Helper()
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("invalid authorization URL: %v", err)
+	}
+	query := u.Query()
+	if query.Get("client_id") != s.ClientID || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected authorization request: %s", authURL)
+	}
+
+	code = rand.Text()
+	s.mu.Lock()
+	s.codes[code] = &authorization{identity: identity, nonce: query.Get("nonce"), challenge: query.Get("code_challenge")}
+	s.mu.Unlock()
+
+	return code, query.Get("state")
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 s.URL,
+		"authorization_endpoint": s.URL + "/authorize",
+		"token_endpoint":         s.URL + "/token",
+		"jwks_uri":               s.URL + "/jwks",
+	})
+}
+
+func (s *Server) keys(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, jwks.Set{Keys: []jwks.Key{s.jwk}})
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	s.mu.Lock()
+	auth, ok := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	s.mu.Unlock()
+
+	if !ok || !pkce.Verify(r.PostForm.Get("code_verifier"), auth.challenge) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	id := auth.identity
+	audience, nonce, expiresIn := s.ClientID, auth.nonce, 5*time.Minute
+	if id.Audience != "" {
+		audience = id.Audience
+	}
+	if id.Nonce != "" {
+		nonce = id.Nonce
+	}
+	if id.ExpiresIn != 0 {
+		expiresIn = id.ExpiresIn
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   s.URL,
+		"sub":   id.Subject,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(expiresIn).Unix(),
+		"nonce": nonce,
+	}
+	for name, value := range map[string]interface{}{
+		"email":              id.Email,
+		"email_verified":     id.EmailVerified,
+		"name":               id.Name,
+		"preferred_username": id.PreferredUsername,
+	} {
+		if value != nil && value != "" {
+			claims[name] = value
+		}
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.jwk.Kid
+	idToken, err := token.SignedString(s.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"access_token": "access", "token_type": "Bearer", "id_token": idToken})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
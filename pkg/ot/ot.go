@@ -0,0 +1,304 @@
+// Package ot implements operational transformation for plain text, using the
+// same operation model and JSON encoding as ot.js: an operation is a list of
+// components where a positive integer retains that many characters, a
+// negative integer deletes that many, and a string inserts it. Positions and
+// lengths count Unicode code points.
+package ot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+var (
+	ErrLengthMismatch = errors.New("operation length does not match the document")
+	ErrIncompatible   = errors.New("operations have different base lengths")
+)
+
+type kind int
+
+const (
+	retain kind = iota
+	insert
+	del
+)
+
+type component struct {
+	kind kind
+	n    int    // retain or delete count
+	s    string // inserted text
+}
+
+func (c component) length() int {
+	if c.kind == insert {
+		return utf8.RuneCountInString(c.s)
+	}
+	return c.n
+}
+
+// Operation transforms a document of BaseLen characters into one of
+// TargetLen characters
+type Operation struct {
+	ops       []component
+	BaseLen   int
+	TargetLen int
+}
+
+// New returns an empty operation; build it with Retain, Insert and Delete
+func New() *Operation {
+	return &Operation{}
+}
+
+// Retain skips over n characters
+func (o *Operation) Retain(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+	o.BaseLen += n
+	o.TargetLen += n
+	if last := o.last(); last != nil && last.kind == retain {
+		last.n += n
+		return o
+	}
+	o.ops = append(o.ops, component{kind: retain, n: n})
+	return o
+}
+
+// Insert adds s at the current position
+func (o *Operation) Insert(s string) *Operation {
+	if s == "" {
+		return o
+	}
+	o.TargetLen += utf8.RuneCountInString(s)
+
+	last := o.last()
+	switch {
+	case last != nil && last.kind == insert:
+		last.s += s
+	case last != nil && last.kind == del:
+		// Keep inserts before deletes so equal operations have one encoding
+		if n := len(o.ops); n >= 2 && o.ops[n-2].kind == insert {
+			o.ops[n-2].s += s
+		} else {
+			o.ops = append(o.ops, *last)
+			o.ops[n-1] = component{kind: insert, s: s}
+		}
+	default:
+		o.ops = append(o.ops, component{kind: insert, s: s})
+	}
+	return o
+}
+
+// Delete removes n characters at the current position
+func (o *Operation) Delete(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+	o.BaseLen += n
+	if last := o.last(); last != nil && last.kind == del {
+		last.n += n
+		return o
+	}
+	o.ops = append(o.ops, component{kind: del, n: n})
+	return o
+}
+
+func (o *Operation) last() *component {
+	if len(o.ops) == 0 {
+		return nil
+	}
+	return &o.ops[len(o.ops)-1]
+}
+
+// IsNoop reports whether applying the operation changes nothing
+func (o *Operation) IsNoop() bool {
+	return len(o.ops) == 0 || (len(o.ops) == 1 && o.ops[0].kind == retain)
+}
+
+// Apply runs the operation on doc
+func (o *Operation) Apply(doc string) (string, error) {
+	runes := []rune(doc)
+	if len(runes) != o.BaseLen {
+		return "", ErrLengthMismatch
+	}
+
+	out := make([]rune, 0, o.TargetLen)
+	pos := 0
+	for _, c := range o.ops {
+		switch c.kind {
+		case retain:
+			out = append(out, runes[pos:pos+c.n]...)
+			pos += c.n
+		case insert:
+			out = append(out, []rune(c.s)...)
+		case del:
+			pos += c.n
+		}
+	}
+	return string(out), nil
+}
+
+// Replace builds an operation turning a document of oldLen characters into
+// text
+func Replace(oldLen int, text string) *Operation {
+	return New().Delete(oldLen).Insert(text)
+}
+
+// Diff builds an operation turning a into b by replacing the span between
+// their common prefix and suffix
+func Diff(a, b string) *Operation {
+	ra, rb := []rune(a), []rune(b)
+
+	prefix := 0
+	for prefix < len(ra) && prefix < len(rb) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(ra)-prefix && suffix < len(rb)-prefix && ra[len(ra)-1-suffix] == rb[len(rb)-1-suffix] {
+		suffix++
+	}
+
+	return New().
+		Retain(prefix).
+		Delete(len(ra) - prefix - suffix).
+		Insert(string(rb[prefix : len(rb)-suffix])).
+		Retain(suffix)
+}
+
+// Transform takes two operations a and b that apply to the same document and
+// returns a' and b' such that b'(a(doc)) == a'(b(doc)). When both insert at
+// the same position, a's text ends up first.
+func Transform(a, b *Operation) (*Operation, *Operation, error) {
+	if a.BaseLen != b.BaseLen {
+		return nil, nil, ErrIncompatible
+	}
+
+	aPrime, bPrime := New(), New()
+	ops1, ops2 := a.ops, b.ops
+	i1, i2 := 0, 0
+
+	next := func(ops []component, i *int) *component {
+		if *i >= len(ops) {
+			return nil
+		}
+		c := ops[*i]
+		*i++
+		return &c
+	}
+
+	op1, op2 := next(ops1, &i1), next(ops2, &i2)
+	for op1 != nil || op2 != nil {
+		if op1 != nil && op1.kind == insert {
+			aPrime.Insert(op1.s)
+			bPrime.Retain(op1.length())
+			op1 = next(ops1, &i1)
+			continue
+		}
+		if op2 != nil && op2.kind == insert {
+			aPrime.Retain(op2.length())
+			bPrime.Insert(op2.s)
+			op2 = next(ops2, &i2)
+			continue
+		}
+		if op1 == nil || op2 == nil {
+			return nil, nil, ErrIncompatible
+		}
+
+		var n int
+		switch {
+		case op1.kind == retain && op2.kind == retain:
+			n = min(op1.n, op2.n)
+			aPrime.Retain(n)
+			bPrime.Retain(n)
+		case op1.kind == del && op2.kind == del:
+			// Both deleted the same text; nothing left to do
+			n = min(op1.n, op2.n)
+		case op1.kind == del && op2.kind == retain:
+			n = min(op1.n, op2.n)
+			aPrime.Delete(n)
+		case op1.kind == retain && op2.kind == del:
+			n = min(op1.n, op2.n)
+			bPrime.Delete(n)
+		}
+
+		op1.n -= n
+		op2.n -= n
+		if op1.n == 0 {
+			op1 = next(ops1, &i1)
+		}
+		if op2.n == 0 {
+			op2 = next(ops2, &i2)
+		}
+	}
+
+	return aPrime, bPrime, nil
+}
+
+// TransformIndex moves a cursor position across op. Text inserted at the
+// cursor pushes it forward.
+func TransformIndex(index int, op *Operation) int {
+	newIndex := index
+	for _, c := range op.ops {
+		switch c.kind {
+		case retain:
+			index -= c.n
+		case insert:
+			newIndex += c.length()
+		case del:
+			newIndex -= min(index, c.n)
+			index -= c.n
+		}
+		if index < 0 {
+			break
+		}
+	}
+	return newIndex
+}
+
+// MarshalJSON encodes the operation in the ot.js format
+func (o *Operation) MarshalJSON() ([]byte, error) {
+	out := make([]interface{}, 0, len(o.ops))
+	for _, c := range o.ops {
+		switch c.kind {
+		case retain:
+			out = append(out, c.n)
+		case insert:
+			out = append(out, c.s)
+		case del:
+			out = append(out, -c.n)
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes the ot.js format
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("operation must be an array: %w", err)
+	}
+
+	op := New()
+	for _, item := range raw {
+		var s string
+		if err := json.Unmarshal(item, &s); err == nil {
+			op.Insert(s)
+			continue
+		}
+
+		var n int
+		if err := json.Unmarshal(item, &n); err != nil || n == 0 {
+			return fmt.Errorf("invalid operation component %s", item)
+		}
+		if n > 0 {
+			op.Retain(n)
+		} else {
+			op.Delete(-n)
+		}
+	}
+
+	*o = *op
+	return nil
+}
@@ -0,0 +1,172 @@
+package ot
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTransformIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		index int
+		op    *Operation
+		want  int
+	}{
+		{"insert before", 5, New().Retain(2).Insert("abc").Retain(8), 8},
+		{"insert at cursor", 5, New().Retain(5).Insert("abc").Retain(5), 8},
+		{"insert after", 5, New().Retain(7).Insert("abc").Retain(3), 5},
+		{"delete before", 5, New().Retain(1).Delete(2).Retain(7), 3},
+		{"delete around", 5, New().Retain(3).Delete(4).Retain(3), 3},
+		{"delete after", 5, New().Retain(6).Delete(2).Retain(2), 5},
+		{"replace everything", 5, New().Delete(10).Insert("xy"), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TransformIndex(tt.index, tt.op); got != tt.want {
+				t.Errorf("TransformIndex(%d) = %d, want %d", tt.index, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		op      *Operation
+		want    string
+		wantErr bool
+	}{
+		{"insert", "hello", New().Retain(5).Insert(" world"), "hello world", false},
+		{"delete", "hello world", New().Retain(5).Delete(6), "hello", false},
+		{"replace middle", "abcdef", New().Retain(2).Delete(2).Insert("XY").Retain(2), "abXYef", false},
+		{"multibyte", "héllo", New().Retain(1).Delete(1).Insert("e").Retain(3), "hello", false},
+		{"length mismatch", "abc", New().Retain(5), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.op.Apply(tt.doc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Apply = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformConverges(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		a, b *Operation
+		want string
+	}{
+		{"inserts at different positions", "abc", New().Insert("X").Retain(3), New().Retain(3).Insert("Y"), "XabcY"},
+		{"inserts at the same position", "abc", New().Retain(1).Insert("X").Retain(2), New().Retain(1).Insert("Y").Retain(2), "aXYbc"},
+		{"overlapping deletes", "abcdef", New().Retain(1).Delete(3).Retain(2), New().Retain(2).Delete(3).Retain(1), "af"},
+		{"insert inside a delete", "abcdef", New().Retain(1).Delete(4).Retain(1), New().Retain(3).Insert("X").Retain(3), "aXf"},
+		{"replace and append", "abc", Replace(3, "xyz"), New().Retain(3).Insert("!"), "xyz!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aPrime, bPrime, err := Transform(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("Transform error = %v", err)
+			}
+
+			afterA, err := tt.a.Apply(tt.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			afterB, err := tt.b.Apply(tt.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			left, err := bPrime.Apply(afterA)
+			if err != nil {
+				t.Fatalf("b' on a(doc): %v", err)
+			}
+			right, err := aPrime.Apply(afterB)
+			if err != nil {
+				t.Fatalf("a' on b(doc): %v", err)
+			}
+
+			if left != tt.want || right != tt.want {
+				t.Errorf("b'(a(doc)) = %q, a'(b(doc)) = %q, want %q", left, right, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformRejectsIncompatibleOperations(t *testing.T) {
+	if _, _, err := Transform(New().Retain(3), New().Retain(4)); err != ErrIncompatible {
+		t.Errorf("Transform error = %v, want %v", err, ErrIncompatible)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"equal", "same", "same"},
+		{"append", "abc", "abcdef"},
+		{"prepend", "def", "abcdef"},
+		{"change middle", "the cat sat", "the dog sat"},
+		{"delete everything", "abc", ""},
+		{"from empty", "", "abc"},
+		{"repeated characters", "aaa", "aaaa"},
+		{"multibyte", "naïve", "naive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := Diff(tt.a, tt.b)
+			got, err := op.Apply(tt.a)
+			if err != nil {
+				t.Fatalf("Apply error = %v", err)
+			}
+			if got != tt.b {
+				t.Errorf("Diff(%q, %q) applied = %q", tt.a, tt.b, got)
+			}
+			if (tt.a == tt.b) != op.IsNoop() {
+				t.Errorf("IsNoop = %v", op.IsNoop())
+			}
+		})
+	}
+}
+
+func TestOperationJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"retain insert delete", `[2,"ab",-3,1]`, false},
+		{"insert only", `["hello"]`, false},
+		{"not an array", `{"ops":[]}`, true},
+		{"zero component", `[0]`, true},
+		{"invalid component", `[true]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var op Operation
+			err := json.Unmarshal([]byte(tt.data), &op)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			out, err := json.Marshal(&op)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.data {
+				t.Errorf("Marshal = %s, want %s", out, tt.data)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// prefixLength is the number of hex characters of the SHA-1 hash that name
+// a range file
+const prefixLength = 5
+
+// BreachedList looks passwords up in a local copy of a breached password
+// corpus, laid out like the Pwned Passwords range API: one file per
+// five-character SHA-1 prefix, named <PREFIX>.txt, holding SUFFIX:COUNT
+// lines. Only the file for a password's prefix is read, so the full hash
+// never has to be compared against the whole corpus.
+type BreachedList struct {
+	dir string
+}
+
+func NewBreachedList(dir string) *BreachedList {
+	return &BreachedList{dir: dir}
+}
+
+// Contains reports whether password appears in the corpus. A missing range
+// file means no breached password has that prefix.
+func (b *BreachedList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	f, err := os.Open(filepath.Join(b.dir, prefix+".txt"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to open breached password range: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		candidate, count, _ := strings.Cut(line, ":")
+		// Padding entries have a count of 0
+		if strings.EqualFold(candidate, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read breached password range: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,238 @@
+package passwordpolicy
+
+import (
+	_ "embed"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//go:embed common.txt
+var commonList string
+
+// commonRanks maps common passwords and words to their rank, 1 being the
+// most common
+var commonRanks = loadRanks(commonList)
+
+var keyboardRows = []string{"qwertyuiop", "asdfghjkl", "zxcvbnm", "1234567890"}
+
+var leetSubstitutions = map[rune]rune{
+	'0': 'o', '1': 'l', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's', '!': 'i',
+}
+
+// Pattern is the kind of weakness found in a password
+type Pattern string
+
+const (
+	PatternNone       Pattern = ""
+	PatternCommon     Pattern = "common"
+	PatternRepeat     Pattern = "repeat"
+	PatternSequence   Pattern = "sequence"
+	PatternKeyboard   Pattern = "keyboard"
+	PatternYear       Pattern = "year"
+	PatternBruteForce Pattern = "bruteforce"
+)
+
+func loadRanks(list string) map[string]int {
+	ranks := make(map[string]int)
+	rank := 0
+	for _, word := range strings.Split(list, "\n") {
+		word = strings.TrimSpace(word)
+		if word == "" {
+			continue
+		}
+		rank++
+		if _, exists := ranks[word]; !exists {
+			ranks[word] = rank
+		}
+	}
+	return ranks
+}
+
+// match is one way to explain password[start:end]
+type match struct {
+	end     int
+	bits    float64
+	pattern Pattern
+}
+
+// Estimate returns an estimate of the password's entropy in bits, in the
+// spirit of zxcvbn: the password is split into common words, repeats,
+// sequences, keyboard runs and years, which are cheap to guess, and the
+// cheapest split wins. Characters matching none of them cost the size of
+// their character class. The returned pattern is the weakness covering
+// the most characters.
+func Estimate(password string) (float64, Pattern) {
+	runes := []rune(password)
+	n := len(runes)
+	if n == 0 {
+		return 0, PatternNone
+	}
+
+	// best[i] is the cheapest explanation of runes[:i]
+	type step struct {
+		bits    float64
+		start   int
+		pattern Pattern
+	}
+	best := make([]step, n+1)
+	for i := 1; i <= n; i++ {
+		best[i].bits = math.Inf(1)
+	}
+
+	for start := 0; start < n; start++ {
+		for _, m := range matchesAt(runes, start) {
+			if bits := best[start].bits + m.bits; bits < best[m.end].bits {
+				best[m.end] = step{bits: bits, start: start, pattern: m.pattern}
+			}
+		}
+	}
+
+	// Walk back through the winning split to find the dominant weakness
+	covered := make(map[Pattern]int)
+	for i := n; i > 0; i = best[i].start {
+		if best[i].pattern != PatternBruteForce {
+			covered[best[i].pattern] += i - best[i].start
+		}
+	}
+	pattern := PatternNone
+	for p, count := range covered {
+		if count > covered[pattern] || (count == covered[pattern] && p < pattern) {
+			pattern = p
+		}
+	}
+
+	return best[n].bits, pattern
+}
+
+func matchesAt(runes []rune, start int) []match {
+	matches := []match{{end: start + 1, bits: math.Log2(float64(classSize(runes[start]))), pattern: PatternBruteForce}}
+
+	for end := start + 3; end <= len(runes); end++ {
+		token := runes[start:end]
+
+		if bits, ok := commonBits(token); ok {
+			matches = append(matches, match{end: end, bits: bits, pattern: PatternCommon})
+		}
+		if isRepeat(token) {
+			matches = append(matches, match{end: end, bits: math.Log2(float64(classSize(token[0]) * len(token))), pattern: PatternRepeat})
+		}
+		if descending, ok := isSequence(token); ok {
+			bits := math.Log2(float64(classSize(token[0]))) + math.Log2(float64(len(token)))
+			if descending {
+				bits++
+			}
+			matches = append(matches, match{end: end, bits: bits, pattern: PatternSequence})
+		}
+		if len(token) >= 4 && isKeyboardRun(token) {
+			matches = append(matches, match{end: end, bits: math.Log2(float64(len(keyboardRows) * 10 * len(token))), pattern: PatternKeyboard})
+		}
+		if len(token) == 4 && isYear(token) {
+			matches = append(matches, match{end: end, bits: math.Log2(140), pattern: PatternYear})
+		}
+	}
+
+	return matches
+}
+
+// commonBits prices a token found in the common list by its rank, plus the
+// variations made to it
+func commonBits(token []rune) (float64, bool) {
+	word := strings.ToLower(string(token))
+	extra := 0.0
+
+	rank, ok := commonRanks[word]
+	if !ok {
+		unleeted := []rune(word)
+		for i, r := range unleeted {
+			if sub, found := leetSubstitutions[r]; found {
+				unleeted[i] = sub
+				extra = 1
+			}
+		}
+		if extra == 0 {
+			return 0, false
+		}
+		if rank, ok = commonRanks[string(unleeted)]; !ok {
+			return 0, false
+		}
+	}
+
+	upper := 0
+	for _, r := range token {
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	switch {
+	case upper == 0:
+	case upper == 1 && unicode.IsUpper(token[0]), upper == len(token):
+		extra++
+	default:
+		extra += float64(upper)
+	}
+
+	return math.Log2(float64(rank)) + extra, true
+}
+
+func isRepeat(token []rune) bool {
+	for _, r := range token[1:] {
+		if r != token[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// isSequence reports runs like abc, 123 or 987
+func isSequence(token []rune) (descending, ok bool) {
+	delta := token[1] - token[0]
+	if delta != 1 && delta != -1 {
+		return false, false
+	}
+	for i := 2; i < len(token); i++ {
+		if token[i]-token[i-1] != delta {
+			return false, false
+		}
+	}
+	return delta == -1, true
+}
+
+func isKeyboardRun(token []rune) bool {
+	run := strings.ToLower(string(token))
+	for _, row := range keyboardRows {
+		if strings.Contains(row, run) || strings.Contains(reverse(row), run) {
+			return true
+		}
+	}
+	return false
+}
+
+func isYear(token []rune) bool {
+	year, err := strconv.Atoi(string(token))
+	return err == nil && year >= 1900 && year < 2040
+}
+
+func classSize(r rune) int {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return 26
+	case r >= 'A' && r <= 'Z':
+		return 26
+	case r >= '0' && r <= '9':
+		return 10
+	case r < unicode.MaxASCII:
+		return 33
+	}
+	// Other scripts are counted as a large alphabet
+	return 100
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
@@ -0,0 +1,139 @@
+package passwordpolicy
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		password    string
+		minBits     float64
+		maxBits     float64
+		wantPattern Pattern
+	}{
+		{"", 0, 0, PatternNone},
+		{"password", 0, 5, PatternCommon},
+		{"P@ssw0rd", 0, 10, PatternCommon},
+		{"monkey123", 0, 15, PatternCommon},
+		{"aaaaaaaaaa", 0, 10, PatternRepeat},
+		{"abcdefgh", 0, 10, PatternSequence},
+		{"87654321", 0, 10, PatternSequence},
+		{"sdfghjk", 0, 10, PatternKeyboard},
+		{"1987", 0, 10, PatternYear},
+		{"x7#Kp!2vQz9&", 50, 80, PatternNone},
+		{"Tr0ub4dor&3", 40, 60, PatternNone},
+		// Several common words are still hard to guess together
+		{"correct horse battery staple", 60, 200, PatternCommon},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			bits, pattern := Estimate(tt.password)
+			if bits < tt.minBits || bits > tt.maxBits {
+				t.Errorf("Estimate(%q) = %.1f bits, want %.0f to %.0f", tt.password, bits, tt.minBits, tt.maxBits)
+			}
+			if pattern != tt.wantPattern {
+				t.Errorf("Estimate(%q) pattern = %q, want %q", tt.password, pattern, tt.wantPattern)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	writeRange(t, dir, "breached but long enough", "0")
+	writeRange(t, dir, "in the corpus with 3 hits", "3")
+	policy := New(12, 40, NewBreachedList(dir))
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"accepted", "violet tractor umbrella", nil},
+		{"padding entries are not breaches", "breached but long enough", nil},
+		{"too short", "x7#Kp!2vQz9", []string{"at least 12 characters"}},
+		{"common", "password123456", []string{"too easy to guess because it is based on a common password"}},
+		{"username", "violet ANNSMITH umbrella", []string{"must not contain your username"}},
+		{"email", "violet a.smith umbrella", []string{"must not contain your email"}},
+		{"breached", "in the corpus with 3 hits", []string{"appeared in a data breach"}},
+		{"several reasons", "annsmith", []string{"at least 12 characters", "username", "too easy to guess"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reasons, err := policy.Check(tt.password, "annsmith", "a.smith@example.com")
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if len(reasons) != len(tt.want) {
+				t.Fatalf("Check() = %q, want %d reasons", reasons, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(reasons[i], want) {
+					t.Errorf("reason %d = %q, want it to mention %q", i, reasons[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckIgnoresShortPersonalDetails(t *testing.T) {
+	reasons, err := New(10, 0, nil).Check("violet al umbrella", "al", "al@example.com")
+	if err != nil || len(reasons) != 0 {
+		t.Errorf("Check() = %q, %v, want no reasons", reasons, err)
+	}
+}
+
+func TestBreachedListContains(t *testing.T) {
+	dir := t.TempDir()
+	writeRange(t, dir, "hunter2", "17")
+
+	// A range file for hunter2's prefix that lists other suffixes only
+	otherDir := t.TempDir()
+	hash := sha1Hex("hunter2")
+	os.WriteFile(filepath.Join(otherDir, hash[:prefixLength]+".txt"), []byte("0018A45C4D1DEF81644B54AB7F969B88D65:1\n"), 0o644)
+
+	tests := []struct {
+		name     string
+		dir      string
+		password string
+		want     bool
+	}{
+		{"listed", dir, "hunter2", true},
+		{"same range, not listed", otherDir, "hunter2", false},
+		{"no range file", dir, "violet tractor umbrella", false},
+		{"missing directory", filepath.Join(dir, "missing"), "hunter2", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewBreachedList(tt.dir).Contains(tt.password)
+			if err != nil || got != tt.want {
+				t.Errorf("Contains(%q) = %v, %v, want %v", tt.password, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// writeRange adds password to its range file with count, in lowercase hex
+// to check that suffixes compare case-insensitively
+func writeRange(t *testing.T, dir, password, count string) {
+	t.Helper()
+	hash := sha1Hex(password)
+	path := filepath.Join(dir, hash[:prefixLength]+".txt")
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("failed to write range file: %v", err)
+	}
+	defer f.Close()
+	f.WriteString(strings.ToLower(hash[prefixLength:]) + ":" + count + "\r\n")
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
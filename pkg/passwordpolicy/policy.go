@@ -0,0 +1,80 @@
+// Package passwordpolicy decides whether a password is strong enough: long
+// enough, hard to guess, free of the user's own details and not part of a
+// known breach.
+package passwordpolicy
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// minPersonalLength is the shortest username or email part checked for
+// inside a password
+const minPersonalLength = 3
+
+var patternAdvice = map[Pattern]string{
+	PatternCommon:   "it is based on a common password or word",
+	PatternRepeat:   "it repeats the same character",
+	PatternSequence: "it contains a sequence like abc or 123",
+	PatternKeyboard: "it contains a keyboard pattern like qwerty",
+	PatternYear:     "it contains a year",
+}
+
+type Policy struct {
+	minLength      int
+	minEntropyBits float64
+	breached       *BreachedList // nil disables the breach check
+}
+
+func New(minLength int, minEntropyBits float64, breached *BreachedList) *Policy {
+	return &Policy{
+		minLength:      minLength,
+		minEntropyBits: minEntropyBits,
+		breached:       breached,
+	}
+}
+
+// Check returns why password is rejected for the user with username and
+// email, or no reasons when it is accepted
+func (p *Policy) Check(password, username, email string) ([]string, error) {
+	var reasons []string
+
+	if utf8.RuneCountInString(password) < p.minLength {
+		reasons = append(reasons, fmt.Sprintf("Password must be at least %d characters long", p.minLength))
+	}
+
+	lower := strings.ToLower(password)
+	if containsPersonal(lower, username) {
+		reasons = append(reasons, "Password must not contain your username")
+	}
+	localPart, _, _ := strings.Cut(email, "@")
+	if containsPersonal(lower, localPart) {
+		reasons = append(reasons, "Password must not contain your email address")
+	}
+
+	if bits, pattern := Estimate(password); bits < p.minEntropyBits {
+		reason := "Password is too easy to guess"
+		if advice, ok := patternAdvice[pattern]; ok {
+			reason += " because " + advice
+		}
+		reasons = append(reasons, reason+"; a few unrelated words make a strong password")
+	}
+
+	if p.breached != nil {
+		breached, err := p.breached.Contains(password)
+		if err != nil {
+			return nil, err
+		}
+		if breached {
+			reasons = append(reasons, "Password has appeared in a data breach and must not be used")
+		}
+	}
+
+	return reasons, nil
+}
+
+func containsPersonal(password, value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	return utf8.RuneCountInString(value) >= minPersonalLength && strings.Contains(password, value)
+}
@@ -0,0 +1,55 @@
+package pkce
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChallenge(t *testing.T) {
+	// RFC 7636 appendix B
+	const verifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	if got, want := Challenge(verifier), "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Errorf("Challenge() = %q, want %q", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	const verifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := Challenge(verifier)
+
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{"matches", verifier, challenge, true},
+		{"other verifier", strings.Repeat("a", 43), challenge, false},
+		{"too short", verifier[:42], Challenge(verifier[:42]), false},
+		{"too long", strings.Repeat("a", 129), Challenge(strings.Repeat("a", 129)), false},
+		{"longest allowed", strings.Repeat("~", 128), Challenge(strings.Repeat("~", 128)), true},
+		{"reserved character", verifier[:42] + "+", Challenge(verifier[:42] + "+"), false},
+		{"plain method", verifier, verifier, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.verifier, tt.challenge); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	first, err := NewVerifier()
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	second, _ := NewVerifier()
+	if len(first) != 43 || first == second {
+		t.Errorf("NewVerifier() = %q, %q, want distinct 43 character verifiers", first, second)
+	}
+	if !Verify(first, Challenge(first)) {
+		t.Errorf("generated verifier %q is not valid", first)
+	}
+}
@@ -59,6 +59,14 @@ func Created(c *gin.Context, data interface{}) {
 	})
 }
 
+// Accepted reports work that will finish in the background
+func Accepted(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusAccepted, Response{
+		Success: true,
+		Data:    data,
+	})
+}
+
 func BadRequest(c *gin.Context, message string) {
 	c.JSON(http.StatusBadRequest, Response{
 		Success: false,
@@ -228,6 +236,29 @@ func Conflict(c *gin.Context, message string) {
 	})
 }
 
+// ConflictWithData is Conflict with details the client needs to resolve it
+func ConflictWithData(c *gin.Context, message string, data any) {
+	c.JSON(http.StatusConflict, Response{
+		Success: false,
+		Message: message,
+		Data:    data,
+	})
+}
+
+func PayloadTooLarge(c *gin.Context, message string) {
+	c.JSON(http.StatusRequestEntityTooLarge, Response{
+		Success: false,
+		Message: message,
+	})
+}
+
+func TooManyRequests(c *gin.Context, message string) {
+	c.JSON(http.StatusTooManyRequests, Response{
+		Success: false,
+		Message: message,
+	})
+}
+
 func InternalServerError(c *gin.Context, message string) {
 	c.JSON(http.StatusInternalServerError, Response{
 		Success: false,
@@ -257,6 +288,8 @@ func getValidationMessage(fieldErr validator.FieldError) string {
 	switch fieldErr.Tag() {
 	case "required":
 		return "This field is required"
+	case "required_without_all":
+		return "This field is required unless a template is used"
 	case "min":
 		if fieldErr.Kind() == reflect.String {
 			return fmt.Sprintf("This field must be at least %s characters long", fieldErr.Param())
@@ -279,6 +312,8 @@ func getValidationMessage(fieldErr validator.FieldError) string {
 		return "This field must contain only letters and numbers"
 	case "url":
 		return "This field must be a valid URL"
+	case "oneof":
+		return fmt.Sprintf("This field must be one of: %s", fieldErr.Param())
 	default:
 		return fmt.Sprintf("This field failed validation (%s)", fieldErr.Tag())
 	}
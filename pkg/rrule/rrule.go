@@ -0,0 +1,197 @@
+// Package rrule implements the subset of RFC 5545 recurrence rules used by
+// note reminders: FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, BYDAY
+// (weekday codes only), COUNT and UNTIL.
+package rrule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []time.Weekday
+	Count    int
+	Until    time.Time
+}
+
+// Parse parses a rule such as "FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=10".
+// An optional "RRULE:" prefix is accepted.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("empty rule")
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			switch f := Frequency(strings.ToUpper(value)); f {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = f
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL %q", value)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid COUNT %q", value)
+			}
+			rule.Count = n
+		case "UNTIL":
+			t, err := parseUntil(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid UNTIL %q", value)
+			}
+			rule.Until = t
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, ok := weekdays[strings.ToUpper(code)]
+				if !ok {
+					return nil, fmt.Errorf("unsupported BYDAY value %q", code)
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rule part %q", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("FREQ is required")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != Weekly && rule.Freq != Daily {
+		return nil, fmt.Errorf("BYDAY is only supported with DAILY or WEEKLY")
+	}
+
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date")
+}
+
+// Next returns the first occurrence strictly after `after` for a series
+// starting at dtstart. Occurrences before `after` still count towards COUNT,
+// as in RFC 5545. ok is false once the series is exhausted.
+func (r *Rule) Next(dtstart, after time.Time) (next time.Time, ok bool) {
+	seen := 0
+
+	// Walk periods forward from dtstart. The loop is bounded so a rule that
+	// can never match (e.g. UNTIL in the past) terminates.
+	for period := 0; period < 100000; period++ {
+		for _, candidate := range r.candidates(dtstart, period) {
+			if candidate.Before(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && candidate.After(r.Until) {
+				return time.Time{}, false
+			}
+			if r.Count > 0 && seen >= r.Count {
+				return time.Time{}, false
+			}
+			seen++
+			if candidate.After(after) {
+				return candidate, true
+			}
+		}
+
+		if !r.Until.IsZero() && r.periodStart(dtstart, period).After(r.Until) {
+			break
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func (r *Rule) periodStart(dtstart time.Time, period int) time.Time {
+	n := period * r.Interval
+	switch r.Freq {
+	case Daily:
+		return dtstart.AddDate(0, 0, n)
+	case Weekly:
+		return dtstart.AddDate(0, 0, 7*n)
+	case Monthly:
+		return dtstart.AddDate(0, n, 0)
+	default:
+		return dtstart.AddDate(n, 0, 0)
+	}
+}
+
+// candidates lists the occurrences within one period in chronological order
+func (r *Rule) candidates(dtstart time.Time, period int) []time.Time {
+	start := r.periodStart(dtstart, period)
+
+	if len(r.ByDay) == 0 {
+		// Skip months/years where the day does not exist (e.g. 31st)
+		if (r.Freq == Monthly || r.Freq == Yearly) && start.Day() != dtstart.Day() {
+			return nil
+		}
+		return []time.Time{start}
+	}
+
+	if r.Freq == Daily {
+		if r.matchesDay(start.Weekday()) {
+			return []time.Time{start}
+		}
+		return nil
+	}
+
+	// Weekly: every listed weekday within the week that starts on dtstart's weekday
+	var result []time.Time
+	for offset := 0; offset < 7; offset++ {
+		day := start.AddDate(0, 0, offset)
+		if r.matchesDay(day.Weekday()) {
+			result = append(result, day)
+		}
+	}
+	return result
+}
+
+func (r *Rule) matchesDay(day time.Weekday) bool {
+	for _, d := range r.ByDay {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package rrule
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// Monday 9:00
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart time.Time // defaults to start
+		after   time.Time
+		want    time.Time
+		wantOK  bool
+	}{
+		{"first daily occurrence", "FREQ=DAILY", time.Time{}, start.Add(-time.Second), start, true},
+		{"daily after start", "FREQ=DAILY", time.Time{}, start, start.Add(day), true},
+		{"daily skips to after now", "FREQ=DAILY", time.Time{}, start.Add(10*day + time.Hour), start.Add(11 * day), true},
+		{"interval", "FREQ=DAILY;INTERVAL=3", time.Time{}, start.Add(4 * day), start.Add(6 * day), true},
+		{"weekly byday", "FREQ=WEEKLY;BYDAY=WE,FR", time.Time{}, start, start.Add(2 * day), true},
+		{"weekly byday wraps to next week", "FREQ=WEEKLY;BYDAY=MO,WE", time.Time{}, start.Add(3 * day), start.Add(7 * day), true},
+		{"daily byday skips weekend", "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", time.Time{}, start.Add(4 * day), start.Add(7 * day), true},
+		{"monthly skips short months", "FREQ=MONTHLY", time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC), time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 9, 0, 0, 0, time.UTC), true},
+		{"count includes missed occurrences", "FREQ=DAILY;COUNT=5", time.Time{}, start.Add(3*day + time.Hour), start.Add(4 * day), true},
+		{"count exhausted by missed occurrences", "FREQ=DAILY;COUNT=5", time.Time{}, start.Add(10 * day), time.Time{}, false},
+		{"until", "FREQ=DAILY;UNTIL=20260304T090000Z", time.Time{}, start.Add(day), start.Add(2 * day), true},
+		{"past until", "FREQ=DAILY;UNTIL=20260304T090000Z", time.Time{}, start.Add(2 * day), time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.rule, err)
+			}
+			dtstart := tt.dtstart
+			if dtstart.IsZero() {
+				dtstart = start
+			}
+
+			got, ok := rule.Next(dtstart, tt.after)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("Next() = %s, %v, want %s, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		want    Rule
+		wantErr bool
+	}{
+		{"daily", "FREQ=DAILY", Rule{Freq: Daily, Interval: 1}, false},
+		{"rrule prefix and lower case", "RRULE:freq=weekly;byday=mo,fr", Rule{Freq: Weekly, Interval: 1, ByDay: []time.Weekday{time.Monday, time.Friday}}, false},
+		{"interval and count", "FREQ=MONTHLY;INTERVAL=2;COUNT=6", Rule{Freq: Monthly, Interval: 2, Count: 6}, false},
+		{"until date", "FREQ=YEARLY;UNTIL=20270101", Rule{Freq: Yearly, Interval: 1, Until: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)}, false},
+		{"until timestamp", "FREQ=DAILY;UNTIL=20260304T090000Z", Rule{Freq: Daily, Interval: 1, Until: time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)}, false},
+		{"empty", "  ", Rule{}, true},
+		{"missing freq", "INTERVAL=2", Rule{}, true},
+		{"unsupported freq", "FREQ=HOURLY", Rule{}, true},
+		{"zero interval", "FREQ=DAILY;INTERVAL=0", Rule{}, true},
+		{"negative count", "FREQ=DAILY;COUNT=-1", Rule{}, true},
+		{"bad until", "FREQ=DAILY;UNTIL=tomorrow", Rule{}, true},
+		{"ordinal byday", "FREQ=WEEKLY;BYDAY=1MO", Rule{}, true},
+		{"byday with monthly", "FREQ=MONTHLY;BYDAY=MO", Rule{}, true},
+		{"unsupported part", "FREQ=DAILY;BYMONTH=1", Rule{}, true},
+		{"part without value", "FREQ=DAILY;COUNT", Rule{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.rule, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.rule, *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package signedurl
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestURLVerifies(t *testing.T) {
+	const path = "/api/v1/exports/7/download"
+	raw := URL("secret", "https://api.notes.example", path, time.Now().Add(time.Hour))
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("URL() = %q is not a URL: %v", raw, err)
+	}
+	if u.Path != path {
+		t.Errorf("path = %q, want %q", u.Path, path)
+	}
+	if !Verify("secret", path, u.Query().Get(ParamExpires), u.Query().Get(ParamSignature)) {
+		t.Errorf("Verify() rejected the URL it built: %s", raw)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	const path = "/api/v1/exports/7/download"
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Second).Unix()
+	valid := Sign("secret", path, future)
+
+	tests := []struct {
+		name      string
+		secret    string
+		path      string
+		expires   string
+		signature string
+		want      bool
+	}{
+		{"valid", "secret", path, strconv.FormatInt(future, 10), valid, true},
+		{"expired", "secret", path, strconv.FormatInt(past, 10), Sign("secret", path, past), false},
+		{"extended expiry", "secret", path, strconv.FormatInt(future+3600, 10), valid, false},
+		{"other path", "secret", "/api/v1/exports/8/download", strconv.FormatInt(future, 10), valid, false},
+		{"other secret", "rotated", path, strconv.FormatInt(future, 10), valid, false},
+		{"malformed expiry", "secret", path, "tomorrow", valid, false},
+		{"missing signature", "secret", path, strconv.FormatInt(future, 10), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.path, tt.expires, tt.signature); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
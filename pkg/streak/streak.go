@@ -0,0 +1,59 @@
+// Package streak computes consecutive-day writing streaks.
+package streak
+
+import (
+	"sort"
+	"time"
+)
+
+const dayLayout = "2006-01-02"
+
+// Days converts timestamps into the sorted, distinct calendar days they fall
+// on in loc, formatted as YYYY-MM-DD
+func Days(timestamps []time.Time, loc *time.Location) []string {
+	seen := make(map[string]bool)
+	var days []string
+	for _, t := range timestamps {
+		day := t.In(loc).Format(dayLayout)
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	sort.Strings(days)
+	return days
+}
+
+// Compute returns the current and longest streak for sorted distinct days.
+// The current streak still counts if the last entry was yesterday, so it
+// does not reset before the user had a chance to write today.
+func Compute(days []string, today time.Time) (current, longest int) {
+	var prev time.Time
+	run := 0
+	for i, day := range days {
+		d, err := time.Parse(dayLayout, day)
+		if err != nil {
+			continue
+		}
+		if i > 0 && d.Sub(prev) == 24*time.Hour {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		prev = d
+	}
+
+	if run == 0 {
+		return 0, longest
+	}
+
+	todayDay, _ := time.Parse(dayLayout, today.Format(dayLayout))
+	if gap := todayDay.Sub(prev); gap == 0 || gap == 24*time.Hour {
+		current = run
+	}
+
+	return current, longest
+}
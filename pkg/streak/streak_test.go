@@ -0,0 +1,95 @@
+package streak
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+func utc(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestDays(t *testing.T) {
+	tests := []struct {
+		name       string
+		zone       string
+		timestamps []string
+		want       []string
+	}{
+		{"empty", "UTC", nil, nil},
+		{"sorted and distinct", "UTC", []string{"2026-03-03T10:00:00Z", "2026-03-01T09:00:00Z", "2026-03-03T23:59:59Z"}, []string{"2026-03-01", "2026-03-03"}},
+		{"local day differs from UTC", "Asia/Jakarta", []string{"2026-03-01T18:00:00Z"}, []string{"2026-03-02"}},
+		// New York moves to EDT at 2026-03-08 07:00 UTC; 03:30 UTC on the
+		// 9th is still the 8th locally in EDT, but would be the 9th in EST
+		{"spring forward", "America/New_York", []string{"2026-03-08T06:30:00Z", "2026-03-09T03:30:00Z"}, []string{"2026-03-08"}},
+		// Back to EST at 2026-11-01 06:00 UTC; 04:30 UTC on the 2nd is the
+		// 1st locally in EST, but would be the 2nd in EDT
+		{"fall back", "America/New_York", []string{"2026-11-01T05:30:00Z", "2026-11-02T04:30:00Z"}, []string{"2026-11-01"}},
+		{"half hour zone", "Asia/Kolkata", []string{"2026-03-01T18:29:00Z", "2026-03-01T18:30:00Z"}, []string{"2026-03-01", "2026-03-02"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := mustLoad(t, tt.zone)
+			var timestamps []time.Time
+			for _, value := range tt.timestamps {
+				timestamps = append(timestamps, utc(value))
+			}
+
+			if got := Days(timestamps, loc); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Days = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name        string
+		days        []string
+		zone        string
+		today       string
+		wantCurrent int
+		wantLongest int
+	}{
+		{"no notes", nil, "UTC", "2026-03-10T12:00:00Z", 0, 0},
+		{"written today", []string{"2026-03-08", "2026-03-09", "2026-03-10"}, "UTC", "2026-03-10T12:00:00Z", 3, 3},
+		{"written yesterday still counts", []string{"2026-03-08", "2026-03-09"}, "UTC", "2026-03-10T12:00:00Z", 2, 2},
+		{"broken two days ago", []string{"2026-03-07", "2026-03-08"}, "UTC", "2026-03-10T12:00:00Z", 0, 2},
+		{"longest earlier", []string{"2026-02-01", "2026-02-02", "2026-02-03", "2026-03-10"}, "UTC", "2026-03-10T12:00:00Z", 1, 3},
+		{"month and year boundaries", []string{"2025-12-31", "2026-01-01", "2026-01-31", "2026-02-01"}, "UTC", "2026-02-01T12:00:00Z", 2, 2},
+		{"leap day", []string{"2028-02-28", "2028-02-29", "2028-03-01"}, "UTC", "2028-03-01T12:00:00Z", 3, 3},
+		{"invalid day skipped", []string{"2026-03-09", "not-a-day", "2026-03-10"}, "UTC", "2026-03-10T12:00:00Z", 2, 2},
+		// The spring forward day is 23 hours long and the fall back day 25
+		{"across spring forward", []string{"2026-03-07", "2026-03-08", "2026-03-09"}, "America/New_York", "2026-03-09T12:00:00Z", 3, 3},
+		{"across fall back", []string{"2026-10-31", "2026-11-01", "2026-11-02"}, "America/New_York", "2026-11-02T12:00:00Z", 3, 3},
+		// 02:00 UTC on 10 March is still the 9th in New York, so the 8th
+		// was yesterday
+		{"today is the local day", []string{"2026-03-07", "2026-03-08"}, "America/New_York", "2026-03-10T02:00:00Z", 2, 2},
+		{"ahead of UTC", []string{"2026-03-09", "2026-03-10"}, "Pacific/Auckland", "2026-03-09T12:00:00Z", 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			today := utc(tt.today).In(mustLoad(t, tt.zone))
+			current, longest := Compute(tt.days, today)
+			if current != tt.wantCurrent || longest != tt.wantLongest {
+				t.Errorf("Compute = (%d, %d), want (%d, %d)", current, longest, tt.wantCurrent, tt.wantLongest)
+			}
+		})
+	}
+}
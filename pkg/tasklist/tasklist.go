@@ -0,0 +1,119 @@
+// Package tasklist reads and edits Markdown task list items in note content:
+// "- [ ] open", "- [x] done" and "- [>] carried over", with -, *, + or
+// numbered list markers.
+package tasklist
+
+import (
+	"regexp"
+	"strings"
+)
+
+type Status string
+
+const (
+	Open    Status = "open"
+	Done    Status = "done"
+	Carried Status = "carried"
+)
+
+var itemPattern = regexp.MustCompile(`^(\s*(?:[-*+]|\d+[.)])\s+\[)([ xX>])(\]\s+)(.*?)\s*$`)
+
+// Item is a task list line. Line is its zero-based line number.
+type Item struct {
+	Line   int
+	Text   string
+	Status Status
+}
+
+// Parse returns the task items in content
+func Parse(content string) []Item {
+	var items []Item
+	for i, line := range strings.Split(content, "\n") {
+		match := itemPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil || match[4] == "" {
+			continue
+		}
+		items = append(items, Item{Line: i, Text: match[4], Status: statusOf(match[2])})
+	}
+	return items
+}
+
+// SetStatus rewrites the checkbox of the item with text, preferring the one
+// on line since the same text can appear twice. It reports false when no
+// such item exists.
+func SetStatus(content string, line int, text string, status Status) (string, bool) {
+	if text == "" {
+		return content, false // would match every line that is not an item
+	}
+	lines := strings.Split(content, "\n")
+
+	target := -1
+	if line >= 0 && line < len(lines) && itemText(lines[line]) == text {
+		target = line
+	} else {
+		for i := range lines {
+			if itemText(lines[i]) == text {
+				target = i
+				break
+			}
+		}
+	}
+	if target < 0 {
+		return content, false
+	}
+
+	raw := lines[target]
+	trimmed := strings.TrimRight(raw, "\r")
+	match := itemPattern.FindStringSubmatchIndex(trimmed)
+	lines[target] = trimmed[:match[4]] + marker(status) + trimmed[match[5]:] + raw[len(trimmed):]
+	return strings.Join(lines, "\n"), true
+}
+
+// Append adds open items for texts under heading at the end of content
+func Append(content, heading string, texts []string) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(content, "\n"))
+	if b.Len() > 0 {
+		b.WriteString("\n\n")
+	}
+	if heading != "" {
+		b.WriteString(heading)
+		b.WriteString("\n\n")
+	}
+	for _, text := range texts {
+		b.WriteString("- [ ] ")
+		b.WriteString(text)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func itemText(line string) string {
+	match := itemPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+	if match == nil {
+		return ""
+	}
+	return match[4]
+}
+
+func statusOf(mark string) Status {
+	switch mark {
+	case "x", "X":
+		return Done
+	case ">":
+		return Carried
+	default:
+		return Open
+	}
+}
+
+func marker(status Status) string {
+	switch status {
+	case Done:
+		return "x"
+	case Carried:
+		return ">"
+	default:
+		return " "
+	}
+}
@@ -0,0 +1,78 @@
+package tasklist
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	content := "# Today\n" +
+		"- [ ] write report\n" +
+		"* [x] call Ann\r\n" +
+		"  + [X] nested done\n" +
+		"1. [>] carried over\n" +
+		"2) [ ] numbered\n" +
+		"- [ ]    \n" +
+		"- [?] unknown mark\n" +
+		"-[ ] no space\n" +
+		"plain text"
+
+	want := []Item{
+		{Line: 1, Text: "write report", Status: Open},
+		{Line: 2, Text: "call Ann", Status: Done},
+		{Line: 3, Text: "nested done", Status: Done},
+		{Line: 4, Text: "carried over", Status: Carried},
+		{Line: 5, Text: "numbered", Status: Open},
+	}
+	if got := Parse(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSetStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		line    int
+		text    string
+		status  Status
+		want    string
+		wantOK  bool
+	}{
+		{"by line", "- [ ] a\n- [ ] b", 1, "b", Done, "- [ ] a\n- [x] b", true},
+		{"line moved", "new first line\n- [ ] a\n- [ ] b", 1, "b", Done, "new first line\n- [ ] a\n- [x] b", true},
+		{"duplicate text prefers line", "- [ ] a\n- [ ] a", 1, "a", Carried, "- [ ] a\n- [>] a", true},
+		{"reopen", "- [x] a", 0, "a", Open, "- [ ] a", true},
+		{"keeps CRLF", "- [ ] a\r\n- [ ] b\r\n", 0, "a", Done, "- [x] a\r\n- [ ] b\r\n", true},
+		{"missing", "- [ ] a", 0, "b", Done, "- [ ] a", false},
+		{"empty text", "plain\n- [ ] a", 0, "", Done, "plain\n- [ ] a", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := SetStatus(tt.content, tt.line, tt.text, tt.status)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("SetStatus() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAppend(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		heading string
+		texts   []string
+		want    string
+	}{
+		{"empty note", "", "", []string{"a"}, "- [ ] a\n"},
+		{"with heading", "notes\n\n\n", "## Carried over", []string{"a", "b"}, "notes\n\n## Carried over\n\n- [ ] a\n- [ ] b\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Append(tt.content, tt.heading, tt.texts); got != tt.want {
+				t.Errorf("Append() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
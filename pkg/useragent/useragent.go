@@ -0,0 +1,81 @@
+// Package useragent turns a User-Agent header into a short device label such
+// as "Chrome on macOS". It only recognises common browsers, platforms and
+// HTTP clients; anything else is reported as unknown.
+package useragent
+
+import (
+	"strings"
+)
+
+// Checked in order: Edge and Opera also claim to be Chrome, and Chrome
+// claims to be Safari
+var browsers = []struct{ token, name string }{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"CriOS/", "Chrome"},
+	{"Safari/", "Safari"},
+}
+
+var platforms = []struct{ token, name string }{
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Android", "Android"},
+	{"CrOS", "ChromeOS"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"Macintosh", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// Non-browser clients identify themselves by product name
+var clients = []struct{ prefix, name string }{
+	{"curl/", "curl"},
+	{"Wget/", "Wget"},
+	{"PostmanRuntime/", "Postman"},
+	{"python-requests/", "Python"},
+	{"Go-http-client/", "Go"},
+	{"okhttp/", "Android app"},
+	{"Dart/", "Flutter app"},
+}
+
+// Describe returns a short label for the client that sent ua
+func Describe(ua string) string {
+	ua = strings.TrimSpace(ua)
+	if ua == "" {
+		return "Unknown device"
+	}
+
+	for _, c := range clients {
+		if strings.HasPrefix(ua, c.prefix) {
+			return c.name
+		}
+	}
+
+	browser := ""
+	for _, b := range browsers {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	platform := ""
+	for _, p := range platforms {
+		if strings.Contains(ua, p.token) {
+			platform = p.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform + " device"
+	}
+	return "Unknown device"
+}
@@ -0,0 +1,142 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every delivery. Receivers verify a request by computing
+// HMAC-SHA256 over "<timestamp>.<body>" with the webhook secret and comparing
+// it to the signature header.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Sign returns the signature header value for body sent at timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature produced by Sign, rejecting timestamps older
+// than tolerance to limit replays
+func Verify(secret string, timestamp int64, body []byte, signature string, tolerance time.Duration) bool {
+	if tolerance > 0 && time.Since(time.Unix(timestamp, 0)) > tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Payload is the JSON body of every delivery
+type Payload struct {
+	Event      string      `json:"event"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// NewPayload encodes data as an event body
+func NewPayload(event string, data interface{}) ([]byte, error) {
+	return json.Marshal(Payload{
+		Event:      event,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	})
+}
+
+// Request is a single signed delivery
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID int
+	Payload    []byte
+}
+
+// Result describes the receiver's answer. StatusCode is zero when no
+// response was received.
+type Result struct {
+	StatusCode int
+	Body       string
+}
+
+// Sender posts signed deliveries. Redirects are not followed, and unless
+// allowPrivate is set only public addresses are dialled, so webhooks cannot
+// be used to reach the server's own network.
+type Sender struct {
+	client *http.Client
+	agent  string
+}
+
+func NewSender(timeout time.Duration, userAgent string, allowPrivate bool) *Sender {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = publicOnlyControl
+	}
+
+	return &Sender{
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				// A proxy would make the dialled address the proxy's
+				Proxy:               nil,
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: timeout,
+				MaxIdleConns:        10,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return errRedirect
+			},
+		},
+		agent: userAgent,
+	}
+}
+
+var errRedirect = errors.New("webhook redirects are not followed")
+
+// Send posts the payload. Any non-2xx response is returned as an error
+// together with the response it got.
+func (s *Sender) Send(req *Request) (*Result, error) {
+	timestamp := time.Now().Unix()
+
+	httpReq, err := http.NewRequest(http.MethodPost, req.URL, bytes.NewReader(req.Payload))
+	if err != nil {
+		return &Result{}, fmt.Errorf("invalid webhook request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", s.agent)
+	httpReq.Header.Set(HeaderEvent, req.Event)
+	httpReq.Header.Set(HeaderDelivery, strconv.Itoa(req.DeliveryID))
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Payload))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return &Result{}, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1000))
+	result := &Result{StatusCode: resp.StatusCode, Body: string(body)}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, fmt.Errorf("webhook receiver returned %s", resp.Status)
+	}
+	return result, nil
+}
@@ -0,0 +1,75 @@
+// Package wikilink parses wiki-style note references in note content:
+// [[Title]] links to a note by title, [[42]] to the note with ID 42, and
+// either form takes an optional label after a pipe, as in [[Title|label]].
+package wikilink
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var linkPattern = regexp.MustCompile(`\[\[([^\[\]|\n]+)(\|[^\[\]\n]*)?\]\]`)
+
+// Link is one reference found in content
+type Link struct {
+	// Target is the text inside the brackets, without the label
+	Target string
+	// ID is set when Target is a note ID
+	ID int
+}
+
+// ByID reports whether the link references a note by ID
+func (l Link) ByID() bool {
+	return l.ID > 0
+}
+
+// Parse returns the distinct links in content, in order of appearance.
+// Titles are compared case-insensitively, like the database does.
+func Parse(content string) []Link {
+	var links []Link
+	seen := make(map[string]bool)
+
+	for _, match := range linkPattern.FindAllStringSubmatch(content, -1) {
+		target := strings.TrimSpace(match[1])
+		if target == "" {
+			continue
+		}
+
+		key := strings.ToLower(target)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		link := Link{Target: target}
+		if id, err := strconv.Atoi(target); err == nil && id > 0 {
+			link.ID = id
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
+// Rename points title links to oldTitle at newTitle, keeping their labels.
+// ID links are left alone since they survive renames, and so is content when
+// newTitle cannot be written inside a link.
+func Rename(content, oldTitle, newTitle string) string {
+	if !Linkable(newTitle) {
+		return content
+	}
+	oldTitle = strings.TrimSpace(oldTitle)
+
+	return linkPattern.ReplaceAllStringFunc(content, func(raw string) string {
+		match := linkPattern.FindStringSubmatch(raw)
+		if !strings.EqualFold(strings.TrimSpace(match[1]), oldTitle) {
+			return raw
+		}
+		return "[[" + newTitle + match[2] + "]]"
+	})
+}
+
+// Linkable reports whether [[title]] would parse back to title
+func Linkable(title string) bool {
+	return strings.TrimSpace(title) != "" && !strings.ContainsAny(title, "[]|\n")
+}
@@ -0,0 +1,74 @@
+package wikilink
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Link
+	}{
+		{"none", "no links here", nil},
+		{"title", "see [[Project Plan]]", []Link{{Target: "Project Plan"}}},
+		{"id", "see [[42]]", []Link{{Target: "42", ID: 42}}},
+		{"label", "see [[Project Plan|the plan]] and [[7|seven]]", []Link{{Target: "Project Plan"}, {Target: "7", ID: 7}}},
+		{"trims spaces", "[[  Groceries ]]", []Link{{Target: "Groceries"}}},
+		{"dedupes case-insensitively", "[[Todo]] [[todo]] [[TODO|x]]", []Link{{Target: "Todo"}}},
+		{"zero and negative are titles", "[[0]] [[-3]]", []Link{{Target: "0"}, {Target: "-3"}}},
+		{"empty and blank", "[[]] [[   ]] [[|label]]", nil},
+		{"no newlines inside", "[[Two\nLines]]", nil},
+		{"nested brackets", "[[[Inner]]]", []Link{{Target: "Inner"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		old, new string
+		want     string
+	}{
+		{"title", "see [[Plan]] today", "Plan", "Roadmap", "see [[Roadmap]] today"},
+		{"keeps label", "see [[Plan|the plan]]", "Plan", "Roadmap", "see [[Roadmap|the plan]]"},
+		{"case-insensitive", "[[plan]] and [[ PLAN ]]", "Plan", "Roadmap", "[[Roadmap]] and [[Roadmap]]"},
+		{"other links untouched", "[[Plan B]] [[42]]", "Plan", "Roadmap", "[[Plan B]] [[42]]"},
+		{"unlinkable new title", "[[Plan]]", "Plan", "a|b", "[[Plan]]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Rename(tt.content, tt.old, tt.new); got != tt.want {
+				t.Errorf("Rename() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkable(t *testing.T) {
+	tests := []struct {
+		title string
+		want  bool
+	}{
+		{"Plan", true},
+		{"Plan (v2)", true},
+		{"", false},
+		{"   ", false},
+		{"a|b", false},
+		{"[draft]", false},
+		{"two\nlines", false},
+	}
+	for _, tt := range tests {
+		if got := Linkable(tt.title); got != tt.want {
+			t.Errorf("Linkable(%q) = %v, want %v", tt.title, got, tt.want)
+		}
+	}
+}